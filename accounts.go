@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/parser"
+)
+
+const CallbackPrefixAccount = "account_default:"
+
+// DefaultAccounts are the payment accounts understood when none are configured
+var DefaultAccounts = []string{"cash", "card", "bank", "upi"}
+
+// accountIcons decorates well-known account names in lists and buttons
+var accountIcons = map[string]string{
+	"cash": "💵",
+	"card": "💳",
+	"bank": "🏦",
+	"upi":  "📲",
+}
+
+type AccountSpend struct {
+	Account string  `json:"account"`
+	Total   float64 `json:"total"`
+	Count   int     `json:"count"`
+}
+
+type AccountsSummaryResponse struct {
+	Accounts []AccountSpend `json:"accounts"`
+}
+
+// knownAccounts returns the configured payment accounts
+func knownAccounts() []string {
+	if len(config.Accounts) > 0 {
+		return config.Accounts
+	}
+	return DefaultAccounts
+}
+
+// isKnownAccount reports whether name is a configured account (case-insensitive)
+func isKnownAccount(name string) (string, bool) {
+	return parser.MatchAccount(name, knownAccounts())
+}
+
+// defaultAccountFor returns the account picked for a chat, if any
+func defaultAccountFor(chatID int64) string {
+	return viewSession(chatID).DefaultAccount
+}
+
+func accountLabel(account string) string {
+	if icon, ok := accountIcons[strings.ToLower(account)]; ok {
+		return icon + " " + account
+	}
+	return account
+}
+
+func (b *botInstance) handleAccountsCommand(msg *tgbotapi.Message) {
+	startTime := time.Now()
+	log.Printf("💳 Starting accounts command processing")
+	b.showTyping(msg.Chat.ID)
+
+	month := time.Now().Format("2006-01")
+	params := url.Values{}
+	params.Set("month", month)
+	params.Set("telegramChatId", strconv.FormatInt(msg.Chat.ID, 10))
+
+	result, err := b.apiCallWithTiming("GET", "/api/expenses/accounts-summary?"+params.Encode(), nil)
+	totalDuration := time.Since(startTime)
+
+	overheadMs := totalDuration.Milliseconds() - result.APITime.Milliseconds()
+	log.Printf("💳⏱️ ACCOUNTS TIMING: Total=%dms | API=%dms | Overhead=%dms",
+		totalDuration.Milliseconds(), result.APITime.Milliseconds(), overheadMs)
+
+	if err != nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, userErrorText("fetching account totals", err))
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
+			log.Printf(ErrorSendMessage, sendErr)
+		}
+		return
+	}
+
+	var summary AccountsSummaryResponse
+	if err := json.Unmarshal(result.Data, &summary); err != nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Error parsing account totals")
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
+			log.Printf(ErrorSendMessage, sendErr)
+		}
+		return
+	}
+
+	formatter := formatterFor(msg.Chat.ID)
+	response := "💳 Spend by Account - " + time.Now().Format("January 2006") + "\n\n"
+	if len(summary.Accounts) == 0 {
+		response += "No expenses this month yet.\n"
+	}
+	for _, spend := range summary.Accounts {
+		account := spend.Account
+		if account == "" {
+			account = "unassigned"
+		}
+		response += fmt.Sprintf("  • %s - %s (%d)\n", accountLabel(account), formatter.Currency(spend.Total), spend.Count)
+	}
+
+	current := defaultAccountFor(msg.Chat.ID)
+	if current == "" {
+		current = "none"
+	}
+	response += fmt.Sprintf("\nDefault account: %s\nTap below to change it, or add \"via card\" to any expense.", current)
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, account := range knownAccounts() {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(accountLabel(account), CallbackPrefixAccount+account))
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf("❌ Failed to send accounts summary to ChatID %d: %v", msg.Chat.ID, err)
+	} else {
+		log.Printf("✅ Accounts summary sent successfully to ChatID: %d", msg.Chat.ID)
+	}
+}
+
+// handleAccountCallback stores the default account picked from the /accounts keyboard
+func (b *botInstance) handleAccountCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	account, ok := isKnownAccount(strings.TrimPrefix(cb.Data, CallbackPrefixAccount))
+	if !ok {
+		log.Printf("❌ Unknown account in callback: %s", cb.Data)
+		b.answerCallback(cb, "Unknown account.")
+		return
+	}
+
+	updateSession(chatID, func(s *chatSession) {
+		s.DefaultAccount = account
+	})
+
+	log.Printf("💳 Default account for ChatID %d set to %s", chatID, account)
+	b.answerCallback(cb, "Default account: "+account)
+}
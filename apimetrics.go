@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSlowAPICall is the latency above which a backend call is logged in detail
+const DefaultSlowAPICall = 2 * time.Second
+
+// callTiming breaks a backend call down into its phases
+type callTiming struct {
+	start, dnsStart, dnsDone, connectStart, connectDone time.Time
+	tlsStart, tlsDone, gotConn, firstByte               time.Time
+	reused                                              bool
+}
+
+// trace returns the httptrace hooks filling in the timing
+func (t *callTiming) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.dnsDone = time.Now() },
+		ConnectStart:         func(string, string) { t.connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { t.connectDone = time.Now() },
+		TLSHandshakeStart:    func() { t.tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.tlsDone = time.Now() },
+		GotConn:              func(info httptrace.GotConnInfo) { t.gotConn, t.reused = time.Now(), info.Reused },
+		GotFirstResponseByte: func() { t.firstByte = time.Now() },
+	}
+}
+
+// phase formats the duration between two trace points, or "-" if a phase didn't happen
+func phase(from, to time.Time) string {
+	if from.IsZero() || to.IsZero() {
+		return "-"
+	}
+	return fmt.Sprintf("%dms", to.Sub(from).Milliseconds())
+}
+
+// breakdown describes where the time of a finished call went
+func (t *callTiming) breakdown(end time.Time) string {
+	ttfb := "-"
+	if !t.gotConn.IsZero() && !t.firstByte.IsZero() {
+		ttfb = phase(t.gotConn, t.firstByte)
+	}
+	return fmt.Sprintf("dns=%s connect=%s tls=%s reused=%t ttfb=%s body=%s",
+		phase(t.dnsStart, t.dnsDone), phase(t.connectStart, t.connectDone), phase(t.tlsStart, t.tlsDone),
+		t.reused, ttfb, phase(t.firstByte, end))
+}
+
+// endpointStats summarizes the calls to one endpoint for /ping
+type endpointStats struct {
+	Calls  int
+	Errors int
+	Total  time.Duration
+	Max    time.Duration
+}
+
+var apiStats = struct {
+	sync.Mutex
+	byEndpoint map[string]*endpointStats
+}{byEndpoint: make(map[string]*endpointStats)}
+
+// slowAPICallThreshold returns the configured slow-call threshold
+func slowAPICallThreshold() time.Duration {
+	if config.SlowAPICall > 0 {
+		return config.SlowAPICall
+	}
+	return DefaultSlowAPICall
+}
+
+// endpointLabel strips the query string so metrics have one series per endpoint
+func endpointLabel(endpoint string) string {
+	path, _, _ := strings.Cut(endpoint, "?")
+	return path
+}
+
+// doBackendRequest sends a SpendWise API request and reads the response,
+// recording latency and outcome per endpoint and logging slow calls with a
+// DNS/connect/TLS/TTFB breakdown.
+func (b *botInstance) doBackendRequest(req *http.Request, method, endpoint string) (*http.Response, []byte, error) {
+	timing := &callTiming{start: time.Now()}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timing.trace()))
+
+	resp, err := backendClient.Do(req)
+	var body []byte
+	if err == nil {
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			err = fmt.Errorf("failed to read response: %v", err)
+		}
+	} else {
+		err = errBackendUnavailable{cause: b.backendError(err), timedOut: isTimeout(err)}
+	}
+	end := time.Now()
+	elapsed := end.Sub(timing.start)
+
+	label := endpointLabel(endpoint)
+	status := "error"
+	if err == nil {
+		status = fmt.Sprintf("%dxx", resp.StatusCode/100)
+	}
+	failed := err != nil || resp.StatusCode >= 500
+	// A write that timed out may have been saved, so only redeliver when it can't have been
+	if failed && b.outage != nil && (method == http.MethodGet || !backendTimedOut(err)) {
+		b.outage.Store(true)
+	}
+	observeLatency("spendwise_api_call_duration_seconds", elapsed.Seconds(), "endpoint", label)
+	incCounter("spendwise_api_calls_total", "endpoint", label, "status", status)
+
+	apiStats.Lock()
+	stats, ok := apiStats.byEndpoint[label]
+	if !ok {
+		stats = &endpointStats{}
+		apiStats.byEndpoint[label] = stats
+	}
+	stats.Calls++
+	stats.Total += elapsed
+	if elapsed > stats.Max {
+		stats.Max = elapsed
+	}
+	if failed {
+		stats.Errors++
+	}
+	apiStats.Unlock()
+
+	if elapsed >= slowAPICallThreshold() {
+		log.Printf("🐢 Slow API call %s %s took %d ms (status %s) - %s",
+			method, label, elapsed.Milliseconds(), status, timing.breakdown(end))
+	}
+	return resp, body, err
+}
+
+// apiStatsLines summarizes per-endpoint call statistics, busiest first
+func apiStatsLines() []string {
+	apiStats.Lock()
+	defer apiStats.Unlock()
+
+	endpoints := mapKeys(apiStats.byEndpoint)
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return apiStats.byEndpoint[endpoints[i]].Calls > apiStats.byEndpoint[endpoints[j]].Calls
+	})
+
+	lines := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		stats := apiStats.byEndpoint[endpoint]
+		lines = append(lines, fmt.Sprintf("• %s: %d calls, avg %d ms, max %d ms, %d errors",
+			endpoint, stats.Calls, (stats.Total/time.Duration(stats.Calls)).Milliseconds(), stats.Max.Milliseconds(), stats.Errors))
+	}
+	return lines
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixApproval = "approval:"
+	// ApprovalTTL is how long an expense waits for an approver before it is dropped
+	ApprovalTTL = 48 * time.Hour
+)
+
+// approvalRequest is an expense batch held until another member approves it.
+// BotID and TenantID remember where the requester's expenses are saved.
+type approvalRequest struct {
+	BotID     string
+	TenantID  string
+	Chat      *tgbotapi.Chat
+	From      *tgbotapi.User
+	MessageID int
+	Requester string
+	Expenses  []ExpenseInput
+	Skipped   []string
+	Approvals map[string]int // approver chat ID -> message ID of their Approve/Reject prompt
+}
+
+func approvalKey(id string) string {
+	return "approval:" + id
+}
+
+// approversFor returns the configured approvers other than the chat itself.
+// Only approvers in the chat's household that this bot serves are asked, so
+// held expenses never reach another household.
+func (b *botInstance) approversFor(chatID int64) []int64 {
+	requester, _ := tenantForChat(chatID)
+	var approvers []int64
+	for _, id := range config.ApproverIDs {
+		approver, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || approver == chatID {
+			continue
+		}
+		if owner, ok := tenantForChat(approver); !ok || owner != requester || !owner.servedBy(b) {
+			continue
+		}
+		approvers = append(approvers, approver)
+	}
+	return approvers
+}
+
+// needsApproval reports whether any debit in the batch is above APPROVAL_THRESHOLD
+func needsApproval(expenses []ExpenseInput) bool {
+	if config.ApprovalThreshold <= 0 {
+		return false
+	}
+	for _, expense := range expenses {
+		if expense.EntryType == "" && expense.Amount > config.ApprovalThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// saveOrRequestApproval saves expenses unless one is above the approval
+// threshold, in which case the batch is held and the other members are asked
+// to approve it
+func (b *botInstance) saveOrRequestApproval(msg *tgbotapi.Message, expenses []ExpenseInput, skipped []string) {
+	approvers := b.approversFor(msg.Chat.ID)
+	if !needsApproval(expenses) || len(approvers) == 0 {
+		b.saveExpenses(msg, expenses, skipped)
+		return
+	}
+
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	id := hex.EncodeToString(buf)
+	request := approvalRequest{
+		BotID:     b.ID,
+		Chat:      msg.Chat,
+		From:      msg.From,
+		MessageID: msg.MessageID,
+		Requester: expenses[0].UserName,
+		Expenses:  expenses,
+		Skipped:   skipped,
+		Approvals: make(map[string]int),
+	}
+	if b.tenant != nil {
+		request.TenantID = b.tenant.ID
+	}
+
+	formatter := formatterFor(msg.Chat.ID)
+	lines := []string{fmt.Sprintf("🧾 %s wants to log %s:", request.Requester, formatter.Currency(debitTotal(expenses)))}
+	for _, expense := range expenses {
+		lines = append(lines, fmt.Sprintf("• %s - %s", expense.Description, formatter.Currency(expense.Amount)))
+	}
+	prompt := strings.Join(lines, "\n")
+	for _, approver := range approvers {
+		ask := tgbotapi.NewMessage(approver, prompt)
+		ask.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Approve", CallbackPrefixApproval+"yes:"+id),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Reject", CallbackPrefixApproval+"no:"+id),
+		))
+		sent, err := b.api.Send(ask)
+		if err != nil {
+			log.Printf("❌ Failed to ask approver %d: %v", approver, err)
+			continue
+		}
+		request.Approvals[strconv.FormatInt(approver, 10)] = sent.MessageID
+	}
+
+	if len(request.Approvals) == 0 {
+		log.Printf("⚠️ No approver could be reached for ChatID %d, saving without approval", msg.Chat.ID)
+		b.saveExpenses(msg, expenses, skipped)
+		return
+	}
+	raw, _ := json.Marshal(request)
+	if err := store.Set(approvalKey(id), string(raw), ApprovalTTL); err != nil {
+		log.Printf("❌ Failed to hold expense for approval for ChatID %d: %v", msg.Chat.ID, err)
+		b.saveExpenses(msg, expenses, skipped)
+		return
+	}
+
+	log.Printf("🧾 Expense from ChatID %d above %.2f sent to %d approver(s) as %s", msg.Chat.ID, config.ApprovalThreshold, len(request.Approvals), id)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("⏳ Over %s, so it needs approval - I've asked the other members and will log it once one of them approves.",
+		formatter.Currency(config.ApprovalThreshold)))
+	reply.ReplyToMessageID = msg.MessageID
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
+
+// handleApprovalCallback commits or drops an expense held for approval. The
+// first approver to answer decides; the others' prompts are updated to match.
+func (b *botInstance) handleApprovalCallback(cb *tgbotapi.CallbackQuery) {
+	action, id, _ := strings.Cut(strings.TrimPrefix(cb.Data, CallbackPrefixApproval), ":")
+	key := approvalKey(id)
+	raw, ok, err := store.Get(key)
+	var request approvalRequest
+	if err == nil && ok {
+		err = json.Unmarshal([]byte(raw), &request)
+	}
+	if err != nil || !ok {
+		log.Printf("❌ Stale approval callback from ChatID %d: %s", cb.Message.Chat.ID, cb.Data)
+		b.answerCallback(cb, "This request was already decided or has expired.")
+		return
+	}
+	if _, approver := request.Approvals[strconv.FormatInt(cb.Message.Chat.ID, 10)]; !approver {
+		log.Printf("❌ ChatID %d isn't an approver of %s", cb.Message.Chat.ID, id)
+		b.answerCallback(cb, "Invalid action.")
+		return
+	}
+	// Claim the decision so two approvers tapping at once can't both act
+	claimed, err := store.SetNX(key+":decided", instanceID, ApprovalTTL)
+	if err != nil || !claimed {
+		b.answerCallback(cb, "This request was already decided.")
+		return
+	}
+	if err := store.Delete(key); err != nil {
+		log.Printf("⚠️ Failed to delete approval %s: %v", id, err)
+	}
+
+	approved := action == "yes"
+	outcome, answer := "❌ Rejected by "+cb.From.FirstName, "Rejected"
+	if approved {
+		outcome, answer = "✅ Approved by "+cb.From.FirstName, "Approved"
+	}
+	log.Printf("🧾 Approval %s for ChatID %d: approved=%t by ChatID %d", id, request.Chat.ID, approved, cb.Message.Chat.ID)
+	b.answerCallback(cb, answer)
+
+	// Reply and save through the requester's bot and household, not the approver's
+	requester := b
+	if bot, ok := bots[request.BotID]; ok {
+		requester = bot
+	}
+	if t, ok := tenantWithID(request.TenantID); ok {
+		requester = requester.forTenant(t)
+	}
+
+	total := formatterFor(request.Chat.ID).Currency(debitTotal(request.Expenses))
+	for approverID, messageID := range request.Approvals {
+		chatID, _ := strconv.ParseInt(approverID, 10, 64)
+		text := fmt.Sprintf("%s - %s from %s", outcome, total, request.Requester)
+		if _, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, messageID, text)); err != nil {
+			log.Printf("⚠️ Failed to update approval prompt for ChatID %d: %v", chatID, err)
+		}
+	}
+
+	result := outcome + " - not logged."
+	if approved {
+		result = outcome + " - logging it."
+	}
+	reply := tgbotapi.NewMessage(request.Chat.ID, result)
+	reply.ReplyToMessageID = request.MessageID
+	if _, err := requester.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+	if approved {
+		requester.saveExpenses(&tgbotapi.Message{
+			MessageID: request.MessageID,
+			From:      request.From,
+			Chat:      request.Chat,
+			Date:      cb.Message.Date,
+		}, request.Expenses, request.Skipped)
+	}
+}
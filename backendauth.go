@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend authentication modes, selected with BACKEND_AUTH
+const (
+	BackendAuthSecret = "secret" // static x-spendwise-secret header (default)
+	BackendAuthOIDC   = "oidc"   // Google-signed identity token (Cloud Run service-to-service)
+	BackendAuthMTLS   = "mtls"   // client TLS certificate
+
+	MetadataIdentityURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/identity"
+	// IdentityTokenTTL is how long a fetched token is reused; Google issues them for an hour
+	IdentityTokenTTL = 50 * time.Minute
+)
+
+// backendTransport is used for every call to the SpendWise API, see initHTTPClients.
+// It carries the client certificate in mTLS mode.
+var backendTransport http.RoundTripper
+
+// identityTokens caches OIDC tokens per audience
+var identityTokens = struct {
+	sync.Mutex
+	tokens map[string]cachedToken
+}{tokens: make(map[string]cachedToken)}
+
+type cachedToken struct {
+	value   string
+	expires time.Time
+}
+
+// initBackendAuth validates the configured mode and loads client certificates
+func initBackendAuth() error {
+	switch config.BackendAuth {
+	case BackendAuthSecret:
+		return nil
+	case BackendAuthOIDC:
+		log.Println("🔑 Authenticating backend calls with Google identity tokens")
+		return nil
+	case BackendAuthMTLS:
+		tlsConfig, err := clientTLSConfig()
+		if err != nil {
+			return err
+		}
+		transport := newTunedTransport()
+		transport.TLSClientConfig = tlsConfig
+		backendTransport = transport
+		log.Println("🔑 Authenticating backend calls with a client TLS certificate")
+		return nil
+	}
+	return fmt.Errorf("unknown backend auth mode %q (use secret, oidc or mtls)", config.BackendAuth)
+}
+
+func clientTLSConfig() (*tls.Config, error) {
+	if config.ClientCertFile == "" || config.ClientKeyFile == "" {
+		return nil, fmt.Errorf("mtls backend auth needs clientCertFile and clientKeyFile")
+	}
+	cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %v", err)
+	}
+
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if config.ClientCAFile != "" {
+		pem, err := os.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", config.ClientCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// authorizeBackendRequest adds the configured credentials to a SpendWise API request
+func (b *botInstance) authorizeBackendRequest(req *http.Request) error {
+	switch config.BackendAuth {
+	case BackendAuthOIDC:
+		audience := config.OIDCAudience
+		if audience == "" {
+			audience = b.apiURL
+		}
+		token, err := identityToken(audience)
+		if err != nil {
+			return fmt.Errorf("failed to get identity token: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case BackendAuthMTLS:
+		// The client certificate is presented by backendTransport
+	default:
+		req.Header.Set(HeaderAPISecret, b.apiSecret)
+	}
+	return nil
+}
+
+// identityToken returns a cached or freshly minted identity token for the audience
+// from the Cloud Run metadata server.
+func identityToken(audience string) (string, error) {
+	identityTokens.Lock()
+	defer identityTokens.Unlock()
+
+	if cached, ok := identityTokens.tokens[audience]; ok && time.Now().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	req, err := http.NewRequest("GET", MetadataIdentityURL+"?audience="+url.QueryEscape(audience), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("metadata server request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read metadata response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server error (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	token := strings.TrimSpace(string(body))
+	identityTokens.tokens[audience] = cachedToken{value: token, expires: time.Now().Add(IdentityTokenTTL)}
+	log.Printf("🔑 Fetched identity token for audience %s", audience)
+	return token, nil
+}
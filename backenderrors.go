@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Machine-readable error codes the backend may return in the "code" field
+const (
+	ErrCodeDuplicateExpense = "duplicate_expense"
+	ErrCodeCategoryNotFound = "category_not_found"
+	ErrCodeExpenseNotFound  = "expense_not_found"
+	ErrCodeInvalidDate      = "invalid_date"
+	ErrCodeInvalidAmount    = "invalid_amount"
+	ErrCodeInvalidLinkCode  = "invalid_link_code"
+
+	// errCodeUnavailable keys the message for 5xx responses and unreachable backends
+	errCodeUnavailable = "unavailable"
+)
+
+const (
+	CallbackPrefixDuplicate = "duplicate:"
+	// DuplicateConfirmTTL is how long an expense rejected as a duplicate can still be logged anyway
+	DuplicateConfirmTTL = time.Hour
+)
+
+// DefaultErrorMessages are shown for backend error codes instead of the
+// backend's own text; ERROR_MESSAGES overrides them, e.g. in another language
+var DefaultErrorMessages = map[string]string{
+	ErrCodeDuplicateExpense: "🔁 This looks like an expense that's already logged.",
+	ErrCodeCategoryNotFound: "🏷️ There's no such category.",
+	ErrCodeExpenseNotFound:  "🤷 That expense doesn't exist any more - it may have been deleted.",
+	ErrCodeInvalidDate:      "📅 The server didn't accept the date - try a date in the current spending month.",
+	ErrCodeInvalidAmount:    "💰 The server didn't accept the amount - send a positive number like 250.",
+	ErrCodeInvalidLinkCode:  "🔑 That code is wrong or has expired - send /link for a new one.",
+	errCodeUnavailable:      "⏳ The SpendWise server isn't answering right now - please try again in a few minutes.",
+}
+
+// errBackend is a non-2xx response from the backend. Code is empty for
+// backends that only send a message.
+type errBackend struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e errBackend) Error() string {
+	return e.Message
+}
+
+// backendErrorCode returns the backend's error code for err, or ""
+func backendErrorCode(err error) string {
+	var backendErr errBackend
+	if errors.As(err, &backendErr) {
+		return backendErr.Code
+	}
+	return ""
+}
+
+// errorMessage returns the configured or default message for an error code, or ""
+func errorMessage(code string) string {
+	if message := config.ErrorMessages[code]; message != "" {
+		return message
+	}
+	return DefaultErrorMessages[code]
+}
+
+// userErrorText is what the chat is told when a backend call for action (e.g.
+// "saving expenses") fails: a friendly message for known error codes and
+// outages, otherwise the backend's own text as before
+func userErrorText(action string, err error) string {
+	if errors.As(err, new(errUnsupported)) {
+		return "❌ " + err.Error()
+	}
+	if message := errorMessage(backendErrorCode(err)); message != "" {
+		return message
+	}
+	if isBackendUnavailable(err) {
+		if message := errorMessage(errCodeUnavailable); message != "" {
+			return message
+		}
+	}
+	return "❌ Error " + action + ": " + err.Error()
+}
+
+// duplicateHold is a batch the backend rejected as a duplicate, kept so the
+// user can log it anyway
+type duplicateHold struct {
+	Expenses []ExpenseInput
+	Skipped  []string
+}
+
+func duplicateHoldKey(chatID int64, messageID int) string {
+	return fmt.Sprintf("duplicate-hold:%d:%d", chatID, messageID)
+}
+
+// offerLogDuplicate explains a duplicate rejection and offers to log the batch
+// anyway. It reports false when the batch couldn't be held for that.
+func (b *botInstance) offerLogDuplicate(msg *tgbotapi.Message, expenses []ExpenseInput, skipped []string) bool {
+	raw, _ := json.Marshal(duplicateHold{Expenses: expenses, Skipped: skipped})
+	if err := store.Set(duplicateHoldKey(msg.Chat.ID, msg.MessageID), string(raw), DuplicateConfirmTTL); err != nil {
+		log.Printf("❌ Failed to hold duplicate expense for ChatID %d: %v", msg.Chat.ID, err)
+		return false
+	}
+
+	messageID := strconv.Itoa(msg.MessageID)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, errorMessage(ErrCodeDuplicateExpense)+"\n\nLog it anyway?")
+	reply.ReplyToMessageID = msg.MessageID
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("➕ Log it anyway", CallbackPrefixDuplicate+"log:"+messageID),
+		tgbotapi.NewInlineKeyboardButtonData("✖️ Don't log", CallbackPrefixDuplicate+"cancel:"+messageID),
+	))
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+	return true
+}
+
+// handleDuplicateCallback logs a batch rejected as a duplicate after the user
+// confirms it, telling the backend to allow the duplicate this time
+func (b *botInstance) handleDuplicateCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	action, messageIDStr, _ := strings.Cut(strings.TrimPrefix(cb.Data, CallbackPrefixDuplicate), ":")
+	messageID, err := strconv.Atoi(messageIDStr)
+	if err != nil {
+		log.Printf("❌ Invalid duplicate callback: %s", cb.Data)
+		b.answerCallback(cb, "Invalid format.")
+		return
+	}
+
+	key := duplicateHoldKey(chatID, messageID)
+	raw, ok, err := store.Get(key)
+	var hold duplicateHold
+	if err == nil && ok {
+		err = json.Unmarshal([]byte(raw), &hold)
+	}
+	if err != nil || !ok {
+		log.Printf("❌ Stale duplicate callback for ChatID %d: %s", chatID, cb.Data)
+		b.answerCallback(cb, "This expense has expired, please send it again.")
+		return
+	}
+	if err := store.Delete(key); err != nil {
+		log.Printf("⚠️ Failed to delete duplicate hold for ChatID %d: %v", chatID, err)
+	}
+
+	if action != "log" {
+		b.answerCallback(cb, "Not logged")
+		if _, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, "👌 Not logged.")); err != nil {
+			log.Printf("⚠️ Failed to update duplicate confirmation: %v", err)
+		}
+		return
+	}
+
+	log.Printf("🔁 ChatID %d is logging a duplicate expense anyway", chatID)
+	b.answerCallback(cb, "Logging")
+	if _, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, "🔁 Logging it anyway.")); err != nil {
+		log.Printf("⚠️ Failed to update duplicate confirmation: %v", err)
+	}
+	for i := range hold.Expenses {
+		hold.Expenses[i].AllowDuplicate = true
+	}
+	b.saveExpenses(&tgbotapi.Message{
+		MessageID: messageID,
+		From:      cb.From,
+		Chat:      cb.Message.Chat,
+		Date:      cb.Message.Date,
+	}, hold.Expenses, hold.Skipped)
+}
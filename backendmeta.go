@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HeaderBotVersion tells the backend which API contract the bot speaks
+	HeaderBotVersion = "X-SpendWise-Bot-Version"
+	// BotAPIVersion is bumped whenever the bot starts relying on new backend behaviour
+	BotAPIVersion = 3
+	// MetaTimeout bounds the startup handshake with each backend
+	MetaTimeout = 10 * time.Second
+)
+
+// Backend capabilities advertised by GET /api/meta
+const (
+	CapStructuredSummaries = "structuredSummaries" // format=structured and include=breakdown on summaries
+	CapSummaryRange        = "summaryRange"        // /api/summary/range
+	CapExpenseList         = "expenseList"         // /api/expenses/list
+	CapExpenseUpdate       = "expenseUpdate"       // /api/expenses/update
+	CapExpenseDelete       = "expenseDelete"       // /api/expenses/delete
+	CapAccountsSummary     = "accountsSummary"     // /api/expenses/accounts-summary
+	CapReminderCreate      = "reminderCreate"      // /api/reminders/create
+	CapReminderUpdate      = "reminderUpdate"      // /api/reminders/update
+	CapFeedback            = "feedback"            // /api/feedback
+	CapBudgets             = "budgets"             // /api/budgets
+	CapBotUsers            = "botUsers"            // /api/bot-users
+	CapAccountLink         = "accountLink"         // /api/link-codes and /api/link-codes/confirm
+)
+
+// endpointCapabilities maps the newer endpoints to the capability they need;
+// endpoints not listed are available on every backend
+var endpointCapabilities = map[string]string{
+	"/api/summary/range":             CapSummaryRange,
+	"/api/expenses/list":             CapExpenseList,
+	"/api/expenses/update":           CapExpenseUpdate,
+	"/api/expenses/delete":           CapExpenseDelete,
+	"/api/expenses/accounts-summary": CapAccountsSummary,
+	"/api/reminders/create":          CapReminderCreate,
+	"/api/reminders/update":          CapReminderUpdate,
+	"/api/feedback":                  CapFeedback,
+	"/api/budgets":                   CapBudgets,
+	"/api/bot-users":                 CapBotUsers,
+	"/api/link-codes":                CapAccountLink,
+	"/api/link-codes/confirm":        CapAccountLink,
+}
+
+// backendMeta is the handshake answer from GET /api/meta
+type backendMeta struct {
+	Version      string   `json:"version"`
+	APIVersion   int      `json:"apiVersion"`
+	Capabilities []string `json:"capabilities"`
+	// Legacy is set when the backend predates /api/meta and supports none of the capabilities
+	Legacy bool `json:"-"`
+}
+
+// backendMetas holds the handshake result per backend URL
+var backendMetas = struct {
+	sync.RWMutex
+	byURL map[string]backendMeta
+}{byURL: make(map[string]backendMeta)}
+
+// errUnsupported is returned instead of calling an endpoint the backend lacks
+type errUnsupported struct {
+	capability string
+}
+
+func (e errUnsupported) Error() string {
+	return fmt.Sprintf("this needs a newer SpendWise backend (missing %s)", e.capability)
+}
+
+// setBackendHeaders adds the headers every SpendWise API request carries
+func (b *botInstance) setBackendHeaders(req *http.Request) error {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderBotVersion, strconv.Itoa(BotAPIVersion))
+	if err := b.authorizeBackendRequest(req); err != nil {
+		return err
+	}
+	if b.tenant != nil && b.tenant.ID != "" {
+		req.Header.Set(HeaderTenantID, b.tenant.ID)
+	}
+	return nil
+}
+
+// negotiateBackend runs the GET /api/meta handshake and records what the
+// backend supports. A 404 marks a backend from before the handshake existed;
+// any other failure leaves the backend unknown so every feature stays enabled.
+func (b *botInstance) negotiateBackend() {
+	req, err := http.NewRequest("GET", b.apiURL+"/api/meta", nil)
+	if err != nil {
+		log.Printf("⚠️ Backend handshake with %s failed: %v", b.apiURL, err)
+		return
+	}
+	if err := b.setBackendHeaders(req); err != nil {
+		log.Printf("⚠️ Backend handshake with %s failed: %v", b.apiURL, err)
+		return
+	}
+
+	client := &http.Client{Timeout: MetaTimeout, Transport: backendTransport}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("⚠️ Backend handshake with %s failed, assuming all features: %v", b.apiURL, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	var meta backendMeta
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		meta.Legacy = true
+		log.Printf("🧓 Backend %s has no /api/meta, disabling newer features", b.apiURL)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		log.Printf("⚠️ Backend handshake with %s answered HTTP %d, assuming all features", b.apiURL, resp.StatusCode)
+		return
+	default:
+		raw, err := io.ReadAll(resp.Body)
+		if err == nil {
+			err = json.Unmarshal(raw, &meta)
+		}
+		if err == nil && meta.APIVersion == 0 {
+			err = fmt.Errorf("no apiVersion in response")
+		}
+		if err != nil {
+			log.Printf("⚠️ Unreadable handshake from backend %s, assuming all features: %v", b.apiURL, err)
+			return
+		}
+		log.Printf("🤝 Backend %s version %s (API %d) - capabilities: %s",
+			b.apiURL, meta.Version, meta.APIVersion, strings.Join(meta.Capabilities, ", "))
+		if meta.APIVersion < BotAPIVersion {
+			log.Printf("⚠️ Backend %s speaks API %d, older than the bot's %d", b.apiURL, meta.APIVersion, BotAPIVersion)
+		}
+	}
+
+	backendMetas.Lock()
+	backendMetas.byURL[b.apiURL] = meta
+	backendMetas.Unlock()
+}
+
+// negotiateBackends runs the handshake once per distinct backend
+func negotiateBackends() {
+	seen := make(map[string]bool)
+	for _, b := range bots {
+		for _, t := range tenants {
+			scoped := b.forTenant(t)
+			if seen[scoped.apiURL] {
+				continue
+			}
+			seen[scoped.apiURL] = true
+			scoped.negotiateBackend()
+		}
+	}
+}
+
+// supports reports whether the bot's backend offers a capability. Backends the
+// handshake could not reach are assumed to support everything.
+func (b *botInstance) supports(capability string) bool {
+	backendMetas.RLock()
+	meta, ok := backendMetas.byURL[b.apiURL]
+	backendMetas.RUnlock()
+	if !ok {
+		return true
+	}
+	if meta.Legacy {
+		return false
+	}
+	for _, c := range meta.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEndpointSupported fails fast for endpoints the backend does not offer
+func (b *botInstance) checkEndpointSupported(endpoint string) error {
+	path, _, _ := strings.Cut(endpoint, "?")
+	if capability, ok := endpointCapabilities[path]; ok && !b.supports(capability) {
+		return errUnsupported{capability: capability}
+	}
+	return nil
+}
+
+// backendVersionLine describes the negotiated backend for /version
+func (b *botInstance) backendVersionLine() string {
+	backendMetas.RLock()
+	meta, ok := backendMetas.byURL[b.apiURL]
+	backendMetas.RUnlock()
+	switch {
+	case !ok:
+		return "Backend: unknown"
+	case meta.Legacy:
+		return "Backend: legacy (no /api/meta)"
+	}
+	return fmt.Sprintf("Backend: %s (API %d)", meta.Version, meta.APIVersion)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// DefaultBotID identifies the bot configured through the top-level botToken
+	DefaultBotID = "default"
+	// HeaderTelegramSecret carries the webhook secret_token on Telegram's requests
+	HeaderTelegramSecret = "X-Telegram-Bot-Api-Secret-Token"
+)
+
+// BotConfig describes an additional bot served by this process. Empty backend
+// fields fall back to the top-level apiUrl and apiSecret.
+type BotConfig struct {
+	ID        string `json:"id"`
+	BotToken  string `json:"botToken"`
+	APIUrl    string `json:"apiUrl"`
+	APISecret string `json:"apiSecret"`
+}
+
+// botInstance is one Telegram bot together with the backend it talks to.
+// Handlers are methods so every reply and API call goes through the bot that
+// received the update.
+type botInstance struct {
+	ID        string
+	api       *tgbotapi.BotAPI
+	token     string
+	apiURL    string
+	apiSecret string
+	tenant    *tenant // set once an update has been resolved to a household
+	limiter   *sendLimiter
+	ctx       context.Context // deadline of the update being handled, see withCommandDeadline
+	outage    *atomic.Bool    // set for Pub/Sub updates, which are nacked when the backend was down
+}
+
+// bots holds every running bot by ID; defaultBot is the one behind /webhook
+var (
+	bots       = make(map[string]*botInstance)
+	defaultBot *botInstance
+)
+
+// botConfigs returns the default bot followed by any additional configured bots
+func botConfigs() []BotConfig {
+	configs := []BotConfig{{
+		ID:        DefaultBotID,
+		BotToken:  config.BotToken,
+		APIUrl:    config.APIUrl,
+		APISecret: config.APISecret,
+	}}
+	for _, extra := range config.Bots {
+		if extra.APIUrl == "" {
+			extra.APIUrl = config.APIUrl
+		}
+		if extra.APISecret == "" {
+			extra.APISecret = config.APISecret
+		}
+		configs = append(configs, extra)
+	}
+	return configs
+}
+
+// initBots connects every configured bot to Telegram, retrying transient failures
+func initBots() error {
+	configs := botConfigs()
+	seen := make(map[string]bool)
+	for _, bc := range configs {
+		if bc.ID == "" || bc.BotToken == "" {
+			return fmt.Errorf("bot %q needs both id and botToken", bc.ID)
+		}
+		if seen[bc.ID] {
+			return fmt.Errorf("duplicate bot id %q", bc.ID)
+		}
+		seen[bc.ID] = true
+	}
+
+	if telegramClient == nil {
+		initHTTPClients()
+	}
+
+	for _, bc := range configs {
+		var api *tgbotapi.BotAPI
+		err := retryStartup("bot "+bc.ID, func() error {
+			var err error
+			if config.DebugSimulator {
+				api, err = tgbotapi.NewBotAPIWithClient(bc.BotToken, tgbotapi.APIEndpoint, simulator)
+			} else {
+				api, err = tgbotapi.NewBotAPIWithClient(bc.BotToken, telegramEndpoint(), telegramClient)
+			}
+			return telegramPermanent(err)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start bot %s: %v", bc.ID, err)
+		}
+		api.Debug = false
+
+		b := &botInstance{
+			ID:        bc.ID,
+			api:       api,
+			token:     bc.BotToken,
+			apiURL:    bc.APIUrl,
+			apiSecret: bc.APISecret,
+			limiter:   newSendLimiter(),
+		}
+		bots[bc.ID] = b
+		if bc.ID == DefaultBotID {
+			defaultBot = b
+		}
+		log.Printf("✅ Bot %s initialized as @%s (API URL: %s)", bc.ID, api.Self.UserName, bc.APIUrl)
+	}
+	return nil
+}
+
+// telegramEndpoint returns the Bot API URL pattern, pointing at TELEGRAM_API_URL when set
+func telegramEndpoint() string {
+	if config.TelegramAPIURL != "" {
+		return config.TelegramAPIURL + "/bot%s/%s"
+	}
+	return tgbotapi.APIEndpoint
+}
+
+// webhookPath returns the path Telegram should post this bot's updates to
+func (b *botInstance) webhookPath() string {
+	if b.ID == DefaultBotID {
+		return "/webhook"
+	}
+	return "/webhook/" + b.ID
+}
+
+// handleWebhook decodes a Telegram update and dispatches it to this bot's handlers
+func (b *botInstance) handleWebhook(c *gin.Context) {
+	log.Printf("📥 Received webhook request for bot %s from IP: %s", b.ID, c.ClientIP())
+
+	if config.WebhookSecret != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader(HeaderTelegramSecret)), []byte(config.WebhookSecret)) != 1 {
+		log.Printf("❌ Webhook request with invalid secret token from IP: %s", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var update tgbotapi.Update
+	if !bindJSON(c, &update, false) {
+		return
+	}
+	if update.UpdateID == 0 {
+		log.Printf("❌ Webhook update without update_id from IP: %s", c.ClientIP())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "update_id is required"})
+		return
+	}
+
+	if alreadyProcessed(b.ID, update.UpdateID) || !markUpdateSeen(b.ID, update.UpdateID) {
+		log.Printf("♻️ Skipping duplicate update %d for bot %s", update.UpdateID, b.ID)
+		c.JSON(http.StatusOK, gin.H{"status": "duplicate"})
+		return
+	}
+	startUpdate(b.ID, update.UpdateID)
+
+	// Log update details
+	if update.Message != nil {
+		log.Printf("📩 Processing message update - ChatID: %d, MessageID: %d, Text: %s",
+			update.Message.Chat.ID, update.Message.MessageID, logMessageText(update.Message))
+	} else if update.CallbackQuery != nil {
+		log.Printf("🔘 Processing callback query - ChatID: %d, Data: %s",
+			updateChatID(update), update.CallbackQuery.Data)
+	} else {
+		log.Printf("⚠️ Received unknown update type")
+	}
+
+	b.handleUpdate(update)
+	advanceWatermark(b.ID, update.UpdateID)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// handleDefaultWebhook routes /webhook to the default bot. It resolves the bot per
+// request because routes are registered before startup has connected the bots.
+func handleDefaultWebhook(c *gin.Context) {
+	defaultBot.handleWebhook(c)
+}
+
+// handleBotWebhook routes /webhook/:botID to the matching bot
+func handleBotWebhook(c *gin.Context) {
+	b, ok := bots[c.Param("botID")]
+	if !ok {
+		log.Printf("❌ Webhook request for unknown bot %q from IP: %s", c.Param("botID"), c.ClientIP())
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown bot"})
+		return
+	}
+	b.handleWebhook(c)
+}
+
+// updateChatID returns the chat an update came from. Callbacks on inline-mode
+// messages carry no Message, so the sender's private chat is used instead.
+func updateChatID(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.From != nil:
+		return update.CallbackQuery.From.ID
+	}
+	return 0
+}
+
+// recoverUpdate stops a handler panic from taking down the request: it logs the
+// stack, reports it to the error sink and tells the user something went wrong.
+func (b *botInstance) recoverUpdate(update tgbotapi.Update) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	stack := debug.Stack()
+	log.Printf("❌ Panic while handling update %d for bot %s: %v\n%s", update.UpdateID, b.ID, r, stack)
+	reportError("update handler", r, stack)
+
+	chatID := updateChatID(update)
+	if chatID == 0 {
+		return
+	}
+	if _, err := b.api.Send(tgbotapi.NewMessage(chatID, ErrorGenericFailure)); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
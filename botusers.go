@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// BotUsersTimeout bounds each /api/bot-users call
+	BotUsersTimeout = 15 * time.Second
+	// BotUsersCacheTTL keeps the last user list so an instance starting while the
+	// backend is down still admits the backend's users
+	BotUsersCacheTTL = 7 * 24 * time.Hour
+)
+
+// BotUser is a household member as listed by GET /api/bot-users
+type BotUser struct {
+	TelegramChatID string `json:"telegramChatId"`
+	UserName       string `json:"userName"`
+}
+
+// BotUsersResponse is the response from /api/bot-users
+type BotUsersResponse struct {
+	Users []BotUser `json:"users"`
+}
+
+func botUsersKey(t *tenant) string {
+	return "bot-users:" + t.ID
+}
+
+// fetchBotUsers loads the members of the bot's tenant from its backend
+func (b *botInstance) fetchBotUsers() ([]BotUser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), BotUsersTimeout)
+	defer cancel()
+	scoped := *b
+	scoped.ctx = ctx
+
+	result, err := scoped.apiCallWithTiming("GET", "/api/bot-users", nil)
+	if err != nil {
+		return nil, err
+	}
+	var response BotUsersResponse
+	if err := json.Unmarshal(result.Data, &response); err != nil {
+		return nil, fmt.Errorf("invalid bot users response: %w", err)
+	}
+	return response.Users, nil
+}
+
+// applyBotUsers makes the tenant's allowed chats the configured ones plus the
+// backend's users, whose names win over USER_NAMES. A chat already allowed in
+// another tenant stays there.
+func applyBotUsers(t *tenant, users []BotUser) {
+	allowed := maps.Clone(t.configuredIDs)
+	if allowed == nil {
+		allowed = make(map[string]bool)
+	}
+	names := maps.Clone(t.configuredNames)
+	if names == nil {
+		names = make(map[string]string)
+	}
+
+	tenantsMu.Lock()
+	defer tenantsMu.Unlock()
+	for _, user := range users {
+		chatID := strings.TrimSpace(user.TelegramChatID)
+		if _, err := strconv.ParseInt(chatID, 10, 64); err != nil {
+			log.Printf("⚠️ Ignoring bot user with invalid chat ID %q for tenant %q", user.TelegramChatID, t.ID)
+			continue
+		}
+		if other, exists := tenantByChat[chatID]; exists && other != t {
+			log.Printf("⚠️ Ignoring bot user %s for tenant %q: the chat belongs to tenant %q", chatID, t.ID, other.ID)
+			continue
+		}
+		allowed[chatID] = true
+		if user.UserName != "" {
+			names[chatID] = user.UserName
+		}
+	}
+
+	for chatID, owner := range tenantByChat {
+		if owner == t && !allowed[chatID] {
+			delete(tenantByChat, chatID)
+		}
+	}
+	for chatID := range allowed {
+		tenantByChat[chatID] = t
+	}
+	t.AllowedIDs, t.UserNames = allowed, names
+}
+
+// refreshBotUsers reloads every tenant's members from its backend. When the
+// backend can't be reached the last list cached in the store is used, and
+// without one the tenant keeps the members it has.
+func refreshBotUsers() {
+	for _, t := range tenants {
+		for _, b := range bots {
+			if !t.escalationBot(b) {
+				continue
+			}
+			key := botUsersKey(t)
+			users, err := b.forTenant(t).fetchBotUsers()
+			if err == nil {
+				raw, _ := json.Marshal(users)
+				if err := store.Set(key, string(raw), BotUsersCacheTTL); err != nil {
+					log.Printf("⚠️ Failed to cache bot users for tenant %q: %v", t.ID, err)
+				}
+			} else {
+				log.Printf("⚠️ Failed to load bot users for tenant %q: %v", t.ID, err)
+				raw, ok, err := store.Get(key)
+				if err != nil || !ok || json.Unmarshal([]byte(raw), &users) != nil {
+					continue
+				}
+				log.Printf("📦 Using the cached bot users for tenant %q", t.ID)
+			}
+			applyBotUsers(t, users)
+			log.Printf("👥 %d bot user(s) from the backend for tenant %q, %d allowed chat(s)", len(users), t.ID, len(t.allowedChats()))
+		}
+	}
+}
+
+// startBotUsersRefresh loads the backend's users now and then every interval.
+// Each instance refreshes its own copy, so this is a ticker rather than a
+// scheduler job that only the leader runs.
+func startBotUsersRefresh(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	refreshBotUsers()
+	log.Printf("👥 Refreshing bot users every %s", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			refreshBotUsers()
+		}
+	}()
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixBroadcast = "broadcast:"
+	// BroadcastDraftTTL is how long a previewed broadcast can still be confirmed
+	BroadcastDraftTTL = time.Hour
+	// BroadcastProgressInterval spaces out progress edits so they stay within
+	// Telegram's per-chat limit while the broadcast itself is being paced
+	BroadcastProgressInterval = 3 * time.Second
+	// BroadcastMaxFailuresListed caps the failed chats named in the delivery report
+	BroadcastMaxFailuresListed = 10
+)
+
+// broadcastDraft is a previewed /broadcast waiting for the admin to confirm it
+type broadcastDraft struct {
+	Text       string
+	AdminID    int64
+	Recipients []int64
+}
+
+func broadcastKey(botID, draftID string) string {
+	return "broadcast:" + botID + ":" + draftID
+}
+
+// broadcastRecipients returns every allowed chat the bot serves, except blocked
+// chats and the admin sending the broadcast
+func (b *botInstance) broadcastRecipients(adminID int64) []int64 {
+	var recipients []int64
+	for _, t := range tenants {
+		if !t.servedBy(b) {
+			continue
+		}
+		for _, chatIDStr := range t.allowedChats() {
+			chatID, err := strconv.ParseInt(chatIDStr, 10, 64)
+			if err != nil || chatID == adminID || isBlocked(chatID) {
+				continue
+			}
+			recipients = append(recipients, chatID)
+		}
+	}
+	sort.Slice(recipients, func(i, j int) bool { return recipients[i] < recipients[j] })
+	return recipients
+}
+
+// handleBroadcastCommand previews /broadcast <text> with a confirm button. Admin only.
+func (b *botInstance) handleBroadcastCommand(msg *tgbotapi.Message) {
+	if !isAdmin(msg.Chat.ID) {
+		log.Printf("❌ Non-admin ChatID %d tried to use /broadcast", msg.Chat.ID)
+		b.handleUnknownCommand(msg)
+		return
+	}
+
+	// Keep the text exactly as typed, line breaks included
+	text := ""
+	if i := strings.IndexFunc(msg.Text, unicode.IsSpace); i > 0 {
+		text = strings.TrimSpace(msg.Text[i:])
+	}
+
+	recipients := b.broadcastRecipients(msg.Chat.ID)
+	header := fmt.Sprintf("📣 Broadcast preview - this goes to %d chat(s):\n\n", len(recipients))
+	var response string
+	switch {
+	case text == "":
+		response = "Usage: /broadcast <text>\n\nThe message is shown for confirmation before it is sent to every allowed chat."
+	case len(recipients) == 0:
+		response = "❌ There are no chats to broadcast to"
+	case len([]rune(header+text)) > MaxTelegramMessageLength:
+		response = fmt.Sprintf("❌ Broadcast is too long - keep it under %d characters", MaxTelegramMessageLength-len([]rune(header)))
+	}
+	if response != "" {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+		return
+	}
+
+	draftID := strconv.FormatInt(time.Now().UnixNano(), 36)
+	raw, _ := json.Marshal(broadcastDraft{Text: text, AdminID: msg.Chat.ID, Recipients: recipients})
+	if err := store.Set(broadcastKey(b.ID, draftID), string(raw), BroadcastDraftTTL); err != nil {
+		log.Printf("❌ Failed to save broadcast draft: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Couldn't prepare the broadcast: "+err.Error())
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+		return
+	}
+
+	log.Printf("📣 Admin %d previewing broadcast %s to %d chats", msg.Chat.ID, draftID, len(recipients))
+	reply := tgbotapi.NewMessage(msg.Chat.ID, header+text)
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ Send to %d", len(recipients)), CallbackPrefixBroadcast+"send:"+draftID),
+		tgbotapi.NewInlineKeyboardButtonData("↩️ Cancel", CallbackPrefixBroadcast+"cancel:"+draftID),
+	))
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf("❌ Failed to send broadcast preview to ChatID %d: %v", msg.Chat.ID, err)
+	}
+}
+
+// handleBroadcastCallback sends or cancels a previewed broadcast
+func (b *botInstance) handleBroadcastCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	if !isAdmin(chatID) {
+		log.Printf("❌ Non-admin ChatID %d pressed a broadcast button", chatID)
+		b.answerCallback(cb, "Invalid action.")
+		return
+	}
+
+	action, draftID, _ := strings.Cut(strings.TrimPrefix(cb.Data, CallbackPrefixBroadcast), ":")
+	key := broadcastKey(b.ID, draftID)
+	raw, ok, err := store.Get(key)
+	var draft broadcastDraft
+	if err == nil && ok {
+		err = json.Unmarshal([]byte(raw), &draft)
+	}
+	switch {
+	case err != nil:
+		log.Printf("❌ Failed to load broadcast draft %s: %v", draftID, err)
+		b.alertCallback(cb, "❌ Couldn't load the broadcast: "+err.Error())
+		return
+	case !ok:
+		b.answerCallback(cb, "This broadcast was already sent, cancelled or has expired")
+		b.clearBroadcastButtons(cb)
+		return
+	}
+
+	switch action {
+	case "cancel":
+		if err := store.Delete(key); err != nil {
+			log.Printf("⚠️ Failed to delete broadcast draft %s: %v", draftID, err)
+		}
+		log.Printf("📣 Admin %d cancelled broadcast %s", chatID, draftID)
+		b.answerCallback(cb, "Cancelled")
+		if _, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, "↩️ Broadcast cancelled")); err != nil {
+			log.Printf("⚠️ Failed to update broadcast preview: %v", err)
+		}
+
+	case "send":
+		// Claim the draft so a double tap, or a second instance, can't send it twice
+		claimed, err := store.SetNX(key+":sending", instanceID, BroadcastDraftTTL)
+		if err != nil || !claimed {
+			b.answerCallback(cb, "This broadcast is already being sent")
+			return
+		}
+		if err := store.Delete(key); err != nil {
+			log.Printf("⚠️ Failed to delete broadcast draft %s: %v", draftID, err)
+		}
+		b.answerCallback(cb, "Sending…")
+		b.clearBroadcastButtons(cb)
+
+		status, err := b.api.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("📣 Sending broadcast… sent 0/%d", len(draft.Recipients))))
+		if err != nil {
+			log.Printf("⚠️ Failed to send broadcast status to ChatID %d: %v", chatID, err)
+		}
+		log.Printf("📣 Admin %d confirmed broadcast %s to %d chats", chatID, draftID, len(draft.Recipients))
+		go b.detached().deliverBroadcast(draftID, draft, status.MessageID)
+
+	default:
+		log.Printf("❌ Invalid broadcast callback: %s", cb.Data)
+		b.answerCallback(cb, "Invalid format.")
+	}
+}
+
+// clearBroadcastButtons removes the send/cancel buttons from the preview
+func (b *botInstance) clearBroadcastButtons(cb *tgbotapi.CallbackQuery) {
+	edit := tgbotapi.NewEditMessageReplyMarkup(cb.Message.Chat.ID, cb.Message.MessageID, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	if _, err := b.api.Request(edit); err != nil {
+		log.Printf("⚠️ Failed to remove broadcast buttons: %v", err)
+	}
+}
+
+// deliverBroadcast sends the draft to every recipient through the paced send
+// queue, editing the admin's status message with progress and, at the end, a
+// delivery report
+func (b *botInstance) deliverBroadcast(draftID string, draft broadcastDraft, statusMessageID int) {
+	startTime := time.Now()
+	total := len(draft.Recipients)
+	var sent, done atomic.Int64
+	failures := make(map[int64]string)
+	var failuresMu sync.Mutex
+
+	updateStatus := func(text string) {
+		if statusMessageID == 0 {
+			return
+		}
+		if _, err := b.api.Send(tgbotapi.NewEditMessageText(draft.AdminID, statusMessageID, text)); err != nil {
+			log.Printf("⚠️ Failed to update broadcast status: %v", err)
+		}
+	}
+
+	finished, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(BroadcastProgressInterval)
+		defer ticker.Stop()
+		reported := int64(0)
+		for {
+			select {
+			case <-finished:
+				return
+			case <-ticker.C:
+				if n := done.Load(); n != reported {
+					reported = n
+					updateStatus(fmt.Sprintf("📣 Sending broadcast… sent %d/%d", sent.Load(), total))
+				}
+			}
+		}
+	}()
+
+	workers := make(chan struct{}, BulkSendWorkers)
+	var wg sync.WaitGroup
+	for _, chatID := range draft.Recipients {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(chatID int64) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			defer done.Add(1)
+
+			if _, err := b.sendPaced(chatID, tgbotapi.NewMessage(chatID, draft.Text)); err != nil {
+				log.Printf("❌ Broadcast %s to ChatID %d failed: %v", draftID, chatID, err)
+				failuresMu.Lock()
+				failures[chatID] = err.Error()
+				failuresMu.Unlock()
+				return
+			}
+			sent.Add(1)
+		}(chatID)
+	}
+	wg.Wait()
+	close(finished)
+	<-stopped // so a late progress edit can't overwrite the report
+
+	log.Printf("✅ Broadcast %s finished in %s - %d/%d delivered", draftID, time.Since(startTime).Round(time.Second), sent.Load(), total)
+	incCounter("spendwise_broadcasts_total")
+	updateStatus(broadcastReport(sent.Load(), total, failures, time.Since(startTime)))
+}
+
+// broadcastReport summarises a finished broadcast for the admin who sent it
+func broadcastReport(sent int64, total int, failures map[int64]string, elapsed time.Duration) string {
+	lines := []string{fmt.Sprintf("✅ Broadcast delivered to %d/%d chat(s) in %s", sent, total, elapsed.Round(time.Second))}
+	if len(failures) == 0 {
+		return lines[0]
+	}
+
+	failed := make([]int64, 0, len(failures))
+	for chatID := range failures {
+		failed = append(failed, chatID)
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i] < failed[j] })
+
+	lines = append(lines, "", fmt.Sprintf("❌ Failed for %d chat(s):", len(failed)))
+	for i, chatID := range failed {
+		if i == BroadcastMaxFailuresListed {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(failed)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("• %d: %s", chatID, failures[chatID]))
+	}
+	return strings.Join(lines, "\n")
+}
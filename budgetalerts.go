@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixBudget = "budget:"
+	// MaxBudgetAlerts caps the over-budget messages sent for one batch
+	MaxBudgetAlerts = 3
+)
+
+// budgetAlert is returned by the backend when a saved expense pushes its
+// category over the monthly budget
+type budgetAlert struct {
+	ExpenseID string  `json:"expenseId"`
+	Category  string  `json:"category"`
+	Budget    float64 `json:"budget"`
+	Spent     float64 `json:"spent"`
+}
+
+// budgetAlertKeyboard offers to move the expense to next month, recategorize it
+// or dismiss the alert. Actions whose callback data would be too long are left out.
+func budgetAlertKeyboard(expenseID string) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	if recategorize := CallbackPrefixBudget + "recat:" + expenseID; expenseID != "" && len(recategorize) <= MaxCallbackDataLen {
+		row = append(row,
+			tgbotapi.NewInlineKeyboardButtonData("📅 Move to next month?", CallbackPrefixBudget+"next:"+expenseID),
+			tgbotapi.NewInlineKeyboardButtonData("🏷️ Recategorize", recategorize))
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData("✖️ Dismiss", CallbackPrefixBudget+"dismiss"))
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// sendBudgetAlerts turns the backend's over-budget alerts into actionable
+// replies to the message that logged the expense
+func (b *botInstance) sendBudgetAlerts(msg *tgbotapi.Message, alerts []budgetAlert) {
+	if len(alerts) == 0 || isLimited(msg.Chat.ID) {
+		return
+	}
+
+	formatter := formatterFor(msg.Chat.ID)
+	for i, alert := range alerts {
+		if i == MaxBudgetAlerts {
+			log.Printf("⚠️ Dropping %d more budget alerts for ChatID %d", len(alerts)-i, msg.Chat.ID)
+			break
+		}
+		log.Printf("💸 %s is over budget for ChatID %d (%.2f of %.2f)", alert.Category, msg.Chat.ID, alert.Spent, alert.Budget)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("⚠️ %s is over budget: %s of %s spent this month.",
+			alert.Category, formatter.Currency(alert.Spent), formatter.Currency(alert.Budget)))
+		reply.ReplyToMessageID = msg.MessageID
+		reply.ReplyMarkup = budgetAlertKeyboard(alert.ExpenseID)
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+}
+
+// handleBudgetCallback acts on an over-budget alert's buttons
+func (b *botInstance) handleBudgetCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	action, expenseID, _ := strings.Cut(strings.TrimPrefix(cb.Data, CallbackPrefixBudget), ":")
+	edit := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, text)); err != nil {
+			log.Printf("⚠️ Failed to update budget alert: %v", err)
+		}
+	}
+
+	switch {
+	case action == "dismiss":
+		b.answerCallback(cb, "Dismissed")
+		markup := tgbotapi.NewEditMessageReplyMarkup(chatID, cb.Message.MessageID, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+		if _, err := b.api.Request(markup); err != nil {
+			log.Printf("⚠️ Failed to remove budget alert buttons: %v", err)
+		}
+
+	case action == "next" && expenseID != "":
+		_, to := billingCycle(time.Now(), cycleStartDayFor(chatID))
+		nextMonth := to.AddDate(0, 0, 1)
+		_, err := b.apiCallWithTiming("POST", "/api/expenses/update", map[string]interface{}{
+			"id":             expenseID,
+			"telegramChatId": strconv.FormatInt(chatID, 10),
+			"date":           nextMonth.Format("2006-01-02"),
+		})
+		if err != nil {
+			log.Printf("❌ Failed to move expense %s to next month: %v", expenseID, err)
+			b.alertCallback(cb, userErrorText("moving the expense", err))
+			return
+		}
+		log.Printf("📅 Expense %s moved to %s for ChatID %d", expenseID, nextMonth.Format("2006-01-02"), chatID)
+		b.answerCallback(cb, "Moved")
+		edit("📅 Moved to " + formatterFor(chatID).Date(nextMonth) + ".")
+
+	case action == "recat" && expenseID != "":
+		setAwaiting(chatID, AwaitExpenseCategory, map[string]string{"expenseId": expenseID})
+		b.answerCallback(cb, "")
+		edit(cb.Message.Text + "\n\n🏷️ Which category should it go to? Reply with the category name.")
+
+	case action == "fix":
+		raw, ok, err := store.Get(categoryFixKey(chatID))
+		var fix categoryFix
+		if err == nil && ok {
+			err = json.Unmarshal([]byte(raw), &fix)
+		}
+		index, indexErr := strconv.Atoi(expenseID)
+		if err != nil || !ok || indexErr != nil || index < 0 || index >= len(fix.Options) {
+			log.Printf("❌ Stale category suggestion for ChatID %d: %s", chatID, cb.Data)
+			b.answerCallback(cb, "These suggestions have expired, please recategorize again.")
+			return
+		}
+		category := fix.Options[index]
+		if err := b.recategorizeExpense(chatID, fix.ExpenseID, category); err != nil {
+			b.alertCallback(cb, userErrorText("updating expense", err))
+			return
+		}
+		if err := store.Delete(categoryFixKey(chatID)); err != nil {
+			log.Printf("⚠️ Failed to clear category suggestions for ChatID %d: %v", chatID, err)
+		}
+		b.answerCallback(cb, "Moved")
+		edit("🏷️ Moved to " + category + ".")
+
+	default:
+		log.Printf("❌ Invalid budget callback: %s", cb.Data)
+		b.answerCallback(cb, "Invalid format.")
+	}
+}
+
+// recategorizeExpense moves an expense to another category
+func (b *botInstance) recategorizeExpense(chatID int64, expenseID, category string) error {
+	_, err := b.apiCallWithTiming("POST", "/api/expenses/update", map[string]interface{}{
+		"id":             expenseID,
+		"telegramChatId": strconv.FormatInt(chatID, 10),
+		"category":       category,
+	})
+	if err != nil {
+		log.Printf("❌ Failed to recategorize expense %s: %v", expenseID, err)
+		return err
+	}
+	log.Printf("🏷️ Expense %s moved to category %s", expenseID, logText(category))
+	return nil
+}
+
+// handleExpenseCategory moves an expense to the category typed after
+// "Recategorize". A name that isn't one of the chat's categories but is close
+// to some gets tap-to-fix suggestions, so a typo doesn't start a new category.
+func (b *botInstance) handleExpenseCategory(msg *tgbotapi.Message, data map[string]string) {
+	category := strings.TrimSpace(msg.Text)
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	known := b.knownCategories(msg.Chat.ID)
+	var suggestions []string
+	for _, name := range known {
+		if strings.EqualFold(name, category) {
+			category, known = name, nil
+			break
+		}
+	}
+	if known != nil {
+		suggestions = closestCategories(category, known)
+	}
+	expenseID := data["expenseId"]
+	if len(suggestions) > 0 && b.offerCategoryFix(msg.Chat.ID, expenseID, "🤔 There's no "+category+" category yet. Did you mean:", suggestions, category) {
+		return
+	}
+
+	if err := b.recategorizeExpense(msg.Chat.ID, expenseID, category); err != nil {
+		// Backends that only accept existing categories say so with a code
+		if backendErrorCode(err) == ErrCodeCategoryNotFound && len(known) > 0 {
+			suggestions = closestCategories(category, known)
+			if len(suggestions) == 0 {
+				suggestions = known[:min(len(known), MaxCategorySuggestions)]
+			}
+			if b.offerCategoryFix(msg.Chat.ID, expenseID, errorMessage(ErrCodeCategoryNotFound)+" Pick one:", suggestions, "") {
+				return
+			}
+		}
+		send(userErrorText("updating expense", err))
+		return
+	}
+	send("🏷️ Moved to " + category + ".")
+}
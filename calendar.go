@@ -0,0 +1,234 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/format"
+)
+
+const (
+	CalendarFileName = "spendwise-reminders.ics"
+	CalendarProdID   = "-//SpendWise//Telegram Bot//EN"
+)
+
+// fetchReminderPayload loads the current reminder payload from the SpendWise API
+func (b *botInstance) fetchReminderPayload() (NotificationPayload, error) {
+	var payload NotificationPayload
+
+	result, err := b.apiCallWithTiming("GET", "/api/reminders/get-payload", nil)
+	if err != nil {
+		return payload, err
+	}
+	log.Printf("🔔⏱️ REMINDER PAYLOAD TIMING: API=%dms", result.APITime.Milliseconds())
+
+	if err := json.Unmarshal(result.Data, &payload); err != nil {
+		return payload, fmt.Errorf("failed to parse reminders: %v", err)
+	}
+	return payload, nil
+}
+
+func (b *botInstance) handleCalendarCommand(msg *tgbotapi.Message) {
+	args := strings.Fields(strings.TrimSpace(msg.Text))
+	if len(args) < 2 || args[1] != "export" {
+		log.Printf("📅 Sending calendar usage to ChatID: %d", msg.Chat.ID)
+		response := "Calendar commands:\n\n" +
+			"• /calendar export - Download your reminders as an .ics file"
+		if config.CalendarToken != "" {
+			response += "\n\nSubscribe from Google/Apple Calendar:\n" + calendarFeedURL(b.tenant)
+		}
+		reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf("❌ Failed to send calendar usage to ChatID %d: %v", msg.Chat.ID, err)
+		}
+		return
+	}
+
+	log.Printf("📅 Exporting reminders calendar for ChatID: %d", msg.Chat.ID)
+	b.showTyping(msg.Chat.ID)
+	payload, err := b.fetchReminderPayload()
+	if err != nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, userErrorText("fetching reminders", err))
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
+			log.Printf(ErrorSendMessage, sendErr)
+		}
+		return
+	}
+
+	payload.Reminders = b.tenant.remindersFor(msg.Chat.ID, payload.Reminders)
+	if len(payload.Reminders) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "No reminders found 📝")
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf("❌ Failed to send 'no reminders' message to ChatID %d: %v", msg.Chat.ID, err)
+		}
+		return
+	}
+
+	doc := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{
+		Name:  CalendarFileName,
+		Bytes: renderRemindersICS(payload.Reminders, formatterFor(msg.Chat.ID), time.Now()),
+	})
+	doc.Caption = fmt.Sprintf("📅 %d reminders - open the file to add them to your calendar", len(payload.Reminders))
+	if _, err := b.api.Send(doc); err != nil {
+		log.Printf("❌ Failed to send calendar file to ChatID %d: %v", msg.Chat.ID, err)
+	} else {
+		log.Printf("✅ Calendar file with %d reminders sent to ChatID: %d", len(payload.Reminders), msg.Chat.ID)
+	}
+}
+
+// handleCalendarFeed serves the reminders as an iCal feed for calendar subscriptions.
+// Calendar clients cannot send custom headers, so the token travels in the query
+// string; each household has its own token, which selects whose bills are served.
+func handleCalendarFeed(c *gin.Context) {
+	log.Printf("📅 Calendar feed request from IP: %s", c.ClientIP())
+
+	t, ok := calendarTenant(c.Query("token"))
+	if !ok {
+		log.Printf("❌ Unauthorized calendar feed request from IP: %s", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	b := defaultBot
+	if bound, ok := bots[t.BotID]; ok {
+		b = bound
+	}
+	payload, err := b.forTenant(t).fetchReminderPayload()
+	if err != nil {
+		log.Printf("❌ Failed to build calendar feed for tenant %q: %v", t.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to fetch reminders"})
+		return
+	}
+
+	formatter := format.New(overlayFormatSettings(defaultFormatSettings(), t.Settings))
+	c.Header("Content-Disposition", "inline; filename="+CalendarFileName)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", renderRemindersICS(payload.Reminders, formatter, time.Now()))
+}
+
+// calendarToken returns a household's feed token. The default household uses
+// CALENDAR_TOKEN itself; tenants get one derived from it, so no household can
+// guess another's.
+func calendarToken(t *tenant) string {
+	if t == nil || t.ID == "" {
+		return config.CalendarToken
+	}
+	mac := hmac.New(sha256.New, []byte(config.CalendarToken))
+	mac.Write([]byte("calendar:" + t.ID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// calendarTenant returns the household whose feed token matches
+func calendarTenant(token string) (*tenant, bool) {
+	if config.CalendarToken == "" {
+		return nil, false
+	}
+	for _, t := range tenants {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(calendarToken(t))) == 1 {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// calendarFeedURL returns the public subscription URL for a household's reminders feed
+func calendarFeedURL(t *tenant) string {
+	return config.BotUrl + "/calendar.ics?token=" + calendarToken(t)
+}
+
+// renderRemindersICS renders reminders as an iCalendar document of all-day events.
+// Reminders with an explicit due date become single events; day-of-month windows
+// become monthly recurring events starting from the current month.
+func renderRemindersICS(reminders []Reminder, formatter *format.Formatter, now time.Time) []byte {
+	var b strings.Builder
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	b.WriteString("VERSION:2.0\r\n")
+	b.WriteString("PRODID:" + CalendarProdID + "\r\n")
+	b.WriteString("CALSCALE:GREGORIAN\r\n")
+	b.WriteString("METHOD:PUBLISH\r\n")
+	b.WriteString("X-WR-CALNAME:SpendWise Bills\r\n")
+
+	for _, reminder := range reminders {
+		start, end, recurring, ok := reminderEventWindow(reminder, now)
+		if !ok {
+			log.Printf("⚠️ Skipping reminder without a usable due window: %s", reminder.ID)
+			continue
+		}
+
+		b.WriteString("BEGIN:VEVENT\r\n")
+		b.WriteString("UID:" + reminder.ID + "@spendwise\r\n")
+		b.WriteString("DTSTAMP:" + stamp + "\r\n")
+		b.WriteString("DTSTART;VALUE=DATE:" + start.Format("20060102") + "\r\n")
+		b.WriteString("DTEND;VALUE=DATE:" + end.Format("20060102") + "\r\n")
+		if recurring {
+			b.WriteString("RRULE:FREQ=MONTHLY\r\n")
+		}
+		b.WriteString("SUMMARY:" + escapeICSText(fmt.Sprintf("%s - %s", reminder.Description, formatter.Currency(reminder.Amount))) + "\r\n")
+		b.WriteString("DESCRIPTION:" + escapeICSText(fmt.Sprintf("%s bill (%s)", reminder.MainType, icsDueText(reminder, start, recurring, formatter))) + "\r\n")
+		b.WriteString("TRANSP:TRANSPARENT\r\n")
+		b.WriteString("END:VEVENT\r\n")
+	}
+
+	b.WriteString("END:VCALENDAR\r\n")
+	return []byte(b.String())
+}
+
+// reminderEventWindow returns the all-day event range for a reminder; end is exclusive
+func reminderEventWindow(reminder Reminder, now time.Time) (time.Time, time.Time, bool, bool) {
+	if reminder.DueDate != "" {
+		due, err := time.ParseInLocation("2006-01-02", reminder.DueDate, now.Location())
+		if err == nil {
+			return due, due.AddDate(0, 0, 1), false, true
+		}
+		log.Printf("⚠️ Invalid due date %q on reminder %s: %v", reminder.DueDate, reminder.ID, err)
+	}
+
+	if reminder.DayOfMonthStart <= 0 {
+		return time.Time{}, time.Time{}, false, false
+	}
+
+	endDay := reminder.DayOfMonthEnd
+	if endDay < reminder.DayOfMonthStart {
+		endDay = reminder.DayOfMonthStart
+	}
+	start := time.Date(now.Year(), now.Month(), reminder.DayOfMonthStart, 0, 0, 0, 0, now.Location())
+	end := time.Date(now.Year(), now.Month(), endDay+1, 0, 0, 0, 0, now.Location())
+	return start, end, true, true
+}
+
+// icsDueText describes when an event is due without reference to today, since
+// calendar clients show the text unchanged on every occurrence
+func icsDueText(reminder Reminder, start time.Time, recurring bool, formatter *format.Formatter) string {
+	if !recurring {
+		return "Due on " + formatter.Date(start)
+	}
+	endDay := max(reminder.DayOfMonthEnd, reminder.DayOfMonthStart)
+	if endDay == reminder.DayOfMonthStart {
+		return fmt.Sprintf("Due on day %d", endDay)
+	}
+	return fmt.Sprintf("Due between day %d and %d", reminder.DayOfMonthStart, endDay)
+}
+
+// escapeICSText escapes a value for use in an iCalendar TEXT property
+func escapeICSText(text string) string {
+	replacer := strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+	)
+	return replacer.Replace(text)
+}
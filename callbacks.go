@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxCallbackAnswerLength is Telegram's limit for callback toast and alert text
+const MaxCallbackAnswerLength = 200
+
+// answeredCallbacks holds the IDs of callback queries answered while they are
+// being handled; Telegram accepts only one answer per query
+var answeredCallbacks sync.Map
+
+// answerCallback shows text as a toast and stops the button's loading spinner
+func (b *botInstance) answerCallback(cb *tgbotapi.CallbackQuery, text string) {
+	b.sendCallbackAnswer(cb, tgbotapi.NewCallback(cb.ID, text))
+}
+
+// alertCallback shows text in a dialog the user has to dismiss, for failures
+func (b *botInstance) alertCallback(cb *tgbotapi.CallbackQuery, text string) {
+	b.sendCallbackAnswer(cb, tgbotapi.NewCallbackWithAlert(cb.ID, text))
+}
+
+func (b *botInstance) sendCallbackAnswer(cb *tgbotapi.CallbackQuery, answer tgbotapi.CallbackConfig) {
+	if _, answered := answeredCallbacks.LoadOrStore(cb.ID, true); answered {
+		debugf("🔘 Callback %s already answered, dropping %q", cb.ID, answer.Text)
+		return
+	}
+	if runes := []rune(answer.Text); len(runes) > MaxCallbackAnswerLength {
+		answer.Text = string(runes[:MaxCallbackAnswerLength-1]) + "…"
+	}
+	if _, err := b.api.Request(answer); err != nil {
+		log.Printf("⚠️ Failed to answer callback query from ChatID %d: %v", updateChatID(tgbotapi.Update{CallbackQuery: cb}), err)
+	}
+}
+
+// finishCallback answers the query if no branch did, so clients never keep
+// showing a spinner, and forgets it
+func (b *botInstance) finishCallback(cb *tgbotapi.CallbackQuery) {
+	if _, answered := answeredCallbacks.LoadAndDelete(cb.ID); answered {
+		return
+	}
+	if _, err := b.api.Request(tgbotapi.NewCallback(cb.ID, "")); err != nil {
+		log.Printf("⚠️ Failed to answer callback query from ChatID %d: %v", updateChatID(tgbotapi.Update{CallbackQuery: cb}), err)
+	}
+}
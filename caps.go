@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixCap = "cap:"
+	// CapConfirmTTL is how long an expense held back by a spending cap can still be logged
+	CapConfirmTTL = time.Hour
+)
+
+// capHold is a parsed message waiting for the user to confirm logging it over their cap
+type capHold struct {
+	Expenses []ExpenseInput
+	Skipped  []string
+	Spent    float64 // spent this month before the held expenses
+}
+
+func capHoldKey(chatID int64, messageID int) string {
+	return fmt.Sprintf("cap-hold:%d:%d", chatID, messageID)
+}
+
+// debitTotal sums the expenses that count towards a cap, leaving out credits
+func debitTotal(expenses []ExpenseInput) float64 {
+	var total float64
+	for _, expense := range expenses {
+		if expense.EntryType == "" {
+			total += expense.Amount
+		}
+	}
+	return total
+}
+
+// monthSpend returns what the chat has logged in its current spending month
+func (b *botInstance) monthSpend(chatID int64, now time.Time) (float64, error) {
+	from, to := billingCycle(now, cycleStartDayFor(chatID))
+	params := url.Values{}
+	params.Set("from", from.Format("2006-01-02"))
+	params.Set("to", to.Format("2006-01-02"))
+	params.Set("telegramChatId", strconv.FormatInt(chatID, 10))
+	expenses, err := b.fetchExpenses(params)
+	if err != nil {
+		return 0, err
+	}
+	return sumExpenses(expenses), nil
+}
+
+// logWithinCap saves expenses, first asking for confirmation when they would
+// take the chat over the monthly cap an admin set with /cap
+func (b *botInstance) logWithinCap(msg *tgbotapi.Message, expenses []ExpenseInput, skipped []string) {
+	limit := viewSession(msg.Chat.ID).MonthlyCap
+	adding := debitTotal(expenses)
+	if limit <= 0 || adding <= 0 {
+		b.saveOrRequestApproval(msg, expenses, skipped)
+		return
+	}
+
+	spent, err := b.monthSpend(msg.Chat.ID, time.Now())
+	if err != nil {
+		// The cap is a guard rail; an unreachable backend shouldn't stop logging
+		log.Printf("⚠️ Couldn't check the spending cap for ChatID %d, logging anyway: %v", msg.Chat.ID, err)
+		b.saveOrRequestApproval(msg, expenses, skipped)
+		return
+	}
+	if spent+adding <= limit {
+		b.saveOrRequestApproval(msg, expenses, skipped)
+		return
+	}
+
+	raw, _ := json.Marshal(capHold{Expenses: expenses, Skipped: skipped, Spent: spent})
+	if err := store.Set(capHoldKey(msg.Chat.ID, msg.MessageID), string(raw), CapConfirmTTL); err != nil {
+		log.Printf("❌ Failed to hold over-cap expense for ChatID %d: %v", msg.Chat.ID, err)
+		b.saveOrRequestApproval(msg, expenses, skipped)
+		return
+	}
+
+	log.Printf("🚦 ChatID %d is over its monthly cap (%.2f + %.2f > %.2f), asking for confirmation", msg.Chat.ID, spent, adding, limit)
+	formatter := formatterFor(msg.Chat.ID)
+	messageID := strconv.Itoa(msg.MessageID)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("🚦 This goes over your monthly cap of %s - you've spent %s so far. Log %s anyway?",
+		formatter.Currency(limit), formatter.Currency(spent), formatter.Currency(adding)))
+	reply.ReplyToMessageID = msg.MessageID
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Log anyway", CallbackPrefixCap+"log:"+messageID),
+		tgbotapi.NewInlineKeyboardButtonData("↩️ Don't log", CallbackPrefixCap+"cancel:"+messageID),
+	))
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
+
+// handleCapCallback logs or drops an expense held back by the spending cap,
+// telling the admins when the user goes ahead
+func (b *botInstance) handleCapCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	action, messageIDStr, _ := strings.Cut(strings.TrimPrefix(cb.Data, CallbackPrefixCap), ":")
+	messageID, err := strconv.Atoi(messageIDStr)
+	if err != nil {
+		log.Printf("❌ Invalid cap callback: %s", cb.Data)
+		b.answerCallback(cb, "Invalid format.")
+		return
+	}
+
+	key := capHoldKey(chatID, messageID)
+	raw, ok, err := store.Get(key)
+	var hold capHold
+	if err == nil && ok {
+		err = json.Unmarshal([]byte(raw), &hold)
+	}
+	if err != nil || !ok {
+		log.Printf("❌ Stale cap callback for ChatID %d: %s", chatID, cb.Data)
+		b.answerCallback(cb, "This expense has expired, please send it again.")
+		return
+	}
+	if err := store.Delete(key); err != nil {
+		log.Printf("⚠️ Failed to delete cap hold for ChatID %d: %v", chatID, err)
+	}
+
+	if action != "log" {
+		b.answerCallback(cb, "Not logged")
+		if _, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, "👌 Not logged.")); err != nil {
+			log.Printf("⚠️ Failed to update cap confirmation: %v", err)
+		}
+		return
+	}
+
+	b.answerCallback(cb, "Logging")
+	if _, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, "🚦 Logged over your monthly cap.")); err != nil {
+		log.Printf("⚠️ Failed to update cap confirmation: %v", err)
+	}
+	b.saveOrRequestApproval(&tgbotapi.Message{
+		MessageID: messageID,
+		From:      cb.From,
+		Chat:      cb.Message.Chat,
+		Date:      cb.Message.Date,
+	}, hold.Expenses, hold.Skipped)
+
+	formatter := formatterFor(chatID)
+	limit := viewSession(chatID).MonthlyCap
+	alert := fmt.Sprintf("🚦 %s (ChatID %d) logged %s over their %s monthly cap - %s spent this month",
+		hold.Expenses[0].UserName, chatID, formatter.Currency(debitTotal(hold.Expenses)),
+		formatter.Currency(limit), formatter.Currency(hold.Spent+debitTotal(hold.Expenses)))
+	runLowPriority("cap-alert", func() { notifyAdmins(b, alert) })
+}
+
+// handleCapCommand lets admins set a chat's monthly spending cap: /cap <chatID> [amount|off]
+func (b *botInstance) handleCapCommand(msg *tgbotapi.Message) {
+	if !isAdmin(msg.Chat.ID) {
+		log.Printf("❌ Non-admin ChatID %d tried to use /cap", msg.Chat.ID)
+		b.handleUnknownCommand(msg)
+		return
+	}
+
+	args := strings.Fields(msg.Text)
+	var response string
+	var target int64
+	var err error
+	if len(args) >= 2 {
+		target, err = strconv.ParseInt(args[1], 10, 64)
+	}
+	formatter := formatterFor(target)
+	switch {
+	case len(args) != 2 && len(args) != 3:
+		response = "Usage: /cap <chatID> <amount|off>\n\nOnce the chat's spending this month would go over the cap, each expense needs an extra confirmation and admins are told when it is logged anyway."
+	case err != nil:
+		response = "❌ Invalid chat ID: " + args[1]
+	case len(args) == 2:
+		limit := viewSession(target).MonthlyCap
+		if limit <= 0 {
+			response = fmt.Sprintf("Chat %d has no monthly cap.", target)
+			break
+		}
+		response = fmt.Sprintf("🚦 Chat %d has a monthly cap of %s", target, formatter.Currency(limit))
+		if spent, err := b.monthSpend(target, time.Now()); err == nil {
+			response += fmt.Sprintf(", %s spent so far", formatter.Currency(spent))
+		}
+	case args[2] == "off" || args[2] == "0":
+		updateSession(target, func(s *chatSession) { s.MonthlyCap = 0 })
+		log.Printf("🚦 Admin %d cleared the monthly cap of ChatID %d", msg.Chat.ID, target)
+		response = fmt.Sprintf("✅ Monthly cap removed for chat %d", target)
+	default:
+		limit, err := parseStatementAmount(args[2], formatter)
+		if err != nil {
+			response = "❌ Give the cap as an amount, e.g. /cap " + args[1] + " 2000 (off to remove)"
+			break
+		}
+		updateSession(target, func(s *chatSession) { s.MonthlyCap = limit })
+		log.Printf("🚦 Admin %d set the monthly cap of ChatID %d to %.2f", msg.Chat.ID, target, limit)
+		response = fmt.Sprintf("🚦 Monthly cap for chat %d set to %s", target, formatter.Currency(limit))
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// CategoryLookback is the history whose categories count as known
+	CategoryLookback = 90 * 24 * time.Hour
+	// MaxCategorySuggestions caps the closest matches offered for an unknown category
+	MaxCategorySuggestions = 3
+)
+
+// categoryFix is a typed category that matched no known one, kept while the
+// chat picks a suggestion; Options may end with the name as typed
+type categoryFix struct {
+	ExpenseID string
+	Options   []string
+}
+
+func categoryFixKey(chatID int64) string {
+	return "category-fix:" + strconv.FormatInt(chatID, 10)
+}
+
+// editDistance is the Levenshtein distance between two names, ignoring case
+func editDistance(a, b string) int {
+	ra, rb := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// closestCategories returns the known categories within a typo's reach of
+// name, closest first. A third of the name's letters may differ, so "grocries"
+// finds "Groceries" but "Gifts" doesn't find "Fuel".
+func closestCategories(name string, known []string) []string {
+	limit := max(1, utf8.RuneCountInString(name)/3)
+	distance := make(map[string]int)
+	var matches []string
+	for _, category := range known {
+		if d := editDistance(name, category); d <= limit {
+			distance[category] = d
+			matches = append(matches, category)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return distance[matches[i]] < distance[matches[j]] })
+	if len(matches) > MaxCategorySuggestions {
+		matches = matches[:MaxCategorySuggestions]
+	}
+	return matches
+}
+
+// knownCategories lists the categories the chat spent in recently, from the
+// summary breakdown. Nil means they couldn't be loaded and names go unchecked.
+func (b *botInstance) knownCategories(chatID int64) []string {
+	now := time.Now()
+	result, err := b.apiCallWithTiming("GET", "/api/summary/range"+b.summaryLocaleQuery(chatID)+
+		"&from="+now.Add(-CategoryLookback).Format("2006-01-02")+"&to="+now.Format("2006-01-02"), nil)
+	var summary SummaryResponse
+	if err == nil {
+		err = json.Unmarshal(result.Data, &summary)
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to load known categories for ChatID %d: %v", chatID, err)
+		return nil
+	}
+	categories := make([]string, 0, len(summary.Categories))
+	for _, bucket := range summary.Categories {
+		categories = append(categories, bucket.Label)
+	}
+	return categories
+}
+
+// offerCategoryFix sends category suggestions as buttons that move the expense;
+// with typed set, a last button creates the category as typed. It reports
+// whether the suggestions could be offered.
+func (b *botInstance) offerCategoryFix(chatID int64, expenseID, prompt string, suggestions []string, typed string) bool {
+	fix := categoryFix{ExpenseID: expenseID, Options: append([]string(nil), suggestions...)}
+	if typed != "" {
+		fix.Options = append(fix.Options, typed)
+	}
+	raw, _ := json.Marshal(fix)
+	if err := store.Set(categoryFixKey(chatID), string(raw), SessionAwaitTTL); err != nil {
+		log.Printf("⚠️ Failed to keep category suggestions for ChatID %d: %v", chatID, err)
+		return false
+	}
+
+	log.Printf("🏷️ Suggesting %d categories for a category from ChatID %d", len(suggestions), chatID)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, option := range fix.Options {
+		label := "🏷️ " + option
+		if i == len(suggestions) {
+			label = "➕ New category " + option
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("%sfix:%d", CallbackPrefixBudget, i))))
+	}
+	reply := tgbotapi.NewMessage(chatID, prompt)
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"spendwise-telegram-go/format"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// ChartBarWidth is the number of cells in a full-length category bar
+	ChartBarWidth = 10
+	// ChartMaxCategories caps the category chart so it stays readable
+	ChartMaxCategories = 8
+	// ChartLabelWidth truncates category names so bars line up
+	ChartLabelWidth = 12
+)
+
+// sparkLevels are the unicode block heights used for charts, lowest first
+var sparkLevels = []rune("▁▂▃▄▅▆▇")
+
+// SummaryBucket is one labelled amount in a structured summary breakdown
+type SummaryBucket struct {
+	Label  string  `json:"label"`
+	Amount float64 `json:"amount"`
+}
+
+// sparkline renders one block per value scaled to the largest value
+func sparkline(values []float64) string {
+	max := 0.0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if max > 0 && v > 0 {
+			level = int(v / max * float64(len(sparkLevels)-1))
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// categoryBars renders a horizontal bar per category, e.g. "Groceries    ▇▇▇▇▇▁▁▁▁▁ ₹15,420.00".
+// Categories netting to zero or less, such as refunds, get an empty bar.
+func categoryBars(buckets []SummaryBucket, formatter *format.Formatter) string {
+	if len(buckets) > ChartMaxCategories {
+		buckets = buckets[:ChartMaxCategories]
+	}
+
+	largest := 0.0
+	for _, bucket := range buckets {
+		if bucket.Amount > largest {
+			largest = bucket.Amount
+		}
+	}
+
+	var lines []string
+	for _, bucket := range buckets {
+		filled := 0
+		if largest > 0 {
+			filled = min(max(int(bucket.Amount/largest*ChartBarWidth+0.5), 0), ChartBarWidth)
+		}
+		bar := strings.Repeat("▇", filled) + strings.Repeat("▁", ChartBarWidth-filled)
+		lines = append(lines, fmt.Sprintf("%s %s %s", padLabel(bucket.Label, ChartLabelWidth), bar, formatter.Currency(bucket.Amount)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// padLabel truncates or pads a label to exactly width characters
+func padLabel(label string, width int) string {
+	runes := []rune(label)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return label + strings.Repeat(" ", width-utf8.RuneCountInString(label))
+}
+
+// summaryCharts renders the optional category and daily breakdowns of a summary
+// as Markdown sections; it returns "" when the backend sent no structured data.
+func summaryCharts(categories, daily []SummaryBucket, formatter *format.Formatter) string {
+	var sections []string
+	if len(categories) > 0 {
+		sections = append(sections, "📊 *By category*\n```\n"+categoryBars(categories, formatter)+"\n```")
+	}
+	if len(daily) > 1 {
+		values := make([]float64, len(daily))
+		peak := daily[0]
+		for i, day := range daily {
+			values[i] = day.Amount
+			if day.Amount > peak.Amount {
+				peak = day
+			}
+		}
+		sections = append(sections, fmt.Sprintf("📈 *Daily spend*\n`%s`\nPeak: %s (%s)",
+			sparkline(values), tgbotapi.EscapeText(tgbotapi.ModeMarkdown, peak.Label), formatter.Currency(peak.Amount)))
+	}
+	return strings.Join(sections, "\n\n")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const cliUsage = `Usage: spendwise-bot [command] [flags]
+
+Commands:
+  serve            Run the bot server (default)
+  set-webhook      Register the Telegram webhook without starting the server
+  delete-webhook   Remove the Telegram webhook
+  send-test        Send a test message to a chat
+  validate-config  Check the configuration and exit non-zero on errors
+                   (also available as --validate-config)
+
+Run "spendwise-bot <command> -h" for a command's flags.
+`
+
+func main() {
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && (!strings.HasPrefix(args[0], "-") || args[0] == "--validate-config") {
+		command, args = strings.TrimPrefix(args[0], "--"), args[1:]
+	}
+
+	switch command {
+	case "serve":
+		serve(args)
+	case "set-webhook":
+		setWebhookCommand(args)
+	case "delete-webhook":
+		deleteWebhookCommand(args)
+	case "send-test":
+		sendTestCommand(args)
+	case "validate-config":
+		validateConfigCommand(args)
+	case "help":
+		fmt.Print(cliUsage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, cliUsage)
+		os.Exit(2)
+	}
+}
+
+// cliBots loads the configuration and connects the selected bot, or every bot
+// when botID is empty, without starting the server
+func cliBots(botID string) []*botInstance {
+	config = loadConfig()
+	initLogging()
+	if err := initBots(); err != nil {
+		log.Fatalf("❌ Failed to start bot: %v", err)
+	}
+
+	if botID != "" {
+		b, ok := bots[botID]
+		if !ok {
+			log.Fatalf("❌ Unknown bot %q", botID)
+		}
+		return []*botInstance{b}
+	}
+	selected := make([]*botInstance, 0, len(bots))
+	for _, b := range bots {
+		selected = append(selected, b)
+	}
+	return selected
+}
+
+func setWebhookCommand(args []string) {
+	flags := flag.NewFlagSet("set-webhook", flag.ExitOnError)
+	botID := flags.String("bot", "", "bot ID to register (default: all bots)")
+	dropPending := flags.Bool("drop-pending-updates", false, "discard updates queued while the webhook was unreachable")
+	flags.Parse(args)
+
+	for _, b := range cliBots(*botID) {
+		if err := b.registerWebhook(*dropPending); err != nil {
+			log.Fatalf("❌ Failed to set webhook for bot %s: %v", b.ID, err)
+		}
+	}
+}
+
+func deleteWebhookCommand(args []string) {
+	flags := flag.NewFlagSet("delete-webhook", flag.ExitOnError)
+	botID := flags.String("bot", "", "bot ID to remove the webhook for (default: all bots)")
+	dropPending := flags.Bool("drop-pending-updates", false, "also discard updates Telegram has queued")
+	flags.Parse(args)
+
+	for _, b := range cliBots(*botID) {
+		if _, err := b.api.Request(tgbotapi.DeleteWebhookConfig{DropPendingUpdates: *dropPending}); err != nil {
+			log.Fatalf("❌ Failed to delete webhook for bot %s: %v", b.ID, err)
+		}
+		log.Printf("✅ Webhook deleted for bot %s", b.ID)
+	}
+}
+
+func sendTestCommand(args []string) {
+	flags := flag.NewFlagSet("send-test", flag.ExitOnError)
+	chatID := flags.Int64("chat-id", 0, "chat to send the test message to (required)")
+	botID := flags.String("bot", DefaultBotID, "bot ID to send from")
+	text := flags.String("text", "", "message text (default: a short test message)")
+	flags.Parse(args)
+
+	if *chatID == 0 {
+		fmt.Fprintln(os.Stderr, "--chat-id is required")
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *text == "" {
+		host, _ := os.Hostname()
+		*text = fmt.Sprintf("✅ SpendWise test message from %s (%s)", host, buildInfo().GitCommit)
+	}
+
+	b := cliBots(*botID)[0]
+	sent, err := b.api.Send(tgbotapi.NewMessage(*chatID, *text))
+	if err != nil {
+		log.Fatalf("❌ Failed to send test message to ChatID %d: %v", *chatID, err)
+	}
+	log.Printf("✅ Test message %d sent via bot %s to ChatID: %d", sent.MessageID, b.ID, *chatID)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixRunCommand = "run_cmd:"
+	// MaxCommandSuggestionDistance is the largest edit distance still offered as a suggestion
+	MaxCommandSuggestionDistance = 2
+	// MaxCallbackDataLen is Telegram's limit for inline button callback data
+	MaxCallbackDataLen = 64
+)
+
+// knownCommands lists the commands understood by handleMessage, used for suggestions
+var knownCommands = []string{
+	"/start",
+	"/help",
+	"/expense",
+	"/e",
+	"/reminders",
+	"/pending",
+	"/quick",
+	"/summary",
+	"/month",
+	"/accounts",
+	"/reconcile",
+	"/plan",
+	"/trip",
+	"/calendar",
+	"/export",
+	"/delete",
+	"/nudges",
+	"/digest",
+	"/dashboard",
+	"/streaks",
+	"/reaction",
+	"/upi",
+	"/parse",
+	"/version",
+	"/link",
+	"/pin",
+	"/feedback",
+}
+
+// resolveAlias rewrites a configured alias to the command it stands for. Slash
+// aliases ("/s") match the first word and keep its arguments; word aliases
+// ("today") only match the whole message so they never swallow an expense line.
+func resolveAlias(text string) (string, bool) {
+	if len(config.CommandAliases) == 0 || text == "" {
+		return "", false
+	}
+
+	word, args, _ := strings.Cut(text, " ")
+	if !strings.HasPrefix(word, "/") {
+		word, args = text, ""
+	} else if at := strings.Index(word, "@"); at > 0 {
+		word = word[:at] // strip /cmd@BotName
+	}
+	for alias, command := range config.CommandAliases {
+		if strings.EqualFold(strings.TrimSpace(alias), word) && strings.HasPrefix(command, "/") {
+			return strings.TrimSpace(command + " " + args), true
+		}
+	}
+	return "", false
+}
+
+// suggestCommand returns the known command closest to the given one, if it is close enough
+func suggestCommand(command string) (string, bool) {
+	command = strings.ToLower(command)
+	if at := strings.Index(command, "@"); at > 0 {
+		command = command[:at] // strip /cmd@BotName
+	}
+
+	best, bestDistance := "", MaxCommandSuggestionDistance+1
+	for _, known := range knownCommands {
+		if d := levenshtein(command, known); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	return best, best != ""
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// handleMistypedCommand offers the closest known command, falling back to the unknown-command reply
+func (b *botInstance) handleMistypedCommand(msg *tgbotapi.Message, text string) {
+	fields := strings.Fields(text)
+	suggestion, ok := suggestCommand(fields[0])
+	if !ok {
+		b.handleUnknownCommand(msg)
+		return
+	}
+
+	corrected := strings.Join(append([]string{suggestion}, fields[1:]...), " ")
+	data := CallbackPrefixRunCommand + corrected
+	if len(data) > MaxCallbackDataLen {
+		data = CallbackPrefixRunCommand + suggestion
+	}
+
+	log.Printf("💡 Suggesting %s for mistyped command %s from ChatID: %d", suggestion, fields[0], msg.Chat.ID)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "Did you mean "+suggestion+"?")
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("▶️ Run "+suggestion, data),
+		),
+	)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf("❌ Failed to send command suggestion to ChatID %d: %v", msg.Chat.ID, err)
+	}
+}
+
+// handleRunCommandCallback runs the command from a suggestion button as if the user typed it
+func (b *botInstance) handleRunCommandCallback(cb *tgbotapi.CallbackQuery) {
+	command := strings.TrimPrefix(cb.Data, CallbackPrefixRunCommand)
+	log.Printf("▶️ Running suggested command %s for ChatID: %d", command, cb.Message.Chat.ID)
+	b.answerCallback(cb, command)
+
+	if _, err := b.api.Request(tgbotapi.NewDeleteMessage(cb.Message.Chat.ID, cb.Message.MessageID)); err != nil {
+		log.Printf("⚠️ Failed to delete suggestion message: %v", err)
+	}
+
+	b.handleMessage(&tgbotapi.Message{
+		MessageID: cb.Message.MessageID,
+		From:      cb.From,
+		Chat:      cb.Message.Chat,
+		Date:      cb.Message.Date,
+		Text:      command,
+	})
+}
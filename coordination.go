@@ -0,0 +1,489 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	CoordKeyPrefix    = "spendwise:"
+	CoordOpTimeout    = 2 * time.Second
+	UpdateDedupeTTL   = 24 * time.Hour
+	LeaderKey         = "leader:scheduler"
+	LeaderTTL         = 45 * time.Second
+	LeaderRenewPeriod = 15 * time.Second
+	// MemorySweepInterval is how often a write to the in-memory store also
+	// removes every expired key, which Get alone would only do for keys read again
+	MemorySweepInterval = time.Minute
+	// LockRetryInterval is how often a waiting acquireLock tries again
+	LockRetryInterval = 20 * time.Millisecond
+)
+
+// coordStore is shared key/value state used to coordinate multiple instances.
+// The in-memory implementation keeps single-instance deployments dependency free.
+type coordStore interface {
+	// SetNX stores value only if key is absent, returning true when it did
+	SetNX(key, value string, ttl time.Duration) (bool, error)
+	Get(key string) (string, bool, error)
+	Set(key, value string, ttl time.Duration) error
+	Delete(key string) error
+	// Renew extends key's TTL only while it still holds value
+	Renew(key, value string, ttl time.Duration) (bool, error)
+	// Release deletes key only while it still holds value
+	Release(key, value string) (bool, error)
+	// SetMax stores value unless key already holds a larger number, renewing the TTL either way
+	SetMax(key string, value int64, ttl time.Duration) error
+
+	// PushBack and PushFront add to the list at key and renew its TTL; PushBack
+	// returns the new length. PopFront removes and returns the list's first value.
+	PushBack(key, value string, ttl time.Duration) (int64, error)
+	PushFront(key, value string, ttl time.Duration) error
+	PopFront(key string) (string, bool, error)
+	ListLen(key string) (int64, error)
+}
+
+var (
+	store      coordStore = newMemoryStore()
+	instanceID            = newInstanceID()
+	isLeader   struct {
+		sync.RWMutex
+		value bool
+	}
+)
+
+// initCoordination selects the shared store; without REDIS_URL state stays in memory
+func initCoordination() error {
+	if config.RedisURL == "" {
+		log.Println("🧠 Using in-memory coordination store (single instance)")
+		return nil
+	}
+
+	rs, err := newRedisStore(config.RedisURL)
+	if err != nil {
+		return err
+	}
+	store = rs
+	log.Printf("🧠 Using Redis coordination store, instance %s", instanceID)
+	return nil
+}
+
+// storeDurable reports whether the coordination store survives a restart
+func storeDurable() bool {
+	_, inMemory := store.(*memoryStore)
+	return !inMemory
+}
+
+func newInstanceID() string {
+	host, _ := os.Hostname()
+	buf := make([]byte, 4)
+	rand.Read(buf)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(buf))
+}
+
+// acquireLock takes a lock on key shared by every instance, waiting up to wait
+// for another holder to finish. The lock expires after ttl in case its holder
+// dies; the returned release only removes it while it is still this caller's.
+func acquireLock(key string, ttl, wait time.Duration) (func(), error) {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	token := instanceID + "-" + hex.EncodeToString(buf)
+
+	deadline := time.Now().Add(wait)
+	for {
+		acquired, err := store.SetNX(key, token, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return func() {
+				if _, err := store.Release(key, token); err != nil {
+					log.Printf("⚠️ Failed to release lock %s: %v", key, err)
+				}
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("lock %s still held after %s", key, wait)
+		}
+		time.Sleep(LockRetryInterval)
+	}
+}
+
+// markUpdateSeen records an update and reports false if another delivery already claimed it
+func markUpdateSeen(botID string, updateID int) bool {
+	claimed, err := store.SetNX(fmt.Sprintf("update:%s:%d", botID, updateID), instanceID, UpdateDedupeTTL)
+	if err != nil {
+		log.Printf("⚠️ Update dedupe unavailable, processing anyway: %v", err)
+		return true
+	}
+	return claimed
+}
+
+// runLeaderElection keeps trying to hold the scheduler lease so only one instance runs jobs
+func runLeaderElection() {
+	for {
+		leader := tryLeadership()
+		isLeader.Lock()
+		if leader != isLeader.value {
+			log.Printf("👑 Scheduler leadership changed for instance %s: leader=%t", instanceID, leader)
+		}
+		isLeader.value = leader
+		isLeader.Unlock()
+		time.Sleep(LeaderRenewPeriod)
+	}
+}
+
+func tryLeadership() bool {
+	renewed, err := store.Renew(LeaderKey, instanceID, LeaderTTL)
+	if err != nil {
+		log.Printf("⚠️ Leader renewal failed: %v", err)
+		return false
+	}
+	if renewed {
+		return true
+	}
+
+	acquired, err := store.SetNX(LeaderKey, instanceID, LeaderTTL)
+	if err != nil {
+		log.Printf("⚠️ Leader election failed: %v", err)
+		return false
+	}
+	return acquired
+}
+
+// schedulerLeader reports whether this instance should run scheduled jobs
+func schedulerLeader() bool {
+	isLeader.RLock()
+	defer isLeader.RUnlock()
+	return isLeader.value
+}
+
+// ---- In-memory store ----
+
+type memoryItem struct {
+	value   string
+	list    []string
+	expires time.Time
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	items     map[string]memoryItem
+	lastSweep time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{items: make(map[string]memoryItem)}
+}
+
+// live returns the item if present and not expired; callers must hold mu
+func (m *memoryStore) live(key string) (memoryItem, bool) {
+	item, ok := m.items[key]
+	if ok && !item.expires.IsZero() && time.Now().After(item.expires) {
+		delete(m.items, key)
+		return memoryItem{}, false
+	}
+	return item, ok
+}
+
+// sweep removes expired keys at most once per MemorySweepInterval, so dedupe
+// and hold keys that are never read again don't pile up; callers must hold mu
+func (m *memoryStore) sweep() {
+	now := time.Now()
+	if now.Sub(m.lastSweep) < MemorySweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, item := range m.items {
+		if !item.expires.IsZero() && now.After(item.expires) {
+			delete(m.items, key)
+		}
+	}
+}
+
+// Len returns the number of live keys, for diagnostics
+func (m *memoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	for key := range m.items {
+		if _, ok := m.live(key); ok {
+			count++
+		}
+	}
+	return count
+}
+
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func (m *memoryStore) SetNX(key, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+	if _, ok := m.live(key); ok {
+		return false, nil
+	}
+	m.items[key] = memoryItem{value: value, expires: expiry(ttl)}
+	return true, nil
+}
+
+func (m *memoryStore) Get(key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.live(key)
+	return item.value, ok, nil
+}
+
+func (m *memoryStore) Set(key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+	m.items[key] = memoryItem{value: value, expires: expiry(ttl)}
+	return nil
+}
+
+func (m *memoryStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+func (m *memoryStore) Renew(key, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.live(key)
+	if !ok || item.value != value {
+		return false, nil
+	}
+	item.expires = expiry(ttl)
+	m.items[key] = item
+	return true, nil
+}
+
+func (m *memoryStore) Release(key, value string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.live(key)
+	if !ok || item.value != value {
+		return false, nil
+	}
+	delete(m.items, key)
+	return true, nil
+}
+
+func (m *memoryStore) SetMax(key string, value int64, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+	item, ok := m.live(key)
+	if current, err := strconv.ParseInt(item.value, 10, 64); !ok || err != nil || current < value {
+		item.value = strconv.FormatInt(value, 10)
+	}
+	item.expires = expiry(ttl)
+	m.items[key] = item
+	return nil
+}
+
+func (m *memoryStore) PushBack(key, value string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+	item, _ := m.live(key)
+	item.list = append(item.list, value)
+	item.expires = expiry(ttl)
+	m.items[key] = item
+	return int64(len(item.list)), nil
+}
+
+func (m *memoryStore) PushFront(key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+	item, _ := m.live(key)
+	item.list = append([]string{value}, item.list...)
+	item.expires = expiry(ttl)
+	m.items[key] = item
+	return nil
+}
+
+func (m *memoryStore) PopFront(key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.live(key)
+	if !ok || len(item.list) == 0 {
+		return "", false, nil
+	}
+	value := item.list[0]
+	if item.list = item.list[1:]; len(item.list) == 0 {
+		delete(m.items, key)
+	} else {
+		m.items[key] = item
+	}
+	return value, true, nil
+}
+
+func (m *memoryStore) ListLen(key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, _ := m.live(key)
+	return int64(len(item.list)), nil
+}
+
+// ---- Redis store ----
+
+// renewScript extends a key's TTL only if it still holds the caller's value
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript deletes a key only if it still holds the caller's value
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// setMaxScript stores a number unless the key already holds a larger one
+var setMaxScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]))
+if current == nil or current < tonumber(ARGV[1]) then
+	return redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+end
+return redis.call("PEXPIRE", KEYS[1], ARGV[2])`)
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func newRedisStore(url string) (*redisStore, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, permanent(fmt.Errorf("invalid redis URL: %v", err))
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("redis ping failed: %v", err)
+	}
+	return &redisStore{client: client}, nil
+}
+
+func (r *redisStore) SetNX(key, value string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	return r.client.SetNX(ctx, CoordKeyPrefix+key, value, ttl).Result()
+}
+
+func (r *redisStore) Get(key string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	value, err := r.client.Get(ctx, CoordKeyPrefix+key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+func (r *redisStore) Set(key, value string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	return r.client.Set(ctx, CoordKeyPrefix+key, value, ttl).Err()
+}
+
+func (r *redisStore) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	return r.client.Del(ctx, CoordKeyPrefix+key).Err()
+}
+
+func (r *redisStore) Renew(key, value string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	n, err := renewScript.Run(ctx, r.client, []string{CoordKeyPrefix + key}, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *redisStore) Release(key, value string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	n, err := releaseScript.Run(ctx, r.client, []string{CoordKeyPrefix + key}, value).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *redisStore) SetMax(key string, value int64, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	return setMaxScript.Run(ctx, r.client, []string{CoordKeyPrefix + key}, value, ttl.Milliseconds()).Err()
+}
+
+func (r *redisStore) PushBack(key, value string, ttl time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	var length *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		length = pipe.RPush(ctx, CoordKeyPrefix+key, value)
+		pipe.PExpire(ctx, CoordKeyPrefix+key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return length.Val(), nil
+}
+
+func (r *redisStore) PushFront(key, value string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, CoordKeyPrefix+key, value)
+		pipe.PExpire(ctx, CoordKeyPrefix+key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (r *redisStore) PopFront(key string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	value, err := r.client.LPop(ctx, CoordKeyPrefix+key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+func (r *redisStore) ListLen(key string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CoordOpTimeout)
+	defer cancel()
+	return r.client.LLen(ctx, CoordKeyPrefix+key).Result()
+}
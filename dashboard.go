@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// DashboardInterval is how often pinned group dashboards are refreshed
+	DashboardInterval = time.Hour
+	// DashboardReminders caps the outstanding bills listed on a dashboard
+	DashboardReminders = 5
+)
+
+// buildDashboard renders a group's pinned dashboard: spend this month, per
+// member totals and outstanding bills. A failed section is noted rather than
+// failing the whole dashboard.
+func (b *botInstance) buildDashboard(chatID int64, now time.Time) string {
+	formatter := formatterFor(chatID)
+	from, to := billingCycle(now, cycleStartDayFor(chatID))
+	lines := []string{fmt.Sprintf("📌 Household dashboard · %s - %s", formatter.Date(from), formatter.Date(to)), ""}
+
+	params := url.Values{}
+	params.Set("from", from.Format("2006-01-02"))
+	params.Set("to", to.Format("2006-01-02"))
+	params.Set("telegramChatId", strconv.FormatInt(chatID, 10))
+	expenses, err := b.fetchExpenses(params)
+	if err != nil {
+		log.Printf("⚠️ Dashboard expenses failed for ChatID %d: %v", chatID, err)
+		lines = append(lines, "💰 Spend couldn't be loaded right now")
+	} else {
+		lines = append(lines, fmt.Sprintf("💰 Spent this month: %s (%d expenses)", formatter.Currency(sumExpenses(expenses)), len(expenses)))
+
+		byMember := make(map[string]float64)
+		for _, expense := range expenses {
+			name := expense.UserName
+			if name == "" {
+				name = "Unknown"
+			}
+			byMember[name] += expense.Amount
+		}
+		members := mapKeys(byMember)
+		sort.SliceStable(members, func(i, j int) bool { return byMember[members[i]] > byMember[members[j]] })
+		if len(members) > 0 {
+			lines = append(lines, "", "👥 By member")
+			for _, member := range members {
+				lines = append(lines, fmt.Sprintf("• %s - %s", member, formatter.Currency(byMember[member])))
+			}
+		}
+	}
+
+	lines = append(lines, "")
+	payload, err := b.fetchReminderPayload()
+	if err != nil {
+		log.Printf("⚠️ Dashboard reminders failed for ChatID %d: %v", chatID, err)
+		lines = append(lines, "🧾 Bills couldn't be loaded right now")
+	} else {
+		pending := pendingReminders(b.tenant.remindersFor(chatID, payload.Reminders), now)
+		var outstanding float64
+		for _, reminder := range pending {
+			outstanding += reminder.Amount
+		}
+		if len(pending) == 0 {
+			lines = append(lines, "🧾 Nothing outstanding 🎉")
+		} else {
+			lines = append(lines, fmt.Sprintf("🧾 Outstanding: %s (%d bills)", formatter.Currency(outstanding), len(pending)))
+			for i, reminder := range pending {
+				if i == DashboardReminders {
+					lines = append(lines, fmt.Sprintf("…and %d more - see /pending", len(pending)-i))
+					break
+				}
+				lines = append(lines, fmt.Sprintf("• %s - %s (%s)", reminder.Description, formatter.Currency(reminder.Amount), formatDueDate(reminder)))
+			}
+		}
+	}
+
+	lines = append(lines, "", "Updated "+now.Format("15:04"))
+	return strings.Join(lines, "\n")
+}
+
+// refreshDashboard edits the chat's pinned dashboard, if it has one
+func (b *botInstance) refreshDashboard(chatID int64) {
+	messageID := viewSession(chatID).DashboardMessageID
+	if messageID == 0 {
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, b.buildDashboard(chatID, time.Now()))
+	if _, err := b.api.Send(edit); err != nil {
+		// Telegram refuses edits that change nothing
+		if strings.Contains(err.Error(), "message is not modified") {
+			return
+		}
+		log.Printf("⚠️ Failed to refresh dashboard for ChatID %d: %v", chatID, err)
+		if strings.Contains(err.Error(), "message to edit not found") {
+			log.Printf("📌 Dashboard message of ChatID %d was deleted, turning the dashboard off", chatID)
+			updateSession(chatID, func(s *chatSession) { s.DashboardMessageID = 0 })
+		}
+		return
+	}
+	log.Printf("📌 Dashboard refreshed for ChatID %d", chatID)
+}
+
+// refreshDashboardSoon refreshes the dashboard after expenses were logged,
+// without holding up the reply
+func (b *botInstance) refreshDashboardSoon(chatID int64) {
+	if viewSession(chatID).DashboardMessageID == 0 {
+		return
+	}
+	runLowPriority("dashboard-refresh", func() { b.detached().refreshDashboard(chatID) })
+}
+
+// runDashboardRefresh is the hourly refresh of every pinned dashboard, which
+// keeps bills and totals current even when nobody logs anything
+func (b *botInstance) runDashboardRefresh() {
+	refreshed := 0
+	for _, chatIDStr := range b.tenant.allowedChats() {
+		chatID, err := strconv.ParseInt(chatIDStr, 10, 64)
+		if err != nil || isLimited(chatID) || viewSession(chatID).DashboardMessageID == 0 {
+			continue
+		}
+		b.refreshDashboard(chatID)
+		refreshed++
+	}
+	log.Printf("📌 Refreshed %d dashboard(s)", refreshed)
+}
+
+// handleDashboardCommand manages a group's pinned dashboard: /dashboard on|off|now
+func (b *botInstance) handleDashboardCommand(msg *tgbotapi.Message) {
+	args := strings.Fields(msg.Text)
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+	if !msg.Chat.IsGroup() && !msg.Chat.IsSuperGroup() {
+		send("📌 The dashboard is for group chats - add me to your household group and send /dashboard on there.")
+		return
+	}
+	log.Printf("📌 Dashboard command from ChatID %d: %s", msg.Chat.ID, strings.Join(args[1:], " "))
+
+	current := viewSession(msg.Chat.ID).DashboardMessageID
+	switch {
+	case len(args) == 2 && args[1] == "on":
+		b.showTyping(msg.Chat.ID)
+		sent, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, b.buildDashboard(msg.Chat.ID, time.Now())))
+		if err != nil {
+			log.Printf("❌ Failed to send dashboard to ChatID %d: %v", msg.Chat.ID, err)
+			return
+		}
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.DashboardMessageID = sent.MessageID })
+		if current != 0 {
+			if _, err := b.api.Request(tgbotapi.UnpinChatMessageConfig{ChatID: msg.Chat.ID, MessageID: current}); err != nil {
+				log.Printf("⚠️ Failed to unpin the previous dashboard in ChatID %d: %v", msg.Chat.ID, err)
+			}
+		}
+		pin := tgbotapi.PinChatMessageConfig{ChatID: msg.Chat.ID, MessageID: sent.MessageID, DisableNotification: true}
+		if _, err := b.api.Request(pin); err != nil {
+			log.Printf("⚠️ Failed to pin dashboard in ChatID %d: %v", msg.Chat.ID, err)
+			send("📌 Dashboard on, but I couldn't pin it - make me an admin who can pin messages, then send /dashboard on again.")
+			return
+		}
+		log.Printf("📌 Dashboard pinned in ChatID %d", msg.Chat.ID)
+
+	case len(args) == 2 && args[1] == "off":
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.DashboardMessageID = 0 })
+		if current != 0 {
+			if _, err := b.api.Request(tgbotapi.UnpinChatMessageConfig{ChatID: msg.Chat.ID, MessageID: current}); err != nil {
+				log.Printf("⚠️ Failed to unpin dashboard in ChatID %d: %v", msg.Chat.ID, err)
+			}
+		}
+		send("🔕 Dashboard turned off.")
+
+	case len(args) == 2 && args[1] == "now":
+		if current == 0 {
+			send("📌 No dashboard yet - send /dashboard on to pin one.")
+			return
+		}
+		b.refreshDashboard(msg.Chat.ID)
+
+	default:
+		status := "off"
+		if current != 0 {
+			status = "on"
+		}
+		send("Dashboard: " + status + "\n\n" +
+			"• /dashboard on - Pin a dashboard with this month's spend, per-member totals and outstanding bills\n" +
+			"• /dashboard off - Unpin it and stop updating it\n" +
+			"• /dashboard now - Refresh it now\n\n" +
+			"It updates after every logged expense and every hour.")
+	}
+}
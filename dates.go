@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SummaryPeriodUsage explains the accepted /summary arguments
+const SummaryPeriodUsage = "Try: /summary 2024-06-12, /summary yesterday, /summary last week, /summary last 7 days or /summary 1 jun - 15 jun"
+
+// dateRange is an inclusive range of calendar days
+type dateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// Label describes the range for replies, e.g. "12 Jun 2024" or "1 Jun 2024 - 15 Jun 2024"
+func (r dateRange) Label() string {
+	if r.From.Equal(r.To) {
+		return r.From.Format("2 Jan 2006")
+	}
+	return r.From.Format("2 Jan 2006") + " - " + r.To.Format("2 Jan 2006")
+}
+
+var (
+	rangeSeparator = regexp.MustCompile(`\s+(?:-|–|to|until)\s+`)
+	lastNDays      = regexp.MustCompile(`^(?:last|past)\s+(\d{1,3})\s+days?$`)
+	dayLayouts     = []string{"2006-01-02", "02/01/2006", "2/1/2006", "2 Jan 2006", "2 January 2006", "Jan 2 2006", "January 2 2006"}
+	dayLayoutsNoYr = []string{"2 Jan", "2 January", "Jan 2", "January 2", "02/01", "2/1"}
+)
+
+// parseDateRange understands single days ("2024-06-12", "yesterday", "12 jun"),
+// relative periods ("last week", "this month", "last 7 days") and explicit ranges
+// ("1 jun - 15 jun", "2024-06-01 to 2024-06-15"). Dates are day-first.
+func parseDateRange(text string, now time.Time) (dateRange, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch text {
+	case "", "today":
+		return dateRange{today, today}, nil
+	case "yesterday":
+		day := today.AddDate(0, 0, -1)
+		return dateRange{day, day}, nil
+	case "this week", "week":
+		start := startOfWeek(today)
+		return dateRange{start, today}, nil
+	case "last week":
+		start := startOfWeek(today).AddDate(0, 0, -7)
+		return dateRange{start, start.AddDate(0, 0, 6)}, nil
+	case "this month", "month":
+		return dateRange{time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location()), today}, nil
+	case "last month":
+		start := time.Date(today.Year(), today.Month()-1, 1, 0, 0, 0, 0, today.Location())
+		return dateRange{start, start.AddDate(0, 1, -1)}, nil
+	}
+
+	if m := lastNDays.FindStringSubmatch(text); m != nil {
+		days, _ := strconv.Atoi(m[1])
+		if days < 1 {
+			return dateRange{}, fmt.Errorf("number of days must be at least 1")
+		}
+		return dateRange{today.AddDate(0, 0, -(days - 1)), today}, nil
+	}
+
+	if parts := rangeSeparator.Split(text, 2); len(parts) == 2 {
+		from, err := parseDay(parts[0], today)
+		if err != nil {
+			return dateRange{}, err
+		}
+		to, err := parseDay(parts[1], today)
+		if err != nil {
+			return dateRange{}, err
+		}
+		if to.Before(from) {
+			return dateRange{}, fmt.Errorf("range ends before it starts")
+		}
+		return dateRange{from, to}, nil
+	}
+
+	day, err := parseDay(text, today)
+	if err != nil {
+		return dateRange{}, err
+	}
+	return dateRange{day, day}, nil
+}
+
+// parseDay parses one calendar day. Without a year, the most recent matching
+// date on or before today is used.
+func parseDay(text string, today time.Time) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	switch text {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	for _, layout := range dayLayouts {
+		if day, err := time.ParseInLocation(layout, text, today.Location()); err == nil {
+			return day, nil
+		}
+	}
+	for _, layout := range dayLayoutsNoYr {
+		if day, err := time.ParseInLocation(layout, text, today.Location()); err == nil {
+			day = day.AddDate(today.Year()-day.Year(), 0, 0)
+			if day.After(today) {
+				day = day.AddDate(-1, 0, 0)
+			}
+			return day, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("couldn't understand the date %q", text)
+}
+
+// startOfWeek returns the Monday of the week containing day
+func startOfWeek(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixDelete = "delete:"
+	// DeleteButtonsPerRow keeps numbered buttons tappable on small screens
+	DeleteButtonsPerRow = 5
+)
+
+// todaysExpenses lists the expenses the chat logged today
+func (b *botInstance) todaysExpenses(chatID int64) ([]ExpenseRecord, error) {
+	today := time.Now().Format("2006-01-02")
+	params := url.Values{}
+	params.Set("from", today)
+	params.Set("to", today)
+	params.Set("telegramChatId", strconv.FormatInt(chatID, 10))
+	return b.fetchExpenses(params)
+}
+
+// deleteListMessage renders today's expenses as a numbered list with one button per expense
+func deleteListMessage(chatID int64, expenses []ExpenseRecord, header string) (string, *tgbotapi.InlineKeyboardMarkup) {
+	if len(expenses) == 0 {
+		return header + "No expenses logged today 📝", nil
+	}
+
+	formatter := formatterFor(chatID)
+	var lines []string
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i, expense := range expenses {
+		lines = append(lines, fmt.Sprintf("%d. %s - %s", i+1, expense.Description, formatter.Currency(expense.Amount)))
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(i+1), CallbackPrefixDelete+"pick:"+expense.ID))
+		if len(row) == DeleteButtonsPerRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return header + "🗑️ Today's expenses - tap a number to delete:\n\n" + strings.Join(lines, "\n"), &markup
+}
+
+func (b *botInstance) handleDeleteCommand(msg *tgbotapi.Message) {
+	log.Printf("🗑️ Listing today's expenses for deletion, ChatID: %d", msg.Chat.ID)
+	expenses, err := b.todaysExpenses(msg.Chat.ID)
+	if err != nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, userErrorText("fetching expenses", err))
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
+			log.Printf(ErrorSendMessage, sendErr)
+		}
+		return
+	}
+
+	text, markup := deleteListMessage(msg.Chat.ID, expenses, "")
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	if markup != nil {
+		reply.ReplyMarkup = *markup
+	}
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf("❌ Failed to send delete list to ChatID %d: %v", msg.Chat.ID, err)
+	}
+}
+
+// handleDeleteCallback drives the pick → confirm → delete flow, editing the list message in place
+func (b *botInstance) handleDeleteCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	action, expenseID, _ := strings.Cut(strings.TrimPrefix(cb.Data, CallbackPrefixDelete), ":")
+
+	expenses, err := b.todaysExpenses(chatID)
+	if err != nil {
+		log.Printf("❌ Failed to fetch expenses for delete callback, ChatID %d: %v", chatID, err)
+		b.alertCallback(cb, "❌ Error fetching expenses")
+		return
+	}
+
+	var target *ExpenseRecord
+	for i := range expenses {
+		if expenses[i].ID == expenseID {
+			target = &expenses[i]
+		}
+	}
+
+	header := ""
+	switch {
+	case action == "cancel":
+		b.answerCallback(cb, "Cancelled")
+
+	case target == nil:
+		log.Printf("⚠️ Expense %s no longer listed for ChatID %d", expenseID, chatID)
+		b.answerCallback(cb, "That expense is already gone")
+
+	case action == "pick":
+		b.answerCallback(cb, "")
+		text := fmt.Sprintf("🗑️ Delete %s - %s?", target.Description, formatterFor(chatID).Currency(target.Amount))
+		if target.Note != "" {
+			text += "\n📝 " + target.Note
+		}
+		markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Yes, delete", CallbackPrefixDelete+"confirm:"+target.ID),
+			tgbotapi.NewInlineKeyboardButtonData("↩️ Cancel", CallbackPrefixDelete+"cancel"),
+		))
+		if _, err := b.api.Send(tgbotapi.NewEditMessageTextAndMarkup(chatID, cb.Message.MessageID, text, markup)); err != nil {
+			log.Printf("⚠️ Failed to show delete confirmation: %v", err)
+		}
+		return
+
+	case action == "confirm":
+		log.Printf("🗑️ Deleting expense %s for ChatID %d", target.ID, chatID)
+		_, err := b.apiCallWithTiming("POST", "/api/expenses/delete", map[string]string{
+			"id":             target.ID,
+			"telegramChatId": strconv.FormatInt(chatID, 10),
+		})
+		if err != nil {
+			log.Printf("❌ Failed to delete expense %s: %v", target.ID, err)
+			b.alertCallback(cb, userErrorText("deleting the expense", err))
+			return
+		}
+		b.answerCallback(cb, "Deleted")
+		unlinkEntity(EntityExpense, target.ID)
+		header = fmt.Sprintf("✅ Deleted %s - %s\n\n", target.Description, formatterFor(chatID).Currency(target.Amount))
+
+		remaining := expenses[:0]
+		for _, expense := range expenses {
+			if expense.ID != target.ID {
+				remaining = append(remaining, expense)
+			}
+		}
+		expenses = remaining
+
+	default:
+		log.Printf("❌ Invalid delete callback: %s", cb.Data)
+		b.answerCallback(cb, "Invalid format.")
+		return
+	}
+
+	text, markup := deleteListMessage(chatID, expenses, header)
+	var edit tgbotapi.EditMessageTextConfig
+	if markup != nil {
+		edit = tgbotapi.NewEditMessageTextAndMarkup(chatID, cb.Message.MessageID, text, *markup)
+	} else {
+		edit = tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, text)
+	}
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("⚠️ Failed to update delete list: %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/format"
+)
+
+const (
+	DigestInterval = time.Hour
+	// The weekly digest goes out on Sunday evening, between these hours
+	DigestWeekday       = time.Sunday
+	DigestSendFromHour  = 18
+	DigestSendUntilHour = 22
+	// DigestStateTTL remembers that a week's digest was already sent
+	DigestStateTTL = 8 * 24 * time.Hour
+	// DigestTopCategories is how many categories the digest lists
+	DigestTopCategories = 3
+)
+
+// digestTemplate renders the weekly digest as Markdown
+var digestTemplate = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"md": func(text string) string { return tgbotapi.EscapeText(tgbotapi.ModeMarkdown, text) },
+}).Parse(`🗓️ *Your week: {{md .Week}}*
+
+💰 Total spent: {{.Currency .Total}}
+{{- if .Count}}
+🧾 Expenses: {{.Count}}{{end}}
+{{- if .Categories}}
+
+🏆 *Top categories*
+{{- range .Categories}}
+• {{md .Label}} - {{$.Currency .Amount}}{{end}}{{end}}
+{{- if .Biggest}}
+
+💸 *Biggest expense*
+{{md .Biggest.Description}} - {{.Currency .Biggest.Amount}}{{if .Biggest.Date}} ({{.Day .Biggest.Date}}){{end}}{{end}}
+
+🔔 *Due next week*
+{{- range .Upcoming}}
+• {{md .Reminder.Description}} - {{$.Currency .Reminder.Amount}} ({{md .When}}){{else}}
+Nothing due 🎉{{end}}
+{{- if .Streaks}}
+
+🔥 *Streaks*
+{{- range .Streaks}}
+{{md .}}{{end}}{{end}}
+{{- if .Failed}}
+
+⚠️ _Some parts couldn't be loaded: {{md .Failed}}_{{end}}
+
+/digest off to stop these`))
+
+// digestView is the data passed to digestTemplate
+type digestView struct {
+	Week       string
+	Total      float64
+	Count      int
+	Categories []SummaryBucket
+	Biggest    *ExpenseRecord
+	Upcoming   []upcomingReminder
+	Streaks    []string
+	Failed     string
+	formatter  *format.Formatter
+}
+
+func (v digestView) Currency(amount float64) string {
+	return v.formatter.Currency(amount)
+}
+
+// Day formats a backend YYYY-MM-DD date, passing anything else through escaped
+func (v digestView) Day(date string) string {
+	if day, err := time.Parse("2006-01-02", date); err == nil {
+		return v.formatter.Date(day)
+	}
+	return tgbotapi.EscapeText(tgbotapi.ModeMarkdown, date)
+}
+
+// upcomingReminder is a reminder falling due in the coming week
+type upcomingReminder struct {
+	Reminder Reminder
+	Due      time.Time
+	When     string
+}
+
+// weekBounds returns Monday and Sunday of the week containing now, as dates
+func weekBounds(now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monday := startOfWeek(today)
+	return monday, monday.AddDate(0, 0, 6)
+}
+
+// remindersDueBetween returns the active, unpaid reminders whose due window
+// touches [from, to], ordered by the first day they fall due
+func remindersDueBetween(reminders []Reminder, from, to time.Time, formatter *format.Formatter) []upcomingReminder {
+	var upcoming []upcomingReminder
+	for _, reminder := range reminders {
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			start, end, ok := reminderWindow(reminder, day)
+			if !ok || day.Before(start) || day.After(end) || !isReminderActive(reminder, day) || isReminderPaid(reminder, day) {
+				continue
+			}
+			upcoming = append(upcoming, upcomingReminder{Reminder: reminder, Due: day, When: day.Weekday().String()[:3] + " " + formatter.Date(day)})
+			break
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool { return upcoming[i].Due.Before(upcoming[j].Due) })
+	return upcoming
+}
+
+// buildWeeklyDigest assembles the digest for the week containing now. The
+// summary, expense list and reminders are fetched in parallel; a failed call
+// leaves its section out rather than failing the whole digest.
+func (b *botInstance) buildWeeklyDigest(chatID int64, now time.Time) (string, error) {
+	formatter := formatterFor(chatID)
+	monday, sunday := weekBounds(now)
+	from, to := monday.Format("2006-01-02"), sunday.Format("2006-01-02")
+
+	var (
+		wg                                sync.WaitGroup
+		summary                           SummaryResponse
+		expenses                          []ExpenseRecord
+		payload                           NotificationPayload
+		streaks                           spendingStreaks
+		summaryErr, expensesErr, fetchErr error
+		streaksErr                        error
+	)
+	withStreaks := viewSession(chatID).StreaksEnabled
+	if withStreaks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			streaks, streaksErr = b.fetchStreaks(chatID, now)
+		}()
+	}
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		result, err := b.apiCallWithTiming("GET", "/api/summary/range"+b.summaryLocaleQuery(chatID)+"&from="+from+"&to="+to, nil)
+		if err == nil {
+			err = json.Unmarshal(result.Data, &summary)
+		}
+		summaryErr = err
+	}()
+	go func() {
+		defer wg.Done()
+		params := url.Values{}
+		params.Set("from", from)
+		params.Set("to", to)
+		expenses, expensesErr = b.fetchExpenses(params)
+	}()
+	go func() {
+		defer wg.Done()
+		payload, fetchErr = b.fetchReminderPayload()
+	}()
+	wg.Wait()
+
+	if summaryErr != nil && expensesErr != nil {
+		return "", fmt.Errorf("couldn't load this week's expenses: %v", summaryErr)
+	}
+
+	view := digestView{Week: formatter.Date(monday) + " - " + formatter.Date(sunday), formatter: formatter}
+	var failed []string
+
+	if summaryErr == nil && summary.isStructured() {
+		view.Total, view.Count = *summary.Total, summary.Count
+	} else {
+		if summaryErr != nil {
+			log.Printf("⚠️ Weekly digest summary failed for ChatID %d: %v", chatID, summaryErr)
+		}
+		for _, expense := range expenses {
+			view.Total += expense.Amount
+		}
+		view.Count = len(expenses)
+	}
+	if summaryErr == nil {
+		view.Categories = append([]SummaryBucket(nil), summary.Categories...)
+		sort.SliceStable(view.Categories, func(i, j int) bool { return view.Categories[i].Amount > view.Categories[j].Amount })
+		if len(view.Categories) > DigestTopCategories {
+			view.Categories = view.Categories[:DigestTopCategories]
+		}
+	}
+
+	if expensesErr != nil {
+		log.Printf("⚠️ Weekly digest expense list failed for ChatID %d: %v", chatID, expensesErr)
+		failed = append(failed, "biggest expense")
+	}
+	for i := range expenses {
+		if view.Biggest == nil || expenses[i].Amount > view.Biggest.Amount {
+			view.Biggest = &expenses[i]
+		}
+	}
+
+	if fetchErr != nil {
+		log.Printf("⚠️ Weekly digest reminders failed for ChatID %d: %v", chatID, fetchErr)
+		failed = append(failed, "reminders")
+	} else {
+		nextMonday := monday.AddDate(0, 0, 7)
+		view.Upcoming = remindersDueBetween(b.tenant.remindersFor(chatID, payload.Reminders), nextMonday, nextMonday.AddDate(0, 0, 6), formatter)
+	}
+	if withStreaks {
+		if streaksErr != nil {
+			log.Printf("⚠️ Weekly digest streaks failed for ChatID %d: %v", chatID, streaksErr)
+		} else {
+			view.Streaks = streaks.lines(formatter)
+		}
+	}
+	view.Failed = strings.Join(failed, ", ")
+
+	var text strings.Builder
+	if err := digestTemplate.Execute(&text, view); err != nil {
+		return "", fmt.Errorf("failed to render digest: %v", err)
+	}
+	return text.String(), nil
+}
+
+// runWeeklyDigests sends the weekly digest to opted-in chats on Sunday evening
+func (b *botInstance) runWeeklyDigests() {
+	now := time.Now()
+	if now.Weekday() != DigestWeekday || now.Hour() < DigestSendFromHour || now.Hour() >= DigestSendUntilHour {
+		log.Printf("🌙 Skipping weekly digests outside the Sunday evening window")
+		return
+	}
+
+	monday, _ := weekBounds(now)
+	sent := 0
+	for _, chatIDStr := range b.tenant.allowedChats() {
+		chatID, err := strconv.ParseInt(chatIDStr, 10, 64)
+		if err != nil || isLimited(chatID) || !viewSession(chatID).DigestEnabled {
+			continue
+		}
+
+		key := fmt.Sprintf("digest:%d:%s", chatID, monday.Format("2006-01-02"))
+		claimed, err := store.SetNX(key, instanceID, DigestStateTTL)
+		if err != nil || !claimed {
+			continue
+		}
+
+		text, err := b.buildWeeklyDigest(chatID, now)
+		if err != nil {
+			log.Printf("❌ Failed to build weekly digest for ChatID %d: %v", chatID, err)
+			store.Delete(key) // retry next run
+			continue
+		}
+
+		reply := tgbotapi.NewMessage(chatID, text)
+		reply.ParseMode = "Markdown"
+		if _, err := b.sendPaced(chatID, reply); err != nil {
+			log.Printf("❌ Failed to send weekly digest to ChatID %d: %v", chatID, err)
+			continue
+		}
+		sent++
+	}
+
+	log.Printf("🗓️ Weekly digest run finished - %d chats sent", sent)
+}
+
+// handleDigestCommand manages the opt-in: /digest on|off|now
+func (b *botInstance) handleDigestCommand(msg *tgbotapi.Message) {
+	args := strings.Fields(msg.Text)
+	log.Printf("🗓️ Digest command from ChatID %d: %s", msg.Chat.ID, strings.Join(args[1:], " "))
+
+	var response string
+	switch {
+	case len(args) == 2 && args[1] == "on":
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.DigestEnabled = true })
+		response = "🗓️ I'll send you a weekly digest every Sunday evening."
+	case len(args) == 2 && args[1] == "off":
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.DigestEnabled = false })
+		response = "🔕 Weekly digest turned off."
+	case len(args) == 2 && args[1] == "now":
+		b.showTyping(msg.Chat.ID)
+		text, err := b.buildWeeklyDigest(msg.Chat.ID, time.Now())
+		if err != nil {
+			log.Printf("❌ Failed to build weekly digest for ChatID %d: %v", msg.Chat.ID, err)
+			response = "❌ " + err.Error()
+			break
+		}
+		if _, err := b.sendMarkdown(msg.Chat.ID, text); err != nil {
+			log.Printf("❌ Failed to send weekly digest to ChatID %d: %v", msg.Chat.ID, err)
+		}
+		return
+	default:
+		status := "off"
+		if viewSession(msg.Chat.ID).DigestEnabled {
+			status = "on"
+		}
+		response = "Weekly digest: " + status + "\n\n" +
+			"• /digest on - Get a summary of the week every Sunday evening\n" +
+			"• /digest off - Stop the weekly digest\n" +
+			"• /digest now - Show this week's digest so far"
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
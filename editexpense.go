@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/parser"
+)
+
+// expenseEdit is a correction sent as a reply: "amount 60" or "desc Filter coffee"
+type expenseEdit struct {
+	Amount      float64
+	Description string
+}
+
+// parseExpenseEdit recognises reply corrections; ok is false for any other text
+func parseExpenseEdit(text string, decimalComma bool) (expenseEdit, bool) {
+	keyword, value, found := strings.Cut(strings.TrimSpace(text), " ")
+	value = strings.TrimSpace(value)
+	if !found || value == "" {
+		return expenseEdit{}, false
+	}
+
+	switch strings.ToLower(keyword) {
+	case "amount", "amt":
+		amount, ok := parser.ParseNumber(value, decimalComma)
+		if !ok || amount <= 0 {
+			return expenseEdit{}, false
+		}
+		return expenseEdit{Amount: amount}, true
+	case "desc", "description":
+		return expenseEdit{Description: value}, true
+	}
+	return expenseEdit{}, false
+}
+
+// resolveRepliedExpense finds the expense behind the message being replied to:
+// first through the stored message mapping, then by matching the original
+// "description amount" text against today's expenses.
+func (b *botInstance) resolveRepliedExpense(msg *tgbotapi.Message) (ExpenseRecord, error) {
+	replied := msg.ReplyToMessage
+	expenses, err := b.todaysExpenses(msg.Chat.ID)
+	if err != nil {
+		return ExpenseRecord{}, err
+	}
+
+	if expenseID, ok := linkedEntity(msg.Chat.ID, replied.MessageID, EntityExpense); ok {
+		for _, expense := range expenses {
+			if expense.ID == expenseID {
+				return expense, nil
+			}
+		}
+		return ExpenseRecord{ID: expenseID}, nil
+	}
+
+	entry, err := parser.ParseLine(replied.Text, parseOptions(msg.Chat.ID))
+	if err != nil || strings.Contains(replied.Text, "\n") {
+		return ExpenseRecord{}, fmt.Errorf("reply to a single expense message to edit it")
+	}
+
+	var matches []ExpenseRecord
+	for _, expense := range expenses {
+		if expense.Amount == entry.Amount && strings.EqualFold(expense.Description, entry.Description) {
+			matches = append(matches, expense)
+		}
+	}
+	if len(matches) != 1 {
+		return ExpenseRecord{}, fmt.Errorf("couldn't find which expense that message logged")
+	}
+	return matches[0], nil
+}
+
+// handleExpenseEdit patches the expense behind the replied-to message
+func (b *botInstance) handleExpenseEdit(msg *tgbotapi.Message, edit expenseEdit) {
+	log.Printf("✏️ Editing expense from reply to message %d in ChatID: %d", msg.ReplyToMessage.MessageID, msg.Chat.ID)
+
+	send := func(text string) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		reply.ReplyToMessageID = msg.ReplyToMessage.MessageID
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	expense, err := b.resolveRepliedExpense(msg)
+	if err != nil {
+		log.Printf("❌ Could not resolve expense for edit in ChatID %d: %v", msg.Chat.ID, err)
+		send("❌ " + err.Error())
+		return
+	}
+
+	body := map[string]interface{}{
+		"id":             expense.ID,
+		"telegramChatId": strconv.FormatInt(msg.Chat.ID, 10),
+	}
+	var change string
+	formatter := formatterFor(msg.Chat.ID)
+	if edit.Amount > 0 {
+		body["amount"] = edit.Amount
+		change = "amount → " + formatter.Currency(edit.Amount)
+	} else {
+		body["description"] = edit.Description
+		change = "description → " + edit.Description
+	}
+
+	if _, err := b.apiCallWithTiming("POST", "/api/expenses/update", body); err != nil {
+		log.Printf("❌ Failed to update expense %s: %v", expense.ID, err)
+		send(userErrorText("updating expense", err))
+		return
+	}
+
+	log.Printf("✅ Expense %s updated: %s", expense.ID, logText(change))
+	if expense.Description != "" {
+		send(fmt.Sprintf("✏️ Updated %s: %s", expense.Description, change))
+	} else {
+		send("✏️ Updated " + change)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// ErrorAlertCooldown suppresses repeat alerts for the same failure
+	ErrorAlertCooldown = 10 * time.Minute
+	// ErrorAlertMaxStack keeps alerts within Telegram's 4096 character limit
+	ErrorAlertMaxStack = 2500
+)
+
+// reportError is the error sink for unexpected failures. It counts the error
+// and alerts every admin through the default bot, at most once per cooldown
+// for the same source and message across all instances.
+func reportError(source string, err interface{}, stack []byte) {
+	incCounter("spendwise_errors_total", "source", source)
+
+	if defaultBot == nil || len(config.AdminIDs) == 0 {
+		return
+	}
+
+	message := fmt.Sprint(err)
+	sum := sha256.Sum256([]byte(source + "|" + message))
+	claimed, storeErr := store.SetNX("error-alert:"+hex.EncodeToString(sum[:8]), instanceID, ErrorAlertCooldown)
+	if storeErr != nil {
+		log.Printf("⚠️ Error alert dedupe unavailable, alerting anyway: %v", storeErr)
+	} else if !claimed {
+		return
+	}
+
+	text := fmt.Sprintf("🚨 %s failed on %s\n\n%s", source, instanceID, message)
+	if len(stack) > 0 {
+		if len(stack) > ErrorAlertMaxStack {
+			stack = stack[:ErrorAlertMaxStack]
+		}
+		text += "\n\n" + string(stack)
+	}
+
+	notifyAdmins(defaultBot, text)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	EscalationInterval      = time.Hour
+	EscalationSendFromHour  = 9
+	EscalationSendUntilHour = 21
+	// EscalationCCLevel is the urgency level from which CC recipients are copied
+	EscalationCCLevel = 2
+	// OverdueLookbackMonths is how many earlier months are checked for an unpaid bill
+	OverdueLookbackMonths = 3
+	// EscalationStateTTL keeps sent levels for as long as a bill can stay overdue
+	EscalationStateTTL = (OverdueLookbackMonths + 1) * 31 * 24 * time.Hour
+)
+
+// escalationLevels maps urgency levels to the minimum days overdue and the message prefix
+var escalationLevels = []struct {
+	Level   int
+	MinDays int
+	Prefix  string
+}{
+	{Level: 3, MinDays: 7, Prefix: "🚨 URGENT"},
+	{Level: 2, MinDays: 3, Prefix: "⚠️ Still unpaid"},
+	{Level: 1, MinDays: 1, Prefix: "⏰ Overdue"},
+}
+
+// runReminderEscalation re-notifies about reminders whose due window passed without
+// being marked done, raising the urgency as more days go by
+func (b *botInstance) runReminderEscalation() {
+	if !config.EscalationEnabled {
+		return
+	}
+
+	now := time.Now()
+	if now.Hour() < EscalationSendFromHour || now.Hour() >= EscalationSendUntilHour {
+		log.Printf("🌙 Skipping reminder escalation outside sending hours")
+		return
+	}
+
+	payload, err := b.fetchReminderPayload()
+	if err != nil {
+		log.Printf("❌ Reminder escalation failed to fetch reminders: %v", err)
+		return
+	}
+
+	escalated := 0
+	for _, reminder := range payload.Reminders {
+		days, dueMonth := daysOverdue(reminder, now)
+		if days == 0 || isReminderPaid(reminder, dueMonth) {
+			continue
+		}
+
+		level, prefix := escalationLevelFor(days)
+		if level == 0 || !recordEscalation(reminder.ID, dueMonth, level) {
+			continue
+		}
+
+		// dueMonth is the explicit due date itself, or the month whose window closed
+		due := dueMonth
+		if reminder.DueDate == "" {
+			due = dayInMonth(dueMonth, reminder.DayOfMonthEnd)
+		}
+		log.Printf("📣 Escalating reminder %s to level %d (%d days overdue)", reminder.ID, level, days)
+		for _, chatID := range escalationRecipients(b.tenant, b.tenant.reminderChats(reminder, payload.TelegramUserIds), level) {
+			b.sendEscalation(chatID, prefix, days, due, reminder)
+		}
+		escalated++
+	}
+
+	log.Printf("📣 Reminder escalation run finished - %d reminders escalated", escalated)
+}
+
+// escalationLevelFor returns the urgency level and prefix for the given days overdue
+func escalationLevelFor(days int) (int, string) {
+	for _, l := range escalationLevels {
+		if days >= l.MinDays {
+			return l.Level, l.Prefix
+		}
+	}
+	return 0, ""
+}
+
+func escalationKey(reminderID string, month time.Time) string {
+	return "escalation:" + reminderID + "|" + month.Format("2006-01")
+}
+
+// recordEscalation stores the highest level sent for a reminder and month in the
+// shared store, returning false if that level was already sent
+func recordEscalation(reminderID string, month time.Time, level int) bool {
+	key := escalationKey(reminderID, month)
+
+	if raw, ok, err := store.Get(key); err != nil {
+		log.Printf("⚠️ Failed to read escalation state for %s: %v", reminderID, err)
+	} else if sent, _ := strconv.Atoi(raw); ok && sent >= level {
+		return false
+	}
+
+	if err := store.Set(key, strconv.Itoa(level), EscalationStateTTL); err != nil {
+		log.Printf("⚠️ Failed to save escalation state for %s: %v", reminderID, err)
+	}
+	return true
+}
+
+// clearEscalation forgets escalation history once a reminder is marked done; overdue
+// windows can only belong to the current month or the lookback before it
+func clearEscalation(reminderID string) {
+	now := time.Now()
+	for i := 0; i <= OverdueLookbackMonths; i++ {
+		month := time.Date(now.Year(), now.Month()-time.Month(i), 1, 0, 0, 0, 0, now.Location())
+		if err := store.Delete(escalationKey(reminderID, month)); err != nil {
+			log.Printf("⚠️ Failed to clear escalation state for %s: %v", reminderID, err)
+		}
+	}
+}
+
+// escalationRecipients returns the reminder owners plus CC recipients at higher levels.
+// CC recipients outside the tenant are skipped so households never see each other's bills.
+func escalationRecipients(t *tenant, owners []string, level int) []string {
+	recipients := append([]string{}, owners...)
+	if level < EscalationCCLevel {
+		return recipients
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range recipients {
+		seen[id] = true
+	}
+	for _, id := range config.EscalationCCIDs {
+		if owner, _ := tenantForChatID(id); !seen[id] && owner == t {
+			recipients = append(recipients, id)
+			seen[id] = true
+		}
+	}
+	return recipients
+}
+
+func (b *botInstance) sendEscalation(chatIDStr, prefix string, days int, due time.Time, reminder Reminder) {
+	chatID, err := strconv.ParseInt(strings.TrimSpace(chatIDStr), 10, 64)
+	if err != nil {
+		log.Printf("❌ Invalid escalation recipient %q: %v", chatIDStr, err)
+		return
+	}
+
+	f := formatterFor(chatID)
+	text := fmt.Sprintf("%s: %s - %s was due %s and is %d day(s) overdue.",
+		prefix, reminder.Description, f.Currency(reminder.Amount), f.Date(due), days)
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	row := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Mark as done",
+			CallbackPrefixMarkDone+reminder.ID+":"+reminder.Type),
+	)
+	if amountData := reminderAmountData(reminder.ID, reminder.Type); len(amountData) <= MaxCallbackDataLen {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("✏️ Update amount", amountData))
+	}
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+	sent, err := b.sendPaced(chatID, msg)
+	if err != nil {
+		log.Printf("❌ Failed to send escalation to ChatID %d: %v", chatID, err)
+		return
+	}
+	linkMessage(chatID, sent.MessageID, EntityReminder, reminder.ID)
+	log.Printf("✅ Escalation sent to ChatID: %d", chatID)
+}
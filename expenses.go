@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+
+	"spendwise-telegram-go/format"
+)
+
+// ExpenseRecord is an expense as stored by the backend
+type ExpenseRecord struct {
+	ID          string  `json:"id"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	Date        string  `json:"date"`
+	UserName    string  `json:"userName"`
+	Account     string  `json:"account"`
+	Note        string  `json:"note,omitempty"`
+	Trip        string  `json:"trip,omitempty"`
+}
+
+type ExpenseListResponse struct {
+	Expenses []ExpenseRecord `json:"expenses"`
+	// NextCursor is set when more expenses follow; pass it back as cursor
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// fetchExpenses lists expenses from the backend filtered by the given query parameters
+// (from, to, account, telegramChatId)
+func (b *botInstance) fetchExpenses(params url.Values) ([]ExpenseRecord, error) {
+	list, err := b.fetchExpensePage(params)
+	return list.Expenses, err
+}
+
+// fetchExpensePage lists one page of expenses; params may add limit and cursor
+func (b *botInstance) fetchExpensePage(params url.Values) (ExpenseListResponse, error) {
+	var list ExpenseListResponse
+	result, err := b.apiCallWithTiming("GET", "/api/expenses/list?"+params.Encode(), nil)
+	if err != nil {
+		return list, err
+	}
+	log.Printf("📄⏱️ EXPENSE LIST TIMING: API=%dms", result.APITime.Milliseconds())
+
+	if err := json.Unmarshal(result.Data, &list); err != nil {
+		return list, fmt.Errorf("failed to parse expenses: %v", err)
+	}
+	return list, nil
+}
+
+// batchTotal totals the amounts of a parsed batch
+func batchTotal(expenses []ExpenseInput) float64 {
+	var total float64
+	for _, expense := range expenses {
+		total += expense.Amount
+	}
+	return total
+}
+
+// batchAckText is the compact reply sent next to a batch's success reaction:
+// the saved count and total, then one line per skipped input line
+func batchAckText(saved []ExpenseInput, skipped []string, formatter *format.Formatter) string {
+	text := fmt.Sprintf("✅ %d saved · %s", len(saved), formatter.Currency(batchTotal(saved)))
+	for _, line := range skipped {
+		text += "\n⚠️ Skipped " + line
+	}
+	return text
+}
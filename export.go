@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// ExportPageSize is how many expenses /export asks the backend for per page
+	ExportPageSize = 500
+	// ExportMaxPages stops an export whose backend never stops returning a cursor
+	ExportMaxPages = 1000
+	// ExportTimeout is how long a running export keeps the chat from starting another
+	ExportTimeout = 15 * time.Minute
+)
+
+// exportHeader are the CSV columns written by /export
+var exportHeader = []string{"date", "description", "amount", "account", "user", "note", "trip"}
+
+// reminderExportHeader are the CSV columns written by /export reminders
+var reminderExportHeader = []string{"description", "type", "amount", "due", "active_months", "paid_months"}
+
+func exportClaimKey(chatID int64) string {
+	return "export:" + strconv.FormatInt(chatID, 10)
+}
+
+// writeExpensesCSV streams the expenses matching params to w page by page, so
+// only one page is held in memory however long the history is. It returns the
+// number of rows and their total.
+func (b *botInstance) writeExpensesCSV(w io.Writer, params url.Values) (int, float64, error) {
+	out := csv.NewWriter(w)
+	if err := out.Write(exportHeader); err != nil {
+		return 0, 0, err
+	}
+
+	rows, total := 0, 0.0
+	seen := make(map[string]bool) // cursors already followed
+	params.Set("limit", strconv.Itoa(ExportPageSize))
+	for page := 1; ; page++ {
+		list, err := b.fetchExpensePage(params)
+		if err != nil {
+			return rows, total, fmt.Errorf("page %d: %w", page, err)
+		}
+		for _, expense := range list.Expenses {
+			record := []string{expense.Date, expense.Description, strconv.FormatFloat(expense.Amount, 'f', 2, 64),
+				expense.Account, expense.UserName, expense.Note, expense.Trip}
+			if err := out.Write(record); err != nil {
+				return rows, total, err
+			}
+			rows++
+			total += expense.Amount
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return rows, total, err
+		}
+		debugf("📤 Export page %d: %d rows so far", page, rows)
+
+		// Backends without paging return everything at once and no cursor
+		if list.NextCursor == "" || seen[list.NextCursor] {
+			return rows, total, nil
+		}
+		if page == ExportMaxPages {
+			return rows, total, fmt.Errorf("stopped after %d pages", ExportMaxPages)
+		}
+		seen[list.NextCursor] = true
+		params.Set("cursor", list.NextCursor)
+	}
+}
+
+// reminderDueWindow is a reminder's fixed due date or day range, e.g. "5-10",
+// independent of today unlike formatDueDate
+func reminderDueWindow(reminder Reminder) string {
+	switch {
+	case reminder.DueDate != "":
+		return reminder.DueDate
+	case reminder.DayOfMonthStart <= 0:
+		return ""
+	case reminder.DayOfMonthEnd <= reminder.DayOfMonthStart:
+		return strconv.Itoa(reminder.DayOfMonthStart)
+	}
+	return fmt.Sprintf("%d-%d", reminder.DayOfMonthStart, reminder.DayOfMonthEnd)
+}
+
+// renderRemindersCSV writes one row per reminder; months are space separated
+func renderRemindersCSV(reminders []Reminder) ([]byte, error) {
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+	if err := out.Write(reminderExportHeader); err != nil {
+		return nil, err
+	}
+	for _, reminder := range reminders {
+		record := []string{reminder.Description, reminder.MainType, strconv.FormatFloat(reminder.Amount, 'f', 2, 64),
+			reminderDueWindow(reminder), strings.Join(reminder.ActiveMonths, " "), strings.Join(reminder.PaidMonths, " ")}
+		if err := out.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	out.Flush()
+	return buf.Bytes(), out.Error()
+}
+
+// exportReminders sends the chat's reminders as a CSV file, so the bill
+// inventory can be reviewed or moved elsewhere
+func (b *botInstance) exportReminders(chatID int64) {
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	b.showTyping(chatID)
+	payload, err := b.fetchReminderPayload()
+	if err != nil {
+		log.Printf("❌ Failed to fetch reminders to export for ChatID %d: %v", chatID, err)
+		send(userErrorText("fetching reminders", err))
+		return
+	}
+	reminders := b.tenant.remindersFor(chatID, payload.Reminders)
+	if len(reminders) == 0 {
+		send("No reminders found 📝")
+		return
+	}
+	data, err := renderRemindersCSV(reminders)
+	if err != nil {
+		log.Printf("❌ Failed to write reminders CSV for ChatID %d: %v", chatID, err)
+		send("❌ Couldn't prepare the export, please try again later.")
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: "spendwise-reminders.csv", Bytes: data})
+	doc.Caption = fmt.Sprintf("📤 %d reminders", len(reminders))
+	if _, err := b.api.Send(doc); err != nil {
+		log.Printf("❌ Failed to send reminders export to ChatID %d: %v", chatID, err)
+		send("❌ Couldn't upload the export: " + err.Error())
+		return
+	}
+	log.Printf("📤 Exported %d reminders to ChatID %d", len(reminders), chatID)
+}
+
+// runExport writes the chat's expenses to a temporary CSV file and uploads it
+// as a document straight from disk
+func (b *botInstance) runExport(chatID int64, scope string, params url.Values) {
+	started := time.Now()
+	defer store.Delete(exportClaimKey(chatID))
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	file, err := os.CreateTemp("", "spendwise-export-*.csv")
+	if err != nil {
+		log.Printf("❌ Failed to create export file for ChatID %d: %v", chatID, err)
+		send("❌ Couldn't prepare the export, please try again later.")
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	rows, total, err := b.writeExpensesCSV(file, params)
+	if err != nil {
+		log.Printf("❌ Export for ChatID %d failed after %d rows: %v", chatID, rows, err)
+		send(userErrorText("exporting expenses", err))
+		return
+	}
+	if rows == 0 {
+		send("📤 No expenses to export.")
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		log.Printf("❌ Failed to rewind export file for ChatID %d: %v", chatID, err)
+		send("❌ Couldn't prepare the export, please try again later.")
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileReader{
+		Name:   "spendwise-expenses-" + scope + ".csv",
+		Reader: file,
+	})
+	doc.Caption = fmt.Sprintf("📤 %d expenses · %s", rows, formatterFor(chatID).Currency(total))
+	if _, err := b.api.Send(doc); err != nil {
+		log.Printf("❌ Failed to send export to ChatID %d: %v", chatID, err)
+		send("❌ Couldn't upload the export: " + err.Error())
+		return
+	}
+	log.Printf("📤 Exported %d expenses (%s) to ChatID %d in %s", rows, scope, chatID, time.Since(started).Round(time.Millisecond))
+}
+
+// handleExportCommand sends the chat's expenses as a CSV file: /export for
+// this spending month, /export all for the whole history. /export reminders
+// sends the reminders instead.
+func (b *botInstance) handleExportCommand(msg *tgbotapi.Message) {
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	args := strings.Fields(msg.Text)[1:]
+	if len(args) == 1 && strings.EqualFold(args[0], "reminders") {
+		log.Printf("📤 Exporting reminders for ChatID %d", msg.Chat.ID)
+		b.exportReminders(msg.Chat.ID)
+		return
+	}
+	params := url.Values{}
+	params.Set("telegramChatId", strconv.FormatInt(msg.Chat.ID, 10))
+	scope := "all"
+	switch {
+	case len(args) == 1 && strings.EqualFold(args[0], "all"):
+	case len(args) == 0:
+		from, to := billingCycle(time.Now(), cycleStartDayFor(msg.Chat.ID))
+		params.Set("from", from.Format("2006-01-02"))
+		params.Set("to", to.Format("2006-01-02"))
+		scope = from.Format("2006-01")
+	default:
+		send("Usage: /export [all|reminders]\n\n/export sends this month's expenses as a CSV file, /export all your whole history and /export reminders your reminders and recurring bills.")
+		return
+	}
+	if !b.supports(CapExpenseList) {
+		send(userErrorText("exporting expenses", errUnsupported{capability: CapExpenseList}))
+		return
+	}
+
+	claimed, err := store.SetNX(exportClaimKey(msg.Chat.ID), instanceID, ExportTimeout)
+	if err != nil || !claimed {
+		send("⏳ An export is already being prepared for this chat.")
+		return
+	}
+	log.Printf("📤 Starting %s export for ChatID %d", scope, msg.Chat.ID)
+	send("⏳ Preparing your export - the file follows in a moment.")
+	// Not low priority: a requested export must not be shed, and it outlives the update's deadline
+	go b.detached().runExport(msg.Chat.ID, scope, params)
+}
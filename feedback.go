@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxFeedbackQuoteLength caps the quoted message included with feedback
+const MaxFeedbackQuoteLength = 500
+
+// feedbackText is what the admins receive: the feedback plus who sent it and,
+// when /feedback replies to a message, the message it is about
+func (b *botInstance) feedbackText(msg *tgbotapi.Message, userName, text string) string {
+	lines := []string{
+		"💬 Feedback from " + userName,
+		fmt.Sprintf("Chat ID: %d · bot %s", msg.Chat.ID, b.ID),
+	}
+	if b.tenant != nil && b.tenant.ID != "" {
+		lines[1] += " · household " + b.tenant.ID
+	}
+	lines = append(lines, "", text)
+	if replied := msg.ReplyToMessage; replied != nil && replied.Text != "" {
+		quote := replied.Text
+		if runes := []rune(quote); len(runes) > MaxFeedbackQuoteLength {
+			quote = string(runes[:MaxFeedbackQuoteLength-1]) + "…"
+		}
+		lines = append(lines, "", "About this message:", quote)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleFeedbackCommand forwards /feedback <text> to the admins and, when the
+// backend supports it, opens a ticket for it
+func (b *botInstance) handleFeedbackCommand(msg *tgbotapi.Message) {
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	// Keep the text exactly as typed, line breaks included
+	text := ""
+	if i := strings.IndexFunc(msg.Text, unicode.IsSpace); i > 0 {
+		text = strings.TrimSpace(msg.Text[i:])
+	}
+	if text == "" {
+		send("Usage: /feedback <text>\n\nTell the maintainer what went wrong, e.g. /feedback \"Chai 2 cups 40\" was saved as 42. Reply to a message with /feedback to include it.")
+		return
+	}
+	if len(config.AdminIDs) == 0 {
+		log.Printf("⚠️ Feedback from ChatID %d dropped, no admins configured", msg.Chat.ID)
+		send("❌ Feedback isn't set up for this bot - please tell the person who runs it directly.")
+		return
+	}
+
+	userName := b.getUserName(msg)
+	report := b.feedbackText(msg, userName, text)
+	response := "🙏 Thanks - your feedback was sent to the maintainer."
+	result, err := b.apiCallWithTiming("POST", "/api/feedback", map[string]interface{}{
+		"telegramChatId": strconv.FormatInt(msg.Chat.ID, 10),
+		"userName":       userName,
+		"botId":          b.ID,
+		"text":           report,
+	})
+	var ticket struct {
+		TicketID string `json:"ticketId"`
+	}
+	switch {
+	case errors.As(err, new(errUnsupported)):
+		// Older backends have no ticketing; the admins still get the message
+	case err != nil:
+		log.Printf("⚠️ Failed to open feedback ticket for ChatID %d: %v", msg.Chat.ID, err)
+	case json.Unmarshal(result.Data, &ticket) == nil && ticket.TicketID != "":
+		report += "\n\nTicket: " + ticket.TicketID
+		response += " Ticket: " + ticket.TicketID
+	}
+
+	log.Printf("💬 Feedback from ChatID %d (%d chars)", msg.Chat.ID, len(text))
+	incCounter("spendwise_feedback_total")
+	// Not low priority: feedback must reach the admins even while the bot is busy
+	go notifyAdmins(b.detached(), report)
+	send(response)
+}
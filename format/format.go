@@ -0,0 +1,112 @@
+// Package format renders currency amounts, numbers, and dates according to a
+// user's locale settings, wrapping golang.org/x/text for digit grouping.
+package format
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+const (
+	DefaultLocale     = "en-IN"
+	DefaultSymbol     = "₹"
+	DefaultDateFormat = "DD MMM YYYY"
+)
+
+// Settings describes how values should be rendered for a user
+type Settings struct {
+	Locale         string `json:"locale"`         // BCP 47 tag, e.g. "en-IN", "de-DE"
+	CurrencySymbol string `json:"currencySymbol"` // e.g. "₹", "€", "$"
+	DateFormat     string `json:"dateFormat"`     // e.g. "DD/MM/YYYY", "MMM D, YYYY"
+}
+
+// Formatter renders values for a single set of settings
+type Formatter struct {
+	tag        language.Tag
+	printer    *message.Printer
+	symbol     string
+	dateLayout string
+}
+
+// New builds a Formatter, falling back to the defaults for empty or invalid settings
+func New(s Settings) *Formatter {
+	tag, err := language.Parse(strings.TrimSpace(s.Locale))
+	if err != nil || s.Locale == "" {
+		tag = language.MustParse(DefaultLocale)
+	}
+
+	symbol := s.CurrencySymbol
+	if symbol == "" {
+		symbol = DefaultSymbol
+	}
+
+	dateFormat := s.DateFormat
+	if dateFormat == "" {
+		dateFormat = DefaultDateFormat
+	}
+
+	return &Formatter{
+		tag:        tag,
+		printer:    message.NewPrinter(tag),
+		symbol:     symbol,
+		dateLayout: LayoutFromPattern(dateFormat),
+	}
+}
+
+// Locale returns the BCP 47 tag used by the formatter
+func (f *Formatter) Locale() string {
+	return f.tag.String()
+}
+
+// Symbol returns the currency symbol used by the formatter
+func (f *Formatter) Symbol() string {
+	return f.symbol
+}
+
+// Currency formats an amount with the currency symbol and locale digit grouping,
+// e.g. ₹12,34,567.89 for en-IN or €1.234.567,89 for de-DE
+func (f *Formatter) Currency(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return sign + f.symbol + f.Number(amount, 2)
+}
+
+// Number formats a value with locale grouping and a fixed number of decimals
+func (f *Formatter) Number(value float64, decimals int) string {
+	return f.printer.Sprint(number.Decimal(value, number.Scale(decimals)))
+}
+
+// DecimalComma reports whether the locale writes decimals with a comma, e.g. 12,50 for de-DE
+func (f *Formatter) DecimalComma() bool {
+	return strings.Contains(f.Number(1.5, 1), ",")
+}
+
+// Date formats a date using the configured date pattern
+func (f *Formatter) Date(t time.Time) string {
+	return t.Format(f.dateLayout)
+}
+
+// LayoutFromPattern converts a user-friendly date pattern (DD, D, MMMM, MMM, MM, M,
+// YYYY, YY, ddd) into a Go time layout. Other characters are kept as-is.
+func LayoutFromPattern(pattern string) string {
+	replacer := strings.NewReplacer(
+		"YYYY", "2006",
+		"YY", "06",
+		"MMMM", "January",
+		"MMM", "Jan",
+		"MM", "01",
+		"M", "1",
+		"DD", "02",
+		"D", "2",
+		"dddd", "Monday",
+		"ddd", "Mon",
+	)
+	return replacer.Replace(pattern)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/parser"
+)
+
+var (
+	// bankAmountPattern finds "Rs. 1,250.00", "INR 450" or "₹120" in payment alerts
+	bankAmountPattern = regexp.MustCompile(`(?i)(?:rs\.?|inr|₹)\s*([\d,]+(?:\.\d{1,2})?)`)
+	bankDebitPattern  = regexp.MustCompile(`(?i)\b(?:debited|spent|paid|withdrawn|sent|purchase|charged)\b`)
+	bankCreditPattern = regexp.MustCompile(`(?i)\b(?:credited|received|refund(?:ed)?|cashback|reversed)\b`)
+	// bankPayeePattern captures the payee after "at", "to" or "towards", up to the next clause
+	bankPayeePattern = regexp.MustCompile(`(?i)\b(?:at|to|towards)\s+([\p{L}0-9@&._*'/ -]+?)(?:\s+(?:on|via|ref|using|from|avl|bal|thru|through)\b|[.,;:(]|$)`)
+	// bankPayerPattern captures who money came back from on refunds and cashback
+	bankPayerPattern = regexp.MustCompile(`(?i)\bfrom\s+([\p{L}0-9@&._*'/ -]+?)(?:\s+(?:on|via|ref|using|to|for|avl|bal|thru|through)\b|[.,;:(]|$)`)
+)
+
+// bankTransaction is what could be read from a bank, card or UPI payment alert
+type bankTransaction struct {
+	Amount    float64
+	Merchant  string
+	EntryType string // parser.EntryTypeRefund or parser.EntryTypeCashback for money back
+	Income    bool   // money received that is neither a refund nor cashback
+	Account   string // known account the alert mentions, e.g. card or upi
+}
+
+// parseBankMessage reads a payment alert such as "Rs.450.00 debited from A/c XX1234
+// on 12-Jun-24 to VPA swiggy@icici". ok is false unless both an amount and a
+// debit or credit keyword are present.
+func parseBankMessage(text string) (bankTransaction, bool) {
+	var tx bankTransaction
+	match := bankAmountPattern.FindStringSubmatch(text)
+	if match == nil {
+		return tx, false
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+	if err != nil || amount <= 0 {
+		return tx, false
+	}
+	tx.Amount = amount
+
+	debit, credit := bankDebitPattern.FindStringIndex(text), bankCreditPattern.FindStringIndex(text)
+	switch {
+	case debit == nil && credit == nil:
+		return tx, false
+	case credit != nil && (debit == nil || credit[0] < debit[0]):
+		lower := strings.ToLower(text)
+		switch {
+		case strings.Contains(lower, "cashback"):
+			tx.EntryType = parser.EntryTypeCashback
+		case strings.Contains(lower, "refund"), strings.Contains(lower, "reversed"):
+			tx.EntryType = parser.EntryTypeRefund
+		default:
+			tx.Income = true
+		}
+	}
+
+	tx.Merchant = "Bank transaction"
+	merchantPattern := bankPayeePattern
+	if tx.EntryType != "" {
+		tx.Merchant, merchantPattern = strings.ToUpper(tx.EntryType[:1])+tx.EntryType[1:], bankPayerPattern
+	}
+	if m := merchantPattern.FindStringSubmatch(text); m != nil {
+		merchant := strings.TrimSpace(m[1])
+		merchant = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(merchant, "VPA "), "vpa "))
+		if handle, _, found := strings.Cut(merchant, "@"); found && handle != "" {
+			merchant = handle
+		}
+		if merchant != "" {
+			tx.Merchant = merchant
+		}
+	}
+
+	lower := strings.ToLower(text)
+	for _, hint := range []struct{ word, account string }{{"upi", "upi"}, {"vpa", "upi"}, {"card", "card"}} {
+		if strings.Contains(lower, hint.word) {
+			if account, ok := isKnownAccount(hint.account); ok {
+				tx.Account = account
+				break
+			}
+		}
+	}
+	return tx, true
+}
+
+// forwardOrigin names the chat or person a forwarded message came from
+func forwardOrigin(msg *tgbotapi.Message) string {
+	switch {
+	case msg.ForwardFromChat != nil && msg.ForwardFromChat.Title != "":
+		return msg.ForwardFromChat.Title
+	case msg.ForwardFromChat != nil && msg.ForwardFromChat.UserName != "":
+		return "@" + msg.ForwardFromChat.UserName
+	case msg.ForwardFrom != nil && msg.ForwardFrom.UserName != "":
+		return "@" + msg.ForwardFrom.UserName
+	case msg.ForwardFrom != nil:
+		return strings.TrimSpace(msg.ForwardFrom.FirstName + " " + msg.ForwardFrom.LastName)
+	case msg.ForwardSenderName != "":
+		return msg.ForwardSenderName
+	}
+	return "Forwarded"
+}
+
+// handleForwardedExpense logs an expense from a forwarded payment confirmation
+// (shop bots, bank channels). Bank alerts are read with parseBankMessage, anything
+// else as a regular "description amount" line; the description is prefixed with
+// the forward origin and the expense is dated when the original was sent.
+func (b *botInstance) handleForwardedExpense(msg *tgbotapi.Message) {
+	text := strings.TrimSpace(msg.Text)
+	if text == "" {
+		text = strings.TrimSpace(msg.Caption)
+	}
+	origin := forwardOrigin(msg)
+	log.Printf("↪️ Forwarded message from %s in ChatID %d: %s", origin, msg.Chat.ID, logText(text))
+
+	reply := func(response string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, response)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	expense := ExpenseInput{
+		Date:           time.Unix(int64(msg.ForwardDate), 0).Format("2006-01-02"),
+		Source:         "bot",
+		UserName:       b.getUserName(msg),
+		TelegramChatID: strconv.FormatInt(msg.Chat.ID, 10),
+		Account:        defaultAccountFor(msg.Chat.ID),
+	}
+
+	if tx, ok := parseBankMessage(text); ok {
+		if tx.Income {
+			reply("💰 That looks like money you received, not an expense - nothing logged.")
+			return
+		}
+		expense.Amount, expense.Description, expense.EntryType = tx.Amount, origin+": "+tx.Merchant, tx.EntryType
+		if tx.Account != "" {
+			expense.Account = tx.Account
+		}
+	} else {
+		entry, err := parser.ParseLine(text, parser.Options{Accounts: knownAccounts(), DecimalComma: formatterFor(msg.Chat.ID).DecimalComma()})
+		if line, _ := parser.SplitNote(text); err != nil || strings.Contains(line, "\n") {
+			log.Printf("❌ No expense found in forwarded message for ChatID %d: %v", msg.Chat.ID, err)
+			reply("❌ I couldn't find an expense in that forwarded message. Send it as \"description amount\" instead.")
+			return
+		}
+		expense.Amount, expense.Description, expense.EntryType, expense.Note = entry.Amount, origin+": "+entry.Description, entry.EntryType, entry.Note
+		if entry.Account != "" {
+			expense.Account = entry.Account
+		}
+	}
+
+	if err := validateExpenseInput(expense); err != nil {
+		reply("❌ " + err.Error())
+		return
+	}
+	b.logExpenses(msg, []ExpenseInput{expense}, nil)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	DefaultFloodMaxMessages = 20
+	DefaultFloodWindow      = time.Minute
+	FloodMuteDuration       = 5 * time.Minute
+	// UnauthorizedReplyCooldown limits the "not authorized" reply and admin alert per chat
+	UnauthorizedReplyCooldown = 24 * time.Hour
+)
+
+// floodState tracks recent message times and temporary mutes per chat
+var floodState = struct {
+	sync.Mutex
+	recent     map[int64][]time.Time
+	mutedUntil map[int64]time.Time
+}{recent: make(map[int64][]time.Time), mutedUntil: make(map[int64]time.Time)}
+
+// isAdmin reports whether the chat belongs to a bot administrator
+func isAdmin(chatID int64) bool {
+	return config.AdminIDs[strconv.FormatInt(chatID, 10)]
+}
+
+// isLimited reports whether the chat has the limited role: it may log expenses
+// and see its own /summary, but not household totals, reminders or budgets
+func isLimited(chatID int64) bool {
+	return config.LimitedIDs[strconv.FormatInt(chatID, 10)]
+}
+
+// limitedCommands are the commands a limited chat may use
+var limitedCommands = []string{"/start", "/help", "/expense", "/quick", "/summary", "/parse", "/feedback"}
+
+// limitedCallbackPrefixes are the buttons a limited chat may press: expense
+// logging, /quick menus, the large amount, duplicate and spending cap confirmations,
+// summary pages and command suggestions, which run through authorizeCommand again
+var limitedCallbackPrefixes = []string{CallbackPrefixQuickPick, CallbackPrefixQuickMenu, CallbackPrefixLargeAmount, CallbackPrefixDuplicate, CallbackPrefixCap, CallbackPrefixSummaryPage, CallbackPrefixRunCommand}
+
+const LimitedHelpText = "SpendWise Bot Help 📖\n\n" +
+	"Log an expense by sending its description and amount, e.g. Snacks 40 or 40 Snacks.\n\n" +
+	"Commands:\n" +
+	"• /quick - Log a routine expense in one tap\n" +
+	"• /summary - What you've spent today (/summary last week for other days)\n" +
+	"• /parse Snacks 40 - Check how a message would be read, without saving it\n" +
+	"• /feedback - Tell the maintainer something went wrong\n" +
+	"• /help - This message"
+
+func limitedCallback(data string) bool {
+	for _, prefix := range limitedCallbackPrefixes {
+		if strings.HasPrefix(data, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizeCommand is the command router's role check: limited chats may only
+// run limitedCommands, everything else is refused before it reaches a handler
+func (b *botInstance) authorizeCommand(msg *tgbotapi.Message, text string) bool {
+	if !strings.HasPrefix(text, "/") || !isLimited(msg.Chat.ID) {
+		return true
+	}
+	if _, escaped := escapeCommandText(text); escaped {
+		return true // /e only logs an expense
+	}
+	for _, command := range limitedCommands {
+		if strings.HasPrefix(text, command) {
+			return true
+		}
+	}
+
+	log.Printf("🔒 Limited ChatID %d tried to use %s", msg.Chat.ID, logText(strings.Fields(text)[0]))
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "🔒 That command isn't available on your account. Log expenses as \"Snacks 40\" and see your own spending with /summary.")
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+	return false
+}
+
+// isBlocked reports whether the chat has been hard-blocked; admins can never be blocked
+func isBlocked(chatID int64) bool {
+	if isAdmin(chatID) {
+		return false
+	}
+	id := strconv.FormatInt(chatID, 10)
+	if config.BlockedIDs[id] {
+		return true
+	}
+
+	// Chats blocked via /block live in the shared store so every instance honours them
+	_, blocked, err := store.Get("blocked:" + id)
+	if err != nil {
+		log.Printf("⚠️ Failed to check block list for ChatID %s: %v", id, err)
+	}
+	return blocked
+}
+
+// checkFlood records a message from the chat and reports whether it may be processed.
+// warn is true exactly once when the chat first crosses the limit.
+func checkFlood(chatID int64) (allowed bool, warn bool) {
+	if isAdmin(chatID) {
+		return true, false
+	}
+
+	maxMessages := config.FloodMaxMessages
+	if maxMessages <= 0 {
+		maxMessages = DefaultFloodMaxMessages
+	}
+	window := config.FloodWindow
+	if window <= 0 {
+		window = DefaultFloodWindow
+	}
+
+	now := time.Now()
+	floodState.Lock()
+	defer floodState.Unlock()
+
+	if until, muted := floodState.mutedUntil[chatID]; muted {
+		if now.Before(until) {
+			return false, false
+		}
+		delete(floodState.mutedUntil, chatID)
+	}
+
+	recent := floodState.recent[chatID][:0]
+	for _, t := range floodState.recent[chatID] {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	floodState.recent[chatID] = recent
+
+	if len(recent) > maxMessages {
+		floodState.mutedUntil[chatID] = now.Add(FloodMuteDuration)
+		delete(floodState.recent, chatID)
+		return false, true
+	}
+	return true, false
+}
+
+// notifyAdmins sends text to every admin chat through b
+func notifyAdmins(b *botInstance, text string) {
+	for id := range config.AdminIDs {
+		chatID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, err := b.sendPaced(chatID, tgbotapi.NewMessage(chatID, text)); err != nil {
+			log.Printf("❌ Failed to notify admin %d: %v", chatID, err)
+		}
+	}
+}
+
+// rejectUnauthorized tells a chat that isn't on the allowlist why it gets no
+// answers and alerts the admins, once per cooldown. The message itself is
+// never processed; hard-blocked chats get no reply at all.
+func (b *botInstance) rejectUnauthorized(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	if isBlocked(chatID) {
+		return
+	}
+	claimed, err := store.SetNX(fmt.Sprintf("unauthorized:%d", chatID), instanceID, UnauthorizedReplyCooldown)
+	if err != nil {
+		log.Printf("⚠️ Unauthorized reply dedupe unavailable, staying silent: %v", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	reply := tgbotapi.NewMessage(chatID, fmt.Sprintf("🔒 You're not authorized to use this bot. Your chat ID is %d - ask the admin to add you.", chatID))
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+
+	who := msg.Chat.Title
+	if who == "" && msg.From != nil {
+		who = strings.TrimSpace(msg.From.FirstName + " " + msg.From.LastName)
+		if msg.From.UserName != "" {
+			who += " (@" + msg.From.UserName + ")"
+		}
+	}
+	alert := fmt.Sprintf("🔒 Access request from %s via bot %s\nChat ID: %d", who, b.ID, chatID)
+	if payload := startPayload(msg.Text); payload != "" {
+		alert += "\nInvite: " + payload
+	}
+	runLowPriority("access-request", func() { notifyAdmins(b, alert) })
+}
+
+// allowSender applies block and flood checks, sending the single flood warning when needed
+func (b *botInstance) allowSender(chatID int64) bool {
+	if isBlocked(chatID) {
+		log.Printf("⛔ Ignoring blocked ChatID: %d", chatID)
+		return false
+	}
+
+	allowed, warn := checkFlood(chatID)
+	if warn {
+		log.Printf("🌊 Flood detected from ChatID %d, muting for %s", chatID, FloodMuteDuration)
+		reply := tgbotapi.NewMessage(chatID, fmt.Sprintf("🌊 Too many messages - I'll ignore you for %d minutes.", int(FloodMuteDuration.Minutes())))
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	} else if !allowed {
+		log.Printf("🔇 Ignoring muted ChatID: %d", chatID)
+	}
+	return allowed
+}
+
+// handleBlockCommand lets admins hard-block or unblock a chat: /block <chatID>, /unblock <chatID>
+func (b *botInstance) handleBlockCommand(msg *tgbotapi.Message, block bool) {
+	if !isAdmin(msg.Chat.ID) {
+		log.Printf("❌ Non-admin ChatID %d tried to use block commands", msg.Chat.ID)
+		b.handleUnknownCommand(msg)
+		return
+	}
+
+	args := strings.Fields(msg.Text)
+	var response string
+	if len(args) != 2 {
+		response = "Usage: /block <chatID> or /unblock <chatID>"
+	} else if _, err := strconv.ParseInt(args[1], 10, 64); err != nil {
+		response = "❌ Invalid chat ID: " + args[1]
+	} else if config.AdminIDs[args[1]] {
+		response = "❌ Admins cannot be blocked"
+	} else {
+		var err error
+		if block {
+			err = store.Set("blocked:"+args[1], strconv.FormatInt(msg.Chat.ID, 10), 0)
+			response = "⛔ Blocked chat " + args[1]
+		} else {
+			err = store.Delete("blocked:" + args[1])
+			response = "✅ Unblocked chat " + args[1]
+			if config.BlockedIDs[args[1]] {
+				response += " (still blocked in configuration)"
+			}
+		}
+		if err != nil {
+			log.Printf("❌ Failed to update block list: %v", err)
+			response = "❌ Failed to update block list: " + err.Error()
+		}
+		log.Printf("⛔ Admin %d set block=%t for ChatID %s", msg.Chat.ID, block, args[1])
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultMaxConnsPerHost is the idle pool kept per host; net/http's default of 2
+	// forces new connections as soon as a few requests overlap
+	DefaultMaxConnsPerHost = 20
+	HTTPMaxIdleConns       = 100
+	HTTPIdleConnTimeout    = 90 * time.Second
+	// BackendRequestTimeout caps a single SpendWise API call
+	BackendRequestTimeout = 30 * time.Second
+	// TelegramRequestTimeout caps a single Bot API call
+	TelegramRequestTimeout = 30 * time.Second
+)
+
+var (
+	// backendClient is shared by every SpendWise API call so connections are reused
+	backendClient *http.Client
+	// telegramClient is shared by every bot's Bot API calls
+	telegramClient *http.Client
+)
+
+// inFlight counts requests currently waiting on each target, for /metrics
+var inFlight = map[string]*atomic.Int64{"backend": {}, "telegram": {}}
+
+// newTunedTransport clones the default transport with pool sizes suited to a
+// bot that talks to a handful of hosts
+func newTunedTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	perHost := config.HTTPMaxConnsPerHost
+	if perHost <= 0 {
+		perHost = DefaultMaxConnsPerHost
+	}
+	transport.MaxIdleConns = HTTPMaxIdleConns
+	transport.MaxIdleConnsPerHost = perHost
+	transport.IdleConnTimeout = HTTPIdleConnTimeout
+	transport.ForceAttemptHTTP2 = config.HTTP2Enabled
+	return transport
+}
+
+// initHTTPClients builds the shared clients; backendTransport must already carry
+// any client certificate set up by initBackendAuth
+func initHTTPClients() {
+	if backendTransport == nil {
+		backendTransport = newTunedTransport()
+	}
+	backendClient = &http.Client{
+		Timeout:   BackendRequestTimeout,
+		Transport: instrumentedTransport{target: "backend", next: backendTransport},
+	}
+	telegramClient = &http.Client{
+		Timeout:   TelegramRequestTimeout,
+		Transport: instrumentedTransport{target: "telegram", next: newTunedTransport()},
+	}
+	log.Printf("🔌 HTTP clients ready - %d idle connections per host, HTTP/2: %t",
+		backendTransport.(*http.Transport).MaxIdleConnsPerHost, config.HTTP2Enabled)
+}
+
+// instrumentedTransport records whether each request reused a pooled connection
+// and how many requests are in flight
+type instrumentedTransport struct {
+	target string
+	next   http.RoundTripper
+}
+
+func (t instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			incCounter("spendwise_http_connections_total", "target", t.target, "reused", strconv.FormatBool(info.Reused))
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	gauge := inFlight[t.target]
+	setGauge("spendwise_http_requests_in_flight", float64(gauge.Add(1)), "target", t.target)
+	defer func() {
+		setGauge("spendwise_http_requests_in_flight", float64(gauge.Add(-1)), "target", t.target)
+	}()
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		incCounter("spendwise_http_errors_total", "target", t.target)
+	}
+	return resp, err
+}
@@ -0,0 +1,2239 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// The integration tests drive full webhook updates through the gin router
+// against a fake SpendWise backend and a fake Bot API server, then assert on
+// the calls the bot made to each.
+
+const (
+	testChatID    = int64(42)
+	testAPISecret = "test-secret"
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	gin.SetMode(gin.TestMode)
+	if !testing.Verbose() {
+		log.SetOutput(io.Discard)
+		gin.DefaultWriter = io.Discard
+	}
+	os.Exit(m.Run())
+}
+
+// recordedCall is one request received by a fake server
+type recordedCall struct {
+	Method string
+	Path   string
+	Query  string
+	Params map[string]interface{}
+}
+
+// fakeTelegram is an httptest Bot API server answering every method with a
+// plausible result
+type fakeTelegram struct {
+	*httptest.Server
+	mu     sync.Mutex
+	calls  []recordedCall
+	nextID atomic.Int64
+}
+
+func newFakeTelegram(t *testing.T) *fakeTelegram {
+	f := &fakeTelegram{}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeTelegram) serve(w http.ResponseWriter, req *http.Request) {
+	method := path.Base(req.URL.Path)
+	params := simulatedParams(req)
+	f.mu.Lock()
+	f.calls = append(f.calls, recordedCall{Method: method, Path: req.URL.Path, Params: params})
+	f.mu.Unlock()
+
+	// Like Telegram, reject Markdown whose entities don't close
+	if paramString(params["parse_mode"]) == "Markdown" && strings.Count(paramString(params["text"]), "*")%2 == 1 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: false, ErrorCode: http.StatusBadRequest,
+			Description: "Bad Request: can't parse entities: Can't find end of the entity starting at byte offset 0"})
+		return
+	}
+
+	var result interface{} = true
+	switch {
+	case method == "getMe":
+		result = tgbotapi.User{ID: 1, IsBot: true, FirstName: "SpendWise", UserName: "spendwise_test_bot"}
+	case method == "getWebhookInfo":
+		result = tgbotapi.WebhookInfo{}
+	case strings.HasPrefix(method, "send"), strings.HasPrefix(method, "edit"):
+		chatID, _ := strconv.ParseInt(paramString(params["chat_id"]), 10, 64)
+		result = tgbotapi.Message{
+			MessageID: int(f.nextID.Add(1)),
+			Date:      int(time.Now().Unix()),
+			Chat:      &tgbotapi.Chat{ID: chatID},
+			Text:      paramString(params["text"]),
+		}
+	}
+
+	raw, _ := json.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: raw})
+}
+
+// sent returns the calls of the given Bot API method, in order
+func (f *fakeTelegram) sent(method string) []recordedCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []recordedCall
+	for _, call := range f.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// texts returns the text of every sendMessage call
+func (f *fakeTelegram) texts() []string {
+	var texts []string
+	for _, call := range f.sent("sendMessage") {
+		texts = append(texts, paramString(call.Params["text"]))
+	}
+	return texts
+}
+
+// waitForTexts waits for n sendMessage calls, for replies sent in the background
+func (f *fakeTelegram) waitForTexts(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		texts := f.texts()
+		if len(texts) >= n {
+			return texts
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d messages, want %d: %q", len(texts), n, texts)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// fakeBackend is an httptest SpendWise API. Handlers are registered per path;
+// unregistered paths answer 404 like an older backend would.
+type fakeBackend struct {
+	*httptest.Server
+	mu       sync.Mutex
+	calls    []recordedCall
+	handlers map[string]http.HandlerFunc
+}
+
+func newFakeBackend(t *testing.T) *fakeBackend {
+	f := &fakeBackend{handlers: make(map[string]http.HandlerFunc)}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeBackend) serve(w http.ResponseWriter, req *http.Request) {
+	if req.Header.Get(HeaderAPISecret) != testAPISecret {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	params := make(map[string]interface{})
+	if len(body) > 0 && !bytes.HasPrefix(body, []byte("[")) {
+		json.Unmarshal(body, &params)
+	} else if len(body) > 0 {
+		params["items"] = json.RawMessage(body)
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, recordedCall{Method: req.Method, Path: req.URL.Path, Query: req.URL.RawQuery, Params: params})
+	handler, ok := f.handlers[req.URL.Path]
+	f.mu.Unlock()
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	handler(w, req)
+}
+
+// handle answers requests to path with a fixed status and JSON body
+func (f *fakeBackend) handle(path string, status int, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[path] = func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}
+}
+
+// received returns the calls made to path, in order
+func (f *fakeBackend) received(path string) []recordedCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []recordedCall
+	for _, call := range f.calls {
+		if call.Path == path {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// testServer is the bot wired to both fakes
+type testServer struct {
+	router   *gin.Engine
+	telegram *fakeTelegram
+	backend  *fakeBackend
+	updateID int
+}
+
+// newTestServer resets the process-wide state and starts a bot for testChatID.
+// configure may adjust the config before the bot is built.
+func newTestServer(t *testing.T, configure func(c *SpendWiseConfig)) *testServer {
+	telegram := newFakeTelegram(t)
+	backend := newFakeBackend(t)
+
+	config = SpendWiseConfig{
+		BotToken:          "123:test",
+		BotUrl:            "https://bot.example",
+		APIUrl:            backend.URL,
+		APISecret:         testAPISecret,
+		AllowedIDs:        map[string]bool{strconv.FormatInt(testChatID, 10): true},
+		Port:              DefaultPort,
+		BackendAuth:       BackendAuthSecret,
+		LogMessageContent: true,
+		HTTP2Enabled:      true,
+		TelegramAPIURL:    telegram.URL,
+	}
+	if configure != nil {
+		configure(&config)
+	}
+
+	store = newMemoryStore()
+	bots, defaultBot = make(map[string]*botInstance), nil
+	tenants, tenantByChat = nil, make(map[string]*tenant)
+	backendTransport, backendClient, telegramClient = nil, nil, nil
+	backendMetas.Lock()
+	backendMetas.byURL = make(map[string]backendMeta)
+	backendMetas.Unlock()
+	floodState.Lock()
+	floodState.recent, floodState.mutedUntil = make(map[int64][]time.Time), make(map[int64]time.Time)
+	floodState.Unlock()
+
+	if err := buildTenants(); err != nil {
+		t.Fatalf("buildTenants: %v", err)
+	}
+	initHTTPClients()
+	if err := initBots(); err != nil {
+		t.Fatalf("initBots: %v", err)
+	}
+	ready.Store(true)
+	t.Cleanup(func() {
+		ready.Store(false)
+		drainLowPriority()
+	})
+
+	pass := func(c *gin.Context) { c.Next() }
+	return &testServer{router: newRouter(pass, pass), telegram: telegram, backend: backend, updateID: 1000}
+}
+
+// drainLowPriority waits for deferred work queued so far, so it can't outlive
+// the test that queued it. Each worker takes one barrier task, which only
+// completes once every worker has reached one.
+func drainLowPriority() {
+	if lowPriority.queue == nil {
+		return
+	}
+	var arrived sync.WaitGroup
+	arrived.Add(LowPriorityWorkers)
+	release := make(chan struct{})
+	for i := 0; i < LowPriorityWorkers; i++ {
+		lowPriority.queue <- lowPriorityTask{name: "drain", run: func() {
+			arrived.Done()
+			<-release
+		}}
+	}
+	arrived.Wait()
+	close(release)
+}
+
+// do sends an HTTP request through the router
+func (s *testServer) do(method, target string, body interface{}, header http.Header) *httptest.ResponseRecorder {
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, target, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// sendText delivers a private-chat text message to /webhook like Telegram would
+func (s *testServer) sendText(t *testing.T, chatID int64, text string) *httptest.ResponseRecorder {
+	t.Helper()
+	s.updateID++
+	update := simulatedUpdate(s.updateID, chatID, "Tester", text, "", 0)
+	rec := s.do(http.MethodPost, "/webhook", update, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("webhook answered %d: %s", rec.Code, rec.Body.String())
+	}
+	return rec
+}
+
+func TestWebhookSavesSingleExpense(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+
+	s.sendText(t, testChatID, "Coffee 50 via card // with Ravi")
+
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("backend got %d create-batch calls, want 1", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	want := ExpenseInput{
+		Description:    "Coffee",
+		Amount:         50,
+		Date:           time.Now().Format("2006-01-02"),
+		Source:         "bot",
+		UserName:       "Tester",
+		TelegramChatID: "42",
+		Account:        "card",
+		Note:           "with Ravi",
+	}
+	if len(expenses) != 1 || expenses[0] != want {
+		t.Fatalf("backend got %+v, want [%+v]", expenses, want)
+	}
+
+	reactions := s.telegram.sent("setMessageReaction")
+	if len(reactions) != 1 || !strings.Contains(paramString(reactions[0].Params["reaction"]), DefaultReactionEmoji) {
+		t.Errorf("expected a single success reaction, got %+v", reactions)
+	}
+	if texts := s.telegram.texts(); len(texts) != 0 {
+		t.Errorf("single expenses should only react, got messages %q", texts)
+	}
+}
+
+func TestWebhookSavesBatch(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "Coffee 50\nBus 20 10")
+
+	texts := s.telegram.texts()
+	if len(texts) != 1 || !strings.Contains(texts[0], "2 expenses saved") || !strings.Contains(texts[0], "80") {
+		t.Errorf("expected a batch confirmation with the total, got %q", texts)
+	}
+}
+
+func TestWebhookRejectsInvalidExpense(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	s.sendText(t, testChatID, "Coffee 0")
+
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 0 {
+		t.Errorf("invalid input reached the backend: %+v", calls)
+	}
+	texts := s.telegram.texts()
+	if len(texts) != 1 || !strings.Contains(texts[0], "no valid amount found") {
+		t.Errorf("expected the parse error, got %q", texts)
+	}
+}
+
+func TestWebhookRejectsUnknownChat(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) { c.AdminIDs = map[string]bool{"7": true} })
+
+	s.sendText(t, 99, "Coffee 50")
+	s.sendText(t, 99, "Tea 20")
+
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 0 {
+		t.Errorf("unauthorized chat reached the backend: %+v", calls)
+	}
+	// The admin alert is sent in the background
+	texts := s.telegram.waitForTexts(t, 2)
+	if !strings.Contains(texts[0], "not authorized") && !strings.Contains(texts[1], "not authorized") {
+		t.Errorf("expected a not-authorized reply, got %q", texts)
+	}
+	if !strings.Contains(strings.Join(texts, "\n"), "Access request") {
+		t.Errorf("expected an access request to the admin, got %q", texts)
+	}
+}
+
+func TestWebhookSecretToken(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) { c.WebhookSecret = "hook-secret" })
+	update := simulatedUpdate(2000, testChatID, "Tester", "Coffee 50", "", 0)
+
+	if rec := s.do(http.MethodPost, "/webhook", update, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("webhook without secret answered %d, want 401", rec.Code)
+	}
+	if len(s.backend.calls) != 0 {
+		t.Errorf("rejected update reached the backend")
+	}
+
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+	header := http.Header{HeaderTelegramSecret: {"hook-secret"}}
+	if rec := s.do(http.MethodPost, "/webhook", update, header); rec.Code != http.StatusOK {
+		t.Errorf("webhook with secret answered %d, want 200", rec.Code)
+	}
+}
+
+func TestWebhookSkipsDuplicateUpdates(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+	update := simulatedUpdate(3000, testChatID, "Tester", "Coffee 50", "", 0)
+
+	s.do(http.MethodPost, "/webhook", update, nil)
+	rec := s.do(http.MethodPost, "/webhook", update, nil)
+
+	if !strings.Contains(rec.Body.String(), "duplicate") {
+		t.Errorf("redelivered update answered %s, want duplicate", rec.Body.String())
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 {
+		t.Errorf("backend got %d saves for one update, want 1", len(calls))
+	}
+}
+
+func TestSummaryCommand(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/summary/today", http.StatusOK,
+		`{"title":"Today","total":120,"count":2,"items":[{"description":"Lunch","amount":100},{"description":"Tea","amount":20}]}`)
+
+	s.sendText(t, testChatID, "/summary")
+
+	calls := s.backend.received("/api/summary/today")
+	if len(calls) != 1 || calls[0].Method != http.MethodGet {
+		t.Fatalf("expected one GET /api/summary/today, got %+v", calls)
+	}
+	if !strings.Contains(calls[0].Query, "format=structured") {
+		t.Errorf("summary query %q doesn't ask for a structured summary", calls[0].Query)
+	}
+	if len(s.telegram.sent("sendChatAction")) == 0 {
+		t.Errorf("expected a typing action before the backend call")
+	}
+	texts := s.telegram.texts()
+	if len(texts) != 1 || !strings.Contains(texts[0], "Lunch") || !strings.Contains(texts[0], "120") {
+		t.Errorf("expected the rendered summary, got %q", texts)
+	}
+}
+
+func TestSummaryCommandWithBotMention(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/summary/today", http.StatusOK, `{"title":"Today","total":0,"count":0}`)
+	s.backend.handle("/api/summary/range", http.StatusOK, `{"title":"Last week","total":0,"count":0}`)
+
+	s.sendText(t, testChatID, "/summary@spendwise_test_bot")
+	s.sendText(t, testChatID, "/summary@spendwise_test_bot last week")
+
+	if calls := s.backend.received("/api/summary/today"); len(calls) != 1 {
+		t.Errorf("expected /summary@bot to fetch today's summary, got %d calls", len(calls))
+	}
+	if calls := s.backend.received("/api/summary/range"); len(calls) != 1 {
+		t.Errorf("expected /summary@bot last week to fetch the range, got %d calls", len(calls))
+	}
+	for _, text := range s.telegram.texts() {
+		if strings.Contains(text, "understand") {
+			t.Errorf("the bot mention was read as a date: %q", text)
+		}
+	}
+}
+
+func TestSummaryFallsBackToPlainTextOnBadMarkdown(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/summary/today", http.StatusOK, `{"markdown":"📊 *Today*\nSnacks_and_tea 2*20"}`)
+
+	s.sendText(t, testChatID, "/summary")
+
+	sent := s.telegram.sent("sendMessage")
+	if len(sent) != 2 {
+		t.Fatalf("expected the rejected Markdown to be resent, got %d sends", len(sent))
+	}
+	last := sent[1]
+	if text := paramString(last.Params["text"]); text != "📊 Today\nSnacksandtea 220" || paramString(last.Params["parse_mode"]) != "" {
+		t.Errorf("resent %q with parse mode %q, want the text without formatting", text, paramString(last.Params["parse_mode"]))
+	}
+}
+
+func TestSummaryCommandBackendError(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/summary/today", http.StatusBadRequest, `{"error":"Unknown chat"}`)
+
+	s.sendText(t, testChatID, "/summary")
+
+	texts := s.telegram.texts()
+	if len(texts) != 1 || !strings.Contains(texts[0], "Unknown chat") {
+		t.Errorf("expected the backend error to be relayed, got %q", texts)
+	}
+}
+
+func TestBackendDownQueuesAndSyncs(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusServiceUnavailable, `{"error":"Service Unavailable"}`)
+
+	s.sendText(t, testChatID, "Coffee 50")
+
+	texts := s.telegram.texts()
+	if len(texts) != 1 || !strings.Contains(texts[0], "Kept in memory") {
+		t.Fatalf("expected the kept-in-memory reply, got %q", texts)
+	}
+
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+	defaultBot.forTenant(tenants[0]).runRetryQueue()
+
+	texts = s.telegram.texts()
+	if len(texts) != 2 || !strings.Contains(texts[1], "Synced 1 expense") {
+		t.Errorf("expected a sync confirmation, got %q", texts)
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 2 {
+		t.Errorf("backend got %d save attempts, want 2", len(calls))
+	}
+
+	defaultBot.forTenant(tenants[0]).runRetryQueue()
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 2 {
+		t.Errorf("synced batch was sent again")
+	}
+}
+
+func TestInternalSendMessage(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := map[string]interface{}{"chatId": testChatID, "message": "Rent is due"}
+
+	if rec := s.do(http.MethodPost, "/internal/send-message", body, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("send-message without secret answered %d, want 401", rec.Code)
+	}
+
+	header := http.Header{http.CanonicalHeaderKey(HeaderAPISecret): {testAPISecret}}
+	if rec := s.do(http.MethodPost, "/internal/send-message", body, header); rec.Code != http.StatusOK {
+		t.Fatalf("send-message answered %d: %s", rec.Code, rec.Body.String())
+	}
+	texts := s.telegram.texts()
+	if len(texts) != 1 || texts[0] != "Rent is due" {
+		t.Errorf("expected the message to be delivered, got %q", texts)
+	}
+}
+
+func TestNotReadyAnswers503(t *testing.T) {
+	s := newTestServer(t, nil)
+	ready.Store(false)
+
+	update := simulatedUpdate(4000, testChatID, "Tester", "Coffee 50", "", 0)
+	if rec := s.do(http.MethodPost, "/webhook", update, nil); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("webhook before ready answered %d, want 503", rec.Code)
+	}
+	if rec := s.do(http.MethodGet, "/health", nil, nil); rec.Code != http.StatusOK {
+		t.Errorf("/health before ready answered %d, want 200", rec.Code)
+	}
+}
+
+func TestWebhookShedsWhenSaturated(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	// Occupy every slot and the whole wait queue
+	slots := updateSlots()
+	for i := 0; i < cap(slots); i++ {
+		slots <- struct{}{}
+	}
+	queued := int64(cap(slots) * UpdateQueueFactor)
+	updateLoad.waiting.Add(queued)
+
+	update := simulatedUpdate(5000, testChatID, "Tester", "Coffee 50", "", 0)
+	rec := s.do(http.MethodPost, "/webhook", update, nil)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("saturated webhook answered %d (Retry-After %q), want 503 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 0 {
+		t.Errorf("shed update reached the backend")
+	}
+
+	updateLoad.waiting.Add(-queued)
+	for i := 0; i < cap(slots); i++ {
+		<-slots
+	}
+	if rec := s.do(http.MethodPost, "/webhook", update, nil); rec.Code != http.StatusOK {
+		t.Errorf("redelivered update answered %d, want 200", rec.Code)
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 {
+		t.Errorf("redelivered update wasn't processed")
+	}
+}
+
+func TestBroadcastPreviewAndSend(t *testing.T) {
+	const adminID = int64(7)
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.AdminIDs = map[string]bool{"7": true}
+		c.AllowedIDs = map[string]bool{"7": true, "42": true, "43": true}
+	})
+
+	s.sendText(t, adminID, "/broadcast Maintenance tonight\nBack by midnight")
+	previews := s.telegram.sent("sendMessage")
+	if len(previews) != 1 || !strings.Contains(paramString(previews[0].Params["text"]), "goes to 2 chat(s)") {
+		t.Fatalf("expected a preview for 2 chats, got %q", s.telegram.texts())
+	}
+	var markup tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(previews[0].Params["reply_markup"])), &markup)
+	if len(markup.InlineKeyboard) != 1 || len(markup.InlineKeyboard[0]) != 2 {
+		t.Fatalf("expected send and cancel buttons, got %+v", markup)
+	}
+	if texts := s.telegram.texts(); len(texts) != 1 {
+		t.Fatalf("nothing should be sent before confirming, got %q", texts)
+	}
+
+	send := *markup.InlineKeyboard[0][0].CallbackData
+	for i := 0; i < 2; i++ { // the second tap must not send again
+		s.updateID++
+		rec := s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, adminID, "Admin", "", send, 1), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("callback answered %d", rec.Code)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	var report string
+	for report == "" && time.Now().Before(deadline) {
+		for _, call := range s.telegram.sent("editMessageText") {
+			if text := paramString(call.Params["text"]); strings.Contains(text, "delivered") {
+				report = text
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.HasPrefix(report, "✅ Broadcast delivered to 2/2 chat(s)") {
+		t.Fatalf("expected a delivery report, got %q", report)
+	}
+
+	delivered := map[string]int{}
+	for _, call := range s.telegram.sent("sendMessage") {
+		if paramString(call.Params["text"]) == "Maintenance tonight\nBack by midnight" {
+			delivered[paramString(call.Params["chat_id"])]++
+		}
+	}
+	if len(delivered) != 2 || delivered["42"] != 1 || delivered["43"] != 1 {
+		t.Errorf("broadcast deliveries = %v, want one each to 42 and 43", delivered)
+	}
+}
+
+func TestBroadcastAdminOnly(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.sendText(t, testChatID, "/broadcast hello")
+	for _, text := range s.telegram.texts() {
+		if strings.Contains(text, "Broadcast preview") {
+			t.Fatalf("non-admin got a broadcast preview: %q", text)
+		}
+	}
+}
+
+func TestNotifyRemindersRendersPerUser(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.AllowedIDs = map[string]bool{"42": true, "43": true}
+	})
+	payload := map[string]interface{}{
+		"telegramUserIds": []string{"42", "43", "42", "99"},
+		"reminders": []map[string]interface{}{
+			{"id": "r1", "description": "Power Bill", "amount": 850, "dayOfMonthStart": 1, "dayOfMonthEnd": 31, "type": "standard"},
+			{"id": "r2", "description": "Rent", "amount": 15000, "dayOfMonthStart": 1, "dayOfMonthEnd": 31, "type": "standard",
+				"paidMonths": []string{time.Now().Format("2006-01")}},
+		},
+	}
+	header := http.Header{http.CanonicalHeaderKey(HeaderAPISecret): {testAPISecret}}
+	rec := s.do(http.MethodPost, "/internal/notify-reminders", payload, header)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("notify-reminders answered %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Delivered int          `json:"delivered"`
+		Results   []bulkResult `json:"results"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Delivered != 2 || len(resp.Results) != 3 || resp.Results[2].Error == "" {
+		t.Fatalf("expected 2 deliveries and the unknown chat skipped, got %s", rec.Body.String())
+	}
+
+	messages := s.telegram.sent("sendMessage")
+	if len(messages) != 2 {
+		t.Fatalf("expected one message per user, got %d", len(messages))
+	}
+	for _, call := range messages {
+		text := paramString(call.Params["text"])
+		if !strings.Contains(text, "Power Bill") {
+			t.Errorf("reminder message is missing the due bill: %q", text)
+		}
+		var markup tgbotapi.InlineKeyboardMarkup
+		json.Unmarshal([]byte(paramString(call.Params["reply_markup"])), &markup)
+		if len(markup.InlineKeyboard) != 1 || *markup.InlineKeyboard[0][0].CallbackData != CallbackPrefixMarkDone+"r1:standard" {
+			t.Errorf("expected a single mark-done button for the unpaid bill, got %+v", markup)
+		}
+	}
+}
+
+func TestNotifyRemindersTargetsOwners(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.AllowedIDs = map[string]bool{"42": true, "43": true}
+		c.ReminderOwners = map[string]string{"42": "user-a", "43": "user-b"}
+	})
+	payload := map[string]interface{}{
+		"telegramUserIds": []string{"42", "43"},
+		"reminders": []map[string]interface{}{
+			{"id": "r1", "description": "Power Bill", "amount": 850, "dayOfMonthStart": 1, "dayOfMonthEnd": 31, "type": "standard", "userId": "user-a"},
+			{"id": "r2", "description": "Internet", "amount": 999, "dayOfMonthStart": 1, "dayOfMonthEnd": 31, "type": "standard"},
+		},
+	}
+	header := http.Header{http.CanonicalHeaderKey(HeaderAPISecret): {testAPISecret}}
+	if rec := s.do(http.MethodPost, "/internal/notify-reminders", payload, header); rec.Code != http.StatusOK {
+		t.Fatalf("notify-reminders answered %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for _, call := range s.telegram.sent("sendMessage") {
+		text := paramString(call.Params["text"])
+		chatID := paramString(call.Params["chat_id"])
+		if !strings.Contains(text, "Internet") {
+			t.Errorf("ChatID %s is missing the shared bill: %q", chatID, text)
+		}
+		if owns := chatID == "42"; strings.Contains(text, "Power Bill") != owns {
+			t.Errorf("ChatID %s: Power Bill shown = %t, want %t: %q", chatID, !owns, owns, text)
+		}
+	}
+	if n := len(s.telegram.sent("sendMessage")); n != 2 {
+		t.Errorf("expected a message per user, got %d", n)
+	}
+}
+
+func TestCommandAliases(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.CommandAliases = map[string]string{"/s": "/summary", "Today": "/summary"}
+	})
+	s.backend.handle("/api/summary/today", http.StatusOK, `{"title":"Today","total":0,"count":0}`)
+	s.backend.handle("/api/summary/range", http.StatusOK, `{"title":"Last week","total":0,"count":0}`)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "/s")
+	s.sendText(t, testChatID, "today")
+	s.sendText(t, testChatID, "/s@spendwise_test_bot last week")
+	s.sendText(t, testChatID, "today lunch 50")
+
+	if calls := s.backend.received("/api/summary/today"); len(calls) != 2 {
+		t.Errorf("expected /s and today to fetch today's summary, got %d calls", len(calls))
+	}
+	if calls := s.backend.received("/api/summary/range"); len(calls) != 1 {
+		t.Errorf("expected /s last week to keep its argument, got %d range calls", len(calls))
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 {
+		t.Errorf("a word alias must not swallow an expense line, got %d saves", len(calls))
+	}
+}
+
+func TestAmountOnlyAsksForDescription(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "150")
+	if texts := s.telegram.texts(); len(texts) != 1 || !strings.HasPrefix(texts[0], "What was ₹150.00 for?") {
+		t.Fatalf("expected the bot to ask what the amount was for, got %q", texts)
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 0 {
+		t.Fatalf("nothing should be saved before the description arrives")
+	}
+
+	s.sendText(t, testChatID, "Lunch with 2 friends")
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("backend got %d create-batch calls, want 1", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].Description != "Lunch with 2 friends" || expenses[0].Amount != 150 {
+		t.Errorf("backend got %+v, want Lunch with 2 friends for 150", expenses)
+	}
+
+	// The question is answered; the next plain text is parsed as usual
+	s.sendText(t, testChatID, "Tea 20")
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 2 {
+		t.Errorf("expected the follow-up expense to be saved on its own, got %d calls", len(calls))
+	}
+}
+
+func TestDescriptionOnlyAsksForAmount(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "hello")
+	s.sendText(t, testChatID, "auto rickshaw")
+	texts := s.telegram.texts()
+	if len(texts) != 2 || !strings.Contains(texts[0], "don't understand") || !strings.HasPrefix(texts[1], "How much was auto rickshaw?") {
+		t.Fatalf("expected small talk to be ignored and the description to be asked about, got %q", texts)
+	}
+
+	s.sendText(t, testChatID, "yesterday 40 via card")
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("backend got %d create-batch calls, want 1", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	if len(expenses) != 1 || expenses[0].Description != "auto rickshaw" || expenses[0].Amount != 40 ||
+		expenses[0].Date != yesterday || expenses[0].Account != "card" {
+		t.Errorf("backend got %+v, want auto rickshaw for 40 yesterday via card", expenses)
+	}
+}
+
+func TestSpendingCapNeedsConfirmation(t *testing.T) {
+	const adminID = int64(7)
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.AdminIDs = map[string]bool{"7": true}
+		c.AllowedIDs = map[string]bool{"7": true, "42": true}
+	})
+	s.backend.handle("/api/expenses/list", http.StatusOK, `{"expenses":[{"id":"e1","description":"Games","amount":1900}]}`)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "/cap 42 2000")
+	s.sendText(t, adminID, "/cap 42 2000")
+	s.sendText(t, testChatID, "Snacks 50")
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 {
+		t.Fatalf("an expense within the cap should be saved, got %d saves", len(calls))
+	}
+
+	s.sendText(t, testChatID, "Comics 150")
+	prompts := s.telegram.sent("sendMessage")
+	last := prompts[len(prompts)-1]
+	if text := paramString(last.Params["text"]); !strings.HasPrefix(text, "🚦 This goes over your monthly cap of ₹2,000.00") {
+		t.Fatalf("expected a cap confirmation, got %q", s.telegram.texts())
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 {
+		t.Fatalf("nothing should be saved over the cap before confirming, got %d saves", len(calls))
+	}
+
+	var markup tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(last.Params["reply_markup"])), &markup)
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", *markup.InlineKeyboard[0][0].CallbackData, 1), nil)
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 2 {
+		t.Fatalf("confirming should save the held expense, got %d saves", len(calls))
+	}
+
+	texts := s.telegram.waitForTexts(t, 4)
+	if !strings.Contains(texts[0], "don't") || !strings.Contains(texts[3], "logged ₹150.00 over their ₹2,000.00 monthly cap") {
+		t.Errorf("expected /cap to be admin only and the admin to be alerted, got %q", texts)
+	}
+}
+
+func TestLimitedAccountCommands(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.LimitedIDs = map[string]bool{"42": true}
+	})
+	s.backend.handle("/api/summary/today", http.StatusOK, `{"title":"Today","total":40,"count":1,"items":[{"description":"Snacks","amount":40}]}`)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "/month")
+	s.sendText(t, testChatID, "/reminders")
+	for _, text := range s.telegram.texts() {
+		if !strings.HasPrefix(text, "🔒") {
+			t.Errorf("limited chat got %q, want the command refused", text)
+		}
+	}
+	if len(s.backend.calls) != 0 {
+		t.Fatalf("refused commands must not reach the backend, got %+v", s.backend.calls)
+	}
+
+	s.sendText(t, testChatID, "Snacks 40")
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 {
+		t.Errorf("limited chats can still log expenses, got %d saves", len(calls))
+	}
+
+	s.sendText(t, testChatID, "/summary")
+	calls := s.backend.received("/api/summary/today")
+	if len(calls) != 1 || !strings.Contains(calls[0].Query, "telegramChatId=42") {
+		t.Errorf("limited /summary should be scoped to the chat, got %+v", calls)
+	}
+}
+
+func TestBudgetAlertSuggestions(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK,
+		`{"success":true,"ids":["exp-1"],"budgetAlerts":[{"expenseId":"exp-1","category":"Groceries","budget":5000,"spent":5200}]}`)
+	s.backend.handle("/api/expenses/update", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "Groceries 700")
+	alerts := s.telegram.sent("sendMessage")
+	if len(alerts) != 1 || paramString(alerts[0].Params["text"]) != "⚠️ Groceries is over budget: ₹5,200.00 of ₹5,000.00 spent this month." {
+		t.Fatalf("expected an over-budget alert, got %q", s.telegram.texts())
+	}
+	var markup tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(alerts[0].Params["reply_markup"])), &markup)
+	if len(markup.InlineKeyboard) != 1 || len(markup.InlineKeyboard[0]) != 3 {
+		t.Fatalf("expected move, recategorize and dismiss buttons, got %+v", markup)
+	}
+
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", *markup.InlineKeyboard[0][1].CallbackData, 2), nil)
+	s.sendText(t, testChatID, "Household")
+	calls := s.backend.received("/api/expenses/update")
+	if len(calls) != 1 || calls[0].Params["id"] != "exp-1" || calls[0].Params["category"] != "Household" {
+		t.Errorf("expected the expense to be recategorized, got %+v", calls)
+	}
+}
+
+func TestRecategorizeSuggestsKnownCategories(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK,
+		`{"success":true,"ids":["exp-1"],"budgetAlerts":[{"expenseId":"exp-1","category":"Dining","budget":5000,"spent":5200}]}`)
+	s.backend.handle("/api/summary/range", http.StatusOK, `{"markdown":"-","categories":[{"label":"Dining","amount":5200},{"label":"Groceries","amount":4100},{"label":"Fuel","amount":900}]}`)
+	s.backend.handle("/api/expenses/update", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "Veggies 700")
+	var alert tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(s.telegram.sent("sendMessage")[0].Params["reply_markup"])), &alert)
+	recategorize := *alert.InlineKeyboard[0][1].CallbackData
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", recategorize, 2), nil)
+	s.sendText(t, testChatID, "grocries")
+	if calls := s.backend.received("/api/expenses/update"); len(calls) != 0 {
+		t.Fatalf("a misspelt category shouldn't be saved before picking a suggestion, got %+v", calls)
+	}
+	sent := s.telegram.sent("sendMessage")
+	var markup tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(sent[len(sent)-1].Params["reply_markup"])), &markup)
+	if len(markup.InlineKeyboard) != 2 || markup.InlineKeyboard[0][0].Text != "🏷️ Groceries" || markup.InlineKeyboard[1][0].Text != "➕ New category grocries" {
+		t.Fatalf("expected Groceries and a create-as-typed button, got %+v", markup.InlineKeyboard)
+	}
+
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", *markup.InlineKeyboard[0][0].CallbackData, 3), nil)
+	calls := s.backend.received("/api/expenses/update")
+	if len(calls) != 1 || calls[0].Params["id"] != "exp-1" || calls[0].Params["category"] != "Groceries" {
+		t.Errorf("expected the expense to move to Groceries, got %+v", calls)
+	}
+
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", recategorize, 2), nil)
+	s.sendText(t, testChatID, "fuel")
+	calls = s.backend.received("/api/expenses/update")
+	if len(calls) != 2 || calls[1].Params["category"] != "Fuel" {
+		t.Errorf("a known category in another case should use its spelling, got %+v", calls)
+	}
+}
+
+func TestUPIPaymentQR(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.UPIID, c.UPIName = "home@okbank", "Home"
+	})
+
+	s.sendText(t, testChatID, "/upi 500 electricity bill")
+	photos := s.telegram.sent("sendPhoto")
+	if len(photos) != 1 || paramString(photos[0].Params["caption"]) != "📷 Scan with any UPI app to pay ₹500.00 to home@okbank for electricity bill" {
+		t.Fatalf("expected a UPI QR photo, got %+v (texts %q)", photos, s.telegram.texts())
+	}
+
+	want := "upi://pay?am=500.00&cu=INR&pa=home%40okbank&pn=Home&tn=electricity%20bill"
+	if got := upiPaymentURI("home@okbank", "Home", 500, "electricity bill"); got != want {
+		t.Errorf("upiPaymentURI = %q, want %q", got, want)
+	}
+}
+
+func TestFeedbackReachesAdmins(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.AdminIDs = map[string]bool{"7": true}
+		c.AllowedIDs = map[string]bool{"7": true, "42": true}
+	})
+	s.backend.handle("/api/feedback", http.StatusOK, `{"ticketId":"FB-1"}`)
+
+	update := simulatedUpdate(1, testChatID, "Tester", "/feedback this was saved as 42", "", 0)
+	update.Message.ReplyToMessage = &tgbotapi.Message{MessageID: 9, Text: "Chai 2 cups 40"}
+	s.do(http.MethodPost, "/webhook", update, nil)
+
+	texts := s.telegram.waitForTexts(t, 2)
+	var report string
+	for _, call := range s.telegram.sent("sendMessage") {
+		if paramString(call.Params["chat_id"]) == "7" {
+			report = paramString(call.Params["text"])
+		}
+	}
+	if !strings.Contains(report, "Chat ID: 42") || !strings.Contains(report, "this was saved as 42") ||
+		!strings.Contains(report, "Chai 2 cups 40") || !strings.Contains(report, "Ticket: FB-1") {
+		t.Errorf("admin report = %q, want the feedback with user context, the quoted message and the ticket", report)
+	}
+	if !strings.Contains(strings.Join(texts, "\n"), "🙏 Thanks") {
+		t.Errorf("expected the user to be thanked, got %q", texts)
+	}
+}
+
+func TestParseDoesNotSave(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	s.sendText(t, testChatID, "/parse Lunch 100 50 // team\nrefund Shoes 40")
+
+	texts := s.telegram.waitForTexts(t, 1)
+	reply := strings.Join(texts, "\n")
+	for _, want := range []string{"nothing was saved", "Description: Lunch", "Amount: ₹150.00", "Note: team", "2 numbers were added up", "Type: refund"} {
+		if !strings.Contains(reply, want) {
+			t.Errorf("reply = %q, want it to contain %q", reply, want)
+		}
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 0 {
+		t.Errorf("expected nothing to be saved, got %d backend calls", len(calls))
+	}
+}
+
+func TestQuickExpenseHeuristics(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.QuickExpenseMaxAmount = 100000
+		c.QuickExpenseMinWords = 1
+		c.QuickExpenseBlocklist = []string{`(?i)\botp\b`}
+	})
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "Your OTP is 4829")
+	s.sendText(t, testChatID, "Ref 48291377")
+	texts := s.telegram.waitForTexts(t, 2)
+	for _, text := range texts {
+		if !strings.Contains(text, "Not logged") || !strings.Contains(text, "/e") {
+			t.Errorf("reply = %q, want a hint to use /e", text)
+		}
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 0 {
+		t.Fatalf("expected nothing to be saved, got %d backend calls", len(calls))
+	}
+
+	s.sendText(t, testChatID, "/e Ref 48291377")
+	s.sendText(t, testChatID, "Lunch 250")
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 2 {
+		t.Errorf("expected /e and the ordinary expense to be saved, got %d backend calls", len(calls))
+	}
+}
+
+func TestImplausibleAmountNeedsConfirmation(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.MaxPlausibleAmount = 200000
+	})
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "Plumber 9876543210")
+	prompts := s.telegram.sent("sendMessage")
+	last := prompts[len(prompts)-1]
+	if text := paramString(last.Params["text"]); !strings.HasPrefix(text, "🤔 This looks too large") || !strings.Contains(text, "Plumber") {
+		t.Fatalf("expected a large amount confirmation, got %q", s.telegram.texts())
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 0 {
+		t.Fatalf("nothing should be saved before confirming, got %d saves", len(calls))
+	}
+
+	var markup tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(last.Params["reply_markup"])), &markup)
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", *markup.InlineKeyboard[0][0].CallbackData, 1), nil)
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("confirming should save the held expense, got %d saves", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].Amount != 9876543210 {
+		t.Errorf("expected the held expense to be saved as sent, got %+v", expenses)
+	}
+}
+
+func TestQuickMenuLogsRoutineExpenses(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/list", http.StatusOK, `{"expenses":[
+		{"id":"e1","description":"Chai","amount":20,"date":"2026-01-05"},
+		{"id":"e2","description":"chai","amount":25,"date":"2026-01-06"},
+		{"id":"e3","description":"Chai","amount":20,"date":"2026-01-07"},
+		{"id":"e4","description":"Metro","amount":40,"date":"2026-01-06"},
+		{"id":"e5","description":"Metro","amount":40,"date":"2026-01-07"},
+		{"id":"e6","description":"Shoes","amount":2000,"date":"2026-01-07"}]}`)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "/quick")
+	menus := s.telegram.sent("sendMessage")
+	var markup tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(menus[len(menus)-1].Params["reply_markup"])), &markup)
+	if len(markup.InlineKeyboard) != 1 || len(markup.InlineKeyboard[0]) != 2 ||
+		markup.InlineKeyboard[0][0].Text != "Chai ₹20.00" || markup.InlineKeyboard[0][1].Text != "Metro ₹40.00" {
+		t.Fatalf("expected Chai and Metro at their usual amounts, got %+v", markup.InlineKeyboard)
+	}
+
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", *markup.InlineKeyboard[0][1].CallbackData, 1), nil)
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("tapping should log the expense, got %d saves", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].Description != "Metro" || expenses[0].Amount != 40 {
+		t.Errorf("backend got %+v, want Metro for 40", expenses)
+	}
+}
+
+func TestGroupDashboardIsPinnedAndRefreshed(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/list", http.StatusOK, `{"expenses":[
+		{"id":"e1","description":"Milk","amount":60,"userName":"Asha"},
+		{"id":"e2","description":"Fuel","amount":900,"userName":"Ravi"}]}`)
+	s.backend.handle("/api/reminders/get-payload", http.StatusOK, `{"reminders":[]}`)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+	groupUpdate := func(text string) {
+		s.updateID++
+		update := simulatedUpdate(s.updateID, testChatID, "Tester", text, "", 0)
+		update.Message.Chat.Type = "group"
+		s.do(http.MethodPost, "/webhook", update, nil)
+	}
+
+	groupUpdate("/dashboard on")
+	dashboard := strings.Join(s.telegram.texts(), "\n")
+	if !strings.Contains(dashboard, "Spent this month: ₹960.00") || !strings.Contains(dashboard, "• Ravi - ₹900.00\n• Asha - ₹60.00") {
+		t.Fatalf("dashboard = %q, want the month total and per-member totals", dashboard)
+	}
+	if pins := s.telegram.sent("pinChatMessage"); len(pins) != 1 {
+		t.Fatalf("expected the dashboard to be pinned, got %d pins", len(pins))
+	}
+
+	groupUpdate("Bread 40")
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.telegram.sent("editMessageText")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the dashboard to be edited after logging an expense")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLargeExpenseWaitsForApproval(t *testing.T) {
+	const approverID = int64(7)
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.AllowedIDs = map[string]bool{"7": true, "42": true}
+		c.ApprovalThreshold = 5000
+		c.ApproverIDs = []string{"7", "42"}
+	})
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "Sofa 6000")
+	var prompt recordedCall
+	for _, call := range s.telegram.sent("sendMessage") {
+		if paramString(call.Params["chat_id"]) == "7" {
+			prompt = call
+		}
+	}
+	if !strings.Contains(paramString(prompt.Params["text"]), "Tester wants to log ₹6,000.00") {
+		t.Fatalf("expected the other member to be asked for approval, got %q", s.telegram.texts())
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 0 {
+		t.Fatalf("nothing should be saved before approval, got %d saves", len(calls))
+	}
+
+	var markup tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(prompt.Params["reply_markup"])), &markup)
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, approverID, "Partner", "", *markup.InlineKeyboard[0][0].CallbackData, 2), nil)
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("approving should save the held expense, got %d saves", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].Description != "Sofa" || expenses[0].TelegramChatID != "42" {
+		t.Errorf("backend got %+v, want the Sofa expense of chat 42", expenses)
+	}
+}
+
+func TestApprovalsStayInTheHousehold(t *testing.T) {
+	other := newFakeBackend(t)
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.AllowedIDs = map[string]bool{"7": true, "42": true}
+		c.Tenants = []TenantConfig{{ID: "other", AllowedIDs: []string{"98", "99"}, APIUrl: other.URL}}
+		c.ApprovalThreshold = 5000
+		c.ApproverIDs = []string{"7", "98"}
+	})
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+	other.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "Sofa 6000")
+	s.sendText(t, 99, "TV 7000")
+
+	prompts := map[string]recordedCall{}
+	for _, call := range s.telegram.sent("sendMessage") {
+		if strings.Contains(paramString(call.Params["text"]), "wants to log") {
+			prompts[paramString(call.Params["chat_id"])] = call
+		}
+	}
+	if len(prompts) != 2 || !strings.Contains(paramString(prompts["7"].Params["text"]), "Sofa") ||
+		!strings.Contains(paramString(prompts["98"].Params["text"]), "TV") {
+		t.Fatalf("expected each household's approver to get only its own request, got %q", s.telegram.texts())
+	}
+
+	var markup tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(prompts["98"].Params["reply_markup"])), &markup)
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, 98, "Partner", "", *markup.InlineKeyboard[0][0].CallbackData, 2), nil)
+	if calls := other.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 {
+		t.Fatalf("expected the TV to be saved to its household's backend, got %d saves", len(calls))
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 0 {
+		t.Fatalf("expected nothing saved to the default backend, got %d saves", len(calls))
+	}
+}
+
+func TestCalendarFeedServesEachHousehold(t *testing.T) {
+	other := newFakeBackend(t)
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.CalendarToken = "feed-secret"
+		c.Tenants = []TenantConfig{{ID: "other", AllowedIDs: []string{"99"}, APIUrl: other.URL}}
+	})
+	s.backend.handle("/api/reminders/get-payload", http.StatusOK, `{"reminders":[
+		{"id":"r1","description":"Rent","amount":25000,"mainType":"bill","dayOfMonthStart":1,"dayOfMonthEnd":5}]}`)
+	other.handle("/api/reminders/get-payload", http.StatusOK, `{"reminders":[
+		{"id":"r2","description":"Internet","amount":3000,"mainType":"bill","dayOfMonthStart":12,"dayOfMonthEnd":12}]}`)
+
+	rec := s.do(http.MethodGet, "/calendar.ics?token=feed-secret", nil, nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Rent") ||
+		!strings.Contains(rec.Body.String(), "(Due between day 1 and 5)") {
+		t.Fatalf("expected the default household's feed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	otherTenant, _ := tenantWithID("other")
+	url := calendarFeedURL(otherTenant)
+	rec = s.do(http.MethodGet, url[strings.Index(url, "/calendar.ics"):], nil, nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Internet") || strings.Contains(rec.Body.String(), "Rent") ||
+		!strings.Contains(rec.Body.String(), "(Due on day 12)") {
+		t.Fatalf("expected the other household's feed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := s.do(http.MethodGet, "/calendar.ics?token=guess", nil, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected an unknown token to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestBudgetPlanWizard(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/summary/range", http.StatusOK, `{"markdown":"","categories":[{"label":"Travel","amount":1210},{"label":"Food","amount":4150}]}`)
+	s.backend.handle("/api/budgets", http.StatusOK, `{"success":true}`)
+
+	s.sendText(t, testChatID, "/plan")
+	s.sendText(t, testChatID, "ok")
+	s.sendText(t, testChatID, "1000")
+
+	texts := s.telegram.waitForTexts(t, 3)
+	if !strings.Contains(texts[0], "Budget 1/2 · Food - last month ₹4,150.00") || !strings.Contains(texts[0], "ok for ₹4,200.00") {
+		t.Errorf("expected the biggest category first, pre-filled from last month, got %q", texts[0])
+	}
+	calls := s.backend.received("/api/budgets")
+	if len(calls) != 1 {
+		t.Fatalf("expected the plan to be submitted once, got %d calls", len(calls))
+	}
+	var budgets []struct {
+		Category string  `json:"category"`
+		Amount   float64 `json:"amount"`
+	}
+	raw, _ := json.Marshal(calls[0].Params["budgets"])
+	json.Unmarshal(raw, &budgets)
+	if len(budgets) != 2 || budgets[0].Category != "Food" || budgets[0].Amount != 4200 || budgets[1].Amount != 1000 {
+		t.Errorf("backend got %+v, want Food 4200 and Travel 1000", budgets)
+	}
+	if !strings.HasPrefix(texts[len(texts)-1], "✅ Budgets saved") {
+		t.Errorf("expected a confirmation, got %q", texts)
+	}
+}
+
+func TestTripTagsExpensesAndReports(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+	s.backend.handle("/api/expenses/list", http.StatusOK, `{"expenses":[
+		{"id":"e1","description":"Hotel","amount":3000,"date":"2026-01-05","userName":"Asha","trip":"Goa"},
+		{"id":"e2","description":"Scooter","amount":600,"date":"2026-01-06","userName":"Ravi","trip":"Goa"},
+		{"id":"e3","description":"Milk","amount":60,"date":"2026-01-06","userName":"Ravi"}]}`)
+
+	s.sendText(t, testChatID, "/trip start Goa")
+	s.sendText(t, testChatID, "Beach shack lunch 900")
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("expected the expense to be saved, got %d saves", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].Trip != "Goa" {
+		t.Fatalf("backend got %+v, want the expense tagged with the trip", expenses)
+	}
+
+	s.sendText(t, testChatID, "/trip end")
+	texts := s.telegram.texts()
+	report := texts[len(texts)-1]
+	if !strings.Contains(report, "Total: ₹3,600.00 (2 expenses)") ||
+		!strings.Contains(report, "• Asha - ₹3,000.00 · gets back ₹1,200.00\n• Ravi - ₹600.00 · owes ₹1,200.00") {
+		t.Fatalf("report = %q, want the trip's total and per-person split without untagged expenses", report)
+	}
+
+	s.sendText(t, testChatID, "Milk 60")
+	calls = s.backend.received("/api/expenses/create-batch-from-bot")
+	expenses = nil
+	json.Unmarshal(calls[len(calls)-1].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].Trip != "" {
+		t.Errorf("backend got %+v, want no trip after /trip end", expenses)
+	}
+}
+
+func TestDuplicateExpenseErrorOffersLogAnyway(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusConflict,
+		`{"error":"Expense already exists.","code":"duplicate_expense"}`)
+
+	s.sendText(t, testChatID, "Chai 20")
+	sent := s.telegram.sent("sendMessage")
+	last := sent[len(sent)-1]
+	if text := paramString(last.Params["text"]); !strings.HasPrefix(text, "🔁 This looks like an expense that's already logged.") {
+		t.Fatalf("expected the friendly duplicate message instead of the backend's text, got %q", text)
+	}
+	var markup tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(last.Params["reply_markup"])), &markup)
+	if len(markup.InlineKeyboard) != 1 || len(markup.InlineKeyboard[0]) != 2 {
+		t.Fatalf("expected log anyway and don't log buttons, got %+v", markup.InlineKeyboard)
+	}
+
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true}`)
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", *markup.InlineKeyboard[0][0].CallbackData, 2), nil)
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 2 {
+		t.Fatalf("expected the batch to be resent, got %d saves", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[1].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].Description != "Chai" || !expenses[0].AllowDuplicate {
+		t.Errorf("backend got %+v, want Chai with allowDuplicate", expenses)
+	}
+}
+
+func TestExportAllStreamsEveryPage(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.mu.Lock()
+	s.backend.handlers["/api/expenses/list"] = func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if req.URL.Query().Get("cursor") == "" {
+			io.WriteString(w, `{"expenses":[{"id":"e1","description":"Chai","amount":20,"date":"2026-01-05"}],"nextCursor":"p2"}`)
+			return
+		}
+		io.WriteString(w, `{"expenses":[{"id":"e2","description":"Rent, flat","amount":25000,"date":"2026-01-01"}]}`)
+	}
+	s.backend.mu.Unlock()
+
+	s.sendText(t, testChatID, "/export all")
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.telegram.sent("sendDocument")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the export to be uploaded as a document")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	calls := s.backend.received("/api/expenses/list")
+	if len(calls) != 2 || !strings.Contains(calls[0].Query, "limit=500") || !strings.Contains(calls[1].Query, "cursor=p2") {
+		t.Fatalf("expected two paged list calls, got %+v", calls)
+	}
+	doc := s.telegram.sent("sendDocument")[0]
+	if file := paramString(doc.Params["document"]); file != "file:spendwise-expenses-all.csv" {
+		t.Errorf("uploaded %q, want the all-time CSV", file)
+	}
+	if caption := paramString(doc.Params["caption"]); caption != "📤 2 expenses · ₹25,020.00" {
+		t.Errorf("caption = %q, want both pages counted", caption)
+	}
+}
+
+func TestBotUsersFromBackendAreAllowedAndNamed(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.UserNames = map[string]string{"42": "Config name"}
+	})
+	s.backend.handle("/api/bot-users", http.StatusOK,
+		`{"users":[{"telegramChatId":"42","userName":"Gopi"},{"telegramChatId":"77","userName":"Asha"}]}`)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+	refreshBotUsers()
+
+	s.sendText(t, 77, "Coffee 50")
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("backend got %d create-batch calls for a backend user, want 1", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].UserName != "Asha" {
+		t.Fatalf("expected the backend's name, got %+v", expenses)
+	}
+
+	// An outage keeps the last list
+	s.backend.handle("/api/bot-users", http.StatusInternalServerError, `{"message":"down"}`)
+	refreshBotUsers()
+	if _, ok := tenantForChat(77); !ok {
+		t.Fatal("expected chat 77 to stay allowed while the backend is down")
+	}
+
+	// Users removed on the backend lose access; configured chats keep it
+	s.backend.handle("/api/bot-users", http.StatusOK, `{"users":[]}`)
+	refreshBotUsers()
+	if _, ok := tenantForChat(77); ok {
+		t.Error("expected chat 77 to be removed")
+	}
+	if _, ok := tenantForChat(testChatID); !ok {
+		t.Error("expected the configured chat to stay allowed")
+	}
+	if name := tenants[0].userName("42"); name != "Config name" {
+		t.Errorf("name of chat 42 = %q, want the configured one back", name)
+	}
+}
+
+func TestLinkCreatesAndConfirmsCodes(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/link-codes", http.StatusOK, `{"code":"482913"}`)
+	s.backend.handle("/api/link-codes/confirm", http.StatusBadRequest, `{"error":"Unknown code","code":"invalid_link_code"}`)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+
+	s.sendText(t, testChatID, "/link")
+	if calls := s.backend.received("/api/link-codes"); len(calls) != 1 || calls[0].Params["telegramChatId"] != "42" {
+		t.Fatalf("expected a link code request for chat 42, got %+v", calls)
+	}
+	if texts := s.telegram.texts(); !strings.Contains(texts[len(texts)-1], "Your link code is 482913") {
+		t.Fatalf("expected the code to be shown, got %q", texts[len(texts)-1])
+	}
+
+	s.sendText(t, testChatID, "/link 000000")
+	if texts := s.telegram.texts(); !strings.Contains(texts[len(texts)-1], "wrong or has expired") {
+		t.Fatalf("expected the invalid code message, got %q", texts[len(texts)-1])
+	}
+
+	s.backend.handle("/api/link-codes/confirm", http.StatusOK, `{"userName":"Gopi"}`)
+	s.sendText(t, testChatID, "/link 482913")
+	calls := s.backend.received("/api/link-codes/confirm")
+	if len(calls) != 2 || calls[1].Params["code"] != "482913" {
+		t.Fatalf("expected the code to be confirmed, got %+v", calls)
+	}
+	s.sendText(t, testChatID, "Coffee 50")
+	var expenses []ExpenseInput
+	json.Unmarshal(s.backend.received("/api/expenses/create-batch-from-bot")[0].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].UserName != "Gopi" {
+		t.Fatalf("expected the expense to be saved under the linked name, got %+v", expenses)
+	}
+}
+
+func TestSensitiveCommandsNeedRecentConfirmation(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.AdminIDs = map[string]bool{"42": true}
+		c.ReauthWindow = 5 * time.Minute
+	})
+	lastText := func() string {
+		texts := s.telegram.texts()
+		return texts[len(texts)-1]
+	}
+
+	s.sendText(t, testChatID, "/block 99")
+	if isBlocked(99) || !strings.Contains(lastText(), "needs a recent confirmation") {
+		t.Fatalf("expected /block to wait for a confirmation, got %q", lastText())
+	}
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", CallbackPrefixReauth+"confirm", 1), nil)
+	if !isBlocked(99) {
+		t.Fatal("expected the confirmed /block to run")
+	}
+	s.sendText(t, testChatID, "/unblock 99")
+	if isBlocked(99) {
+		t.Fatal("expected /unblock to run without asking again within the window")
+	}
+
+	// The confirmation is the sender's, not the whole chat's
+	s.updateID++
+	other := simulatedUpdate(s.updateID, testChatID, "Other", "/block 99", "", 0)
+	other.Message.From = &tgbotapi.User{ID: 7, FirstName: "Other"}
+	s.do(http.MethodPost, "/webhook", other, nil)
+	if isBlocked(99) || !strings.Contains(lastText(), "needs a recent confirmation") {
+		t.Fatalf("expected another member to need their own confirmation, got %q", lastText())
+	}
+
+	// Chats with a PIN confirm by sending it
+	config.ReauthPINs = map[string]string{"42": "4821"}
+	store.Delete(reauthKey(testChatID, testChatID))
+	deletions := len(s.telegram.sent("deleteMessage"))
+	s.sendText(t, testChatID, "/block 99")
+	s.sendText(t, testChatID, "1111")
+	if isBlocked(99) || !strings.Contains(lastText(), "Wrong PIN") {
+		t.Fatalf("expected a wrong PIN to be refused, got %q", lastText())
+	}
+	s.sendText(t, testChatID, "/block 99")
+	// Another member's message in the meantime isn't read as the PIN
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+	s.updateID++
+	coffee := simulatedUpdate(s.updateID, testChatID, "Other", "Coffee 50", "", 0)
+	coffee.Message.From = &tgbotapi.User{ID: 7, FirstName: "Other"}
+	s.do(http.MethodPost, "/webhook", coffee, nil)
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 {
+		t.Fatalf("expected the other member's expense to be logged, got %d saves", len(calls))
+	}
+	s.sendText(t, testChatID, "4821")
+	if !isBlocked(99) {
+		t.Fatal("expected /block to run after the right PIN")
+	}
+	if deleted := len(s.telegram.sent("deleteMessage")) - deletions; deleted != 2 {
+		t.Errorf("expected both PIN messages to be deleted, got %d deletions", deleted)
+	}
+
+	// Guessing is cut off after MaxPINAttempts wrong PINs, even with the right one
+	store.Delete(reauthKey(testChatID, testChatID))
+	for i := 0; i < MaxPINAttempts; i++ {
+		s.sendText(t, testChatID, "/unblock 99")
+		s.sendText(t, testChatID, "1111")
+	}
+	if !strings.Contains(lastText(), "Too many wrong PINs") {
+		t.Fatalf("expected the PIN to be locked out, got %q", lastText())
+	}
+	s.sendText(t, testChatID, "/unblock 99")
+	s.sendText(t, testChatID, "4821")
+	if !isBlocked(99) {
+		t.Fatal("expected the locked out PIN not to be checked")
+	}
+}
+
+func TestPINLockHoldsMessagesUntilUnlocked(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+	lastText := func() string {
+		texts := s.telegram.texts()
+		return texts[len(texts)-1]
+	}
+
+	s.sendText(t, testChatID, "/pin set 4821")
+	if raw, ok, _ := store.Get(pinLockKey(testChatID)); !ok || strings.Contains(raw, "4821") {
+		t.Fatalf("expected a hashed PIN to be stored, got %q", raw)
+	}
+	if !strings.Contains(lastText(), "removed whenever it restarts") {
+		t.Fatalf("expected a warning that the in-memory PIN doesn't survive restarts, got %q", lastText())
+	}
+	s.sendText(t, testChatID, "/pin lock")
+
+	s.sendText(t, testChatID, "Coffee 50")
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 0 || !strings.Contains(lastText(), "locked") {
+		t.Fatalf("expected the locked chat to hold the expense back, got %d calls and %q", len(calls), lastText())
+	}
+	s.sendText(t, testChatID, "1111")
+	if !strings.Contains(lastText(), "Wrong PIN") {
+		t.Fatalf("expected a wrong PIN to be refused, got %q", lastText())
+	}
+	s.sendText(t, testChatID, "4821")
+	if !strings.Contains(lastText(), "Unlocked") {
+		t.Fatalf("expected the right PIN to unlock, got %q", lastText())
+	}
+
+	s.sendText(t, testChatID, "Coffee 50")
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 {
+		t.Fatalf("expected the expense to be saved once unlocked, got %d calls", len(calls))
+	}
+
+	// Idle chats lock again
+	updateSession(testChatID, func(s *chatSession) { s.PINLastActive = time.Now().Add(-DefaultPINIdle - time.Minute) })
+	s.sendText(t, testChatID, "/summary")
+	if !strings.Contains(lastText(), "locked") {
+		t.Fatalf("expected the idle chat to lock, got %q", lastText())
+	}
+
+	// Losing the session doesn't lift the lock
+	store.Delete(sessionKey(testChatID))
+	s.sendText(t, testChatID, "Coffee 50")
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 || !strings.Contains(lastText(), "locked") {
+		t.Fatalf("expected the chat to stay locked without its session, got %d calls and %q", len(calls), lastText())
+	}
+
+	// An unreadable lock fails closed
+	store.Set(pinLockKey(testChatID), "{", 0)
+	s.sendText(t, testChatID, "Coffee 50")
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 || !strings.Contains(lastText(), "Couldn't check") {
+		t.Fatalf("expected an unreadable lock to hold the expense back, got %d calls and %q", len(calls), lastText())
+	}
+}
+
+func TestExportRemindersAsCSV(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/reminders/get-payload", http.StatusOK, `{"telegramUserIds":["42"],"reminders":[
+		{"id":"r1","description":"Rent, flat","amount":25000,"mainType":"bill","dayOfMonthStart":1,"dayOfMonthEnd":5,"activeMonths":["2026-01","2026-02"],"paidMonths":["2026-01"]},
+		{"id":"r2","description":"Insurance","amount":12000,"mainType":"bill","dueDate":"2026-03-15"}]}`)
+
+	s.sendText(t, testChatID, "/export reminders")
+
+	docs := s.telegram.sent("sendDocument")
+	if len(docs) != 1 {
+		t.Fatalf("expected one document, got %d", len(docs))
+	}
+	if caption := paramString(docs[0].Params["caption"]); caption != "📤 2 reminders" {
+		t.Errorf("caption = %q", caption)
+	}
+	data, err := renderRemindersCSV([]Reminder{{Description: "Rent, flat", Amount: 25000, MainType: "bill", DayOfMonthStart: 1, DayOfMonthEnd: 5,
+		ActiveMonths: []string{"2026-01", "2026-02"}, PaidMonths: []string{"2026-01"}}})
+	want := "description,type,amount,due,active_months,paid_months\n\"Rent, flat\",bill,25000.00,1-5,2026-01 2026-02,2026-01\n"
+	if err != nil || string(data) != want {
+		t.Errorf("CSV = %q, %v; want %q", data, err, want)
+	}
+}
+
+func TestReminderAmountButtonUpdatesAndMarksDone(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/reminders/update", http.StatusOK, `{"success":true}`)
+	s.backend.handle("/api/reminders/mark-as-done", http.StatusOK, `{"message":"Done"}`)
+	payload := map[string]interface{}{
+		"telegramUserIds": []string{"42"},
+		"reminders": []map[string]interface{}{
+			{"id": "r1", "description": "Power Bill", "amount": 850, "dayOfMonthStart": 1, "dayOfMonthEnd": 31, "type": "standard"},
+			{"id": "r2", "description": "Internet", "amount": 999, "dayOfMonthStart": 1, "dayOfMonthEnd": 31, "type": "standard"},
+		},
+	}
+	header := http.Header{http.CanonicalHeaderKey(HeaderAPISecret): {testAPISecret}}
+	if rec := s.do(http.MethodPost, "/internal/notify-reminders", payload, header); rec.Code != http.StatusOK {
+		t.Fatalf("notify-reminders answered %d: %s", rec.Code, rec.Body.String())
+	}
+	var markup tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(s.telegram.sent("sendMessage")[0].Params["reply_markup"])), &markup)
+	if len(markup.InlineKeyboard) != 2 || len(markup.InlineKeyboard[0]) != 2 {
+		t.Fatalf("expected mark-done and amount buttons per bill, got %+v", markup)
+	}
+
+	s.updateID++
+	update := simulatedUpdate(s.updateID, testChatID, "Tester", "", *markup.InlineKeyboard[0][1].CallbackData, 7)
+	update.CallbackQuery.Message.ReplyMarkup = &markup
+	s.do(http.MethodPost, "/webhook", update, nil)
+	s.sendText(t, testChatID, "1,240.50")
+
+	updates := s.backend.received("/api/reminders/update")
+	if len(updates) != 1 || updates[0].Method != http.MethodPatch || updates[0].Params["reminderId"] != "r1" || updates[0].Params["amount"] != 1240.5 {
+		t.Fatalf("expected the reminder amount to be patched, got %+v", updates)
+	}
+	if done := s.backend.received("/api/reminders/mark-as-done"); len(done) != 1 || done[0].Params["reminderId"] != "r1" {
+		t.Fatalf("expected the reminder to be marked done, got %+v", done)
+	}
+	edits := s.telegram.sent("editMessageReplyMarkup")
+	if len(edits) != 1 {
+		t.Fatalf("expected the notification's buttons to be updated, got %d edits", len(edits))
+	}
+	var remaining tgbotapi.InlineKeyboardMarkup
+	json.Unmarshal([]byte(paramString(edits[0].Params["reply_markup"])), &remaining)
+	if len(remaining.InlineKeyboard) != 1 || *remaining.InlineKeyboard[0][0].CallbackData != CallbackPrefixMarkDone+"r2:standard" {
+		t.Errorf("expected only the other bill's buttons to stay, got %+v", remaining)
+	}
+	if texts := s.telegram.texts(); !strings.Contains(texts[len(texts)-1], "₹1,240.50 and marked as done") {
+		t.Errorf("unexpected confirmation %q", texts[len(texts)-1])
+	}
+}
+
+func TestMarkDoneAsksForAmountPaid(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) { c.MarkDoneExpense = MarkDoneExpenseAsk })
+	s.backend.handle("/api/reminders/get-payload", http.StatusOK, `{"telegramUserIds":["42"],"reminders":[
+		{"id":"r1","description":"Power Bill","amount":1800,"dayOfMonthStart":1,"dayOfMonthEnd":31,"type":"standard"}]}`)
+	s.backend.handle("/api/reminders/mark-as-done", http.StatusOK, `{"message":"Done"}`)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", CallbackPrefixMarkDone+"r1:standard", 5), nil)
+	if calls := s.backend.received("/api/reminders/mark-as-done"); len(calls) != 0 {
+		t.Fatalf("expected mark-done to wait for the amount, got %d calls", len(calls))
+	}
+	texts := s.telegram.texts()
+	if !strings.Contains(texts[len(texts)-1], "How much did you pay for Power Bill? (default ₹1,800.00)") {
+		t.Fatalf("unexpected question %q", texts[len(texts)-1])
+	}
+
+	s.sendText(t, testChatID, "1750")
+	done := s.backend.received("/api/reminders/mark-as-done")
+	if len(done) != 1 || done[0].Params["reminderId"] != "r1" || done[0].Params["amountPaid"] != 1750.0 {
+		t.Fatalf("expected the reminder to be marked done with the amount paid, got %+v", done)
+	}
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("expected the payment to be logged as an expense, got %d calls", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].Description != "Power Bill" || expenses[0].Amount != 1750 || expenses[0].Category != ReminderExpenseCategory {
+		t.Fatalf("unexpected expense %+v", expenses)
+	}
+}
+
+func TestMarkDoneLogsReminderAmountAutomatically(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) { c.MarkDoneExpense = MarkDoneExpenseAuto })
+	s.backend.handle("/api/reminders/get-payload", http.StatusOK, `{"telegramUserIds":["42"],"reminders":[
+		{"id":"r1","description":"Power Bill","amount":1800,"dayOfMonthStart":1,"dayOfMonthEnd":31,"type":"standard"},
+		{"id":"r2","description":"Maid","dayOfMonthStart":1,"dayOfMonthEnd":31,"type":"standard"}]}`)
+	s.backend.handle("/api/reminders/mark-as-done", http.StatusOK, `{"message":"Done"}`)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", CallbackPrefixMarkDone+"r1:standard", 5), nil)
+	done := s.backend.received("/api/reminders/mark-as-done")
+	if len(done) != 1 || done[0].Params["amountPaid"] != 1800.0 {
+		t.Fatalf("expected the reminder to be marked done with its amount, got %+v", done)
+	}
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("expected the bill to be logged as an expense, got %d calls", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].Description != "Power Bill" || expenses[0].Amount != 1800 || expenses[0].Category != ReminderExpenseCategory {
+		t.Fatalf("unexpected expense %+v", expenses)
+	}
+
+	// A second tap on the same button is dropped
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", CallbackPrefixMarkDone+"r1:standard", 5), nil)
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 {
+		t.Fatalf("expected a double tap to log the bill once, got %d expenses", len(calls))
+	}
+
+	// Without an amount there is nothing to log
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", CallbackPrefixMarkDone+"r2:standard", 6), nil)
+	if done := s.backend.received("/api/reminders/mark-as-done"); len(done) != 2 || done[1].Params["amountPaid"] != nil {
+		t.Fatalf("expected a plain mark-done, got %+v", done)
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 1 {
+		t.Fatalf("expected no expense for a reminder without an amount, got %d calls", len(calls))
+	}
+}
+
+func TestReminderAmountReplyLogsExpenseWhenEnabled(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) { c.MarkDoneExpense = MarkDoneExpenseAuto })
+	s.backend.handle("/api/reminders/get-payload", http.StatusOK, `{"telegramUserIds":["42"],"reminders":[
+		{"id":"r1","description":"Power Bill","amount":1800,"dayOfMonthStart":1,"dayOfMonthEnd":31,"type":"standard"}]}`)
+	s.backend.handle("/api/reminders/update", http.StatusOK, `{"success":true}`)
+	s.backend.handle("/api/reminders/mark-as-done", http.StatusOK, `{"message":"Done"}`)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", reminderAmountData("r1", "standard"), 5), nil)
+	s.sendText(t, testChatID, "1950")
+
+	done := s.backend.received("/api/reminders/mark-as-done")
+	if len(done) != 1 || done[0].Params["amountPaid"] != 1950.0 {
+		t.Fatalf("expected the reminder to be marked done with the new amount paid, got %+v", done)
+	}
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("expected the bill to be logged as an expense, got %d calls", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].Description != "Power Bill" || expenses[0].Amount != 1950 || expenses[0].Category != ReminderExpenseCategory {
+		t.Fatalf("unexpected expense %+v", expenses)
+	}
+}
+
+// pushUpdate delivers an update through the Pub/Sub push endpoint
+func (s *testServer) pushUpdate(update tgbotapi.Update) *httptest.ResponseRecorder {
+	raw, _ := json.Marshal(update)
+	var push pubSubPush
+	push.Message.Data = base64.StdEncoding.EncodeToString(raw)
+	push.Message.Attributes = map[string]string{"type": PubSubTypeTelegramUpdate}
+	push.Message.MessageID = strconv.Itoa(update.UpdateID)
+	return s.do(http.MethodPost, "/pubsub/push?token=push-token", push, nil)
+}
+
+func TestPubSubNacksPanickingUpdates(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) { c.PubSubToken = "push-token" })
+
+	// A callback without a sender panics in the handler
+	update := simulatedUpdate(2001, testChatID, "Tester", "", "mark_done:r1:standard", 5)
+	update.CallbackQuery.From = nil
+	if rec := s.pushUpdate(update); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected the panicking update to be nacked, got %d", rec.Code)
+	}
+	if _, claimed, _ := store.Get(fmt.Sprintf("update:%s:%d", DefaultBotID, update.UpdateID)); claimed {
+		t.Fatalf("expected the dedupe claim to be released for the redelivery")
+	}
+
+	// The webhook has nothing to redeliver, so it recovers and answers 200
+	update.UpdateID = 2002
+	if rec := s.do(http.MethodPost, "/webhook", update, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected the webhook to recover the panic, got %d", rec.Code)
+	}
+
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+	if rec := s.pushUpdate(simulatedUpdate(2003, testChatID, "Tester", "Lunch 250", "", 0)); rec.Code != http.StatusOK {
+		t.Fatalf("expected the update to be acked, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestInlineCallbacksAreAnswered(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) { c.PubSubToken = "push-token" })
+
+	update := simulatedUpdate(2001, testChatID, "Tester", "", "mark_done:r1:standard", 5)
+	update.CallbackQuery.Message, update.CallbackQuery.InlineMessageID = nil, "inline-1"
+	if rec := s.pushUpdate(update); rec.Code != http.StatusOK {
+		t.Fatalf("expected the inline callback to be acked, got %d", rec.Code)
+	}
+	if answers := s.telegram.sent("answerCallbackQuery"); len(answers) != 1 {
+		t.Fatalf("expected the inline callback to be answered, got %d answers", len(answers))
+	}
+}
+
+func TestPubSubNacksUpdatesWhileBackendIsDown(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) { c.PubSubToken = "push-token" })
+
+	if rec := s.do(http.MethodPost, "/pubsub/push?token=wrong", pubSubPush{}, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a wrong token to be rejected, got %d", rec.Code)
+	}
+
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusServiceUnavailable, `{"error":"maintenance"}`)
+	update := simulatedUpdate(2101, testChatID, "Tester", "Lunch 250", "", 0)
+	if rec := s.pushUpdate(update); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected the update to be nacked while the backend is down, got %d", rec.Code)
+	}
+	if queued, _ := store.ListLen(retryQueueKey(DefaultBotID, testChatID)); queued != 0 {
+		t.Fatalf("expected Pub/Sub to redeliver instead of the local retry queue")
+	}
+
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+	if rec := s.pushUpdate(update); rec.Code != http.StatusOK {
+		t.Fatalf("expected the redelivery to be processed and acked, got %d", rec.Code)
+	}
+	if calls := s.backend.received("/api/expenses/create-batch-from-bot"); len(calls) != 2 {
+		t.Fatalf("expected the redelivery to save the expense, got %d calls", len(calls))
+	}
+}
+
+func TestMemoryStoreSweepsExpiredKeys(t *testing.T) {
+	m := newMemoryStore()
+	m.Set("update:default:1", "instance", time.Millisecond)
+	m.SetNX("update:default:2", "instance", time.Millisecond)
+	m.Set("kept", "value", time.Hour)
+	m.Set("forever", "value", 0)
+	time.Sleep(5 * time.Millisecond)
+
+	// Writes within the sweep interval leave expired keys for later
+	m.Set("other", "value", time.Hour)
+	if len(m.items) != 5 {
+		t.Fatalf("expected no sweep within the interval, got %d keys", len(m.items))
+	}
+
+	m.lastSweep = time.Now().Add(-MemorySweepInterval)
+	m.SetNX("update:default:3", "instance", time.Hour)
+	for _, key := range []string{"update:default:1", "update:default:2"} {
+		if _, ok := m.items[key]; ok {
+			t.Fatalf("expected expired key %s to be swept", key)
+		}
+	}
+	if len(m.items) != 4 {
+		t.Fatalf("expected the live keys to be kept, got %d keys", len(m.items))
+	}
+}
+
+func TestStatementAmountsAreSingleNumbers(t *testing.T) {
+	newTestServer(t, nil)
+	formatter := formatterFor(testChatID)
+	for text, want := range map[string]float64{
+		"12,345.50": 12345.50,
+		"₹1,800":    1800,
+		"Rs. 1240":  1240,
+		" 99 ":      99,
+	} {
+		if got, err := parseStatementAmount(text, formatter); err != nil || got != want {
+			t.Errorf("parseStatementAmount(%q) = %v, %v; want %v", text, got, err, want)
+		}
+	}
+	for _, text := range []string{"12 Lunch 5.50", "paid 1800 on 5th", "1800 2", "Lunch", "0", "-50", "1,80"} {
+		if got, err := parseStatementAmount(text, formatter); err == nil {
+			t.Errorf("parseStatementAmount(%q) = %v, want an error", text, got)
+		}
+	}
+}
+
+func TestAmountQuestionLetsOtherMessagesThrough(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) { c.MarkDoneExpense = MarkDoneExpenseAsk })
+	s.backend.handle("/api/reminders/get-payload", http.StatusOK, `{"telegramUserIds":["42"],"reminders":[
+		{"id":"r1","description":"Power Bill","amount":1800,"dayOfMonthStart":1,"dayOfMonthEnd":31,"type":"standard"}]}`)
+	s.backend.handle("/api/expenses/create-batch-from-bot", http.StatusOK, `{"success":true,"ids":["exp-1"]}`)
+
+	s.updateID++
+	s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", CallbackPrefixMarkDone+"r1:standard", 5), nil)
+	s.sendText(t, testChatID, "Lunch 250")
+
+	if calls := s.backend.received("/api/reminders/mark-as-done"); len(calls) != 0 {
+		t.Fatalf("expected the reminder to stay open, got %d mark-done calls", len(calls))
+	}
+	calls := s.backend.received("/api/expenses/create-batch-from-bot")
+	if len(calls) != 1 {
+		t.Fatalf("expected the message to be logged as an expense, got %d calls", len(calls))
+	}
+	var expenses []ExpenseInput
+	json.Unmarshal(calls[0].Params["items"].(json.RawMessage), &expenses)
+	if len(expenses) != 1 || expenses[0].Description != "Lunch" || expenses[0].Amount != 250 {
+		t.Fatalf("unexpected expense %+v", expenses)
+	}
+}
+
+func TestSummaryChartsHandleRefundsAndMarkdown(t *testing.T) {
+	newTestServer(t, nil)
+	formatter := formatterFor(testChatID)
+
+	bars := strings.Split(categoryBars([]SummaryBucket{
+		{Label: "Groceries", Amount: 1000},
+		{Label: "Refunds", Amount: -400},
+		{Label: "Transport", Amount: 500},
+	}, formatter), "\n")
+	if len(bars) != 3 {
+		t.Fatalf("expected a bar per category, got %q", bars)
+	}
+	for _, line := range bars {
+		if cells := strings.Count(line, "▇") + strings.Count(line, "▁"); cells != ChartBarWidth {
+			t.Errorf("expected %d cells in %q, got %d", ChartBarWidth, line, cells)
+		}
+	}
+	if strings.Contains(bars[1], "▇") {
+		t.Errorf("expected an empty bar for a negative bucket, got %q", bars[1])
+	}
+
+	charts := summaryCharts(nil, []SummaryBucket{{Label: "Mon_1", Amount: 10}, {Label: "Tue", Amount: 5}}, formatter)
+	if !strings.Contains(charts, `Peak: Mon\_1`) {
+		t.Errorf("expected the peak label to be escaped, got %q", charts)
+	}
+}
+
+func TestTimedOutSaveIsNotQueued(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) { c.CommandTimeout = 100 * time.Millisecond })
+	s.backend.mu.Lock()
+	s.backend.handlers["/api/expenses/create-batch-from-bot"] = func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-req.Context().Done():
+		}
+	}
+	s.backend.mu.Unlock()
+
+	s.sendText(t, testChatID, "Coffee 50")
+
+	if queued, _ := store.ListLen(retryQueueKey(DefaultBotID, testChatID)); queued != 0 {
+		t.Fatalf("expected a save that may have gone through not to be queued, got %d batches", queued)
+	}
+	texts := s.telegram.texts()
+	if len(texts) != 1 || strings.Contains(texts[0], "sync") {
+		t.Fatalf("expected a plain failure reply, got %q", texts)
+	}
+}
+
+func TestWatermarkWaitsForEarlierUpdates(t *testing.T) {
+	newTestServer(t, nil)
+	const botID = "watermark-test"
+	startUpdate(botID, 10)
+	startUpdate(botID, 11)
+
+	advanceWatermark(botID, 11)
+	if value, ok, _ := store.Get(watermarkKey(botID)); ok {
+		t.Fatalf("expected the watermark to wait for update 10, got %s", value)
+	}
+
+	advanceWatermark(botID, 10)
+	if value, _, _ := store.Get(watermarkKey(botID)); value != "11" {
+		t.Fatalf("expected the watermark to move to 11, got %q", value)
+	}
+}
+
+func TestWatermarkSkipsStaleGaps(t *testing.T) {
+	newTestServer(t, nil)
+	const botID = "watermark-gap-test"
+	startUpdate(botID, 20)
+
+	// Update 20 went to another instance a day ago; 21 finished here back then
+	watermarks.Lock()
+	watermarks.done[botID] = map[int]time.Time{21: time.Now().Add(-WatermarkGapTimeout - time.Minute)}
+	watermarks.lastCheck[botID] = time.Time{}
+	watermarks.Unlock()
+
+	advanceWatermark(botID, 22)
+	if value, _, _ := store.Get(watermarkKey(botID)); value != "22" {
+		t.Fatalf("expected the watermark to skip the stale gap, got %q", value)
+	}
+	watermarks.Lock()
+	pending := len(watermarks.done[botID])
+	watermarks.Unlock()
+	if pending != 0 {
+		t.Fatalf("expected no completed update_ids to be kept, got %d", pending)
+	}
+}
+
+func TestWatermarkNeverMovesBack(t *testing.T) {
+	newTestServer(t, nil)
+	const botID = "watermark-max-test"
+	// Another instance has already persisted a higher watermark
+	store.Set(watermarkKey(botID), "50", UpdateWatermarkTTL)
+
+	startUpdate(botID, 30)
+	advanceWatermark(botID, 30)
+	if value, _, _ := store.Get(watermarkKey(botID)); value != "50" {
+		t.Fatalf("expected the shared watermark to stay at 50, got %q", value)
+	}
+}
+
+func TestEscalationsNameTheDueDate(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) { c.DateFormat = "DD.MM.YYYY" })
+	reminder := Reminder{ID: "r1", Description: "Rent", Amount: 1000, DayOfMonthStart: 5, DayOfMonthEnd: 10}
+
+	defaultBot.sendEscalation(strconv.FormatInt(testChatID, 10), "⚠️ Overdue", 3, time.Date(2026, time.April, 10, 0, 0, 0, 0, time.UTC), reminder)
+
+	texts := s.telegram.texts()
+	if len(texts) != 1 || !strings.Contains(texts[0], "was due 10.04.2026 and is 3 day(s) overdue") {
+		t.Fatalf("expected the escalation to name the due date, got %q", texts)
+	}
+}
+
+func TestMonthEndRemindersClampToShortMonths(t *testing.T) {
+	bill := Reminder{ID: "r1", Description: "Rent", Amount: 1000, DayOfMonthStart: 31, DayOfMonthEnd: 31}
+	for _, now := range []time.Time{
+		time.Date(2026, time.April, 30, 9, 0, 0, 0, time.UTC),
+		time.Date(2027, time.February, 28, 9, 0, 0, 0, time.UTC),
+	} {
+		urgency, due := classifyReminder(bill, now)
+		if urgency != UrgencyDueToday || due.Month() != now.Month() || due.Day() != now.Day() {
+			t.Errorf("%s: expected the day-31 bill to be due today, got urgency %d on %s", now.Format("2 Jan"), urgency, due.Format("2 Jan"))
+		}
+		if len(pendingReminders([]Reminder{bill}, now)) != 1 {
+			t.Errorf("%s: expected the day-31 bill to be pending", now.Format("2 Jan"))
+		}
+	}
+
+	insurance := Reminder{ID: "r2", Description: "Insurance", Amount: 500, DayOfMonthStart: 25, DayOfMonthEnd: 29}
+	now := time.Date(2026, time.April, 30, 9, 0, 0, 0, time.UTC)
+	if urgency, _ := classifyReminder(insurance, now); urgency != UrgencyOverdue {
+		t.Errorf("expected the bill due by the 29th to be overdue on 30 April, got urgency %d", urgency)
+	}
+	if days, _ := daysOverdue(insurance, now); days != 1 {
+		t.Errorf("expected 1 day overdue, got %d", days)
+	}
+}
+
+func TestOverdueBillsKeepCountingIntoTheNextMonth(t *testing.T) {
+	now := time.Date(2026, time.May, 1, 9, 0, 0, 0, time.UTC)
+	bill := Reminder{ID: "r1", Description: "Rent", Amount: 1000, DayOfMonthStart: 25, DayOfMonthEnd: 28}
+	if days, month := daysOverdue(bill, now); days != 3 || month.Month() != time.April {
+		t.Fatalf("expected April's bill to be 3 days overdue, got %d days for %s", days, month.Format("Jan"))
+	}
+
+	bill.PaidMonths = []string{"2026-04"}
+	if days, _ := daysOverdue(bill, now); days != 0 {
+		t.Fatalf("expected a paid bill not to be overdue, got %d days", days)
+	}
+
+	bill.PaidMonths = nil
+	bill.ActiveMonths = []string{"March"}
+	if days, month := daysOverdue(bill, now); days != 34 || month.Month() != time.March {
+		t.Fatalf("expected March's bill to be 34 days overdue, got %d days for %s", days, month.Format("Jan"))
+	}
+
+	bill.CreatedAt.Seconds = time.Date(2026, time.April, 10, 0, 0, 0, 0, time.UTC).Unix()
+	if days, _ := daysOverdue(bill, now); days != 0 {
+		t.Fatalf("expected months before the reminder existed to be skipped, got %d days", days)
+	}
+}
+
+func TestRecurringSuggestionIgnoresSecondTap(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.backend.handle("/api/reminders/create", http.StatusOK, `{"success":true}`)
+	key, hash := recurringOfferKey(testChatID, "Netflix")
+	if err := store.Set(key, `{"description":"Netflix","amount":649,"day":5}`, RecurringOfferTTL); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		s.updateID++
+		s.do(http.MethodPost, "/webhook", simulatedUpdate(s.updateID, testChatID, "Tester", "", CallbackPrefixRecurring+"yes:"+hash, 9), nil)
+	}
+
+	if calls := s.backend.received("/api/reminders/create"); len(calls) != 1 {
+		t.Fatalf("expected one reminder for a double tap, got %d", len(calls))
+	}
+	if _, offered, _ := store.Get(key); !offered {
+		t.Errorf("expected the description to stay marked as offered")
+	}
+}
+
+func TestNilTenantMeansNoRestriction(t *testing.T) {
+	newTestServer(t, func(c *SpendWiseConfig) {
+		c.AllowedIDs = map[string]bool{"42": true, "43": true}
+		c.UserNames = map[string]string{"43": "Priya"}
+		c.ReminderOwners = map[string]string{"42": "user-a", "43": "user-b"}
+	})
+	var none *tenant
+	reminders := []Reminder{{ID: "r1", UserID: "user-a"}, {ID: "r2", UserID: "user-b"}, {ID: "r3"}}
+
+	if chats := none.allowedChats(); len(chats) != 2 {
+		t.Errorf("expected every allowed chat, got %v", chats)
+	}
+	if name := none.userName("43"); name != "Priya" {
+		t.Errorf("expected the chat's configured name, got %q", name)
+	}
+	if own := none.remindersFor(42, reminders); len(own) != 2 || own[0].ID != "r1" || own[1].ID != "r3" {
+		t.Errorf("expected the chat's household owners to still apply, got %+v", own)
+	}
+	if chats := none.reminderChats(reminders[0], []string{"42", "43"}); len(chats) != 2 {
+		t.Errorf("expected the fallback chats, got %v", chats)
+	}
+	if !none.servedBy(&botInstance{ID: "other"}) || !none.escalationBot(&botInstance{ID: DefaultBotID}) {
+		t.Errorf("expected a nil tenant to be served by any bot and run jobs on the default bot")
+	}
+	none.setUserName("42", "Arun")
+	if name := none.userName("42"); name != "Arun" {
+		t.Errorf("expected the linked name to be stored on the chat's household, got %q", name)
+	}
+}
+
+func TestMetricsRequireAPISecret(t *testing.T) {
+	s := newTestServer(t, nil)
+	if rec := s.do(http.MethodGet, "/metrics", nil, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected /metrics without the secret to be rejected, got %d", rec.Code)
+	}
+	header := http.Header{http.CanonicalHeaderKey(HeaderAPISecret): {testAPISecret}}
+	if rec := s.do(http.MethodGet, "/metrics", nil, header); rec.Code != http.StatusOK {
+		t.Fatalf("expected /metrics with the secret to answer 200, got %d", rec.Code)
+	}
+}
+
+func TestAllowlistOnlyTrustsConfiguredProxies(t *testing.T) {
+	check := func(remoteAddr, forwardedFor string) int {
+		r := gin.New()
+		trustForwardedFor(r)
+		allowlist, err := allowCIDRs("internal", []string{"10.0.0.5"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.GET("/internal/ping", allowlist, func(c *gin.Context) { c.Status(http.StatusOK) })
+		req := httptest.NewRequest(http.MethodGet, "/internal/ping", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	config = SpendWiseConfig{}
+	if code := check("203.0.113.9:4000", "10.0.0.5"); code != http.StatusForbidden {
+		t.Errorf("expected a spoofed X-Forwarded-For to be ignored without trusted proxies, got %d", code)
+	}
+	if code := check("10.0.0.5:4000", ""); code != http.StatusOK {
+		t.Errorf("expected the allowed address to pass, got %d", code)
+	}
+
+	config = SpendWiseConfig{TrustedProxies: []string{"169.254.0.0/16"}}
+	if code := check("169.254.1.1:4000", "203.0.113.9, 10.0.0.5"); code != http.StatusOK {
+		t.Errorf("expected the hop appended by the trusted proxy to be used, got %d", code)
+	}
+	if code := check("203.0.113.9:4000", "10.0.0.5"); code != http.StatusForbidden {
+		t.Errorf("expected X-Forwarded-For from an untrusted peer to be ignored, got %d", code)
+	}
+}
+
+// lockedBuffer collects log output written from several goroutines
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+func (l *lockedBuffer) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func TestPINsAreNeverLogged(t *testing.T) {
+	s := newTestServer(t, func(c *SpendWiseConfig) {
+		c.AdminIDs = map[string]bool{"42": true}
+		c.ReauthWindow = 5 * time.Minute
+		c.ReauthPINs = map[string]string{"42": "5555"}
+	})
+	logs := &lockedBuffer{}
+	previous := log.Writer()
+	log.SetOutput(logs)
+	t.Cleanup(func() { log.SetOutput(previous) })
+
+	s.sendText(t, testChatID, "/pin set 4821")
+	s.sendText(t, testChatID, "/pin lock")
+	s.sendText(t, testChatID, "4821")
+	s.sendText(t, testChatID, "/block 99")
+	s.sendText(t, testChatID, "5555")
+	if !isBlocked(99) {
+		t.Fatal("expected /block to run after the right PIN")
+	}
+	config.LogMessageContent = false
+	s.sendText(t, testChatID, "/pin lock")
+	s.sendText(t, testChatID, "4821")
+
+	for _, secret := range []string{"4821", "5555", logText("4821")} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("logs contain %q:\n%s", secret, logs.String())
+		}
+	}
+}
+
+func TestSessionUpdatesWaitForOtherInstances(t *testing.T) {
+	newTestServer(t, nil)
+
+	// Another instance holds the chat's session lock
+	if claimed, _ := store.SetNX(sessionLockKey(testChatID), "other-instance", SessionLockTTL); !claimed {
+		t.Fatal("expected to take the session lock")
+	}
+	done := make(chan struct{})
+	go func() {
+		updateSession(testChatID, func(s *chatSession) { s.PINFailures++ })
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("expected the update to wait for the other instance's lock")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if released, _ := store.Release(sessionLockKey(testChatID), "other-instance"); !released {
+		t.Fatal("expected the other instance to release its lock")
+	}
+	<-done
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			updateSession(testChatID, func(s *chatSession) { s.PINFailures++ })
+		}()
+	}
+	wg.Wait()
+	if failures := viewSession(testChatID).PINFailures; failures != 21 {
+		t.Fatalf("expected every update to be kept, got %d", failures)
+	}
+	if _, held, _ := store.Get(sessionLockKey(testChatID)); held {
+		t.Fatal("expected the session lock to be released")
+	}
+}
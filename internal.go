@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// MaxBulkRecipients bounds a single /internal/send-bulk request
+	MaxBulkRecipients = 500
+	// BulkSendWorkers is how many bulk sends wait on the rate limiter at once
+	BulkSendWorkers = 20
+)
+
+// requireAPISecret guards /internal/* with the shared backend secret
+func requireAPISecret(c *gin.Context) {
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader(HeaderAPISecret)), []byte(config.APISecret)) != 1 {
+		log.Printf("❌ Unauthorized internal API request from IP: %s", c.ClientIP())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	c.Next()
+}
+
+// outboundMessage is one message the backend asks the bot to deliver
+type outboundMessage struct {
+	ChatID  int64                  `json:"chatId"`
+	Message string                 `json:"message"`
+	Options map[string]interface{} `json:"options"`
+}
+
+// validate returns a client-facing problem description, or "" when the message is sendable
+func (m outboundMessage) validate() string {
+	switch {
+	case m.ChatID == 0:
+		return "chatId is required"
+	case strings.TrimSpace(m.Message) == "":
+		return "message is required"
+	case len([]rune(m.Message)) > MaxTelegramMessageLength:
+		return fmt.Sprintf("message exceeds %d characters", MaxTelegramMessageLength)
+	}
+	return ""
+}
+
+// config builds the Telegram message, applying supported options:
+// parseMode, disableNotification and disableWebPagePreview
+func (m outboundMessage) config() tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(m.ChatID, m.Message)
+	if mode, ok := m.Options["parseMode"].(string); ok {
+		msg.ParseMode = mode
+	}
+	if silent, ok := m.Options["disableNotification"].(bool); ok {
+		msg.DisableNotification = silent
+	}
+	if noPreview, ok := m.Options["disableWebPagePreview"].(bool); ok {
+		msg.DisableWebPagePreview = noPreview
+	}
+	return msg
+}
+
+// internalBot returns the bot named in a request, defaulting to the main bot
+func internalBot(botID string) (*botInstance, bool) {
+	if botID == "" {
+		return defaultBot, true
+	}
+	b, ok := bots[botID]
+	return b, ok
+}
+
+// bulkResult is the delivery outcome for one recipient of a bulk send
+type bulkResult struct {
+	ChatID    int64  `json:"chatId"`
+	Success   bool   `json:"success"`
+	MessageID int    `json:"messageId,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Skipped   bool   `json:"skipped,omitempty"` // nothing to send to this chat
+}
+
+// handleSendBulk fans a batch of messages out through the bot's rate-limited
+// send queue and reports the outcome per recipient, in request order.
+func handleSendBulk(c *gin.Context) {
+	var req struct {
+		Messages []outboundMessage `json:"messages"`
+		BotID    string            `json:"botId"` // optional, defaults to the main bot
+	}
+	if !bindJSON(c, &req, true) {
+		return
+	}
+
+	if len(req.Messages) == 0 || len(req.Messages) > MaxBulkRecipients {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("messages must contain 1-%d entries", MaxBulkRecipients)})
+		return
+	}
+	b, ok := internalBot(req.BotID)
+	if !ok {
+		log.Printf("❌ Internal send-bulk for unknown bot %q", req.BotID)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown bot"})
+		return
+	}
+
+	log.Printf("📤 Bulk sending %d messages via bot %s", len(req.Messages), b.ID)
+	results := make([]bulkResult, len(req.Messages))
+	workers := make(chan struct{}, BulkSendWorkers)
+	var wg sync.WaitGroup
+	for i, m := range req.Messages {
+		results[i] = bulkResult{ChatID: m.ChatID}
+		if problem := m.validate(); problem != "" {
+			results[i].Error = problem
+			continue
+		}
+
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, m outboundMessage) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			sent, err := b.sendPaced(m.ChatID, m.config())
+			if err != nil {
+				log.Printf("❌ Bulk send to ChatID %d failed: %v", m.ChatID, err)
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Success = true
+			results[i].MessageID = sent.MessageID
+		}(i, m)
+	}
+	wg.Wait()
+
+	delivered := 0
+	for _, r := range results {
+		if r.Success {
+			delivered++
+		}
+	}
+	log.Printf("✅ Bulk send finished via bot %s - %d/%d delivered", b.ID, delivered, len(results))
+	c.JSON(http.StatusOK, gin.H{"success": delivered == len(results), "delivered": delivered, "results": results})
+}
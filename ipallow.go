@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseCIDRs parses CIDR ranges; bare IPs are treated as single-host ranges
+func parseCIDRs(ranges []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, r := range ranges {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		if !strings.Contains(r, "/") {
+			ip := net.ParseIP(r)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %q", r)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %v", r, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// trustForwardedFor only lets TRUSTED_PROXIES set X-Forwarded-For and
+// TRUSTED_PLATFORM name a client IP header. Without them anyone could claim an
+// allowed address, so the connection's own address is used.
+func trustForwardedFor(r *gin.Engine) {
+	if err := r.SetTrustedProxies(config.TrustedProxies); err != nil {
+		log.Printf("❌ Invalid TRUSTED_PROXIES, ignoring X-Forwarded-For: %v", err)
+		r.SetTrustedProxies(nil)
+	}
+	r.TrustedPlatform = config.TrustedPlatform
+}
+
+// allowlistClientIP returns the address to check against an allowlist: the
+// connection's address, or the client a trusted proxy or platform forwarded
+func allowlistClientIP(c *gin.Context) net.IP {
+	return net.ParseIP(c.ClientIP())
+}
+
+// allowCIDRs only admits requests from the given ranges. An empty list allows
+// everyone, so the check is opt-in per endpoint group.
+func allowCIDRs(name string, ranges []string) (gin.HandlerFunc, error) {
+	nets, err := parseCIDRs(ranges)
+	if err != nil {
+		return nil, fmt.Errorf("%s allowlist: %v", name, err)
+	}
+	if len(nets) == 0 {
+		return func(c *gin.Context) { c.Next() }, nil
+	}
+
+	log.Printf("🛡️ %s endpoints restricted to %d IP range(s)", name, len(nets))
+	return func(c *gin.Context) {
+		ip := allowlistClientIP(c)
+		for _, ipNet := range nets {
+			if ip != nil && ipNet.Contains(ip) {
+				c.Next()
+				return
+			}
+		}
+		log.Printf("❌ Rejecting %s request from IP %v outside the allowlist", name, ip)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	}, nil
+}
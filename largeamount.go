@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixLargeAmount = "large:"
+	// LargeAmountConfirmTTL is how long an implausibly large expense can still be confirmed
+	LargeAmountConfirmTTL = time.Hour
+)
+
+// largeAmountHold is a parsed message with an amount above MaxPlausibleAmount,
+// waiting for the user to confirm it
+type largeAmountHold struct {
+	Expenses []ExpenseInput
+	Skipped  []string
+}
+
+func largeAmountHoldKey(chatID int64, messageID int) string {
+	return fmt.Sprintf("large-hold:%d:%d", chatID, messageID)
+}
+
+// implausibleExpenses returns the expenses above the configured MaxPlausibleAmount
+func implausibleExpenses(expenses []ExpenseInput) []ExpenseInput {
+	if config.MaxPlausibleAmount <= 0 {
+		return nil
+	}
+	var large []ExpenseInput
+	for _, expense := range expenses {
+		if expense.Amount > config.MaxPlausibleAmount {
+			large = append(large, expense)
+		}
+	}
+	return large
+}
+
+// logExpenses saves expenses the user sent, first asking them to confirm
+// amounts too large to be plausible - usually a phone number typed where the
+// amount should be - and then spending over the monthly cap. Expenses above
+// the approval threshold finally wait for another member to approve them.
+func (b *botInstance) logExpenses(msg *tgbotapi.Message, expenses []ExpenseInput, skipped []string) {
+	tagTrip(msg.Chat.ID, expenses)
+	large := implausibleExpenses(expenses)
+	if len(large) == 0 {
+		b.logWithinCap(msg, expenses, skipped)
+		return
+	}
+
+	raw, _ := json.Marshal(largeAmountHold{Expenses: expenses, Skipped: skipped})
+	if err := store.Set(largeAmountHoldKey(msg.Chat.ID, msg.MessageID), string(raw), LargeAmountConfirmTTL); err != nil {
+		log.Printf("❌ Failed to hold large expense for ChatID %d: %v", msg.Chat.ID, err)
+		b.logWithinCap(msg, expenses, skipped)
+		return
+	}
+
+	log.Printf("🤔 Asking ChatID %d to confirm %d expense(s) above %.2f", msg.Chat.ID, len(large), config.MaxPlausibleAmount)
+	formatter := formatterFor(msg.Chat.ID)
+	lines := []string{"🤔 This looks too large - was a phone number or reference typed as the amount?"}
+	for _, expense := range large {
+		lines = append(lines, fmt.Sprintf("• %s %s", expense.Description, formatter.Currency(expense.Amount)))
+	}
+	lines = append(lines, "", "Log it anyway?")
+	messageID := strconv.Itoa(msg.MessageID)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, strings.Join(lines, "\n"))
+	reply.ReplyToMessageID = msg.MessageID
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Yes, log it", CallbackPrefixLargeAmount+"log:"+messageID),
+		tgbotapi.NewInlineKeyboardButtonData("↩️ Don't log", CallbackPrefixLargeAmount+"cancel:"+messageID),
+	))
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
+
+// handleLargeAmountCallback logs or drops an expense held back for being implausibly large
+func (b *botInstance) handleLargeAmountCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	action, messageIDStr, _ := strings.Cut(strings.TrimPrefix(cb.Data, CallbackPrefixLargeAmount), ":")
+	messageID, err := strconv.Atoi(messageIDStr)
+	if err != nil {
+		log.Printf("❌ Invalid large amount callback: %s", cb.Data)
+		b.answerCallback(cb, "Invalid format.")
+		return
+	}
+
+	key := largeAmountHoldKey(chatID, messageID)
+	raw, ok, err := store.Get(key)
+	var hold largeAmountHold
+	if err == nil && ok {
+		err = json.Unmarshal([]byte(raw), &hold)
+	}
+	if err != nil || !ok {
+		log.Printf("❌ Stale large amount callback for ChatID %d: %s", chatID, cb.Data)
+		b.answerCallback(cb, "This expense has expired, please send it again.")
+		return
+	}
+	if err := store.Delete(key); err != nil {
+		log.Printf("⚠️ Failed to delete large amount hold for ChatID %d: %v", chatID, err)
+	}
+
+	if action != "log" {
+		b.answerCallback(cb, "Not logged")
+		if _, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, "👌 Not logged - send it again with the right amount.")); err != nil {
+			log.Printf("⚠️ Failed to update large amount confirmation: %v", err)
+		}
+		return
+	}
+
+	log.Printf("✅ ChatID %d confirmed a large expense", chatID)
+	b.answerCallback(cb, "Logging")
+	if _, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, "✅ Logging the amount as sent.")); err != nil {
+		log.Printf("⚠️ Failed to update large amount confirmation: %v", err)
+	}
+	b.logWithinCap(&tgbotapi.Message{
+		MessageID: messageID,
+		From:      cb.From,
+		Chat:      cb.Message.Chat,
+		Date:      cb.Message.Date,
+	}, hold.Expenses, hold.Skipped)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// LinkCodeResponse is the response from /api/link-codes
+type LinkCodeResponse struct {
+	Code      string `json:"code"`
+	ExpiresAt string `json:"expiresAt"` // RFC 3339, optional
+}
+
+// LinkConfirmResponse is the response from /api/link-codes/confirm
+type LinkConfirmResponse struct {
+	UserName string `json:"userName"`
+}
+
+// setUserName records the name of a chat's linked account until the next
+// /api/bot-users refresh brings the same name from the backend
+func (t *tenant) setUserName(chatID, name string) {
+	if t == nil {
+		owner, ok := tenantForChatID(chatID)
+		if !ok {
+			return
+		}
+		t = owner
+	}
+	tenantsMu.Lock()
+	defer tenantsMu.Unlock()
+	names := make(map[string]string, len(t.UserNames)+1)
+	for id, userName := range t.UserNames {
+		names[id] = userName
+	}
+	names[chatID] = name
+	t.UserNames = names
+}
+
+// handleLinkCommand binds the chat to a SpendWise account: /link asks the
+// backend for a code to enter in the web app, /link <code> confirms a code
+// the web app showed
+func (b *botInstance) handleLinkCommand(msg *tgbotapi.Message) {
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	args := strings.Fields(msg.Text)[1:]
+	if len(args) > 1 {
+		send("Usage: /link or /link <code>\n\n/link gives you a code to enter in the SpendWise web app; /link 482913 confirms a code the web app showed you.")
+		return
+	}
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+
+	if len(args) == 0 {
+		result, err := b.apiCallWithTiming("POST", "/api/link-codes", map[string]interface{}{
+			"telegramChatId": chatID,
+			"telegramName":   b.getUserName(msg),
+			"botId":          b.ID,
+		})
+		var code LinkCodeResponse
+		if err == nil {
+			err = json.Unmarshal(result.Data, &code)
+		}
+		if err == nil && code.Code == "" {
+			err = fmt.Errorf("no code in the response")
+		}
+		if err != nil {
+			log.Printf("❌ Failed to create link code for ChatID %d: %v", msg.Chat.ID, err)
+			send(userErrorText("creating a link code", err))
+			return
+		}
+
+		validity := ""
+		if expires, err := time.Parse(time.RFC3339, code.ExpiresAt); err == nil {
+			validity = fmt.Sprintf(" within %d minutes", max(1, int(time.Until(expires).Round(time.Minute).Minutes())))
+		}
+		log.Printf("🔗 Link code created for ChatID %d", msg.Chat.ID)
+		send("🔗 Your link code is " + code.Code + "\n\nEnter it in the SpendWise web app under Settings → Telegram" + validity +
+			". Got a code from the web app instead? Send /link <code>.")
+		return
+	}
+
+	result, err := b.apiCallWithTiming("POST", "/api/link-codes/confirm", map[string]interface{}{
+		"telegramChatId": chatID,
+		"code":           args[0],
+		"botId":          b.ID,
+	})
+	var linked LinkConfirmResponse
+	if err == nil {
+		err = json.Unmarshal(result.Data, &linked)
+	}
+	if err != nil {
+		log.Printf("❌ Failed to link ChatID %d: %v", msg.Chat.ID, err)
+		send(userErrorText("linking your account", err))
+		return
+	}
+
+	if linked.UserName == "" {
+		log.Printf("🔗 ChatID %d linked to a SpendWise account", msg.Chat.ID)
+		send("✅ Linked to your SpendWise account.")
+		return
+	}
+	b.tenant.setUserName(chatID, linked.UserName)
+	log.Printf("🔗 ChatID %d linked to the SpendWise account of %s", msg.Chat.ID, linked.UserName)
+	send("✅ Linked to " + linked.UserName + "'s SpendWise account - expenses you log here are saved as " + linked.UserName + ".")
+}
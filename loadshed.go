@@ -0,0 +1,176 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultMaxConcurrentUpdates bounds the updates handled at once, staying
+	// under DefaultMaxConnsPerHost so a burst can't exhaust backend connections
+	DefaultMaxConcurrentUpdates = 16
+	// UpdateQueueFactor sizes the wait queue as a multiple of the concurrency limit
+	UpdateQueueFactor = 4
+	// UpdateSlotWait is how long a delivery may wait for a slot; Telegram's
+	// webhook timeout is about a minute, after which it redelivers anyway
+	UpdateSlotWait = 20 * time.Second
+	// ShedRetryAfterSeconds is sent with 503s so Telegram backs off before redelivering
+	ShedRetryAfterSeconds = 5
+	// LowPriorityWorkers run deferred work such as admin alerts and streak checks
+	LowPriorityWorkers   = 2
+	LowPriorityQueueSize = 100
+	// TelegramMaxWebhookConnections is the highest max_connections setWebhook accepts
+	TelegramMaxWebhookConnections = 100
+)
+
+// webhookMaxConnections is the max_connections registered with setWebhook, so
+// Telegram itself holds back deliveries beyond what one instance handles at once
+func webhookMaxConnections() int {
+	return min(max(maxConcurrentUpdates(), 1), TelegramMaxWebhookConnections)
+}
+
+// updateLoad tracks webhook deliveries being handled or waiting for a slot
+var updateLoad struct {
+	once    sync.Once
+	slots   chan struct{}
+	waiting atomic.Int64
+}
+
+// maxConcurrentUpdates returns the configured concurrency limit
+func maxConcurrentUpdates() int {
+	if config.MaxConcurrentUpdates > 0 {
+		return config.MaxConcurrentUpdates
+	}
+	return DefaultMaxConcurrentUpdates
+}
+
+func updateSlots() chan struct{} {
+	updateLoad.once.Do(func() {
+		updateLoad.slots = make(chan struct{}, maxConcurrentUpdates())
+	})
+	return updateLoad.slots
+}
+
+// underPressure reports whether every update slot is taken
+func underPressure() bool {
+	slots := updateSlots()
+	return len(slots) == cap(slots)
+}
+
+// limitUpdates handles at most maxConcurrentUpdates deliveries at once. Extra
+// deliveries wait briefly for a slot; once the wait queue is full, or the wait
+// runs out, they are answered 503 so Telegram keeps the update and redelivers
+// it later instead of the instance piling up goroutines and backend calls.
+func limitUpdates(c *gin.Context) {
+	release, ok := acquireUpdateSlot(c)
+	if !ok {
+		return
+	}
+	defer release()
+	c.Next()
+}
+
+// acquireUpdateSlot takes a slot in the interactive lane for one update,
+// answering the request itself and reporting false when the update was shed
+func acquireUpdateSlot(c *gin.Context) (func(), bool) {
+	slots := updateSlots()
+	select {
+	case slots <- struct{}{}:
+	default:
+		if !waitForSlot(c, slots) {
+			return nil, false
+		}
+	}
+	setGauge("spendwise_updates_in_flight", float64(len(slots)))
+	return func() {
+		<-slots
+		setGauge("spendwise_updates_in_flight", float64(len(slots)))
+	}, true
+}
+
+// waitForSlot queues a delivery for a free slot, shedding it when the queue is
+// full or the wait times out
+func waitForSlot(c *gin.Context, slots chan struct{}) bool {
+	queueLimit := int64(cap(slots) * UpdateQueueFactor)
+	waiting := updateLoad.waiting.Add(1)
+	setGauge("spendwise_updates_waiting", float64(waiting))
+	defer func() {
+		setGauge("spendwise_updates_waiting", float64(updateLoad.waiting.Add(-1)))
+	}()
+	if waiting > queueLimit {
+		shedUpdate(c, "queue full")
+		return false
+	}
+
+	timer := time.NewTimer(UpdateSlotWait)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		shedUpdate(c, "timed out waiting for a slot")
+		return false
+	case <-c.Request.Context().Done():
+		c.Abort()
+		return false
+	}
+}
+
+func shedUpdate(c *gin.Context, reason string) {
+	log.Printf("🚦 Shedding webhook delivery on %s (%s), Telegram will redeliver", c.Request.URL.Path, reason)
+	incCounter("spendwise_updates_shed_total")
+	c.Header("Retry-After", strconv.Itoa(ShedRetryAfterSeconds))
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "overloaded, retry later"})
+}
+
+// lowPriorityTask is work that can be deferred or dropped without losing data
+type lowPriorityTask struct {
+	name string
+	run  func()
+}
+
+var lowPriority struct {
+	once  sync.Once
+	queue chan lowPriorityTask
+}
+
+// runLowPriority defers fn to a small worker pool so it never holds an update
+// slot. While every slot is busy, or the queue is full, the task is dropped.
+func runLowPriority(name string, fn func()) {
+	lowPriority.once.Do(func() {
+		lowPriority.queue = make(chan lowPriorityTask, LowPriorityQueueSize)
+		for i := 0; i < LowPriorityWorkers; i++ {
+			go func() {
+				for task := range lowPriority.queue {
+					task.run()
+				}
+			}()
+		}
+	})
+
+	if underPressure() {
+		log.Printf("🚦 Dropping low-priority %s while all update slots are busy", name)
+		incCounter("spendwise_low_priority_shed_total", "task", name)
+		return
+	}
+	select {
+	case lowPriority.queue <- lowPriorityTask{name: name, run: fn}:
+	default:
+		log.Printf("🚦 Dropping low-priority %s, queue is full", name)
+		incCounter("spendwise_low_priority_shed_total", "task", name)
+	}
+}
+
+// detached returns the bot without the current update's deadline, for work that
+// outlives the update
+func (b *botInstance) detached() *botInstance {
+	scoped := *b
+	scoped.ctx = nil
+	return &scoped
+}
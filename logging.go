@@ -0,0 +1,186 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// RedactedPlaceholder replaces secrets in log output
+const RedactedPlaceholder = "[REDACTED]"
+
+// Log levels. Lines are classified by the repo's severity markers: ❌, ⚠️ and 🚨
+// lines are warnings, debugf lines are debug and everything else is info.
+const (
+	LogLevelDebug int32 = iota
+	LogLevelInfo
+	LogLevelWarn
+)
+
+var logLevelNames = map[string]int32{
+	"debug": LogLevelDebug,
+	"info":  LogLevelInfo,
+	"warn":  LogLevelWarn,
+}
+
+// logLevel is read on every write and changed at runtime by /loglevel
+var logLevel atomic.Int32
+
+// telegramTokenPattern matches bot tokens that were not configured here, e.g. in URLs
+var telegramTokenPattern = regexp.MustCompile(`\d{6,}:[A-Za-z0-9_-]{30,}`)
+
+// redactingWriter scrubs configured secrets and anything shaped like a bot
+// token from every log line before it is written.
+type redactingWriter struct {
+	out      io.Writer
+	replacer *strings.Replacer
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	if logLevel.Load() >= LogLevelWarn && !isWarnLine(p) {
+		return len(p), nil
+	}
+
+	line := w.replacer.Replace(string(p))
+	line = telegramTokenPattern.ReplaceAllString(line, RedactedPlaceholder)
+	if _, err := io.WriteString(w.out, line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func isWarnLine(p []byte) bool {
+	line := string(p)
+	return strings.Contains(line, "❌") || strings.Contains(line, "⚠️") || strings.Contains(line, "🚨")
+}
+
+// configSecrets collects every secret value in the loaded configuration
+func configSecrets() []string {
+	secrets := []string{config.BotToken, config.APISecret, config.WebhookSecret, config.PubSubToken, config.CalendarToken}
+	for _, bc := range config.Bots {
+		secrets = append(secrets, bc.BotToken, bc.APISecret)
+	}
+	for _, tc := range config.Tenants {
+		secrets = append(secrets, tc.APISecret)
+	}
+	for _, pin := range config.ReauthPINs {
+		secrets = append(secrets, pin)
+	}
+	if config.RedisURL != "" {
+		if u, err := url.Parse(config.RedisURL); err == nil && u.User != nil {
+			if password, ok := u.User.Password(); ok {
+				secrets = append(secrets, password)
+			}
+		}
+	}
+	return secrets
+}
+
+// initLogging routes the standard logger and gin through the redacting writer.
+// It runs right after the config is loaded, since that is where the secrets come from.
+func initLogging() {
+	logLevel.Store(LogLevelInfo)
+
+	var pairs []string
+	for _, secret := range configSecrets() {
+		// Very short values would redact unrelated text
+		if len(secret) >= 6 {
+			pairs = append(pairs, secret, RedactedPlaceholder)
+		}
+	}
+
+	writer := &redactingWriter{out: os.Stderr, replacer: strings.NewReplacer(pairs...)}
+	log.SetOutput(writer)
+	gin.DefaultWriter = writer
+	gin.DefaultErrorWriter = writer
+
+	if !config.LogMessageContent {
+		log.Println("🙈 Message content logging disabled, logging lengths and hashes only")
+	}
+}
+
+// logText returns user-provided text for logging, or only its length and a
+// short hash when LOG_MESSAGE_CONTENT=false so logs stay correlatable.
+func logText(text string) string {
+	if config.LogMessageContent {
+		return text
+	}
+	sum := sha256.Sum256([]byte(text))
+	return fmt.Sprintf("[%d chars, sha256:%s]", len([]rune(text)), hex.EncodeToString(sum[:4]))
+}
+
+// logMessageText is logText for an incoming message, except that PINs are never
+// logged, not even hashed, as the hash of a 4-digit PIN is easily reversed:
+// /pin set, a bare PIN in a chat with a PIN lock and a reauth PIN reply
+func logMessageText(msg *tgbotapi.Message) string {
+	text := strings.TrimSpace(msg.Text)
+	if fields := strings.Fields(text); len(fields) > 1 {
+		if command, _, _ := strings.Cut(fields[0], "@"); command == "/pin" && strings.EqualFold(fields[1], "set") {
+			return fields[0] + " set " + RedactedPlaceholder
+		}
+	}
+	if msg.From != nil && awaitingReauthPIN(msg.Chat.ID, msg.From.ID) {
+		return RedactedPlaceholder
+	}
+	if pinPattern.MatchString(text) {
+		if _, locked, err := loadPINLock(msg.Chat.ID); locked || err != nil {
+			return RedactedPlaceholder
+		}
+	}
+	return logText(msg.Text)
+}
+
+// debugf logs only while the level is debug; use it for per-request chatter
+func debugf(format string, args ...interface{}) {
+	if logLevel.Load() == LogLevelDebug {
+		log.Printf(format, args...)
+	}
+}
+
+// logLevelName returns the name of the current log level
+func logLevelName() string {
+	current := logLevel.Load()
+	for name, level := range logLevelNames {
+		if level == current {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// handleLogLevelCommand lets admins change the log level without a redeploy: /loglevel debug|info|warn
+func (b *botInstance) handleLogLevelCommand(msg *tgbotapi.Message) {
+	if !isAdmin(msg.Chat.ID) {
+		log.Printf("❌ Non-admin ChatID %d tried to use /loglevel", msg.Chat.ID)
+		b.handleUnknownCommand(msg)
+		return
+	}
+
+	args := strings.Fields(msg.Text)
+	var response string
+	if len(args) != 2 {
+		response = fmt.Sprintf("Current log level: %s\n\nUsage: /loglevel debug|info|warn", logLevelName())
+	} else if level, ok := logLevelNames[strings.ToLower(args[1])]; !ok {
+		response = "❌ Unknown log level: " + args[1] + "\n\nUsage: /loglevel debug|info|warn"
+	} else {
+		logLevel.Store(level)
+		// Logged as a warning so the change is recorded at every level
+		log.Printf("⚠️ Admin %d set log level to %s on instance %s", msg.Chat.ID, logLevelName(), instanceID)
+		response = fmt.Sprintf("✅ Log level set to %s on instance %s", logLevelName(), instanceID)
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
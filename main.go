@@ -2,12 +2,16 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +19,9 @@ import (
 	"github.com/gin-gonic/gin"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
+
+	"spendwise-telegram-go/format"
+	"spendwise-telegram-go/parser"
 )
 
 // Constants
@@ -25,6 +32,10 @@ const (
 	DefaultAPIURL          = "http://localhost:3000"
 	ErrorSendMessage       = "Failed to send error message: %v"
 	ErrorSendSuccess       = "Failed to send success message: %v"
+	ErrorGenericFailure    = "❌ Something went wrong while handling that. Please try again."
+	DefaultCurrencySymbol  = "₹"
+	GroupingIndian         = "indian"  // 12,34,567.89
+	GroupingWestern        = "western" // 1,234,567.89
 )
 
 // ---- Config Structures ----
@@ -36,6 +47,93 @@ type SpendWiseConfig struct {
 	APISecret  string
 	Port       string
 	UserNames  map[string]string // chatID -> userName mapping
+	Bots       []BotConfig       // additional bots served at /webhook/:botID
+	Tenants    []TenantConfig    // additional households sharing the deployment
+	// ReminderOwners maps chat IDs to the SpendWise userId their reminders belong to
+	ReminderOwners map[string]string
+	// CalendarToken enables the subscribable iCal feed when set
+	CalendarToken string
+	// EscalationEnabled turns on re-notification of overdue reminders
+	EscalationEnabled bool
+	EscalationCCIDs   []string // chat IDs copied on higher urgency escalations
+	CurrencySymbol    string
+	DigitGrouping     string // GroupingIndian or GroupingWestern
+	Locale            string // BCP 47 tag; overrides DigitGrouping when set
+	DateFormat        string
+	UserSettings      map[string]UserSettings // chatID -> per-user overrides
+	Accounts          []string                // payment accounts accepted after "via"
+	AdminIDs          map[string]bool
+	BlockedIDs        map[string]bool // always ignored, even if allowed
+	LimitedIDs        map[string]bool // may only log expenses and see their own /summary
+	FloodMaxMessages  int             // messages allowed per FloodWindow
+	FloodWindow       time.Duration
+	RedisURL          string // shared coordination store for multi-instance deployments
+	PubSubToken       string // enables the Pub/Sub push endpoint when set
+	WebhookSecret     string // Telegram secret_token checked on every webhook call
+	LogMessageContent bool   // false logs message lengths and hashes instead of text
+	// CIDR ranges allowed to call /webhook and /internal/*; empty allows any IP
+	WebhookAllowedCIDRs  []string
+	InternalAllowedCIDRs []string
+	// TrustedProxies may set X-Forwarded-For; TrustedPlatform names a header carrying
+	// the client IP (e.g. CF-Connecting-IP). Otherwise the connection address is used.
+	TrustedProxies  []string
+	TrustedPlatform string
+	// BackendAuth is BackendAuthSecret (default), BackendAuthOIDC or BackendAuthMTLS
+	BackendAuth    string
+	OIDCAudience   string // defaults to the backend API URL
+	ClientCertFile string
+	ClientKeyFile  string
+	ClientCAFile   string // optional CA bundle for verifying the backend
+	// BatchReactions acknowledges multi-line batches with a reaction and a compact
+	// reply, saving valid lines even when others are skipped
+	BatchReactions bool
+	// CycleStartDay is the day of month the spending month starts on (1-28), e.g. payday
+	CycleStartDay int
+	// CommandTimeout is the deadline for the backend calls of one update
+	CommandTimeout time.Duration
+	// KeepAliveInterval enables self-pings of /health; zero disables them
+	KeepAliveInterval time.Duration
+	// BotUsersRefresh reloads allowed chats and names from the backend's
+	// /api/bot-users this often; zero keeps the configured lists only
+	BotUsersRefresh time.Duration
+	// SlowAPICall is the latency above which backend calls are logged with a timing breakdown
+	SlowAPICall time.Duration
+	// HTTPMaxConnsPerHost sizes the idle connection pool per backend/Telegram host
+	HTTPMaxConnsPerHost int
+	HTTP2Enabled        bool // attempt HTTP/2 to the backend and Telegram
+	// TelegramAPIURL points the bots at a self-hosted Bot API server instead of api.telegram.org
+	TelegramAPIURL string
+	// MaxConcurrentUpdates bounds webhook updates handled at once; extra deliveries queue, then get 503
+	MaxConcurrentUpdates int
+	// CommandAliases maps short forms like "/s" or "today" to the command they stand for
+	CommandAliases map[string]string
+	// UPIID is the default payee of /upi payment QRs, shown to payers as UPIName
+	UPIID   string
+	UPIName string
+	// MarkDoneExpense decides whether marking a reminder done logs an expense: "off",
+	// "ask" for the amount paid or "auto" with the reminder's amount; USER_SETTINGS
+	// markDoneExpense overrides it per chat
+	MarkDoneExpense string
+	// QuickExpense* decide whether a plain message with a number is an expense;
+	// zero values and an empty blocklist take any such message
+	QuickExpenseMinAmount float64
+	QuickExpenseMaxAmount float64
+	QuickExpenseMinWords  int      // description words needed next to the amount
+	QuickExpenseBlocklist []string // regular expressions of messages never taken as expenses
+	// MaxPlausibleAmount is the largest expense logged without an extra confirmation; zero disables the check
+	MaxPlausibleAmount float64
+	// Expenses above ApprovalThreshold are held until one of ApproverIDs (other than the sender) approves
+	ApprovalThreshold float64
+	ApproverIDs       []string
+	// ErrorMessages overrides the messages shown for backend error codes, e.g. to translate them
+	ErrorMessages map[string]string
+	// ReauthWindow is how long a confirmation unlocks sensitive commands; zero turns confirmations off.
+	// ReauthPINs maps chat IDs to the PIN they confirm with instead of a button.
+	ReauthWindow time.Duration
+	ReauthPINs   map[string]string
+	// DebugSimulator answers Bot API calls locally and enables /debug/simulate-update
+	DebugSimulator bool
+	PprofEnabled   bool // exposes /debug/pprof behind the internal allowlist and API secret
 }
 
 // SecretConfig represents the JSON structure in Google Cloud Secret Manager
@@ -47,6 +145,102 @@ type SecretConfig struct {
 	APISecret  string            `json:"apiSecret"`
 	Port       string            `json:"port"`
 	UserNames  map[string]string `json:"userNames"`
+	// ReminderOwners maps chat IDs to SpendWise userIds so each person only sees
+	// and is notified about their own reminders
+	ReminderOwners map[string]string `json:"reminderOwners"`
+	// Bots are additional bots (e.g. staging or a second household) served at /webhook/<id>
+	Bots []BotConfig `json:"bots"`
+	// Tenants are independent households, each with its own allowlist, names and backend
+	Tenants []TenantConfig `json:"tenants"`
+	// CalendarToken is optional; when empty the iCal feed endpoint is disabled
+	CalendarToken string `json:"calendarToken"`
+	// EscalationEnabled re-notifies about overdue reminders; EscalationCCIDs are copied on urgent ones
+	EscalationEnabled bool     `json:"escalationEnabled"`
+	EscalationCCIDs   []string `json:"escalationCcIds"`
+	// CurrencySymbol defaults to ₹; DigitGrouping is "indian" (default) or "western"
+	CurrencySymbol string `json:"currencySymbol"`
+	DigitGrouping  string `json:"digitGrouping"`
+	// Locale and DateFormat are deployment defaults; UserSettings overrides them per chat ID
+	Locale       string                  `json:"locale"`
+	DateFormat   string                  `json:"dateFormat"`
+	UserSettings map[string]UserSettings `json:"userSettings"`
+	// Accounts lists payment accounts (default: cash, card, bank, upi)
+	Accounts []string `json:"accounts"`
+	// AdminIDs may use admin commands; BlockedIDs are ignored even when allowed;
+	// LimitedIDs may only log expenses and see their own /summary
+	AdminIDs           []string `json:"adminIds"`
+	BlockedIDs         []string `json:"blockedIds"`
+	LimitedIDs         []string `json:"limitedIds"`
+	FloodMaxMessages   int      `json:"floodMaxMessages"`
+	FloodWindowSeconds int      `json:"floodWindowSeconds"`
+	// CommandTimeoutSeconds bounds how long one command may wait on the backend
+	CommandTimeoutSeconds int `json:"commandTimeoutSeconds"`
+	// KeepAliveMinutes pings the bot's own /health this often to avoid cold starts
+	KeepAliveMinutes int `json:"keepAliveMinutes"`
+	// BotUsersRefreshMinutes loads allowed chats and names from /api/bot-users at startup and this often
+	BotUsersRefreshMinutes int `json:"botUsersRefreshMinutes"`
+	// SlowAPICallMs logs backend calls slower than this with DNS/connect/TTFB timings
+	SlowAPICallMs int `json:"slowApiCallMs"`
+	// HTTPMaxConnsPerHost and HTTP2 tune the shared outbound HTTP clients; HTTP2 defaults to true
+	HTTPMaxConnsPerHost int   `json:"httpMaxConnsPerHost"`
+	HTTP2               *bool `json:"http2"`
+	// TelegramAPIURL is the base URL of a self-hosted Bot API server, e.g. http://localhost:8081
+	TelegramAPIURL string `json:"telegramApiUrl"`
+	// MaxConcurrentUpdates limits updates in progress per instance (default 16)
+	MaxConcurrentUpdates int `json:"maxConcurrentUpdates"`
+	// CommandAliases such as {"/s": "/summary", "today": "/summary"}
+	CommandAliases map[string]string `json:"commandAliases"`
+	// UPIID such as "household@okhdfcbank" is the default payee of /upi QRs
+	UPIID   string `json:"upiId"`
+	UPIName string `json:"upiName"`
+	// MarkDoneExpense is "off" (default), "ask" (mark-done asks how much was paid and logs it as an
+	// expense) or "auto" (mark-done logs the reminder's amount as an expense)
+	MarkDoneExpense string `json:"markDoneExpense"`
+	// QuickExpense* tighten when a plain message with a number is logged, e.g.
+	// {"quickExpenseMaxAmount": 100000, "quickExpenseBlocklist": ["(?i)\\botp\\b"]}
+	QuickExpenseMinAmount float64  `json:"quickExpenseMinAmount"`
+	QuickExpenseMaxAmount float64  `json:"quickExpenseMaxAmount"`
+	QuickExpenseMinWords  int      `json:"quickExpenseMinWords"`
+	QuickExpenseBlocklist []string `json:"quickExpenseBlocklist"`
+	// MaxPlausibleAmount such as 200000 makes larger expenses ask "log it anyway?"
+	MaxPlausibleAmount float64 `json:"maxPlausibleAmount"`
+	// ApprovalThreshold and ApproverIDs make larger expenses wait for another member's approval
+	ApprovalThreshold float64  `json:"approvalThreshold"`
+	ApproverIDs       []string `json:"approverIds"`
+	// ErrorMessages such as {"duplicate_expense": "🔁 Yeh expense pehle se hai."} replace the default texts
+	ErrorMessages map[string]string `json:"errorMessages"`
+	// ReauthMinutes asks for a confirmation before /delete, /broadcast and other sensitive commands
+	// unless one was given this recently; ReauthPINs such as {"123456": "4821"} confirm with a PIN
+	ReauthMinutes int               `json:"reauthMinutes"`
+	ReauthPINs    map[string]string `json:"reauthPins"`
+	// RedisURL enables shared sessions, update dedupe and scheduler leader election
+	RedisURL string `json:"redisUrl"`
+	// PubSubToken enables POST /pubsub/push?token=... for Pub/Sub push subscriptions
+	PubSubToken string `json:"pubSubToken"`
+	// WebhookSecret is registered as the webhook secret_token and required on /webhook
+	WebhookSecret string `json:"webhookSecret"`
+	// LogMessageContent defaults to true; false keeps user text out of the logs
+	LogMessageContent *bool `json:"logMessageContent"`
+	// WebhookAllowedCIDRs and InternalAllowedCIDRs restrict callers by IP on top of the shared secrets
+	WebhookAllowedCIDRs  []string `json:"webhookAllowedCidrs"`
+	InternalAllowedCIDRs []string `json:"internalAllowedCidrs"`
+	// TrustedProxies and TrustedPlatform decide which forwarded client IP headers are believed
+	TrustedProxies  []string `json:"trustedProxies"`
+	TrustedPlatform string   `json:"trustedPlatform"`
+	// BackendAuth selects how backend calls authenticate: "secret" (default), "oidc" or "mtls"
+	BackendAuth    string `json:"backendAuth"`
+	OIDCAudience   string `json:"oidcAudience"`
+	ClientCertFile string `json:"clientCertFile"`
+	ClientKeyFile  string `json:"clientKeyFile"`
+	ClientCAFile   string `json:"clientCaFile"`
+	// BatchReactions reacts to multi-line batches and replies "5 saved, line 3 skipped" style
+	BatchReactions bool `json:"batchReactions"`
+	// CycleStartDay makes /month cover e.g. the 25th to the 24th instead of calendar months
+	CycleStartDay int `json:"cycleStartDay"`
+	// DebugSimulator is for local development only: Telegram is replaced by an in-process simulator
+	DebugSimulator bool `json:"debugSimulator"`
+	// PprofEnabled exposes net/http/pprof at /debug/pprof, guarded like /internal/*
+	PprofEnabled bool `json:"pprofEnabled"`
 }
 
 // ---- Data Models ----
@@ -82,45 +276,138 @@ type ExpenseInput struct {
 	Source         string  `json:"source"`
 	UserName       string  `json:"userName"`
 	TelegramChatID string  `json:"telegramChatId"`
+	Account        string  `json:"account,omitempty"`
+	// EntryType marks credits (refund/cashback/adjustment) that the backend nets against spend
+	EntryType string `json:"entryType,omitempty"`
+	Note      string `json:"note,omitempty"`
+	// Trip is the /trip running when the expense was logged
+	Trip string `json:"trip,omitempty"`
+	// Category is set for expenses whose category is known up front, e.g. paid bills
+	Category string `json:"category,omitempty"`
+	// AllowDuplicate is set when the user logs an expense the backend rejected as a duplicate
+	AllowDuplicate bool `json:"allowDuplicate,omitempty"`
 }
 
+// SummaryResponse is either pre-rendered Markdown (older backends) or a structured
+// payload with a total, which the bot renders itself
 type SummaryResponse struct {
 	Markdown string `json:"markdown"`
+	// Optional breakdowns (requested with include=breakdown) used to draw charts bot-side
+	Categories []SummaryBucket `json:"categories,omitempty"`
+	Daily      []SummaryBucket `json:"daily,omitempty"`
+	// Structured payload (requested with format=structured)
+	Title        string        `json:"title,omitempty"`
+	Total        *float64      `json:"total,omitempty"`
+	Count        int           `json:"count,omitempty"`
+	DailyAverage float64       `json:"dailyAverage,omitempty"`
+	Items        []SummaryItem `json:"items,omitempty"`
+}
+
+// withCharts returns the summary Markdown followed by any charts the breakdowns allow
+func (s SummaryResponse) withCharts(formatter *format.Formatter) string {
+	if charts := summaryCharts(s.Categories, s.Daily, formatter); charts != "" {
+		return s.Markdown + "\n\n" + charts
+	}
+	return s.Markdown
 }
 
 var config SpendWiseConfig
-var bot *tgbotapi.BotAPI
 
-// setupWebhook registers the webhook with Telegram
-func setupWebhook() {
-	webhookURL := config.BotUrl + "/webhook"
+// setupWebhook registers the webhook with Telegram. Telegram rate-limits setWebhook,
+// so it is skipped when getWebhookInfo already reports the expected URL unless forced.
+func (b *botInstance) setupWebhook(force bool) error {
+	webhookURL := b.webhookURL()
+
+	if !force {
+		info, err := b.api.GetWebhookInfo()
+		if err != nil {
+			log.Printf("⚠️ Failed to get webhook info for bot %s, setting it anyway: %v", b.ID, err)
+		} else if info.URL == webhookURL && info.MaxConnections == webhookMaxConnections() {
+			log.Printf("✅ Webhook for bot %s already set to: %s", b.ID, webhookURL)
+			return nil
+		}
+	}
+
+	return b.registerWebhook(false)
+}
+
+// registerWebhook calls setWebhook with the bot's current URL and secret token.
+// dropPending discards updates Telegram queued while no webhook was reachable.
+func (b *botInstance) registerWebhook(dropPending bool) error {
+	webhookURL := b.webhookURL()
 	log.Printf("🔗 Setting webhook to: %s", webhookURL)
-	webhookConfig, _ := tgbotapi.NewWebhook(webhookURL)
-	_, err := bot.Request(webhookConfig)
-	if err != nil {
-		log.Fatalf("❌ Failed to set webhook: %v", err)
+	params := tgbotapi.Params{"url": webhookURL}
+	params.AddNonEmpty("secret_token", config.WebhookSecret)
+	params.AddBool("drop_pending_updates", dropPending)
+	params.AddNonZero("max_connections", webhookMaxConnections())
+	if _, err := b.api.MakeRequest("setWebhook", params); err != nil {
+		return telegramPermanent(err)
 	}
 	log.Printf("✅ Webhook set successfully to: %s", webhookURL)
+	return nil
+}
+
+// webhookURL returns the full webhook URL for the bot. Telegram does not report the
+// secret token back, so a short fingerprint of it is embedded in the URL to detect
+// secret changes through getWebhookInfo.
+func (b *botInstance) webhookURL() string {
+	webhookURL := config.BotUrl + b.webhookPath()
+	if config.WebhookSecret != "" {
+		sum := sha256.Sum256([]byte(config.WebhookSecret))
+		webhookURL += "?v=" + hex.EncodeToString(sum[:4])
+	}
+	return webhookURL
 }
 
-func main() {
+// serve runs the bot server; it is the default subcommand
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	forceWebhook := flags.Bool("force-webhook", false, "register the Telegram webhook even if it is already set")
+	flags.Parse(args)
+
 	log.Println("🚀 Starting SpendWise Telegram Bot")
 
 	config = loadConfig()
+	initLogging()
 	log.Printf("✅ Configuration loaded - Port: %s, API URL: %s", config.Port, config.APIUrl)
 
-	var err error
-	bot, err = tgbotapi.NewBotAPI(config.BotToken)
+	if err := buildTenants(); err != nil {
+		log.Fatalf("❌ Invalid tenant configuration: %v", err)
+	}
+
+	if err := initBackendAuth(); err != nil {
+		log.Fatalf("❌ Invalid backend auth configuration: %v", err)
+	}
+	initHTTPClients()
+
+	webhookAllowlist, err := allowCIDRs("webhook", config.WebhookAllowedCIDRs)
+	if err != nil {
+		log.Fatalf("❌ Invalid IP allowlist: %v", err)
+	}
+	internalAllowlist, err := allowCIDRs("internal", config.InternalAllowedCIDRs)
 	if err != nil {
-		log.Fatalf("❌ Failed to start bot: %v", err)
+		log.Fatalf("❌ Invalid IP allowlist: %v", err)
 	}
-	bot.Debug = false
-	log.Println("✅ Bot initialized successfully")
 
-	// Setup webhook (uncomment to enable webhook mode)
-	// setupWebhook()
+	// Connect Telegram, Redis and the webhook in the background so /health is
+	// served while transient failures are retried
+	go startup(*forceWebhook)
+
+	r := newRouter(webhookAllowlist, internalAllowlist)
+
+	log.Printf("🚀 Starting server on port %s", config.Port)
+	log.Printf("📊 Configured for %d allowed users", allowedChatCount())
+
+	if err := r.Run(":" + config.Port); err != nil {
+		log.Fatalf("❌ Failed to start server: %v", err)
+	}
+}
 
+// newRouter registers every HTTP route; routes that need the bots answer 503
+// until startup has marked the instance ready
+func newRouter(webhookAllowlist, internalAllowlist gin.HandlerFunc) *gin.Engine {
 	r := gin.Default()
+	trustForwardedFor(r)
 
 	// Add request logging middleware
 	r.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -138,56 +425,61 @@ func main() {
 		)
 	}))
 
-	r.POST("/webhook", func(c *gin.Context) {
-		log.Printf("📥 Received webhook request from IP: %s", c.ClientIP())
+	// Metrics carry per-chat and per-command series, so they get the same guard as pprof
+	r.GET("/metrics", internalAllowlist, requireAPISecret, handleMetrics)
+	r.GET("/version", handleVersion)
 
-		var update tgbotapi.Update
-		if err := c.BindJSON(&update); err != nil {
-			log.Printf("❌ Invalid webhook update received: %v", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid update"})
-			return
+	r.GET("/warmup", handleWarmup)
+	r.GET("/health", func(c *gin.Context) {
+		log.Printf("💚 Health check request from IP: %s", c.ClientIP())
+		status := "ok"
+		if !ready.Load() {
+			status = "starting"
 		}
+		c.JSON(http.StatusOK, gin.H{"status": status})
+	})
 
-		// Log update details
-		if update.Message != nil {
-			log.Printf("📩 Processing message update - ChatID: %d, MessageID: %d, Text: %s",
-				update.Message.Chat.ID, update.Message.MessageID, update.Message.Text)
-		} else if update.CallbackQuery != nil {
-			log.Printf("🔘 Processing callback query - ChatID: %d, Data: %s",
-				update.CallbackQuery.Message.Chat.ID, update.CallbackQuery.Data)
-		} else {
-			log.Printf("⚠️ Received unknown update type")
-		}
+	if config.PprofEnabled {
+		registerPprof(r, internalAllowlist)
+	}
 
-		handleUpdate(update)
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
+	// Everything below needs the bots and coordination store
+	app := r.Group("", requireReady)
+
+	webhooks := app.Group("", webhookAllowlist, limitUpdates, jsonBody(MaxWebhookBodyBytes))
+	webhooks.POST("/webhook", handleDefaultWebhook)
+	webhooks.POST("/webhook/:botID", handleBotWebhook)
 
-	r.POST("/internal/send-message", func(c *gin.Context) {
+	internal := app.Group("/internal", internalAllowlist, requireAPISecret, jsonBody(MaxInternalBodyBytes))
+	internal.POST("/send-bulk", handleSendBulk)
+	internal.POST("/notify-reminders", handleNotifyReminders)
+	internal.POST("/send-message", func(c *gin.Context) {
 		log.Printf("🔐 Internal send-message request from IP: %s", c.ClientIP())
 
-		if c.GetHeader(HeaderAPISecret) != config.APISecret {
-			log.Printf("❌ Unauthorized internal API request from IP: %s", c.ClientIP())
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		var req struct {
+			outboundMessage
+			BotID string `json:"botId"` // optional, defaults to the main bot
+		}
+		if !bindJSON(c, &req, true) {
 			return
 		}
 
-		var req struct {
-			ChatID  int64                  `json:"chatId"`
-			Message string                 `json:"message"`
-			Options map[string]interface{} `json:"options"`
+		if problem := req.validate(); problem != "" {
+			log.Printf("❌ Invalid send-message request: %s", problem)
+			c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+			return
 		}
-		if err := c.BindJSON(&req); err != nil || req.ChatID == 0 || req.Message == "" {
-			log.Printf("❌ Invalid send-message request: ChatID=%d, MessageEmpty=%t, Error=%v",
-				req.ChatID, req.Message == "", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "missing fields"})
+
+		b, ok := internalBot(req.BotID)
+		if !ok {
+			log.Printf("❌ Internal send-message for unknown bot %q", req.BotID)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown bot"})
 			return
 		}
 
-		log.Printf("📤 Sending internal message to ChatID: %d, Message: %s", req.ChatID, req.Message)
+		log.Printf("📤 Sending internal message via bot %s to ChatID: %d, Message: %s", b.ID, req.ChatID, logText(req.Message))
 
-		msg := tgbotapi.NewMessage(req.ChatID, req.Message)
-		if _, err := bot.Send(msg); err != nil {
+		if _, err := b.sendPaced(req.ChatID, req.config()); err != nil {
 			log.Printf("❌ Failed to send internal message to ChatID %d: %v", req.ChatID, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send message"})
 			return
@@ -197,35 +489,61 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"success": true})
 	})
 
-	r.GET("/health", func(c *gin.Context) {
-		log.Printf("💚 Health check request from IP: %s", c.ClientIP())
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
+	if config.PubSubToken != "" {
+		log.Println("📨 Pub/Sub push ingestion enabled at /pubsub/push")
+		app.POST("/pubsub/push", jsonBody(MaxWebhookBodyBytes), handlePubSubPush)
+	}
 
-	log.Printf("🚀 Starting server on port %s", config.Port)
-	log.Printf("📊 Configured for %d allowed users", len(config.AllowedIDs))
-	log.Println("🔗 Server ready to accept requests")
+	if config.CalendarToken != "" {
+		log.Println("📅 iCal reminders feed enabled at /calendar.ics")
+		app.GET("/calendar.ics", handleCalendarFeed)
+	}
 
-	if err := r.Run(":" + config.Port); err != nil {
-		log.Fatalf("❌ Failed to start server: %v", err)
+	if config.DebugSimulator {
+		log.Println("🧪 DEBUG_SIMULATOR is on: Telegram calls are simulated and /debug/simulate-update is enabled")
+		app.POST("/debug/simulate-update", requireAPISecret, jsonBody(MaxInternalBodyBytes), handleSimulateUpdate)
 	}
+
+	return r
+}
+
+// handleUpdate dispatches an update from the webhook, polling or the simulator,
+// recovering a handler panic so it can't take the request down with it
+func (b *botInstance) handleUpdate(update tgbotapi.Update) {
+	defer b.recoverUpdate(update)
+	b.dispatchUpdate(update)
 }
 
-func handleUpdate(update tgbotapi.Update) {
-	log.Printf("🔄 Processing update type: Message=%t, CallbackQuery=%t",
+// dispatchUpdate routes an update to the message or callback handlers. Panics
+// reach the caller, so Pub/Sub can nack the delivery instead of losing it.
+func (b *botInstance) dispatchUpdate(update tgbotapi.Update) {
+	if chatID := updateChatID(update); chatID != 0 {
+		scoped, done := b.withCommandDeadline(chatID)
+		defer done()
+		b = scoped
+	}
+
+	debugf("🔄 Processing update type: Message=%t, CallbackQuery=%t",
 		update.Message != nil, update.CallbackQuery != nil)
 
 	if update.Message != nil {
-		handleMessage(update.Message)
+		b.handleMessage(update.Message)
 	} else if update.CallbackQuery != nil {
-		handleCallbackQuery(update.CallbackQuery)
+		b.handleCallbackQuery(update.CallbackQuery)
 	} else {
 		log.Printf("⚠️ Received unsupported update type")
 	}
 }
 
-func handleCallbackQuery(cb *tgbotapi.CallbackQuery) {
+func (b *botInstance) handleCallbackQuery(cb *tgbotapi.CallbackQuery) {
 	startTime := time.Now()
+	if cb.Message == nil {
+		// Buttons on inline-mode messages carry no chat, and every handler needs one
+		log.Printf("⚠️ Ignoring callback without a message - UserID: %d, Data: %s", cb.From.ID, cb.Data)
+		b.answerCallback(cb, "This button only works in the bot's chat.")
+		b.finishCallback(cb)
+		return
+	}
 	chatID := cb.Message.Chat.ID
 	log.Printf("🔘 Processing callback query - ChatID: %d, Data: %s, UserID: %d",
 		chatID, cb.Data, cb.From.ID)
@@ -236,22 +554,103 @@ func handleCallbackQuery(cb *tgbotapi.CallbackQuery) {
 			duration.Milliseconds(), duration.Seconds(), chatID)
 	}()
 
-	if !config.AllowedIDs[strconv.FormatInt(chatID, 10)] {
+	defer b.finishCallback(cb)
+
+	b, allowed := b.resolveTenant(chatID)
+	if !allowed {
 		log.Printf("❌ Unauthorized callback query from ChatID: %d, UserID: %d", chatID, cb.From.ID)
+		b.alertCallback(cb, "🔒 You're not authorized to use this bot.")
+		return
+	}
+
+	if !b.allowSender(chatID) || b.pinLockedCallback(cb) {
 		return
 	}
 
 	data := cb.Data
+	if isLimited(chatID) && !limitedCallback(data) {
+		log.Printf("🔒 Limited ChatID %d pressed a restricted button: %s", chatID, data)
+		b.answerCallback(cb, "🔒 Not available on your account.")
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixAccount) {
+		b.handleAccountCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixRunCommand) {
+		b.handleRunCommandCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixRecurring) {
+		b.handleRecurringCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixDelete) {
+		b.handleDeleteCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixSummaryPage) {
+		b.handleSummaryPageCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixQuickMenu) {
+		b.handleQuickMenuCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixQuickPick) {
+		b.handleQuickPickCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixBroadcast) {
+		b.handleBroadcastCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixApproval) {
+		b.handleApprovalCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixDuplicate) {
+		b.handleDuplicateCallback(cb)
+		return
+	}
+
+	if strings.HasPrefix(data, CallbackPrefixReauth) {
+		b.handleReauthCallback(cb)
+		return
+	}
+
+	if strings.HasPrefix(data, CallbackPrefixReminderAmount) {
+		b.handleReminderAmountCallback(cb)
+		return
+	}
+
+	if strings.HasPrefix(data, CallbackPrefixPaid) {
+		b.handlePaidCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixLargeAmount) {
+		b.handleLargeAmountCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixCap) {
+		b.handleCapCallback(cb)
+		return
+	}
+	if strings.HasPrefix(data, CallbackPrefixBudget) {
+		b.handleBudgetCallback(cb)
+		return
+	}
+
 	if !strings.HasPrefix(data, CallbackPrefixMarkDone) {
 		log.Printf("❌ Invalid callback action: %s", data)
-		bot.Request(tgbotapi.NewCallback(cb.ID, "Invalid action."))
+		b.answerCallback(cb, "Invalid action.")
 		return
 	}
 
 	parts := strings.Split(data, ":")
 	if len(parts) != 3 {
 		log.Printf("❌ Invalid callback format: %s", data)
-		bot.Request(tgbotapi.NewCallback(cb.ID, "Invalid format."))
+		b.answerCallback(cb, "Invalid format.")
 		return
 	}
 
@@ -262,16 +661,17 @@ func handleCallbackQuery(cb *tgbotapi.CallbackQuery) {
 	log.Printf("📝 Marking reminder as done - ID: %s, Type: %s, UserID: %s",
 		reminderID, reminderType, userID)
 
-	bot.Request(tgbotapi.NewCallback(cb.ID, "Processing..."))
-
-	body := map[string]string{
-		"reminderId":   reminderID,
-		"reminderType": reminderType,
-		"userId":       userID,
+	switch markDoneExpenseMode(chatID) {
+	case MarkDoneExpenseAsk:
+		b.askPaidAmount(cb, reminderID, reminderType)
+		return
+	case MarkDoneExpenseAuto:
+		if b.markPaidAutomatically(cb, reminderID, reminderType) {
+			return
+		}
 	}
 
-	// Use the timing-aware API call
-	result, err := apiCallWithTiming("POST", "/api/reminders/mark-as-done", body)
+	result, err := b.markReminderDone(chatID, reminderID, reminderType, 0)
 	totalDuration := time.Since(startTime)
 
 	// Single consolidated timing log
@@ -281,18 +681,30 @@ func handleCallbackQuery(cb *tgbotapi.CallbackQuery) {
 
 	if err != nil {
 		log.Printf("❌ Failed to mark reminder as done - ID: %s, Error: %v", reminderID, err)
-		if _, sendErr := bot.Send(tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, "❌ Error: "+err.Error())); sendErr != nil {
+		b.alertCallback(cb, userErrorText("marking it as done", err))
+		if _, sendErr := b.api.Send(tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, userErrorText("marking it as done", err))); sendErr != nil {
 			log.Printf("Failed to send error message: %v", sendErr)
 		}
 		return
 	}
 
+	clearEscalation(reminderID)
+	b.answerCallback(cb, "✅ Marked as done")
+
+	// Reminder lists carry buttons per bill; keep the list and the other bills' buttons
+	if remaining := withoutReminderButtons(cb.Message.ReplyMarkup, reminderID, reminderType); remaining != nil {
+		if _, err := b.api.Send(tgbotapi.NewEditMessageReplyMarkup(chatID, cb.Message.MessageID, *remaining)); err != nil {
+			log.Printf("⚠️ Failed to remove mark-done button: %v", err)
+		}
+		return
+	}
+
 	var resp struct {
 		Message string `json:"message"`
 	}
 	if err := json.Unmarshal(result.Data, &resp); err != nil || resp.Message == "" {
 		log.Printf("✅ Reminder marked as done (default message) - ID: %s", reminderID)
-		if _, sendErr := bot.Send(tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, "✅ Marked as done.")); sendErr != nil {
+		if _, sendErr := b.api.Send(tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, "✅ Marked as done.")); sendErr != nil {
 			log.Printf(ErrorSendSuccess, sendErr)
 		}
 		return
@@ -301,101 +713,289 @@ func handleCallbackQuery(cb *tgbotapi.CallbackQuery) {
 	log.Printf("✅ Reminder marked as done - ID: %s, Response: %s", reminderID, resp.Message)
 	msg := tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, "✅ "+resp.Message)
 	msg.ParseMode = "Markdown"
-	if _, err := bot.Send(msg); err != nil {
+	if _, err := b.sendFormatted(msg); err != nil {
 		log.Printf("Failed to send callback response: %v", err)
 	}
 }
 
-func handleMessage(msg *tgbotapi.Message) {
+// markReminderDone tells the backend a reminder is paid for the current period;
+// a positive amountPaid is recorded in the reminder's payment history
+func (b *botInstance) markReminderDone(chatID int64, reminderID, reminderType string, amountPaid float64) (TimingResult, error) {
+	body := map[string]interface{}{
+		"reminderId":   reminderID,
+		"reminderType": reminderType,
+		"userId":       strconv.FormatInt(chatID, 10),
+	}
+	if amountPaid > 0 {
+		body["amountPaid"] = amountPaid
+	}
+	return b.apiCallWithTiming("POST", "/api/reminders/mark-as-done", body)
+}
+
+func (b *botInstance) handleMessage(msg *tgbotapi.Message) {
 	startTime := time.Now()
 	chatID := msg.Chat.ID
 	userID := msg.From.ID
 	username := msg.From.UserName
 	text := strings.TrimSpace(msg.Text)
+	logged := logMessageText(msg)
 
 	log.Printf("📨 Processing message - ChatID: %d, UserID: %d, Username: %s, Text: %s",
-		chatID, userID, username, text)
+		chatID, userID, username, logged)
 
-	if !config.AllowedIDs[strconv.FormatInt(chatID, 10)] {
+	b, allowed := b.resolveTenant(chatID)
+	if !allowed {
 		log.Printf("❌ Unauthorized message from ChatID: %d, UserID: %d, Username: %s",
 			chatID, userID, username)
+		b.rejectUnauthorized(msg)
+		return
+	}
+
+	if !b.allowSender(chatID) || !b.pinGate(msg) {
 		return
 	}
 
 	defer func() {
 		duration := time.Since(startTime)
 		log.Printf("⏱️ Message processing completed in %d ms (%.3f seconds) - Command: %s",
-			duration.Milliseconds(), duration.Seconds(), text)
+			duration.Milliseconds(), duration.Seconds(), logged)
 	}()
 
+	// Replies like "amount 60" correct the expense logged by the replied-to message
+	if msg.ReplyToMessage != nil && !strings.HasPrefix(text, "/") {
+		if edit, ok := parseExpenseEdit(text, formatterFor(chatID).DecimalComma()); ok {
+			clearAwaiting(chatID)
+			b.handleExpenseEdit(msg, edit)
+			return
+		}
+	}
+
+	// Forwarded payment confirmations are logged as expenses
+	if msg.ForwardDate != 0 {
+		clearAwaiting(chatID)
+		b.handleForwardedExpense(msg)
+		return
+	}
+
+	// Configured aliases ("/s", "today") stand for a full command
+	if command, ok := resolveAlias(text); ok {
+		log.Printf("🔀 Alias %s resolved to %s", logText(text), logText(command))
+		text = command
+		msg.Text = command
+	}
+
+	// Answers to a pending bot question take precedence over command parsing
+	if !strings.HasPrefix(text, "/") {
+		if command, ok := takeReauthPIN(chatID, userID); ok {
+			b.handleReauthPIN(msg, command)
+			return
+		}
+		if kind, data, ok := takeAwaiting(chatID); ok && b.handleAwaitedReply(msg, kind, data) {
+			return
+		}
+	} else {
+		clearAwaiting(chatID)
+		dropReauthPIN(chatID, userID)
+	}
+
+	if !b.authorizeCommand(msg, text) || !b.reauthorized(msg, text) {
+		return
+	}
+
 	// Handle different commands
-	log.Printf("🔍 Analyzing command type for: %s", text)
+	debugf("🔍 Analyzing command type for: %s", logged)
+	escapeText, escaped := escapeCommandText(text)
 	switch {
+	case escaped:
+		log.Printf("💸 Handling /e command")
+		b.handleEscapeCommand(msg, escapeText)
 	case strings.HasPrefix(text, "/start"):
 		log.Printf("▶️ Handling /start command")
-		handleStartCommand(msg)
+		b.handleStartCommand(msg)
 	case strings.HasPrefix(text, "/help"):
 		log.Printf("❓ Handling /help command")
-		handleHelpCommand(msg)
+		b.handleHelpCommand(msg)
 	case strings.HasPrefix(text, "/expense"):
 		log.Printf("💰 Handling /expense command")
-		handleExpenseCommand(msg)
+		b.handleExpenseCommand(msg)
 	case strings.HasPrefix(text, "/reminders"):
 		log.Printf("🔔 Handling /reminders command")
-		handleRemindersCommand(msg)
+		b.handleRemindersCommand(msg)
+	case strings.HasPrefix(text, "/plan"):
+		log.Printf("🗓️ Handling /plan command")
+		b.handlePlanCommand(msg)
+	case strings.HasPrefix(text, "/trip"):
+		log.Printf("🧳 Handling /trip command")
+		b.handleTripCommand(msg)
+	case strings.HasPrefix(text, "/pending"):
+		log.Printf("🧾 Handling /pending command")
+		b.handlePendingCommand(msg)
 	case strings.HasPrefix(text, "/summary"):
 		log.Printf("📊 Handling /summary command")
-		handleSummaryCommand(msg)
+		b.handleSummaryCommand(msg)
 	case strings.HasPrefix(text, "/month"):
 		log.Printf("📈 Handling /month command")
-		handleMonthCommand(msg)
+		b.handleMonthCommand(msg)
+	case strings.HasPrefix(text, "/accounts"):
+		log.Printf("💳 Handling /accounts command")
+		b.handleAccountsCommand(msg)
+	case strings.HasPrefix(text, "/reconcile"):
+		log.Printf("🧮 Handling /reconcile command")
+		b.handleReconcileCommand(msg)
+	case strings.HasPrefix(text, "/reaction"):
+		log.Printf("👍 Handling /reaction command")
+		b.handleReactionCommand(msg)
+	case strings.HasPrefix(text, "/nudges"):
+		log.Printf("👋 Handling /nudges command")
+		b.handleNudgesCommand(msg)
+	case strings.HasPrefix(text, "/digest"):
+		log.Printf("🗓️ Handling /digest command")
+		b.handleDigestCommand(msg)
+	case strings.HasPrefix(text, "/streaks"):
+		log.Printf("🔥 Handling /streaks command")
+		b.handleStreaksCommand(msg)
+	case strings.HasPrefix(text, "/delete"):
+		log.Printf("🗑️ Handling /delete command")
+		b.handleDeleteCommand(msg)
+	case strings.HasPrefix(text, "/export"):
+		log.Printf("📤 Handling /export command")
+		b.handleExportCommand(msg)
+	case strings.HasPrefix(text, "/calendar"):
+		log.Printf("📅 Handling /calendar command")
+		b.handleCalendarCommand(msg)
+	case strings.HasPrefix(text, "/block"):
+		log.Printf("⛔ Handling /block command")
+		b.handleBlockCommand(msg, true)
+	case strings.HasPrefix(text, "/unblock"):
+		log.Printf("✅ Handling /unblock command")
+		b.handleBlockCommand(msg, false)
+	case strings.HasPrefix(text, "/version"):
+		log.Printf("🏷️ Handling /version command")
+		b.handleVersionCommand(msg)
+	case strings.HasPrefix(text, "/loglevel"):
+		log.Printf("🐛 Handling /loglevel command")
+		b.handleLogLevelCommand(msg)
+	case strings.HasPrefix(text, "/ping"):
+		log.Printf("🏓 Handling /ping command")
+		b.handlePingCommand(msg)
+	case strings.HasPrefix(text, "/pin"):
+		log.Printf("🔒 Handling /pin command")
+		b.handlePINCommand(msg)
+	case strings.HasPrefix(text, "/broadcast"):
+		log.Printf("📣 Handling /broadcast command")
+		b.handleBroadcastCommand(msg)
+	case strings.HasPrefix(text, "/link"):
+		log.Printf("🔗 Handling /link command")
+		b.handleLinkCommand(msg)
+	case strings.HasPrefix(text, "/feedback"):
+		log.Printf("💬 Handling /feedback command")
+		b.handleFeedbackCommand(msg)
+	case strings.HasPrefix(text, "/dashboard"):
+		log.Printf("📌 Handling /dashboard command")
+		b.handleDashboardCommand(msg)
+	case strings.HasPrefix(text, "/quick"):
+		log.Printf("⚡ Handling /quick command")
+		b.handleQuickCommand(msg)
+	case strings.HasPrefix(text, "/parse"):
+		log.Printf("🔍 Handling /parse command")
+		b.handleParseCommand(msg)
+	case strings.HasPrefix(text, "/upi"):
+		log.Printf("📷 Handling /upi command")
+		b.handleUPICommand(msg)
+	case strings.HasPrefix(text, "/invite"):
+		log.Printf("📷 Handling /invite command")
+		b.handleInviteCommand(msg)
+	case strings.HasPrefix(text, "/cap"):
+		log.Printf("🚦 Handling /cap command")
+		b.handleCapCommand(msg)
+	case strings.HasPrefix(text, "/"):
+		log.Printf("💡 Looking for a command close to: %s", logText(text))
+		b.handleMistypedCommand(msg, text)
 	default:
 		// Try to parse as expense - check if it contains numbers (no currency symbols needed)
-		if containsNumber(text) {
-			log.Printf("💸 Detected quick expense input")
-			handleQuickExpense(msg)
+		decimalComma := formatterFor(chatID).DecimalComma()
+		if !containsNumber(text, decimalComma) {
+			b.handleDescriptionOnly(msg)
+		} else if reason := quickExpenseRejection(text, decimalComma); reason != "" {
+			b.handleNotAnExpense(msg, reason)
+		} else if amount, ok := parseBareAmount(text, decimalComma); ok {
+			log.Printf("⚡ Detected amount-only input")
+			b.handleAmountOnly(msg, amount)
 		} else {
-			log.Printf("❓ Unknown command received")
-			handleUnknownCommand(msg)
+			log.Printf("💸 Detected quick expense input")
+			b.handleQuickExpense(msg)
 		}
 	}
 }
 
 // containsNumber checks if text contains any numeric values
-func containsNumber(text string) bool {
+func containsNumber(text string, decimalComma bool) bool {
 	parts := strings.Fields(text)
 	for _, part := range parts {
-		if _, err := strconv.ParseFloat(part, 64); err == nil {
+		if _, ok := parser.ParseNumber(part, decimalComma); ok {
 			return true
 		}
 	}
 	return false
 }
 
-func handleStartCommand(msg *tgbotapi.Message) {
+func (b *botInstance) handleStartCommand(msg *tgbotapi.Message) {
 	log.Printf("▶️ Sending welcome message to ChatID: %d", msg.Chat.ID)
 	response := "Welcome to SpendWise Bot! Use /summary for today's expenses, or log expenses like 'Groceries 50'."
 
 	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-	if _, err := bot.Send(reply); err != nil {
+	if _, err := b.api.Send(reply); err != nil {
 		log.Printf("❌ Failed to send start message to ChatID %d: %v", msg.Chat.ID, err)
 	} else {
 		log.Printf("✅ Welcome message sent successfully to ChatID: %d", msg.Chat.ID)
 	}
 }
 
-func handleHelpCommand(msg *tgbotapi.Message) {
+func (b *botInstance) handleHelpCommand(msg *tgbotapi.Message) {
 	log.Printf("❓ Sending help message to ChatID: %d", msg.Chat.ID)
+	if isLimited(msg.Chat.ID) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, LimitedHelpText)
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf("❌ Failed to send help message to ChatID %d: %v", msg.Chat.ID, err)
+		}
+		return
+	}
+
 	response := "SpendWise Bot Help 📖\n\n" +
 		"Commands:\n" +
 		"• /start - Welcome message\n" +
 		"• /expense - Add a new expense\n" +
-		"• /reminders - View your reminders\n" +
+		"• /reminders - View your reminders (/reminders all includes other months)\n" +
+		"• /pending - Unpaid bills this month\n" +
+		"• /quick - Log a routine expense in one tap\n" +
 		"• /summary - View today's expense summary\n" +
-		"• /month - View this month's summary\n\n" +
+		"• /month - View this month's summary\n" +
+		"• /accounts - Spend per payment account\n" +
+		"• /reconcile card - Compare logged card spend with your statement\n" +
+		"• /plan - Set this month's category budgets\n" +
+		"• /trip start Goa - Tag expenses with a trip until /trip end\n" +
+		"• /delete - Delete one of today's expenses\n" +
+		"• /nudges on - Get nudged after a day with no expenses\n" +
+		"• /digest on - Get a weekly digest on Sunday evening\n" +
+		"• /dashboard on - Pin a live household dashboard in a group\n" +
+		"• /streaks on - Celebrate logging and budget streaks\n" +
+		"• /reaction 🔥 - Pick the reaction on logged expenses\n" +
+		"• /calendar export - Download reminders as a calendar file\n" +
+		"• /export all - Download your expenses as a CSV file (/export reminders for bills)\n" +
+		"• /upi 500 electricity - QR code to pay with any UPI app\n" +
+		"• /parse Lunch 250 - See how a message would be read, without saving\n" +
+		"• /version - Show the running bot version\n" +
+		"• /link - Link this chat to your SpendWise account\n" +
+		"• /pin set 1234 - Lock this chat with a PIN when idle\n" +
+		"• /feedback - Report a problem to the maintainer\n\n" +
 		"Expense formats (both work):\n" +
 		"• description amount\n" +
-		"• amount description\n\n" +
+		"• amount description\n" +
+		"• add \"via card\" to tag the payment account\n" +
+		"• start with \"refund\", \"cashback\" or \"adjust\" to record money back\n" +
+		"• add \"// note\" at the end to attach a note\n" +
+		"• start with \"yesterday\" to log it for yesterday\n" +
+		"• /e Rent 25000 logs a message that wasn't taken as an expense\n\n" +
 		"Examples:\n" +
 		"Coffee Tea 15.50\n" +
 		"25 Lunch at restaurant\n\n" +
@@ -405,14 +1005,14 @@ func handleHelpCommand(msg *tgbotapi.Message) {
 		"Gas bill 45"
 
 	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-	if _, err := bot.Send(reply); err != nil {
+	if _, err := b.api.Send(reply); err != nil {
 		log.Printf("❌ Failed to send help message to ChatID %d: %v", msg.Chat.ID, err)
 	} else {
 		log.Printf("✅ Help message sent successfully to ChatID: %d", msg.Chat.ID)
 	}
 }
 
-func handleExpenseCommand(msg *tgbotapi.Message) {
+func (b *botInstance) handleExpenseCommand(msg *tgbotapi.Message) {
 	log.Printf("💰 Sending expense help to ChatID: %d", msg.Chat.ID)
 	response := "To add expenses, use either format:\n\n" +
 		"Format 1: description amount\n" +
@@ -428,19 +1028,20 @@ func handleExpenseCommand(msg *tgbotapi.Message) {
 		"Gas bill 45.75"
 
 	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-	if _, err := bot.Send(reply); err != nil {
+	if _, err := b.api.Send(reply); err != nil {
 		log.Printf("❌ Failed to send expense help to ChatID %d: %v", msg.Chat.ID, err)
 	} else {
 		log.Printf("✅ Expense help sent successfully to ChatID: %d", msg.Chat.ID)
 	}
 }
 
-func handleRemindersCommand(msg *tgbotapi.Message) {
+func (b *botInstance) handleRemindersCommand(msg *tgbotapi.Message) {
 	startTime := time.Now()
 	log.Printf("🔔 Starting reminders command processing")
+	b.showTyping(msg.Chat.ID)
 
 	// Use the timing-aware API call
-	result, err := apiCallWithTiming("GET", "/api/reminders/get-payload", nil)
+	result, err := b.apiCallWithTiming("GET", "/api/reminders/get-payload", nil)
 	totalDuration := time.Since(startTime)
 
 	// Single consolidated timing log
@@ -449,8 +1050,8 @@ func handleRemindersCommand(msg *tgbotapi.Message) {
 		totalDuration.Milliseconds(), result.APITime.Milliseconds(), overheadMs)
 
 	if err != nil {
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Error fetching reminders: "+err.Error())
-		if _, sendErr := bot.Send(reply); sendErr != nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, userErrorText("fetching reminders", err))
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
 			log.Printf(ErrorSendMessage, sendErr)
 		}
 		return
@@ -459,61 +1060,45 @@ func handleRemindersCommand(msg *tgbotapi.Message) {
 	var payload NotificationPayload
 	if err := json.Unmarshal(result.Data, &payload); err != nil {
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Error parsing reminders")
-		if _, sendErr := bot.Send(reply); sendErr != nil {
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
 			log.Printf(ErrorSendMessage, sendErr)
 		}
 		return
 	}
+	payload.Reminders = b.tenant.remindersFor(msg.Chat.ID, payload.Reminders)
 
 	if len(payload.Reminders) == 0 {
 		log.Printf("📝 No reminders found for ChatID: %d", msg.Chat.ID)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "No reminders found 📝")
-		if _, err := bot.Send(reply); err != nil {
+		if _, err := b.api.Send(reply); err != nil {
 			log.Printf("❌ Failed to send 'no reminders' message to ChatID %d: %v", msg.Chat.ID, err)
 		}
 		return
 	}
 
 	log.Printf("📋 Found %d reminders for ChatID: %d", len(payload.Reminders), msg.Chat.ID)
-	response := "🔔 Daily Reminders\n\n"
-	for i, reminder := range payload.Reminders {
-		formattedAmount := formatCurrency(reminder.Amount)
-		dueDateText := formatDueDate(reminder)
-		response += fmt.Sprintf("  • %s - %s (%s)\n",
-			reminder.Description, formattedAmount, dueDateText)
-		log.Printf("📌 Reminder %d: %s - %s (%s)", i+1, reminder.Description, formattedAmount, dueDateText)
-	}
-
-	response += "\nPlease check the app to take action."
-
-	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-	if _, err := bot.Send(reply); err != nil {
+	args := strings.Fields(msg.Text)
+	showAll := len(args) == 2 && args[1] == "all"
+	response := renderRemindersList(payload.Reminders, formatterFor(msg.Chat.ID), time.Now(), showAll)
+	if _, err := b.api.Send(response.message(msg.Chat.ID)); err != nil {
 		log.Printf("❌ Failed to send reminders list to ChatID %d: %v", msg.Chat.ID, err)
 	} else {
 		log.Printf("✅ Reminders list sent successfully to ChatID: %d", msg.Chat.ID)
 	}
 }
 
-// formatCurrency formats amount with Indian Rupee symbol and proper comma separation
+// formatCurrency formats amount with the deployment's default currency settings;
+// use formatterFor when rendering for a specific chat
 func formatCurrency(amount float64) string {
-	// Format with 2 decimal places
-	amountStr := fmt.Sprintf("%.2f", amount)
-
-	// Add commas for thousands separator (simple implementation for Indian format)
-	if amount >= 1000 {
-		// For amounts >= 1000, add comma separator
-		parts := strings.Split(amountStr, ".")
-		intPart := parts[0]
-		decPart := parts[1]
-
-		// Add comma before last 3 digits
-		if len(intPart) > 3 {
-			intPart = intPart[:len(intPart)-3] + "," + intPart[len(intPart)-3:]
-		}
-		amountStr = intPart + "." + decPart
-	}
+	return format.New(defaultFormatSettings()).Currency(amount)
+}
 
-	return "₹" + amountStr
+// normalizeGrouping maps a configured grouping style to a known value, defaulting to Indian
+func normalizeGrouping(style string) string {
+	if strings.EqualFold(strings.TrimSpace(style), GroupingWestern) {
+		return GroupingWestern
+	}
+	return GroupingIndian
 }
 
 // formatDueDate formats the due date based on day range or if it's today
@@ -521,6 +1106,14 @@ func formatDueDate(reminder Reminder) string {
 	now := time.Now()
 	currentDay := now.Day()
 
+	// One-off reminders carry an explicit date instead of a day-of-month window
+	if due, ok := reminderDueDate(reminder, now.Location()); ok {
+		return formatExplicitDueDate(due, now)
+	}
+	if reminder.DayOfMonthStart <= 0 {
+		return "No due date"
+	}
+
 	// If start and end dates are the same, check if it's today
 	if reminder.DayOfMonthStart == reminder.DayOfMonthEnd {
 		if currentDay == reminder.DayOfMonthStart {
@@ -538,12 +1131,37 @@ func formatDueDate(reminder Reminder) string {
 	return fmt.Sprintf("Due between %d-%d", reminder.DayOfMonthStart, reminder.DayOfMonthEnd)
 }
 
-func handleSummaryCommand(msg *tgbotapi.Message) {
+func (b *botInstance) handleSummaryCommand(msg *tgbotapi.Message) {
 	startTime := time.Now()
 	log.Printf("📊 Starting daily summary command processing")
+	b.showTyping(msg.Chat.ID)
+
+	// Optional date or range argument: /summary 2024-06-12, /summary last week, /summary 1 jun - 15 jun
+	endpoint := "/api/summary/today" + b.summaryLocaleQuery(msg.Chat.ID)
+	own := ""
+	if isLimited(msg.Chat.ID) {
+		// Limited accounts only see what they logged themselves
+		own = "&telegramChatId=" + strconv.FormatInt(msg.Chat.ID, 10)
+		endpoint += own
+	}
+	if args := strings.Fields(msg.Text)[1:]; len(args) > 0 {
+		arg := strings.Join(args, " ")
+		period, err := parseDateRange(arg, time.Now())
+		if err != nil {
+			log.Printf("❌ Invalid summary period from ChatID %d: %v", msg.Chat.ID, err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ "+err.Error()+"\n\n"+SummaryPeriodUsage)
+			if _, sendErr := b.api.Send(reply); sendErr != nil {
+				log.Printf(ErrorSendMessage, sendErr)
+			}
+			return
+		}
+		log.Printf("📊 Summary requested for %s", period.Label())
+		endpoint = "/api/summary/range" + b.summaryLocaleQuery(msg.Chat.ID) +
+			"&from=" + period.From.Format("2006-01-02") + "&to=" + period.To.Format("2006-01-02") + own
+	}
 
 	// Use the timing-aware API call
-	result, err := apiCallWithTiming("GET", "/api/summary/today", nil)
+	result, err := b.apiCallWithTiming("GET", endpoint, nil)
 	totalDuration := time.Since(startTime)
 
 	// Single consolidated timing log
@@ -552,9 +1170,9 @@ func handleSummaryCommand(msg *tgbotapi.Message) {
 		totalDuration.Milliseconds(), result.APITime.Milliseconds(), overheadMs)
 
 	if err != nil {
-		errorMsg := fmt.Sprintf("Sorry, I couldn't fetch your daily summary: %s", err.Error())
+		errorMsg := userErrorText("fetching your daily summary", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, errorMsg)
-		if _, sendErr := bot.Send(reply); sendErr != nil {
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
 			log.Printf(ErrorSendMessage, sendErr)
 		}
 		return
@@ -563,28 +1181,43 @@ func handleSummaryCommand(msg *tgbotapi.Message) {
 	var summaryResp SummaryResponse
 	if err := json.Unmarshal(result.Data, &summaryResp); err != nil {
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Error parsing daily summary response")
-		if _, sendErr := bot.Send(reply); sendErr != nil {
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
 			log.Printf(ErrorSendMessage, sendErr)
 		}
 		return
 	}
 
-	// Send the markdown response
-	reply := tgbotapi.NewMessage(msg.Chat.ID, summaryResp.Markdown)
-	reply.ParseMode = "Markdown"
-	if _, err := bot.Send(reply); err != nil {
+	// Send the rendered summary
+	if _, err := b.sendMarkdown(msg.Chat.ID, summaryResp.render(formatterFor(msg.Chat.ID))); err != nil {
 		log.Printf("❌ Failed to send daily summary to ChatID %d: %v", msg.Chat.ID, err)
 	} else {
 		log.Printf("✅ Daily summary sent successfully to ChatID: %d", msg.Chat.ID)
 	}
 }
 
-func handleMonthCommand(msg *tgbotapi.Message) {
+func (b *botInstance) handleMonthCommand(msg *tgbotapi.Message) {
 	startTime := time.Now()
 	log.Printf("📈 Starting monthly summary command processing")
+	b.showTyping(msg.Chat.ID)
+
+	// Spending months that don't start on the 1st are requested as an explicit
+	// range, or via cycleStartDay on backends without range summaries
+	endpoint := "/api/summary/month" + b.summaryLocaleQuery(msg.Chat.ID)
+	var cycleLabel string
+	if startDay := cycleStartDayFor(msg.Chat.ID); startDay > 1 {
+		from, to := billingCycle(time.Now(), startDay)
+		formatter := formatterFor(msg.Chat.ID)
+		cycleLabel = formatter.Date(from) + " - " + formatter.Date(to)
+		log.Printf("📈 Monthly summary for cycle starting on day %d: %s", startDay, cycleLabel)
+		if b.supports(CapSummaryRange) {
+			endpoint = "/api/summary/range" + b.summaryLocaleQuery(msg.Chat.ID) +
+				"&from=" + from.Format("2006-01-02") + "&to=" + to.Format("2006-01-02")
+		}
+		endpoint += "&cycleStartDay=" + strconv.Itoa(startDay)
+	}
 
 	// Use the timing-aware API call
-	result, err := apiCallWithTiming("GET", "/api/summary/month", nil)
+	result, err := b.apiCallWithTiming("GET", endpoint, nil)
 	totalDuration := time.Since(startTime)
 
 	// Single consolidated timing log
@@ -593,9 +1226,9 @@ func handleMonthCommand(msg *tgbotapi.Message) {
 		totalDuration.Milliseconds(), result.APITime.Milliseconds(), overheadMs)
 
 	if err != nil {
-		errorMsg := fmt.Sprintf("Sorry, I couldn't fetch your monthly summary: %s", err.Error())
+		errorMsg := userErrorText("fetching your monthly summary", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, errorMsg)
-		if _, sendErr := bot.Send(reply); sendErr != nil {
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
 			log.Printf(ErrorSendMessage, sendErr)
 		}
 		return
@@ -604,45 +1237,65 @@ func handleMonthCommand(msg *tgbotapi.Message) {
 	var summaryResp SummaryResponse
 	if err := json.Unmarshal(result.Data, &summaryResp); err != nil {
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Error parsing monthly summary response")
-		if _, sendErr := bot.Send(reply); sendErr != nil {
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
 			log.Printf(ErrorSendMessage, sendErr)
 		}
 		return
 	}
 
-	// Send the markdown response
-	reply := tgbotapi.NewMessage(msg.Chat.ID, summaryResp.Markdown)
-	reply.ParseMode = "Markdown"
-	if _, err := bot.Send(reply); err != nil {
+	if cycleLabel != "" && summaryResp.isStructured() {
+		summaryResp.Title = "Month " + cycleLabel
+	}
+
+	// Send the markdown response, one section per page
+	if _, err := b.sendSummaryPages(msg.Chat.ID, summaryResp.render(formatterFor(msg.Chat.ID))); err != nil {
 		log.Printf("❌ Failed to send monthly summary to ChatID %d: %v", msg.Chat.ID, err)
 	} else {
 		log.Printf("✅ Monthly summary sent successfully to ChatID: %d", msg.Chat.ID)
 	}
 }
 
-func handleQuickExpense(msg *tgbotapi.Message) {
-	startTime := time.Now()
+func (b *botInstance) handleQuickExpense(msg *tgbotapi.Message) {
 	text := strings.TrimSpace(msg.Text)
-	log.Printf("🚀 Starting expense processing for ChatID: %d, Text: %s", msg.Chat.ID, text)
+	log.Printf("🚀 Starting expense processing for ChatID: %d, Text: %s", msg.Chat.ID, logText(text))
 
 	// Parse expenses (single or batch)
-	expenses, err := parseExpenses(text, msg)
+	expenses, skipped, err := b.parseExpenses(text, msg)
 	if err != nil {
 		log.Printf("❌ Failed to parse expenses for ChatID %d: %v", msg.Chat.ID, err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ "+err.Error())
-		if _, sendErr := bot.Send(reply); sendErr != nil {
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
 			log.Printf(ErrorSendMessage, sendErr)
 		}
 		return
 	}
 
 	log.Printf("📝 Parsed %d expenses for ChatID: %d", len(expenses), msg.Chat.ID)
+	b.logExpenses(msg, expenses, skipped)
+}
+
+// saveExpenses sends parsed expenses to the backend and acknowledges msg with a
+// reaction or reply; skipped lists input lines that could not be parsed
+func (b *botInstance) saveExpenses(msg *tgbotapi.Message, expenses []ExpenseInput, skipped []string) {
+	startTime := time.Now()
 	for i, expense := range expenses {
-		log.Printf("💰 Expense %d: %s - %.2f", i+1, expense.Description, expense.Amount)
+		log.Printf("💰 Expense %d: %s - %.2f", i+1, logText(expense.Description), expense.Amount)
+	}
+
+	// Larger batches can take a while; show they are being worked on
+	processing := len(expenses)+len(skipped) >= ProcessingReactionMinLines
+	if len(expenses) > 1 {
+		b.showTyping(msg.Chat.ID)
+	}
+	if processing {
+		if err := b.sendReaction(msg.Chat.ID, msg.MessageID, ProcessingReactionEmoji); err != nil {
+			log.Printf("⚠️ Failed to set processing reaction for ChatID %d: %v", msg.Chat.ID, err)
+			processing = false
+		}
 	}
 
 	// Use the timing-aware API call
-	result, err := apiCallWithTiming("POST", "/api/expenses/create-batch-from-bot", expenses)
+	result, err := b.apiCallWithTiming("POST", "/api/expenses/create-batch-from-bot", expenses)
 	totalDuration := time.Since(startTime)
 
 	// Single consolidated timing log
@@ -650,10 +1303,34 @@ func handleQuickExpense(msg *tgbotapi.Message) {
 	log.Printf("💰⏱️ EXPENSE TIMING: Total=%dms | API=%dms | Overhead=%dms",
 		totalDuration.Milliseconds(), result.APITime.Milliseconds(), overheadMs)
 
+	// The outcome replaces the processing reaction: success emoji below, or none on failure
+	if processing {
+		defer func() {
+			if processing {
+				b.sendReaction(msg.Chat.ID, msg.MessageID, "")
+			}
+		}()
+	}
+
 	if err != nil {
 		log.Printf("❌ API call failed for ChatID %d: %v", msg.Chat.ID, err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Error saving expenses: "+err.Error())
-		if _, sendErr := bot.Send(reply); sendErr != nil {
+		if backendErrorCode(err) == ErrCodeDuplicateExpense && b.offerLogDuplicate(msg, expenses, skipped) {
+			return
+		}
+		text := userErrorText("saving expenses", err)
+		if isBackendUnavailable(err) && !backendTimedOut(err) && b.outage != nil {
+			// Pub/Sub redelivers the update once the server is back
+			text = "⏳ The SpendWise server isn't answering right now. I'll retry these expenses automatically."
+		} else if isBackendUnavailable(err) && !backendTimedOut(err) && b.queueForRetry(msg, expenses) {
+			// A timed out save may have gone through, so only unreachable and 5xx answers are queued
+			if storeDurable() {
+				text = fmt.Sprintf("💾 Saved locally - the SpendWise server isn't answering right now. I'll sync %d expense(s) when it's back and let you know.", len(expenses))
+			} else {
+				text = fmt.Sprintf("💾 Kept in memory - the SpendWise server isn't answering right now. I'll sync %d expense(s) when it's back and let you know, unless the bot restarts first.", len(expenses))
+			}
+		}
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
 			log.Printf(ErrorSendMessage, sendErr)
 		}
 		return
@@ -661,16 +1338,19 @@ func handleQuickExpense(msg *tgbotapi.Message) {
 
 	// Parse API response
 	var apiResp struct {
-		Success bool   `json:"success"`
-		Message string `json:"message"`
-		Error   string `json:"error"`
-		Details string `json:"details"`
+		Success bool     `json:"success"`
+		Message string   `json:"message"`
+		Error   string   `json:"error"`
+		Details string   `json:"details"`
+		IDs     []string `json:"ids"` // created expense IDs, in input order
+		// BudgetAlerts lists categories these expenses pushed over budget
+		BudgetAlerts []budgetAlert `json:"budgetAlerts"`
 	}
 
 	if err := json.Unmarshal(result.Data, &apiResp); err != nil {
 		log.Printf("❌ Failed to parse API response for ChatID %d: %v", msg.Chat.ID, err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Error parsing API response")
-		if _, sendErr := bot.Send(reply); sendErr != nil {
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
 			log.Printf(ErrorSendMessage, sendErr)
 		}
 		return
@@ -681,36 +1361,71 @@ func handleQuickExpense(msg *tgbotapi.Message) {
 
 	// Send success or error message based on API response
 	if apiResp.Success {
-		if len(expenses) == 1 {
+		for _, expense := range expenses {
+			recordRecentDescription(msg.Chat.ID, expense.Description)
+		}
+
+		if len(expenses) == 1 && len(skipped) == 0 {
+			if len(apiResp.IDs) == 1 {
+				linkMessage(msg.Chat.ID, msg.MessageID, EntityExpense, apiResp.IDs[0])
+			}
+
 			log.Printf("👍 Sending reaction for single expense to ChatID: %d", msg.Chat.ID)
 			// Single expense - send reaction instead of message
-			if err := sendReaction(msg.Chat.ID, msg.MessageID, "👍"); err != nil {
+			if err := b.sendReaction(msg.Chat.ID, msg.MessageID, successReaction(msg.Chat.ID)); err != nil {
 				log.Printf("❌ Failed to send reaction, falling back to message for ChatID %d: %v", msg.Chat.ID, err)
 				// Fallback to text message if reaction fails
 				successMsg := tgbotapi.NewMessage(msg.Chat.ID, "✅ Expense logged successfully!")
-				if _, sendErr := bot.Send(successMsg); sendErr != nil {
+				if sent, sendErr := b.api.Send(successMsg); sendErr != nil {
 					log.Printf(ErrorSendSuccess, sendErr)
+				} else if len(apiResp.IDs) == 1 {
+					linkMessage(msg.Chat.ID, sent.MessageID, EntityExpense, apiResp.IDs[0])
 				}
 			} else {
 				log.Printf("✅ Reaction sent successfully for ChatID: %d", msg.Chat.ID)
 			}
+
+			if expenses[0].EntryType == "" {
+				b.maybeSuggestRecurring(msg, expenses[0])
+			}
 		} else {
-			// Multiple expenses - send text reply
+			// Multiple expenses - send text reply, compact when the reaction already says it worked
+			reacted := false
+			if processing || config.BatchReactions {
+				if err := b.sendReaction(msg.Chat.ID, msg.MessageID, successReaction(msg.Chat.ID)); err == nil {
+					processing, reacted = false, true
+				}
+			}
+
 			var successMsg string
-			if apiResp.Message != "" {
+			switch {
+			case config.BatchReactions && reacted:
+				successMsg = batchAckText(expenses, skipped, formatterFor(msg.Chat.ID))
+			case apiResp.Message != "":
 				successMsg = "✅ " + apiResp.Message
-			} else {
-				successMsg = fmt.Sprintf("✅ %d expenses saved successfully", len(expenses))
+			default:
+				successMsg = fmt.Sprintf("✅ %d expenses saved successfully (total %s)",
+					len(expenses), formatterFor(msg.Chat.ID).Currency(batchTotal(expenses)))
+			}
+			if len(skipped) > 0 && !(config.BatchReactions && reacted) {
+				successMsg += "\n⚠️ Skipped " + strings.Join(skipped, "\n⚠️ Skipped ")
 			}
 
 			log.Printf("✅ Sending success message for %d expenses to ChatID: %d", len(expenses), msg.Chat.ID)
 			reply := tgbotapi.NewMessage(msg.Chat.ID, successMsg)
-			if _, err := bot.Send(reply); err != nil {
+			if config.BatchReactions {
+				reply.ReplyToMessageID = msg.MessageID
+			}
+			if _, err := b.api.Send(reply); err != nil {
 				log.Printf(ErrorSendSuccess, err)
 			} else {
 				log.Printf("✅ Success message sent for ChatID: %d", msg.Chat.ID)
 			}
 		}
+
+		b.sendBudgetAlerts(msg, apiResp.BudgetAlerts)
+		b.refreshDashboardSoon(msg.Chat.ID)
+		runLowPriority("streak-check", func() { b.detached().maybeCelebrateStreak(msg.Chat.ID) })
 	} else {
 		// Error response - always send text message
 		errorMsg := "❌ API Error"
@@ -723,67 +1438,79 @@ func handleQuickExpense(msg *tgbotapi.Message) {
 
 		log.Printf("❌ Sending error message to ChatID %d: %s", msg.Chat.ID, errorMsg)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, errorMsg)
-		if _, err := bot.Send(reply); err != nil {
+		if _, err := b.api.Send(reply); err != nil {
 			log.Printf(ErrorSendMessage, err)
 		}
 	}
 }
 
-func parseExpenses(text string, msg *tgbotapi.Message) ([]ExpenseInput, error) {
-	lines := strings.Split(text, "\n")
-	var expenses []ExpenseInput
-
-	log.Printf("📊 Parsing %d lines of expense input for ChatID: %d", len(lines), msg.Chat.ID)
-
-	for i, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			log.Printf("⏭️ Skipping empty line %d", i+1)
-			continue // Skip empty lines
-		}
-
-		log.Printf("🔍 Parsing line %d: %s", i+1, line)
-		amount, description, err := parseExpenseText(line)
-		if err != nil {
-			log.Printf("❌ Failed to parse line %d (%s): %v", i+1, line, err)
-			return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
-		}
-
-		expense := ExpenseInput{
-			Description:    description,
-			Amount:         amount,
-			Date:           time.Now().Format("2006-01-02"),
-			Source:         "bot",
-			UserName:       getUserName(msg),
-			TelegramChatID: strconv.FormatInt(msg.Chat.ID, 10),
-		}
+// parseExpenses parses one expense per line. Invalid lines reject the whole
+// message unless BatchReactions is on, in which case they are returned as
+// skipped ("line 3: invalid amount") and the valid lines are still saved.
+func (b *botInstance) parseExpenses(text string, msg *tgbotapi.Message) ([]ExpenseInput, []string, error) {
+	log.Printf("📊 Parsing %d lines of expense input for ChatID: %d", strings.Count(text, "\n")+1, msg.Chat.ID)
 
+	entries, lineErrs := parser.Parse(text, parseOptions(msg.Chat.ID))
+	var expenses []ExpenseInput
+	for _, entry := range entries {
+		expense := b.expenseFromEntry(entry, msg)
 		if err := validateExpenseInput(expense); err != nil {
-			log.Printf("❌ Validation failed for line %d: %v", i+1, err)
-			return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+			log.Printf("❌ Validation failed for line %d: %v", entry.Line, err)
+			lineErrs = append(lineErrs, parser.LineError{Line: entry.Line, Err: err})
+			continue
 		}
 
-		log.Printf("✅ Parsed expense: %s - %.2f (User: %s)", description, amount, expense.UserName)
+		log.Printf("✅ Parsed expense: %s - %.2f (User: %s, Type: %s)", logText(expense.Description), expense.Amount, expense.UserName, expense.EntryType)
 		expenses = append(expenses, expense)
 	}
 
+	sort.Slice(lineErrs, func(i, j int) bool { return lineErrs[i].Line < lineErrs[j].Line })
+	skipped := make([]string, 0, len(lineErrs))
+	for _, lineErr := range lineErrs {
+		log.Printf("❌ Failed to parse %v", lineErr)
+		skipped = append(skipped, lineErr.Error())
+	}
+
+	if len(skipped) > 0 && (len(expenses) == 0 || !config.BatchReactions) {
+		return nil, nil, errors.New(skipped[0])
+	}
 	if len(expenses) == 0 {
 		log.Printf("❌ No valid expenses found in input")
-		return nil, fmt.Errorf("no valid expenses found")
+		return nil, nil, fmt.Errorf("no valid expenses found")
 	}
 
-	log.Printf("✅ Successfully parsed %d expenses", len(expenses))
-	return expenses, nil
+	log.Printf("✅ Successfully parsed %d expenses, skipped %d lines", len(expenses), len(skipped))
+	return expenses, skipped, nil
+}
+
+// expenseFromEntry turns a parsed line into the backend's expense input, filling
+// in the chat's default account
+func (b *botInstance) expenseFromEntry(entry parser.Entry, msg *tgbotapi.Message) ExpenseInput {
+	account := entry.Account
+	if account == "" {
+		account = defaultAccountFor(msg.Chat.ID)
+	}
+	return ExpenseInput{
+		Description:    entry.Description,
+		Amount:         entry.Amount,
+		Date:           entry.Date.Format("2006-01-02"),
+		Source:         "bot",
+		UserName:       b.getUserName(msg),
+		TelegramChatID: strconv.FormatInt(msg.Chat.ID, 10),
+		Account:        account,
+		EntryType:      entry.EntryType,
+		Note:           entry.Note,
+	}
 }
 
 // getUserName gets the username for a chat ID from config or fallback to Telegram name
-func getUserName(msg *tgbotapi.Message) string {
+func (b *botInstance) getUserName(msg *tgbotapi.Message) string {
 	chatID := strconv.FormatInt(msg.Chat.ID, 10)
 
 	log.Printf("🔍 Getting username for ChatID: %s", chatID)
 
 	// Check if we have a configured username for this chat ID
-	if userName, exists := config.UserNames[chatID]; exists && userName != "" {
+	if userName := b.tenant.userName(chatID); userName != "" {
 		log.Printf("✅ Found configured username for ChatID %s: %s", chatID, userName)
 		return userName
 	}
@@ -806,62 +1533,17 @@ func getUserName(msg *tgbotapi.Message) string {
 	return fallbackName
 }
 
-func handleUnknownCommand(msg *tgbotapi.Message) {
-	log.Printf("❓ Unknown command received from ChatID: %d, Text: %s", msg.Chat.ID, msg.Text)
+func (b *botInstance) handleUnknownCommand(msg *tgbotapi.Message) {
+	log.Printf("❓ Unknown command received from ChatID: %d, Text: %s", msg.Chat.ID, logText(msg.Text))
 	response := "I don't understand that command. Type /help for available commands."
 	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-	if _, err := bot.Send(reply); err != nil {
+	if _, err := b.api.Send(reply); err != nil {
 		log.Printf("❌ Failed to send unknown command message to ChatID %d: %v", msg.Chat.ID, err)
 	} else {
 		log.Printf("✅ Unknown command response sent to ChatID: %d", msg.Chat.ID)
 	}
 }
 
-func parseExpenseText(text string) (float64, string, error) {
-	// Clean up text (no currency symbols needed)
-	text = strings.TrimSpace(text)
-	log.Printf("🔍 Parsing expense text: %s", text)
-
-	parts := strings.Fields(text)
-	if len(parts) < 2 {
-		log.Printf("❌ Invalid format - need description and amount")
-		return 0, "", fmt.Errorf("invalid format - need description and amount")
-	}
-
-	var amounts []float64
-	var descriptionParts []string
-
-	// Separate amounts from description
-	for _, part := range parts {
-		if amount, err := strconv.ParseFloat(part, 64); err == nil && amount > 0 {
-			amounts = append(amounts, amount)
-			log.Printf("💰 Found amount: %.2f", amount)
-		} else {
-			descriptionParts = append(descriptionParts, part)
-		}
-	}
-
-	if len(amounts) == 0 {
-		log.Printf("❌ No valid amount found in: %s", text)
-		return 0, "", fmt.Errorf("no valid amount found")
-	}
-
-	if len(descriptionParts) == 0 {
-		log.Printf("❌ Missing description in: %s", text)
-		return 0, "", fmt.Errorf("missing description")
-	}
-
-	// Sum all amounts
-	var totalAmount float64
-	for _, amount := range amounts {
-		totalAmount += amount
-	}
-
-	description := strings.Join(descriptionParts, " ")
-	log.Printf("✅ Parsed: %s - %.2f (total from %d amounts)", description, totalAmount, len(amounts))
-	return totalAmount, description, nil
-}
-
 // validateExpenseInput validates expense input data
 func validateExpenseInput(input ExpenseInput) error {
 	if input.Amount <= 0 {
@@ -876,6 +1558,25 @@ func validateExpenseInput(input ExpenseInput) error {
 	return nil
 }
 
+// parseChatMappings reads "chatID1:value1,chatID2:value2" pairs, skipping malformed entries
+func parseChatMappings(raw string) map[string]string {
+	mappings := make(map[string]string)
+	if raw == "" {
+		return mappings
+	}
+	for _, mapping := range strings.Split(raw, ",") {
+		parts := strings.Split(mapping, ":")
+		if len(parts) == 2 {
+			chatID := strings.TrimSpace(parts[0])
+			value := strings.TrimSpace(parts[1])
+			if chatID != "" && value != "" {
+				mappings[chatID] = value
+			}
+		}
+	}
+	return mappings
+}
+
 // loadConfig loads configuration from JSON env var or individual environment variables
 func loadConfig() SpendWiseConfig {
 	// Option 1: Try JSON in environment variable
@@ -916,6 +1617,11 @@ func convertSecretConfigToSpendWiseConfig(secretConfig *SecretConfig) SpendWiseC
 		port = DefaultPort
 	}
 
+	currencySymbol := secretConfig.CurrencySymbol
+	if currencySymbol == "" {
+		currencySymbol = DefaultCurrencySymbol
+	}
+
 	// Validate required fields
 	if secretConfig.BotToken == "" {
 		log.Fatal("botToken is required in configuration")
@@ -936,6 +1642,72 @@ func convertSecretConfigToSpendWiseConfig(secretConfig *SecretConfig) SpendWiseC
 		APISecret:  secretConfig.APISecret,
 		Port:       port,
 		UserNames:  secretConfig.UserNames,
+		Bots:       secretConfig.Bots,
+		Tenants:    secretConfig.Tenants,
+
+		ReminderOwners: secretConfig.ReminderOwners,
+
+		CalendarToken: secretConfig.CalendarToken,
+
+		EscalationEnabled: secretConfig.EscalationEnabled,
+		EscalationCCIDs:   secretConfig.EscalationCCIDs,
+		CurrencySymbol:    currencySymbol,
+		DigitGrouping:     normalizeGrouping(secretConfig.DigitGrouping),
+		Locale:            secretConfig.Locale,
+		DateFormat:        secretConfig.DateFormat,
+		UserSettings:      secretConfig.UserSettings,
+		Accounts:          secretConfig.Accounts,
+		AdminIDs:          idSet(secretConfig.AdminIDs),
+		BlockedIDs:        idSet(secretConfig.BlockedIDs),
+		LimitedIDs:        idSet(secretConfig.LimitedIDs),
+		FloodMaxMessages:  secretConfig.FloodMaxMessages,
+		FloodWindow:       time.Duration(secretConfig.FloodWindowSeconds) * time.Second,
+		CommandTimeout:    time.Duration(secretConfig.CommandTimeoutSeconds) * time.Second,
+		KeepAliveInterval: time.Duration(secretConfig.KeepAliveMinutes) * time.Minute,
+		BotUsersRefresh:   time.Duration(secretConfig.BotUsersRefreshMinutes) * time.Minute,
+		SlowAPICall:       time.Duration(secretConfig.SlowAPICallMs) * time.Millisecond,
+
+		HTTPMaxConnsPerHost: secretConfig.HTTPMaxConnsPerHost,
+		HTTP2Enabled:        secretConfig.HTTP2 == nil || *secretConfig.HTTP2,
+		TelegramAPIURL:      strings.TrimSuffix(secretConfig.TelegramAPIURL, "/"),
+
+		MaxConcurrentUpdates: secretConfig.MaxConcurrentUpdates,
+		CommandAliases:       secretConfig.CommandAliases,
+		UPIID:                secretConfig.UPIID,
+		UPIName:              secretConfig.UPIName,
+		MarkDoneExpense:      secretConfig.MarkDoneExpense,
+
+		QuickExpenseMinAmount: secretConfig.QuickExpenseMinAmount,
+		QuickExpenseMaxAmount: secretConfig.QuickExpenseMaxAmount,
+		QuickExpenseMinWords:  secretConfig.QuickExpenseMinWords,
+		QuickExpenseBlocklist: secretConfig.QuickExpenseBlocklist,
+		MaxPlausibleAmount:    secretConfig.MaxPlausibleAmount,
+		ApprovalThreshold:     secretConfig.ApprovalThreshold,
+		ErrorMessages:         secretConfig.ErrorMessages,
+		ReauthWindow:          time.Duration(secretConfig.ReauthMinutes) * time.Minute,
+		ReauthPINs:            secretConfig.ReauthPINs,
+		ApproverIDs:           secretConfig.ApproverIDs,
+
+		RedisURL:          secretConfig.RedisURL,
+		PubSubToken:       secretConfig.PubSubToken,
+		WebhookSecret:     secretConfig.WebhookSecret,
+		LogMessageContent: secretConfig.LogMessageContent == nil || *secretConfig.LogMessageContent,
+
+		WebhookAllowedCIDRs:  secretConfig.WebhookAllowedCIDRs,
+		InternalAllowedCIDRs: secretConfig.InternalAllowedCIDRs,
+		TrustedProxies:       secretConfig.TrustedProxies,
+		TrustedPlatform:      secretConfig.TrustedPlatform,
+
+		BackendAuth:    backendAuthMode(secretConfig.BackendAuth),
+		OIDCAudience:   secretConfig.OIDCAudience,
+		ClientCertFile: secretConfig.ClientCertFile,
+		ClientKeyFile:  secretConfig.ClientKeyFile,
+		ClientCAFile:   secretConfig.ClientCAFile,
+
+		BatchReactions: secretConfig.BatchReactions,
+		CycleStartDay:  secretConfig.CycleStartDay,
+		DebugSimulator: secretConfig.DebugSimulator,
+		PprofEnabled:   secretConfig.PprofEnabled,
 	}
 }
 
@@ -971,6 +1743,11 @@ func loadConfigFromEnvVars() SpendWiseConfig {
 		port = DefaultPort // default
 	}
 
+	currencySymbol := os.Getenv("CURRENCY_SYMBOL")
+	if currencySymbol == "" {
+		currencySymbol = DefaultCurrencySymbol // default
+	}
+
 	// Parse allowed IDs
 	allowedIDsStr := os.Getenv("ALLOWED_IDS")
 	allowedIDs := make(map[string]bool)
@@ -982,18 +1759,98 @@ func loadConfigFromEnvVars() SpendWiseConfig {
 	}
 
 	// Parse username mappings: "chatID1:username1,chatID2:username2"
-	userNamesStr := os.Getenv("USER_NAMES")
-	userNames := make(map[string]string)
-	if userNamesStr != "" {
-		mappings := strings.Split(userNamesStr, ",")
-		for _, mapping := range mappings {
-			parts := strings.Split(mapping, ":")
-			if len(parts) == 2 {
-				chatID := strings.TrimSpace(parts[0])
-				userName := strings.TrimSpace(parts[1])
-				if chatID != "" && userName != "" {
-					userNames[chatID] = userName
-				}
+	userNames := parseChatMappings(os.Getenv("USER_NAMES"))
+	// Parse reminder owners: "chatID1:userId1,chatID2:userId2"
+	reminderOwners := parseChatMappings(os.Getenv("REMINDER_OWNERS"))
+
+	// Parse per-user settings: JSON object keyed by chat ID
+	userSettings := make(map[string]UserSettings)
+	if settingsStr := os.Getenv("USER_SETTINGS"); settingsStr != "" {
+		if err := json.Unmarshal([]byte(settingsStr), &userSettings); err != nil {
+			log.Printf("❌ Failed to parse USER_SETTINGS, ignoring: %v", err)
+		}
+	}
+
+	// Parse command aliases: JSON object of alias -> command
+	commandAliases := make(map[string]string)
+	if aliasesStr := os.Getenv("COMMAND_ALIASES"); aliasesStr != "" {
+		if err := json.Unmarshal([]byte(aliasesStr), &commandAliases); err != nil {
+			log.Printf("❌ Failed to parse COMMAND_ALIASES, ignoring: %v", err)
+		}
+	}
+
+	// Parse error message overrides: JSON object of error code -> message
+	errorMessages := make(map[string]string)
+	if messagesStr := os.Getenv("ERROR_MESSAGES"); messagesStr != "" {
+		if err := json.Unmarshal([]byte(messagesStr), &errorMessages); err != nil {
+			log.Printf("❌ Failed to parse ERROR_MESSAGES, ignoring: %v", err)
+		}
+	}
+
+	// Parse re-authentication PINs: JSON object of chat ID -> PIN
+	reauthPINs := make(map[string]string)
+	if pinsStr := os.Getenv("REAUTH_PINS"); pinsStr != "" {
+		if err := json.Unmarshal([]byte(pinsStr), &reauthPINs); err != nil {
+			log.Printf("❌ Failed to parse REAUTH_PINS, ignoring: %v", err)
+		}
+	}
+	reauthMinutes, _ := strconv.Atoi(os.Getenv("REAUTH_MINUTES"))
+
+	// Parse quick-expense blocklist: JSON array of regular expressions, which may contain commas
+	var quickExpenseBlocklist []string
+	if blocklistStr := os.Getenv("QUICK_EXPENSE_BLOCKLIST"); blocklistStr != "" {
+		if err := json.Unmarshal([]byte(blocklistStr), &quickExpenseBlocklist); err != nil {
+			log.Printf("❌ Failed to parse QUICK_EXPENSE_BLOCKLIST, ignoring: %v", err)
+		}
+	}
+
+	// Parse additional bots: JSON array of {id, botToken, apiUrl, apiSecret}
+	var extraBots []BotConfig
+	if botsStr := os.Getenv("BOTS"); botsStr != "" {
+		if err := json.Unmarshal([]byte(botsStr), &extraBots); err != nil {
+			log.Printf("❌ Failed to parse BOTS, ignoring: %v", err)
+		}
+	}
+
+	// Parse tenants: JSON array of household configurations
+	var tenantConfigs []TenantConfig
+	if tenantsStr := os.Getenv("TENANTS"); tenantsStr != "" {
+		if err := json.Unmarshal([]byte(tenantsStr), &tenantConfigs); err != nil {
+			log.Printf("❌ Failed to parse TENANTS, ignoring: %v", err)
+		}
+	}
+
+	// Parse payment accounts
+	var accounts []string
+	if accountsStr := os.Getenv("ACCOUNTS"); accountsStr != "" {
+		for _, account := range strings.Split(accountsStr, ",") {
+			if account = strings.TrimSpace(account); account != "" {
+				accounts = append(accounts, account)
+			}
+		}
+	}
+
+	floodMaxMessages, _ := strconv.Atoi(os.Getenv("FLOOD_MAX_MESSAGES"))
+	floodWindowSeconds, _ := strconv.Atoi(os.Getenv("FLOOD_WINDOW_SECONDS"))
+	cycleStartDay, _ := strconv.Atoi(os.Getenv("CYCLE_START_DAY"))
+	commandTimeoutSeconds, _ := strconv.Atoi(os.Getenv("COMMAND_TIMEOUT_SECONDS"))
+	keepAliveMinutes, _ := strconv.Atoi(os.Getenv("KEEP_ALIVE_MINUTES"))
+	botUsersRefreshMinutes, _ := strconv.Atoi(os.Getenv("BOT_USERS_REFRESH_MINUTES"))
+	httpMaxConnsPerHost, _ := strconv.Atoi(os.Getenv("HTTP_MAX_CONNS_PER_HOST"))
+	slowAPICallMs, _ := strconv.Atoi(os.Getenv("SLOW_API_CALL_MS"))
+	maxConcurrentUpdates, _ := strconv.Atoi(os.Getenv("MAX_CONCURRENT_UPDATES"))
+	quickExpenseMinAmount, _ := strconv.ParseFloat(os.Getenv("QUICK_EXPENSE_MIN_AMOUNT"), 64)
+	quickExpenseMaxAmount, _ := strconv.ParseFloat(os.Getenv("QUICK_EXPENSE_MAX_AMOUNT"), 64)
+	quickExpenseMinWords, _ := strconv.Atoi(os.Getenv("QUICK_EXPENSE_MIN_WORDS"))
+	maxPlausibleAmount, _ := strconv.ParseFloat(os.Getenv("MAX_PLAUSIBLE_AMOUNT"), 64)
+	approvalThreshold, _ := strconv.ParseFloat(os.Getenv("APPROVAL_THRESHOLD"), 64)
+
+	// Parse escalation CC chat IDs
+	var escalationCCIDs []string
+	if ccStr := os.Getenv("ESCALATION_CC_IDS"); ccStr != "" {
+		for _, id := range strings.Split(ccStr, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				escalationCCIDs = append(escalationCCIDs, id)
 			}
 		}
 	}
@@ -1007,7 +1864,104 @@ func loadConfigFromEnvVars() SpendWiseConfig {
 		APISecret:  apiSecret,
 		Port:       port,
 		UserNames:  userNames,
+		Bots:       extraBots,
+		Tenants:    tenantConfigs,
+
+		ReminderOwners: reminderOwners,
+
+		CalendarToken: os.Getenv("CALENDAR_TOKEN"),
+
+		EscalationEnabled: os.Getenv("ESCALATION_ENABLED") == "true",
+		EscalationCCIDs:   escalationCCIDs,
+		CurrencySymbol:    currencySymbol,
+		DigitGrouping:     normalizeGrouping(os.Getenv("DIGIT_GROUPING")),
+		Locale:            os.Getenv("LOCALE"),
+		DateFormat:        os.Getenv("DATE_FORMAT"),
+		UserSettings:      userSettings,
+		Accounts:          accounts,
+		AdminIDs:          idSet(strings.Split(os.Getenv("ADMIN_IDS"), ",")),
+		BlockedIDs:        idSet(strings.Split(os.Getenv("BLOCKED_IDS"), ",")),
+		LimitedIDs:        idSet(strings.Split(os.Getenv("LIMITED_IDS"), ",")),
+		FloodMaxMessages:  floodMaxMessages,
+		FloodWindow:       time.Duration(floodWindowSeconds) * time.Second,
+		CommandTimeout:    time.Duration(commandTimeoutSeconds) * time.Second,
+		KeepAliveInterval: time.Duration(keepAliveMinutes) * time.Minute,
+		BotUsersRefresh:   time.Duration(botUsersRefreshMinutes) * time.Minute,
+		SlowAPICall:       time.Duration(slowAPICallMs) * time.Millisecond,
+
+		HTTPMaxConnsPerHost: httpMaxConnsPerHost,
+		HTTP2Enabled:        os.Getenv("HTTP2") != "false",
+		TelegramAPIURL:      strings.TrimSuffix(os.Getenv("TELEGRAM_API_URL"), "/"),
+
+		MaxConcurrentUpdates: maxConcurrentUpdates,
+		CommandAliases:       commandAliases,
+		UPIID:                os.Getenv("UPI_ID"),
+		UPIName:              os.Getenv("UPI_NAME"),
+		MarkDoneExpense:      os.Getenv("MARK_DONE_EXPENSE"),
+
+		QuickExpenseMinAmount: quickExpenseMinAmount,
+		QuickExpenseMaxAmount: quickExpenseMaxAmount,
+		QuickExpenseMinWords:  quickExpenseMinWords,
+		QuickExpenseBlocklist: quickExpenseBlocklist,
+		MaxPlausibleAmount:    maxPlausibleAmount,
+		ApprovalThreshold:     approvalThreshold,
+		ApproverIDs:           splitList(os.Getenv("APPROVER_IDS")),
+		ErrorMessages:         errorMessages,
+		ReauthWindow:          time.Duration(reauthMinutes) * time.Minute,
+		ReauthPINs:            reauthPINs,
+
+		RedisURL:          os.Getenv("REDIS_URL"),
+		PubSubToken:       os.Getenv("PUBSUB_TOKEN"),
+		WebhookSecret:     os.Getenv("WEBHOOK_SECRET"),
+		LogMessageContent: os.Getenv("LOG_MESSAGE_CONTENT") != "false",
+
+		WebhookAllowedCIDRs:  splitList(os.Getenv("WEBHOOK_ALLOWED_CIDRS")),
+		InternalAllowedCIDRs: splitList(os.Getenv("INTERNAL_ALLOWED_CIDRS")),
+		TrustedProxies:       splitList(os.Getenv("TRUSTED_PROXIES")),
+		TrustedPlatform:      os.Getenv("TRUSTED_PLATFORM"),
+
+		BackendAuth:    backendAuthMode(os.Getenv("BACKEND_AUTH")),
+		OIDCAudience:   os.Getenv("OIDC_AUDIENCE"),
+		ClientCertFile: os.Getenv("CLIENT_CERT_FILE"),
+		ClientKeyFile:  os.Getenv("CLIENT_KEY_FILE"),
+		ClientCAFile:   os.Getenv("CLIENT_CA_FILE"),
+
+		BatchReactions: os.Getenv("BATCH_REACTIONS") == "true",
+		CycleStartDay:  cycleStartDay,
+		DebugSimulator: os.Getenv("DEBUG_SIMULATOR") == "true",
+		PprofEnabled:   os.Getenv("PPROF_ENABLED") == "true",
+	}
+}
+
+// backendAuthMode normalizes the configured backend auth mode, defaulting to the shared secret
+func backendAuthMode(mode string) string {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		return BackendAuthSecret
+	}
+	return mode
+}
+
+// splitList splits a comma-separated value, trimming and skipping blanks
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
+}
+
+// idSet converts a list of chat IDs to a lookup set, skipping blanks
+func idSet(ids []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			set[id] = true
+		}
+	}
+	return set
 }
 
 // TimingResult holds timing information for operations
@@ -1017,8 +1971,11 @@ type TimingResult struct {
 }
 
 // apiCallWithTiming makes HTTP requests to the SpendWise API and returns timing info
-func apiCallWithTiming(method, endpoint string, body interface{}) (TimingResult, error) {
+func (b *botInstance) apiCallWithTiming(method, endpoint string, body interface{}) (TimingResult, error) {
 	startTime := time.Now()
+	if err := b.checkEndpointSupported(endpoint); err != nil {
+		return TimingResult{}, err
+	}
 
 	var reqBody []byte
 	var err error
@@ -1030,46 +1987,23 @@ func apiCallWithTiming(method, endpoint string, body interface{}) (TimingResult,
 		}
 	}
 
-	url := config.APIUrl + endpoint
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(reqBody))
+	url := b.apiURL + endpoint
+	req, err := http.NewRequestWithContext(b.context(), method, url, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return TimingResult{}, fmt.Errorf("failed to create request: %v", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set(HeaderAPISecret, config.APISecret)
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	if err := b.setBackendHeaders(req); err != nil {
+		return TimingResult{}, err
 	}
 
-	resp, err := client.Do(req)
+	resp, respBody, err := b.doBackendRequest(req, method, endpoint)
 	if err != nil {
-		return TimingResult{}, fmt.Errorf("request failed: %v", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return TimingResult{}, fmt.Errorf("failed to read response: %v", err)
+		return TimingResult{}, err
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		// Try to parse error response for better error messages
-		var errorResp struct {
-			Error   string `json:"error"`
-			Details string `json:"details"`
-		}
-
-		if json.Unmarshal(respBody, &errorResp) == nil && errorResp.Error != "" {
-			errorMsg := errorResp.Error
-			if errorResp.Details != "" {
-				errorMsg += ": " + errorResp.Details
-			}
-			return TimingResult{}, fmt.Errorf("%s", errorMsg)
-		}
-
-		return TimingResult{}, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return TimingResult{}, responseError(resp.StatusCode, respBody)
 	}
 
 	apiDuration := time.Since(startTime)
@@ -1077,9 +2011,12 @@ func apiCallWithTiming(method, endpoint string, body interface{}) (TimingResult,
 }
 
 // apiCall makes HTTP requests to the SpendWise API (legacy function for backward compatibility)
-func apiCall(method, endpoint string, body interface{}) ([]byte, error) {
+func (b *botInstance) apiCall(method, endpoint string, body interface{}) ([]byte, error) {
 	startTime := time.Now()
-	log.Printf("🌐 Starting API call: %s %s", method, endpoint)
+	debugf("🌐 Starting API call: %s %s", method, endpoint)
+	if err := b.checkEndpointSupported(endpoint); err != nil {
+		return nil, err
+	}
 
 	defer func() {
 		duration := time.Since(startTime)
@@ -1097,107 +2034,51 @@ func apiCall(method, endpoint string, body interface{}) ([]byte, error) {
 		}
 	}
 
-	url := config.APIUrl + endpoint
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(reqBody))
+	url := b.apiURL + endpoint
+	req, err := http.NewRequestWithContext(b.context(), method, url, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set(HeaderAPISecret, config.APISecret)
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	if err := b.setBackendHeaders(req); err != nil {
+		return nil, err
 	}
 
-	resp, err := client.Do(req)
+	resp, respBody, err := b.doBackendRequest(req, method, endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %v", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
+		return nil, err
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		// Try to parse error response for better error messages
-		var errorResp struct {
-			Error   string `json:"error"`
-			Details string `json:"details"`
-		}
-
-		if json.Unmarshal(respBody, &errorResp) == nil && errorResp.Error != "" {
-			errorMsg := errorResp.Error
-			if errorResp.Details != "" {
-				errorMsg += ": " + errorResp.Details
-			}
-			return nil, fmt.Errorf("%s", errorMsg)
-		}
-
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return nil, responseError(resp.StatusCode, respBody)
 	}
 
 	return respBody, nil
 }
 
-// sendReaction sends a reaction to a specific message
-func sendReaction(chatID int64, messageID int, emoji string) error {
-	startTime := time.Now()
-	log.Printf("👍 Starting reaction send: %s to message %d", emoji, messageID)
-
-	defer func() {
-		duration := time.Since(startTime)
-		log.Printf("⏱️ Reaction send completed in %d ms (%.3f seconds)",
-			duration.Milliseconds(), duration.Seconds())
-	}()
-
-	// Create the reaction payload according to Telegram Bot API
-	payload := map[string]interface{}{
-		"chat_id":    chatID,
-		"message_id": messageID,
-		"reaction": []map[string]interface{}{
-			{
-				"type":  "emoji",
-				"emoji": emoji,
-			},
-		},
-	}
-
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal reaction payload: %v", err)
-	}
-
-	// Create HTTP request to Telegram Bot API
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/setMessageReaction", config.BotToken)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return fmt.Errorf("failed to create reaction request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("reaction request failed: %v", err)
+// responseError turns a non-2xx API response into an error, preferring the
+// backend's own error message. 5xx responses are marked as the server being
+// unavailable so callers can retry them later.
+func responseError(status int, respBody []byte) error {
+	var errorResp struct {
+		Error   string `json:"error"`
+		Details string `json:"details"`
+		Code    string `json:"code"` // machine-readable, e.g. duplicate_expense
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read reaction response: %v", err)
+	var err error
+	if json.Unmarshal(respBody, &errorResp) == nil && errorResp.Error != "" {
+		errorMsg := errorResp.Error
+		if errorResp.Details != "" {
+			errorMsg += ": " + errorResp.Details
+		}
+		err = errBackend{Status: status, Code: errorResp.Code, Message: errorMsg}
+	} else {
+		err = errBackend{Status: status, Message: fmt.Sprintf("API error (%d): %s", status, string(respBody))}
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("reaction API error (%d): %s", resp.StatusCode, string(respBody))
+	if status >= 500 {
+		return errBackendUnavailable{cause: err}
 	}
-
-	log.Printf("Reaction sent successfully: %s to message %d", emoji, messageID)
-	return nil
+	return err
 }
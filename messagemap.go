@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// MessageLinkTTL is how long a Telegram message stays linked to the entity it
+// created or announced; the shared store expires links on its own after that.
+const MessageLinkTTL = 30 * 24 * time.Hour
+
+// entityKind names the backend collection a message points at
+type entityKind string
+
+const (
+	EntityExpense  entityKind = "expense"
+	EntityReminder entityKind = "reminder"
+)
+
+// messageRef identifies one Telegram message
+type messageRef struct {
+	ChatID    int64 `json:"chatId"`
+	MessageID int   `json:"messageId"`
+}
+
+func messageLinkKey(chatID int64, messageID int) string {
+	return fmt.Sprintf("msg-link:%d:%d", chatID, messageID)
+}
+
+func entityLinksKey(kind entityKind, entityID string) string {
+	return fmt.Sprintf("entity-msgs:%s:%s", kind, entityID)
+}
+
+// linkMessage records that a message corresponds to a backend entity, in both
+// directions: chat+message → entity for replies, entity → messages for syncing
+// edits and deletes made elsewhere.
+func linkMessage(chatID int64, messageID int, kind entityKind, entityID string) {
+	if err := store.Set(messageLinkKey(chatID, messageID), string(kind)+":"+entityID, MessageLinkTTL); err != nil {
+		log.Printf("⚠️ Failed to link message %d in ChatID %d to %s %s: %v", messageID, chatID, kind, entityID, err)
+		return
+	}
+
+	refs := append(linkedMessages(kind, entityID), messageRef{ChatID: chatID, MessageID: messageID})
+	data, _ := json.Marshal(refs)
+	if err := store.Set(entityLinksKey(kind, entityID), string(data), MessageLinkTTL); err != nil {
+		log.Printf("⚠️ Failed to index messages for %s %s: %v", kind, entityID, err)
+	}
+}
+
+// linkedEntity returns the entity of the given kind behind a message, if any
+func linkedEntity(chatID int64, messageID int, kind entityKind) (string, bool) {
+	value, ok, err := store.Get(messageLinkKey(chatID, messageID))
+	if err != nil {
+		log.Printf("⚠️ Message link lookup failed for message %d in ChatID %d: %v", messageID, chatID, err)
+		return "", false
+	}
+	gotKind, entityID, found := strings.Cut(value, ":")
+	if !ok || !found || entityKind(gotKind) != kind {
+		return "", false
+	}
+	return entityID, true
+}
+
+// linkedMessages returns every message still linked to an entity
+func linkedMessages(kind entityKind, entityID string) []messageRef {
+	value, ok, err := store.Get(entityLinksKey(kind, entityID))
+	if err != nil || !ok {
+		return nil
+	}
+	var refs []messageRef
+	if err := json.Unmarshal([]byte(value), &refs); err != nil {
+		log.Printf("⚠️ Corrupt message index for %s %s: %v", kind, entityID, err)
+		return nil
+	}
+	return refs
+}
+
+// unlinkEntity forgets all messages linked to an entity, e.g. once it is deleted
+func unlinkEntity(kind entityKind, entityID string) {
+	for _, ref := range linkedMessages(kind, entityID) {
+		store.Delete(messageLinkKey(ref.ChatID, ref.MessageID))
+	}
+	if err := store.Delete(entityLinksKey(kind, entityID)); err != nil {
+		log.Printf("⚠️ Failed to unlink messages for %s %s: %v", kind, entityID, err)
+	}
+}
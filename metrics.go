@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricsRegistry holds process-local gauges, counters and histograms exposed on
+// /metrics in the Prometheus text format. Label sets are encoded into the series name.
+type metricsRegistry struct {
+	mu         sync.Mutex
+	gauges     map[string]float64
+	counters   map[string]float64
+	histograms map[string]*histogram
+}
+
+var metrics = &metricsRegistry{
+	gauges:     make(map[string]float64),
+	counters:   make(map[string]float64),
+	histograms: make(map[string]*histogram),
+}
+
+// latencyBuckets are the upper bounds, in seconds, of latency histograms
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// histogram counts observations into cumulative buckets
+type histogram struct {
+	name   string
+	labels []string
+	counts []uint64 // per bucket in latencyBuckets, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// metricName builds a series name from a metric name and label key/value pairs
+func metricName(name string, labels ...string) string {
+	if len(labels) < 2 {
+		return name
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// setGauge records the current value of a gauge series
+func setGauge(name string, value float64, labels ...string) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.gauges[metricName(name, labels...)] = value
+}
+
+// incCounter increments a counter series by one
+func incCounter(name string, labels ...string) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.counters[metricName(name, labels...)]++
+}
+
+// observeLatency records a duration in the latency histogram of a series
+func observeLatency(name string, seconds float64, labels ...string) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	series := metricName(name, labels...)
+	h, ok := metrics.histograms[series]
+	if !ok {
+		h = &histogram{name: name, labels: labels, counts: make([]uint64, len(latencyBuckets))}
+		metrics.histograms[series] = h
+	}
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// lines renders the histogram's _bucket, _sum and _count series
+func (h *histogram) lines() []string {
+	lines := make([]string, 0, len(latencyBuckets)+3)
+	var cumulative uint64
+	for i, bound := range latencyBuckets {
+		cumulative += h.counts[i]
+		labels := append(append([]string(nil), h.labels...), "le", strconv.FormatFloat(bound, 'g', -1, 64))
+		lines = append(lines, fmt.Sprintf("%s %d", metricName(h.name+"_bucket", labels...), cumulative))
+	}
+	inf := append(append([]string(nil), h.labels...), "le", "+Inf")
+	lines = append(lines, fmt.Sprintf("%s %d", metricName(h.name+"_bucket", inf...), h.count))
+	lines = append(lines, fmt.Sprintf("%s %g", metricName(h.name+"_sum", h.labels...), h.sum))
+	lines = append(lines, fmt.Sprintf("%s %d", metricName(h.name+"_count", h.labels...), h.count))
+	return lines
+}
+
+// handleMetrics renders every recorded series, sorted for stable output; histogram
+// buckets keep their ascending order after the plain series
+func handleMetrics(c *gin.Context) {
+	metrics.mu.Lock()
+	lines := make([]string, 0, len(metrics.gauges)+len(metrics.counters))
+	for series, value := range metrics.gauges {
+		lines = append(lines, fmt.Sprintf("%s %g", series, value))
+	}
+	for series, value := range metrics.counters {
+		lines = append(lines, fmt.Sprintf("%s %g", series, value))
+	}
+	sort.Strings(lines)
+	for _, series := range mapKeys(metrics.histograms) {
+		lines = append(lines, metrics.histograms[series].lines()...)
+	}
+	metrics.mu.Unlock()
+
+	c.String(http.StatusOK, strings.Join(lines, "\n")+"\n")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// MaxWebhookBodyBytes is well above any real Telegram update or Pub/Sub envelope
+	MaxWebhookBodyBytes = 1 << 20
+	// MaxInternalBodyBytes bounds calls from the SpendWise backend
+	MaxInternalBodyBytes = 64 << 10
+	// MaxTelegramMessageLength is Telegram's limit for a single text message
+	MaxTelegramMessageLength = 4096
+)
+
+// jsonBody only admits application/json requests and caps the body at max bytes
+func jsonBody(max int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.ContentType() != "application/json" {
+			log.Printf("❌ Rejecting %s %s with content type %q from IP: %s", c.Request.Method, c.Request.URL.Path, c.ContentType(), c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "content type must be application/json"})
+			return
+		}
+		if c.Request.ContentLength > max {
+			log.Printf("❌ Rejecting %s %s with %d byte body from IP: %s", c.Request.Method, c.Request.URL.Path, c.Request.ContentLength, c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("request body exceeds %d bytes", max)})
+			return
+		}
+		// Also enforced while reading, for chunked bodies without a Content-Length
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, max)
+		c.Next()
+	}
+}
+
+// bindJSON decodes the request body into v and responds with a descriptive 400
+// (or 413) when it cannot. strict rejects fields v does not declare; Telegram
+// updates are decoded leniently since the Bot API keeps adding fields.
+func bindJSON(c *gin.Context, v interface{}, strict bool) bool {
+	decoder := json.NewDecoder(c.Request.Body)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	err := decoder.Decode(v)
+	if err == nil && decoder.More() {
+		err = errors.New("request body must contain a single JSON object")
+	}
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit)})
+		return false
+	}
+
+	message := describeJSONError(err)
+	log.Printf("❌ Invalid JSON body on %s from IP %s: %s", c.Request.URL.Path, c.ClientIP(), message)
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": message})
+	return false
+}
+
+// describeJSONError turns a decoding error into a message for the API caller
+func describeJSONError(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return "request body is empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "request body is truncated JSON"
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		return fmt.Sprintf("field %q must be of type %s", typeErr.Field, typeErr.Type)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return "unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+	}
+	return err.Error()
+}
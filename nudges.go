@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	NudgeInterval = time.Hour
+	// Nudges are only sent between these hours, keeping nights quiet
+	NudgeSendFromHour  = 9
+	NudgeSendUntilHour = 21
+	// NudgeStateTTL remembers that a day was already nudged for
+	NudgeStateTTL = 48 * time.Hour
+	// MaxNudgeMuteDays bounds /nudges mute
+	MaxNudgeMuteDays = 90
+)
+
+// runMissedDayNudges reminds opted-in chats that logged nothing yesterday
+func (b *botInstance) runMissedDayNudges() {
+	now := time.Now()
+	if now.Hour() < NudgeSendFromHour || now.Hour() >= NudgeSendUntilHour {
+		log.Printf("🌙 Skipping missed-day nudges outside sending hours")
+		return
+	}
+
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	nudged := 0
+	for _, chatIDStr := range b.tenant.allowedChats() {
+		chatID, err := strconv.ParseInt(chatIDStr, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		session := viewSession(chatID)
+		if !session.NudgesEnabled || now.Before(session.NudgesMutedUntil) {
+			continue
+		}
+
+		claimed, err := store.SetNX(fmt.Sprintf("nudged:%d:%s", chatID, yesterday), instanceID, NudgeStateTTL)
+		if err != nil || !claimed {
+			continue
+		}
+
+		params := url.Values{}
+		params.Set("from", yesterday)
+		params.Set("to", yesterday)
+		params.Set("telegramChatId", chatIDStr)
+		expenses, err := b.fetchExpenses(params)
+		if err != nil {
+			log.Printf("❌ Missed-day check failed for ChatID %d: %v", chatID, err)
+			store.Delete(fmt.Sprintf("nudged:%d:%s", chatID, yesterday)) // retry next run
+			continue
+		}
+		if len(expenses) > 0 {
+			continue
+		}
+
+		reply := tgbotapi.NewMessage(chatID, "👋 Nothing logged for yesterday. Log anything for yesterday? Reply with `yesterday <item> <amount>`\n\n/nudges off to stop these")
+		reply.ParseMode = "Markdown"
+		if _, err := b.sendPaced(chatID, reply); err != nil {
+			log.Printf("❌ Failed to send missed-day nudge to ChatID %d: %v", chatID, err)
+			continue
+		}
+		nudged++
+	}
+
+	log.Printf("👋 Missed-day nudge run finished - %d chats nudged", nudged)
+}
+
+// handleNudgesCommand manages the opt-in: /nudges on|off|mute <days>
+func (b *botInstance) handleNudgesCommand(msg *tgbotapi.Message) {
+	args := strings.Fields(msg.Text)
+	var response string
+
+	switch {
+	case len(args) == 2 && args[1] == "on":
+		updateSession(msg.Chat.ID, func(s *chatSession) {
+			s.NudgesEnabled = true
+			s.NudgesMutedUntil = time.Time{}
+		})
+		response = "👋 I'll nudge you when a day goes by without any expenses."
+	case len(args) == 2 && args[1] == "off":
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.NudgesEnabled = false })
+		response = "🔕 Missed-day nudges turned off."
+	case len(args) == 3 && args[1] == "mute":
+		days, err := strconv.Atoi(args[2])
+		if err != nil || days < 1 || days > MaxNudgeMuteDays {
+			response = fmt.Sprintf("❌ Mute for 1-%d days, e.g. /nudges mute 7", MaxNudgeMuteDays)
+			break
+		}
+		until := time.Now().AddDate(0, 0, days)
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.NudgesMutedUntil = until })
+		response = fmt.Sprintf("🔇 Nudges muted until %s.", formatterFor(msg.Chat.ID).Date(until))
+	default:
+		session := viewSession(msg.Chat.ID)
+		status := "off"
+		if session.NudgesEnabled {
+			status = "on"
+			if time.Now().Before(session.NudgesMutedUntil) {
+				status += ", muted until " + formatterFor(msg.Chat.ID).Date(session.NudgesMutedUntil)
+			}
+		}
+		response = "Missed-day nudges: " + status + "\n\n" +
+			"• /nudges on - Remind me when I logged nothing yesterday\n" +
+			"• /nudges off - Stop reminding me\n" +
+			"• /nudges mute 7 - Pause for a few days"
+	}
+
+	log.Printf("👋 Nudge settings command from ChatID %d: %s", msg.Chat.ID, strings.Join(args[1:], " "))
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
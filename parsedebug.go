@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/parser"
+)
+
+// MaxParseDebugLines caps the lines explained by one /parse
+const MaxParseDebugLines = 20
+
+// amountCount returns how many numbers on an expense line are added up as its amount
+func amountCount(line string, decimalComma bool) int {
+	line, _ = parser.SplitNote(line)
+	count := 0
+	for _, part := range strings.Fields(line) {
+		if amount, ok := parser.ParseNumber(part, decimalComma); ok && amount > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// explainParse describes how handleMessage would read text, line by line
+func (b *botInstance) explainParse(msg *tgbotapi.Message, text string) string {
+	opts := parseOptions(msg.Chat.ID)
+	formatter := formatterFor(msg.Chat.ID)
+	header := "🔍 How I'd read this (nothing was saved):\n\n"
+
+	if reason := quickExpenseRejection(text, opts.DecimalComma); reason != "" && containsNumber(text, opts.DecimalComma) {
+		return header + "Not an expense - " + reason + ". Start it with /e to log it anyway."
+	}
+	// Single-line messages may take the amount-only or description-only path
+	if amount, ok := parseBareAmount(text, opts.DecimalComma); ok {
+		return header + fmt.Sprintf("Just an amount (%s) - I'd ask what it was for.", formatter.Currency(amount))
+	}
+	if !containsNumber(text, opts.DecimalComma) {
+		return header + "No amount found - in a private chat I'd ask how much a short description like this was, otherwise it isn't an expense."
+	}
+
+	entries, lineErrs := parser.Parse(text, opts)
+	explained := make(map[int]string)
+	for _, entry := range entries {
+		lines := []string{
+			"• Description: " + entry.Description,
+			"• Amount: " + formatter.Currency(entry.Amount),
+			"• Date: " + formatter.Date(entry.Date),
+		}
+		switch {
+		case entry.Account != "":
+			lines = append(lines, "• Account: "+entry.Account)
+		case defaultAccountFor(msg.Chat.ID) != "":
+			lines = append(lines, "• Account: "+defaultAccountFor(msg.Chat.ID)+" (your default)")
+		}
+		if entry.EntryType != "" {
+			lines = append(lines, "• Type: "+entry.EntryType+" (money back, netted against spend)")
+		}
+		if entry.Note != "" {
+			lines = append(lines, "• Note: "+entry.Note)
+		}
+		lines = append(lines, "• Category: assigned by the SpendWise server")
+
+		source := strings.Split(text, "\n")[entry.Line-1]
+		if n := amountCount(source, opts.DecimalComma); n > 1 {
+			lines = append(lines, fmt.Sprintf("⚠️ %d numbers were added up into the amount", n))
+		}
+		if config.MaxPlausibleAmount > 0 && entry.Amount > config.MaxPlausibleAmount {
+			lines = append(lines, "⚠️ Above "+formatter.Currency(config.MaxPlausibleAmount)+", so I'd ask you to confirm it")
+		}
+		if err := validateExpenseInput(b.expenseFromEntry(entry, msg)); err != nil {
+			lines = append(lines, "❌ "+err.Error())
+		}
+		explained[entry.Line] = strings.Join(lines, "\n")
+	}
+	for _, lineErr := range lineErrs {
+		explained[lineErr.Line] = "❌ " + lineErr.Err.Error()
+	}
+
+	var sections []string
+	for i, line := range strings.Split(text, "\n") {
+		explanation, ok := explained[i+1]
+		if !ok {
+			continue
+		}
+		if len(sections) == MaxParseDebugLines {
+			sections = append(sections, fmt.Sprintf("…and %d more line(s)", len(explained)-MaxParseDebugLines))
+			break
+		}
+		sections = append(sections, fmt.Sprintf("Line %d: %s\n%s", i+1, strings.TrimSpace(line), explanation))
+	}
+	return header + strings.Join(sections, "\n\n")
+}
+
+// handleParseCommand shows how /parse <text> would be logged, without saving it
+func (b *botInstance) handleParseCommand(msg *tgbotapi.Message) {
+	// Keep the text exactly as typed, line breaks included
+	text := ""
+	if i := strings.IndexFunc(msg.Text, unicode.IsSpace); i > 0 {
+		text = strings.TrimSpace(msg.Text[i:])
+	}
+
+	response := "Usage: /parse <text>\n\nShows how an expense message would be read - amounts, description, date and account - without saving anything. Example: /parse yesterday Lunch 250 via card"
+	if text != "" {
+		response = b.explainParse(msg, text)
+		log.Printf("🔍 Explained parse of %d line(s) for ChatID %d", strings.Count(text, "\n")+1, msg.Chat.ID)
+	}
+	if runes := []rune(response); len(runes) > MaxTelegramMessageLength {
+		response = string(runes[:MaxTelegramMessageLength-1]) + "…"
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// FuzzParseLine checks the guarantees callers rely on for any input: no panics,
+// and a parsed entry always has a positive finite amount, a trimmed non-empty
+// description free of the note separator, a configured account and a known type.
+func FuzzParseLine(f *testing.F) {
+	for _, tc := range lineCorpus {
+		f.Add(tc.line)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		entry, err := ParseLine(line, testOptions)
+		if err != nil {
+			if entry != (Entry{}) {
+				t.Fatalf("ParseLine(%q) returned %+v alongside error %v", line, entry, err)
+			}
+			return
+		}
+
+		if !(entry.Amount > 0) || math.IsInf(entry.Amount, 0) {
+			t.Errorf("ParseLine(%q) amount = %v, want positive and finite", line, entry.Amount)
+		}
+		if entry.Description == "" || entry.Description != strings.TrimSpace(entry.Description) {
+			t.Errorf("ParseLine(%q) description = %q, want non-empty and trimmed", line, entry.Description)
+		}
+		if strings.Contains(entry.Description, NoteSeparator) {
+			t.Errorf("ParseLine(%q) description %q contains the note separator", line, entry.Description)
+		}
+		if entry.Note != strings.TrimSpace(entry.Note) {
+			t.Errorf("ParseLine(%q) note = %q, want trimmed", line, entry.Note)
+		}
+		if entry.Account != "" {
+			if _, ok := MatchAccount(entry.Account, testOptions.Accounts); !ok {
+				t.Errorf("ParseLine(%q) account = %q, want a configured account", line, entry.Account)
+			}
+		}
+		switch entry.EntryType {
+		case "", EntryTypeRefund, EntryTypeCashback, EntryTypeAdjustment:
+		default:
+			t.Errorf("ParseLine(%q) entry type = %q", line, entry.EntryType)
+		}
+		if !entry.Date.Equal(testNow) && !entry.Date.Equal(testYesterday) {
+			t.Errorf("ParseLine(%q) date = %v, want today or yesterday", line, entry.Date)
+		}
+	})
+}
+
+// FuzzParse checks that every non-blank line of a message yields exactly one
+// entry or one error, in line order
+func FuzzParse(f *testing.F) {
+	f.Add("Coffee 50\nBus 20")
+	f.Add("Coffee 50\n\nnonsense\n// 5")
+	for _, tc := range lineCorpus {
+		f.Add(tc.line + "\n" + tc.line)
+	}
+
+	f.Fuzz(func(t *testing.T, text string) {
+		entries, lineErrs := Parse(text, testOptions)
+
+		nonBlank := 0
+		for _, line := range strings.Split(text, "\n") {
+			if strings.TrimSpace(line) != "" {
+				nonBlank++
+			}
+		}
+		if len(entries)+len(lineErrs) != nonBlank {
+			t.Fatalf("Parse(%q) returned %d entries and %d errors for %d non-blank lines",
+				text, len(entries), len(lineErrs), nonBlank)
+		}
+
+		for i := 1; i < len(entries); i++ {
+			if entries[i].Line <= entries[i-1].Line {
+				t.Errorf("Parse(%q) entries out of line order: %d after %d", text, entries[i].Line, entries[i-1].Line)
+			}
+		}
+		for i := 1; i < len(lineErrs); i++ {
+			if lineErrs[i].Line <= lineErrs[i-1].Line {
+				t.Errorf("Parse(%q) errors out of line order: %d after %d", text, lineErrs[i].Line, lineErrs[i-1].Line)
+			}
+		}
+	})
+}
@@ -0,0 +1,327 @@
+// Package parser reads the expense lines users send to the bot, one expense
+// per line:
+//
+//	[yesterday] [refund|cashback|adjust] description amount [amount...] [via <account>] [// note]
+//
+// It knows nothing about Telegram or the SpendWise backend so every supported
+// syntax can be covered by table tests and fuzzing.
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NoteSeparator starts a free-text note on an expense line: "groceries 850 // monthly big shop"
+const NoteSeparator = "//"
+
+const (
+	EntryTypeRefund     = "refund"
+	EntryTypeCashback   = "cashback"
+	EntryTypeAdjustment = "adjustment"
+)
+
+// creditKeywords maps leading keywords to the credit entry type they create
+var creditKeywords = map[string]string{
+	"refund":     EntryTypeRefund,
+	"refunded":   EntryTypeRefund,
+	"cashback":   EntryTypeCashback,
+	"adjust":     EntryTypeAdjustment,
+	"adjustment": EntryTypeAdjustment,
+	"correction": EntryTypeAdjustment,
+}
+
+var (
+	ErrFormat         = errors.New("invalid format - need description and amount")
+	ErrNoAmount       = errors.New("no valid amount found")
+	ErrNoDescription  = errors.New("missing description")
+	ErrAmountTooLarge = errors.New("amount too large")
+	// ErrNegativeAmount keeps a stray minus sign from silently turning spend into a credit
+	ErrNegativeAmount = errors.New("negative amount - start the line with refund or adjust to record money back")
+)
+
+// Entry is one parsed expense line
+type Entry struct {
+	Line        int // 1-based line number within the message
+	Description string
+	Amount      float64
+	Date        time.Time
+	Account     string // "" when the line names no account
+	EntryType   string // EntryTypeRefund, EntryTypeCashback, EntryTypeAdjustment or "" for a normal expense
+	Note        string
+}
+
+// Options carries the context a line is read in
+type Options struct {
+	Now      time.Time // the date expenses are logged for unless a line says "yesterday"
+	Accounts []string  // account names accepted after "via"
+	// DecimalComma reads amounts the way locales like de-DE write them:
+	// "12,50" is twelve and a half and "1.200" is twelve hundred
+	DecimalComma bool
+}
+
+// LineError reports why one line of a message couldn't be parsed
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e LineError) Unwrap() error {
+	return e.Err
+}
+
+// Parse reads one expense per line, skipping blank lines. Lines that can't be
+// parsed are returned as errors alongside the entries that could.
+func Parse(text string, opts Options) ([]Entry, []LineError) {
+	var entries []Entry
+	var lineErrs []LineError
+	for i, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, err := ParseLine(line, opts)
+		if err != nil {
+			lineErrs = append(lineErrs, LineError{Line: i + 1, Err: err})
+			continue
+		}
+		entry.Line = i + 1
+		entries = append(entries, entry)
+	}
+	return entries, lineErrs
+}
+
+// ParseLine reads a single expense line
+func ParseLine(line string, opts Options) (Entry, error) {
+	line, note := SplitNote(strings.TrimSpace(line))
+	line, account := SplitAccount(line, opts.Accounts)
+	line, date := SplitDateKeyword(line, opts.Now)
+	line, entryType := SplitCreditKeyword(line)
+	if entryType != "" {
+		// "adjust -200 groceries returned" is the same credit as "adjust 200 ..."
+		line = unsignAmounts(line, opts.DecimalComma)
+	}
+
+	amount, description, err := ParseAmounts(line, opts.DecimalComma)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		Description: description,
+		Amount:      amount,
+		Date:        date,
+		Account:     account,
+		EntryType:   entryType,
+		Note:        note,
+	}, nil
+}
+
+// ParseDescription reads the reply to "what was this amount for?": the same
+// keywords, account and note as an expense line, but every remaining word,
+// numbers included, is the description and amount is taken as given
+func ParseDescription(line string, amount float64, opts Options) (Entry, error) {
+	line, note := SplitNote(strings.TrimSpace(line))
+	line, account := SplitAccount(line, opts.Accounts)
+	line, date := SplitDateKeyword(line, opts.Now)
+	line, entryType := SplitCreditKeyword(line)
+
+	description := strings.Join(strings.Fields(line), " ")
+	switch {
+	case description == "":
+		return Entry{}, ErrNoDescription
+	case !(amount > 0) || math.IsInf(amount, 0):
+		return Entry{}, ErrNoAmount
+	}
+	return Entry{
+		Description: description,
+		Amount:      amount,
+		Date:        date,
+		Account:     account,
+		EntryType:   entryType,
+		Note:        note,
+	}, nil
+}
+
+// ParseAmounts splits "description amount [amount...]" into the description and
+// the sum of every positive number in it, wherever the numbers appear. A
+// negative number is an error rather than description: credits are recorded
+// with a keyword, not a minus sign.
+func ParseAmounts(text string, decimalComma bool) (float64, string, error) {
+	parts := strings.Fields(text)
+	if len(parts) < 2 {
+		return 0, "", ErrFormat
+	}
+
+	var amounts []float64
+	var descriptionParts []string
+
+	// Separate amounts from description
+	for _, part := range parts {
+		amount, ok := ParseNumber(part, decimalComma)
+		switch {
+		case ok && amount > 0 && !math.IsInf(amount, 0):
+			amounts = append(amounts, amount)
+		case ok && amount < 0 && !math.IsInf(amount, 0):
+			return 0, "", ErrNegativeAmount
+		default:
+			descriptionParts = append(descriptionParts, part)
+		}
+	}
+
+	if len(amounts) == 0 {
+		return 0, "", ErrNoAmount
+	}
+	if len(descriptionParts) == 0 {
+		return 0, "", ErrNoDescription
+	}
+
+	var total float64
+	for _, amount := range amounts {
+		total += amount
+	}
+	if math.IsInf(total, 0) {
+		return 0, "", ErrAmountTooLarge
+	}
+	return total, strings.Join(descriptionParts, " "), nil
+}
+
+// ParseNumber reads one number as typed in the user's locale. Grouped thousands
+// are accepted in either style ("12,000" and "1,20,000", or "1.200" with a
+// decimal comma); a separator that can't be grouping is only read as the
+// decimal point when it is the locale's, so "12,50" is not mistaken for 1250.
+func ParseNumber(token string, decimalComma bool) (float64, bool) {
+	decimal, group := ".", ","
+	if decimalComma {
+		decimal, group = ",", "."
+	}
+
+	sign := ""
+	if strings.HasPrefix(token, "-") {
+		sign, token = "-", token[1:]
+	}
+
+	normalized := token
+	whole, fraction, hasFraction := strings.Cut(token, decimal)
+	switch {
+	case strings.Contains(whole, group) && validGrouping(whole, group):
+		normalized = strings.ReplaceAll(whole, group, "")
+		if hasFraction {
+			normalized += "." + fraction
+		}
+	case strings.Contains(token, group) && !decimalComma:
+		return 0, false
+	case decimalComma:
+		// "12.5" has no valid grouping, so it is read with a decimal point as typed
+		normalized = strings.Replace(token, decimal, ".", 1)
+	}
+
+	value, err := strconv.ParseFloat(sign+normalized, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// unsignAmounts drops the minus sign from negative numbers on a credit line
+func unsignAmounts(line string, decimalComma bool) string {
+	parts := strings.Fields(line)
+	for i, part := range parts {
+		if value, ok := ParseNumber(part, decimalComma); ok && value < 0 {
+			parts[i] = strings.TrimPrefix(part, "-")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// validGrouping reports whether whole is digits grouped by sep in thousands
+// ("1,234,567") or the Indian lakh style ("12,34,567")
+func validGrouping(whole, sep string) bool {
+	groups := strings.Split(whole, sep)
+	for _, group := range groups {
+		if group == "" || strings.Trim(group, "0123456789") != "" {
+			return false
+		}
+	}
+
+	first, middle, last := groups[0], groups[1:len(groups)-1], groups[len(groups)-1]
+	if len(first) > 3 || len(last) != 3 {
+		return false
+	}
+	for _, group := range middle {
+		if len(group) != len(middle[0]) || (len(group) != 2 && len(group) != 3) {
+			return false
+		}
+	}
+	return len(middle) == 0 || len(middle[0]) == 3 || len(first) <= 2
+}
+
+// SplitNote removes a trailing "// note" from an expense line so numbers and
+// keywords inside the note don't affect parsing
+func SplitNote(line string) (string, string) {
+	before, note, found := strings.Cut(line, NoteSeparator)
+	if !found {
+		return line, ""
+	}
+	return strings.TrimSpace(before), strings.TrimSpace(note)
+}
+
+// MatchAccount finds name among accounts, ignoring case, and returns it as configured
+func MatchAccount(name string, accounts []string) (string, bool) {
+	for _, account := range accounts {
+		if strings.EqualFold(account, name) {
+			return account, true
+		}
+	}
+	return "", false
+}
+
+// SplitAccount strips a trailing "via <account>" from an expense line
+func SplitAccount(line string, accounts []string) (string, string) {
+	parts := strings.Fields(line)
+	if len(parts) < 3 || !strings.EqualFold(parts[len(parts)-2], "via") {
+		return line, ""
+	}
+	account, ok := MatchAccount(parts[len(parts)-1], accounts)
+	if !ok {
+		return line, ""
+	}
+	return strings.Join(parts[:len(parts)-2], " "), account
+}
+
+// SplitDateKeyword strips a leading "yesterday" from an expense line and returns
+// the date the expense should be logged for
+func SplitDateKeyword(line string, now time.Time) (string, time.Time) {
+	parts := strings.Fields(line)
+	if len(parts) >= 2 && strings.EqualFold(parts[0], "yesterday") {
+		return strings.Join(parts[1:], " "), now.AddDate(0, 0, -1)
+	}
+	return line, now
+}
+
+// LeadingKeyword reports whether word is one of the keywords an expense line may
+// start with: "yesterday" or a refund, cashback or adjustment keyword
+func LeadingKeyword(word string) bool {
+	_, credit := creditKeywords[strings.ToLower(word)]
+	return credit || strings.EqualFold(word, "yesterday")
+}
+
+// SplitCreditKeyword strips a leading refund, cashback or adjustment keyword from an expense line
+// and returns the credit entry type it stands for
+func SplitCreditKeyword(line string) (string, string) {
+	parts := strings.Fields(line)
+	if len(parts) < 2 {
+		return line, ""
+	}
+	entryType, ok := creditKeywords[strings.ToLower(parts[0])]
+	if !ok {
+		return line, ""
+	}
+	return strings.Join(parts[1:], " "), entryType
+}
@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	testNow       = time.Date(2026, 3, 15, 10, 30, 0, 0, time.UTC)
+	testYesterday = testNow.AddDate(0, 0, -1)
+	testOptions   = Options{Now: testNow, Accounts: []string{"cash", "card", "HDFC"}}
+)
+
+// lineCorpus covers every syntax an expense line supports. It also seeds the fuzzers.
+var lineCorpus = []struct {
+	name  string
+	line  string
+	want  Entry
+	error error
+}{
+	// description and amount
+	{name: "description then amount", line: "Coffee 50",
+		want: Entry{Description: "Coffee", Amount: 50}},
+	{name: "amount then description", line: "50 Coffee",
+		want: Entry{Description: "Coffee", Amount: 50}},
+	{name: "amount between words", line: "Auto 120 to office",
+		want: Entry{Description: "Auto to office", Amount: 120}},
+	{name: "decimal amount", line: "Bus ticket 8.50",
+		want: Entry{Description: "Bus ticket", Amount: 8.5}},
+	{name: "leading dot amount", line: "Candy .5",
+		want: Entry{Description: "Candy", Amount: 0.5}},
+	{name: "several amounts are summed", line: "Groceries 100 250.5 49.5",
+		want: Entry{Description: "Groceries", Amount: 400}},
+	{name: "extra whitespace is collapsed", line: "  Lunch \t with   team   450  ",
+		want: Entry{Description: "Lunch with team", Amount: 450}},
+	{name: "unicode description", line: "चाय 20",
+		want: Entry{Description: "चाय", Amount: 20}},
+	{name: "exponent amount", line: "Server 1e3",
+		want: Entry{Description: "Server", Amount: 1000}},
+	{name: "currency symbol stays in description", line: "Pizza ₹300 300",
+		want: Entry{Description: "Pizza ₹300", Amount: 300}},
+	{name: "thousands separator", line: "Rent 12,000",
+		want: Entry{Description: "Rent", Amount: 12000}},
+	{name: "indian grouping", line: "Rent 1,20,000.50",
+		want: Entry{Description: "Rent", Amount: 120000.5}},
+	{name: "decimal comma in a decimal point locale", line: "Coffee 12,50",
+		error: ErrNoAmount},
+	{name: "mixed grouping is not an amount", line: "Rent 1,234,56",
+		error: ErrNoAmount},
+	{name: "zero is not an amount", line: "Water 0",
+		error: ErrNoAmount},
+	{name: "negative needs a keyword", line: "-200 groceries returned",
+		error: ErrNegativeAmount},
+	{name: "negative beside amount needs a keyword", line: "Pizza 300 -50",
+		error: ErrNegativeAmount},
+	{name: "zero beside amount becomes description", line: "Plan 0 199",
+		want: Entry{Description: "Plan 0", Amount: 199}},
+	{name: "infinity is not an amount", line: "Coffee Inf",
+		error: ErrNoAmount},
+	{name: "nan is not an amount", line: "Coffee NaN",
+		error: ErrNoAmount},
+	{name: "overflowing sum", line: "Loan 1e308 1e308",
+		error: ErrAmountTooLarge},
+
+	// format errors
+	{name: "empty line", line: "", error: ErrFormat},
+	{name: "single word", line: "Coffee", error: ErrFormat},
+	{name: "single amount", line: "50", error: ErrFormat},
+	{name: "words only", line: "Coffee with Ravi", error: ErrNoAmount},
+	{name: "amounts only", line: "50 60", error: ErrNoDescription},
+
+	// yesterday
+	{name: "yesterday", line: "yesterday Dinner 800",
+		want: Entry{Description: "Dinner", Amount: 800, Date: testYesterday}},
+	{name: "yesterday any case", line: "Yesterday Dinner 800",
+		want: Entry{Description: "Dinner", Amount: 800, Date: testYesterday}},
+	{name: "yesterday only leading", line: "Dinner yesterday 800",
+		want: Entry{Description: "Dinner yesterday", Amount: 800}},
+	{name: "yesterday alone", line: "yesterday", error: ErrFormat},
+	{name: "yesterday with amount only", line: "yesterday 800", error: ErrFormat},
+
+	// credits
+	{name: "refund", line: "refund Amazon 499",
+		want: Entry{Description: "Amazon", Amount: 499, EntryType: EntryTypeRefund}},
+	{name: "refunded", line: "Refunded shoes 1200",
+		want: Entry{Description: "shoes", Amount: 1200, EntryType: EntryTypeRefund}},
+	{name: "cashback", line: "CASHBACK card 25",
+		want: Entry{Description: "card", Amount: 25, EntryType: EntryTypeCashback}},
+	{name: "refund keyword later is description", line: "Amazon refund 499",
+		want: Entry{Description: "Amazon refund", Amount: 499}},
+	{name: "refund with amount only", line: "refund 499", error: ErrFormat},
+	{name: "yesterday refund", line: "yesterday refund Uber 150",
+		want: Entry{Description: "Uber", Amount: 150, Date: testYesterday, EntryType: EntryTypeRefund}},
+	{name: "adjust", line: "adjust groceries returned 200",
+		want: Entry{Description: "groceries returned", Amount: 200, EntryType: EntryTypeAdjustment}},
+	{name: "adjust negative amount", line: "Adjust -200 groceries returned",
+		want: Entry{Description: "groceries returned", Amount: 200, EntryType: EntryTypeAdjustment}},
+	{name: "refund negative grouped amount", line: "refund -1,200 shoes",
+		want: Entry{Description: "shoes", Amount: 1200, EntryType: EntryTypeRefund}},
+
+	// accounts
+	{name: "via account", line: "Taxi 300 via card",
+		want: Entry{Description: "Taxi", Amount: 300, Account: "card"}},
+	{name: "via account keeps configured case", line: "Taxi 300 VIA hdfc",
+		want: Entry{Description: "Taxi", Amount: 300, Account: "HDFC"}},
+	{name: "via unknown account stays in description", line: "Taxi 300 via paytm",
+		want: Entry{Description: "Taxi via paytm", Amount: 300}},
+	{name: "via not last", line: "Taxi via card 300",
+		want: Entry{Description: "Taxi via card", Amount: 300}},
+	{name: "via account without description", line: "300 via cash", error: ErrFormat},
+
+	// notes
+	{name: "note", line: "Groceries 850 // monthly big shop",
+		want: Entry{Description: "Groceries", Amount: 850, Note: "monthly big shop"}},
+	{name: "numbers in note are ignored", line: "Groceries 850 // 12 items, 3 bags",
+		want: Entry{Description: "Groceries", Amount: 850, Note: "12 items, 3 bags"}},
+	{name: "keywords in note are ignored", line: "Taxi 300 // via card yesterday",
+		want: Entry{Description: "Taxi", Amount: 300, Note: "via card yesterday"}},
+	{name: "note split at first separator", line: "Wifi 999 // see http://isp.example",
+		want: Entry{Description: "Wifi", Amount: 999, Note: "see http://isp.example"}},
+	{name: "empty note", line: "Tea 15 //",
+		want: Entry{Description: "Tea", Amount: 15}},
+	{name: "note only", line: "// 500", error: ErrFormat},
+
+	// everything together
+	{name: "all syntaxes", line: "yesterday cashback Flipkart 40 via card // festive offer",
+		want: Entry{Description: "Flipkart", Amount: 40, Date: testYesterday, Account: "card", EntryType: EntryTypeCashback, Note: "festive offer"}},
+}
+
+func TestParseLine(t *testing.T) {
+	for _, tc := range lineCorpus {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseLine(tc.line, testOptions)
+			if tc.error != nil {
+				if !errors.Is(err, tc.error) {
+					t.Fatalf("ParseLine(%q) error = %v, want %v", tc.line, err, tc.error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLine(%q) unexpected error: %v", tc.line, err)
+			}
+
+			want := tc.want
+			if want.Date.IsZero() {
+				want.Date = testNow
+			}
+			if got != want {
+				t.Errorf("ParseLine(%q)\n got  %+v\n want %+v", tc.line, got, want)
+			}
+		})
+	}
+}
+
+func TestParseNumberDecimalComma(t *testing.T) {
+	tests := []struct {
+		token string
+		want  float64
+		ok    bool
+	}{
+		{"12,50", 12.5, true},
+		{"1.200", 1200, true},
+		{"1.200,50", 1200.5, true},
+		{"1.234.567", 1234567, true},
+		{"12.5", 12.5, true},
+		{"50", 50, true},
+		{"1,2,3", 0, false},
+		{"1.2.3", 0, false},
+	}
+	for _, tc := range tests {
+		got, ok := ParseNumber(tc.token, true)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("ParseNumber(%q, true) = %v, %t, want %v, %t", tc.token, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	opts := testOptions
+	opts.DecimalComma = true
+	entry, err := ParseLine("1.200 groceries", opts)
+	if err != nil || entry.Amount != 1200 || entry.Description != "groceries" {
+		t.Errorf("ParseLine with decimal comma = %+v, %v, want groceries 1200", entry, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	text := "Coffee 50\n\n  \nBus 20 30\nnonsense\nrefund Amazon 499 via cash\n50 60\n"
+	entries, lineErrs := Parse(text, testOptions)
+
+	wantEntries := []Entry{
+		{Line: 1, Description: "Coffee", Amount: 50, Date: testNow},
+		{Line: 4, Description: "Bus", Amount: 50, Date: testNow},
+		{Line: 6, Description: "Amazon", Amount: 499, Date: testNow, Account: "cash", EntryType: EntryTypeRefund},
+	}
+	if len(entries) != len(wantEntries) {
+		t.Fatalf("Parse returned %d entries, want %d: %+v", len(entries), len(wantEntries), entries)
+	}
+	for i := range wantEntries {
+		if entries[i] != wantEntries[i] {
+			t.Errorf("entry %d\n got  %+v\n want %+v", i, entries[i], wantEntries[i])
+		}
+	}
+
+	wantErrs := []string{"line 5: invalid format - need description and amount", "line 7: missing description"}
+	if len(lineErrs) != len(wantErrs) {
+		t.Fatalf("Parse returned %d line errors, want %d: %v", len(lineErrs), len(wantErrs), lineErrs)
+	}
+	for i, want := range wantErrs {
+		if lineErrs[i].Error() != want {
+			t.Errorf("line error %d = %q, want %q", i, lineErrs[i].Error(), want)
+		}
+	}
+	if !errors.Is(lineErrs[1], ErrNoDescription) {
+		t.Errorf("line errors should unwrap to the parse error, got %v", lineErrs[1].Err)
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	for _, text := range []string{"", "\n", "  \n\t\n"} {
+		entries, lineErrs := Parse(text, testOptions)
+		if len(entries) != 0 || len(lineErrs) != 0 {
+			t.Errorf("Parse(%q) = %v, %v, want nothing", text, entries, lineErrs)
+		}
+	}
+}
+
+func TestSplitAccountWithoutAccounts(t *testing.T) {
+	line, account := SplitAccount("Taxi 300 via card", nil)
+	if line != "Taxi 300 via card" || account != "" {
+		t.Errorf("SplitAccount with no accounts = %q, %q, want the line unchanged", line, account)
+	}
+}
+
+func TestMatchAccount(t *testing.T) {
+	accounts := []string{"Cash", "UPI"}
+	if got, ok := MatchAccount("upi", accounts); !ok || got != "UPI" {
+		t.Errorf("MatchAccount(upi) = %q, %t, want UPI, true", got, ok)
+	}
+	if _, ok := MatchAccount("card", accounts); ok {
+		t.Errorf("MatchAccount(card) matched an account that isn't configured")
+	}
+}
+
+func TestParseDescription(t *testing.T) {
+	got, err := ParseDescription("yesterday Lunch with 2 friends via card // team outing", 150, testOptions)
+	want := Entry{Description: "Lunch with 2 friends", Amount: 150, Date: testYesterday, Account: "card", Note: "team outing"}
+	if err != nil || got != want {
+		t.Errorf("ParseDescription = %+v, %v, want %+v", got, err, want)
+	}
+
+	if _, err := ParseDescription("  // just a note", 150, testOptions); !errors.Is(err, ErrNoDescription) {
+		t.Errorf("ParseDescription without description error = %v, want %v", err, ErrNoDescription)
+	}
+	if _, err := ParseDescription("Lunch", 0, testOptions); !errors.Is(err, ErrNoAmount) {
+		t.Errorf("ParseDescription with zero amount error = %v, want %v", err, ErrNoAmount)
+	}
+}
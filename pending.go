@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func (b *botInstance) handlePendingCommand(msg *tgbotapi.Message) {
+	startTime := time.Now()
+	log.Printf("🧾 Starting pending reminders command processing")
+	b.showTyping(msg.Chat.ID)
+
+	payload, err := b.fetchReminderPayload()
+	log.Printf("🧾⏱️ PENDING TIMING: Total=%dms", time.Since(startTime).Milliseconds())
+
+	if err != nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, userErrorText("fetching reminders", err))
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
+			log.Printf(ErrorSendMessage, sendErr)
+		}
+		return
+	}
+
+	payload.Reminders = b.tenant.remindersFor(msg.Chat.ID, payload.Reminders)
+	pending := pendingReminders(payload.Reminders, time.Now())
+	if len(pending) == 0 {
+		log.Printf("🎉 No pending reminders for ChatID: %d", msg.Chat.ID)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Nothing outstanding this month 🎉")
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf("❌ Failed to send 'no pending' message to ChatID %d: %v", msg.Chat.ID, err)
+		}
+		return
+	}
+
+	log.Printf("🧾 Found %d pending of %d reminders for ChatID: %d", len(pending), len(payload.Reminders), msg.Chat.ID)
+	formatter := formatterFor(msg.Chat.ID)
+	response := "🧾 Pending This Month\n\n"
+	var total float64
+	for _, reminder := range pending {
+		response += fmt.Sprintf("  • %s - %s (%s)\n",
+			reminder.Description, formatter.Currency(reminder.Amount), formatDueDate(reminder))
+		total += reminder.Amount
+	}
+	response += fmt.Sprintf("\nTotal outstanding: %s (%d bills)", formatter.Currency(total), len(pending))
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf("❌ Failed to send pending list to ChatID %d: %v", msg.Chat.ID, err)
+	} else {
+		log.Printf("✅ Pending list sent successfully to ChatID: %d", msg.Chat.ID)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// processStart is used to report instance uptime
+var processStart = time.Now()
+
+// handlePingCommand reports latency to the backend and Telegram plus in-process
+// cache and queue depths, for triaging "the bot feels slow" reports. Admin only.
+func (b *botInstance) handlePingCommand(msg *tgbotapi.Message) {
+	if !isAdmin(msg.Chat.ID) {
+		log.Printf("❌ Non-admin ChatID %d tried to use /ping", msg.Chat.ID)
+		b.handleUnknownCommand(msg)
+		return
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("🏓 Pong from %s (bot %s)", instanceID, b.ID))
+	lines = append(lines, fmt.Sprintf("⏳ Uptime: %s", time.Since(processStart).Round(time.Second)))
+
+	if result, err := b.apiCallWithTiming("GET", "/health", nil); err != nil {
+		lines = append(lines, "🌐 Backend: ❌ "+err.Error())
+	} else {
+		lines = append(lines, fmt.Sprintf("🌐 Backend /health: %d ms", result.APITime.Milliseconds()))
+	}
+
+	if stats := apiStatsLines(); len(stats) > 0 {
+		lines = append(lines, "📈 API calls since start:")
+		lines = append(lines, stats...)
+	}
+
+	telegramStart := time.Now()
+	info, err := b.api.GetWebhookInfo()
+	telegramTime := time.Since(telegramStart)
+	if err != nil {
+		lines = append(lines, "✈️ Telegram: ❌ "+err.Error())
+	} else {
+		lines = append(lines, fmt.Sprintf("✈️ Telegram getWebhookInfo: %d ms", telegramTime.Milliseconds()))
+		lines = append(lines, fmt.Sprintf("📬 Pending webhook updates: %d", info.PendingUpdateCount))
+	}
+
+	if mem, ok := store.(*memoryStore); ok {
+		lines = append(lines, fmt.Sprintf("🧠 Coordination store: memory, %d keys", mem.Len()))
+	} else {
+		lines = append(lines, "🧠 Coordination store: Redis")
+	}
+
+	lines = append(lines, fmt.Sprintf("📤 Send queue: %d waiting", b.limiter.queueDepth()))
+
+	floodState.Lock()
+	tracked, muted := len(floodState.recent), len(floodState.mutedUntil)
+	floodState.Unlock()
+	lines = append(lines, fmt.Sprintf("🌊 Flood tracker: %d chats, %d muted", tracked, muted))
+	lines = append(lines, fmt.Sprintf("⏰ Scheduled jobs: %d, leader: %t", len(scheduledJobs), schedulerLeader()))
+
+	log.Printf("🏓 Ping diagnostics sent to admin ChatID: %d", msg.Chat.ID)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, strings.Join(lines, "\n"))
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
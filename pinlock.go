@@ -0,0 +1,243 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// DefaultPINIdle is how long a chat with a PIN stays unlocked without activity
+	DefaultPINIdle = 15 * time.Minute
+	// MaxPINIdleMinutes caps the idle period /pin set accepts
+	MaxPINIdleMinutes = 24 * 60
+	// MaxPINAttempts wrong PINs in a row lock the chat out for PINLockout
+	MaxPINAttempts = 5
+	PINLockout     = 15 * time.Minute
+)
+
+var pinPattern = regexp.MustCompile(`^\d{4}$`)
+
+// pinLock is a chat's /pin set lock. It is stored without a TTL apart from the
+// session, which expires, so the lock only goes away with /pin off.
+type pinLock struct {
+	Hash string        // salted hash of the PIN
+	Idle time.Duration // inactivity after which the chat locks
+}
+
+func pinLockKey(chatID int64) string {
+	return "pin-lock:" + strconv.FormatInt(chatID, 10)
+}
+
+// loadPINLock returns the chat's PIN lock, reporting false when it has none
+func loadPINLock(chatID int64) (pinLock, bool, error) {
+	raw, ok, err := store.Get(pinLockKey(chatID))
+	if err != nil || !ok {
+		return pinLock{}, false, err
+	}
+	var lock pinLock
+	if err := json.Unmarshal([]byte(raw), &lock); err != nil || lock.Hash == "" {
+		return pinLock{}, false, fmt.Errorf("unreadable PIN lock: %v", err)
+	}
+	return lock, true, nil
+}
+
+// pinHash is what the session stores instead of the PIN, salted with the chat ID
+func pinHash(chatID int64, pin string) string {
+	sum := sha256.Sum256([]byte(strconv.FormatInt(chatID, 10) + ":" + pin))
+	return hex.EncodeToString(sum[:])
+}
+
+// pinLocked reports whether the chat was idle for longer than its lock's idle period
+func pinLocked(lock pinLock, s *chatSession, now time.Time) bool {
+	return now.Sub(s.PINLastActive) > lock.Idle
+}
+
+// pinGate is checked before a chat's message is handled. Unlocked chats with
+// a PIN have their idle timer reset; locked chats are asked for the PIN, and a
+// message that is the PIN unlocks them. It reports whether the message may be
+// handled, and fails closed when the lock can't be read.
+func (b *botInstance) pinGate(msg *tgbotapi.Message) bool {
+	chatID := msg.Chat.ID
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	now := time.Now()
+	lock, ok, err := loadPINLock(chatID)
+	if err != nil {
+		log.Printf("❌ Failed to read the PIN lock of ChatID %d, holding back the message: %v", chatID, err)
+		send("🔒 Couldn't check this chat's PIN lock, please try again in a moment.")
+		return false
+	}
+	if !ok {
+		return true
+	}
+	session := viewSession(chatID)
+	if !pinLocked(lock, session, now) {
+		updateSession(chatID, func(s *chatSession) { s.PINLastActive = now })
+		return true
+	}
+
+	text := strings.TrimSpace(msg.Text)
+	if !pinPattern.MatchString(text) {
+		log.Printf("🔒 ChatID %d is PIN locked, holding back the message", chatID)
+		send("🔒 This chat is locked. Send your 4-digit PIN to unlock it.")
+		return false
+	}
+
+	// The PIN shouldn't stay readable in the chat
+	if _, err := b.api.Request(tgbotapi.NewDeleteMessage(chatID, msg.MessageID)); err != nil {
+		log.Printf("⚠️ Failed to delete PIN message: %v", err)
+	}
+	if now.Before(session.PINLockedOutUntil) {
+		send(fmt.Sprintf("⏳ Too many wrong PINs - try again in %d minutes.", int(time.Until(session.PINLockedOutUntil).Minutes())+1))
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(pinHash(chatID, text)), []byte(lock.Hash)) != 1 {
+		var lockedOut bool
+		updateSession(chatID, func(s *chatSession) {
+			s.PINFailures++
+			if s.PINFailures >= MaxPINAttempts {
+				s.PINFailures, s.PINLockedOutUntil = 0, now.Add(PINLockout)
+				lockedOut = true
+			}
+		})
+		log.Printf("🔒 Wrong PIN for ChatID %d (locked out: %t)", chatID, lockedOut)
+		if lockedOut {
+			send(fmt.Sprintf("⏳ Too many wrong PINs - try again in %d minutes.", int(PINLockout.Minutes())))
+		} else {
+			send("❌ Wrong PIN.")
+		}
+		return false
+	}
+
+	updateSession(chatID, func(s *chatSession) {
+		s.PINLastActive, s.PINFailures = now, 0
+	})
+	log.Printf("🔓 ChatID %d unlocked with its PIN", chatID)
+	send("🔓 Unlocked - go ahead.")
+	return false
+}
+
+// pinLockedCallback answers buttons pressed in a locked chat, or in one whose
+// lock can't be read
+func (b *botInstance) pinLockedCallback(cb *tgbotapi.CallbackQuery) bool {
+	lock, ok, err := loadPINLock(cb.Message.Chat.ID)
+	if err != nil {
+		log.Printf("❌ Failed to read the PIN lock of ChatID %d: %v", cb.Message.Chat.ID, err)
+		b.alertCallback(cb, "🔒 Couldn't check this chat's PIN lock, please try again in a moment.")
+		return true
+	}
+	if !ok {
+		return false
+	}
+	if !pinLocked(lock, viewSession(cb.Message.Chat.ID), time.Now()) {
+		updateSession(cb.Message.Chat.ID, func(s *chatSession) { s.PINLastActive = time.Now() })
+		return false
+	}
+	log.Printf("🔒 Button pressed in PIN locked ChatID %d: %s", cb.Message.Chat.ID, cb.Data)
+	b.alertCallback(cb, "🔒 This chat is locked. Send your PIN to unlock it first.")
+	return true
+}
+
+// handlePINCommand manages the chat's PIN lock: /pin set 1234 [minutes] locks
+// the chat after that long without activity, /pin lock locks it now and
+// /pin off removes the PIN
+func (b *botInstance) handlePINCommand(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+	usage := fmt.Sprintf("Usage: /pin set 1234 [minutes] | /pin lock | /pin off\n\n"+
+		"With a PIN, the chat locks after %d idle minutes (or the minutes you give, up to %d) and asks for the PIN before showing summaries or logging expenses.",
+		int(DefaultPINIdle.Minutes()), MaxPINIdleMinutes)
+
+	args := strings.Fields(msg.Text)[1:]
+	action := ""
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+	}
+	lock, locked, err := loadPINLock(chatID)
+	if err != nil && action != "set" && action != "off" {
+		log.Printf("❌ Failed to read the PIN lock of ChatID %d: %v", chatID, err)
+		send("❌ Couldn't read this chat's PIN lock, please try again later.")
+		return
+	}
+
+	switch action {
+	case "set":
+		if len(args) < 2 || len(args) > 3 || !pinPattern.MatchString(args[1]) {
+			send(usage)
+			return
+		}
+		// The PIN shouldn't stay readable in the chat
+		if _, err := b.api.Request(tgbotapi.NewDeleteMessage(chatID, msg.MessageID)); err != nil {
+			log.Printf("⚠️ Failed to delete PIN message: %v", err)
+		}
+		idle := DefaultPINIdle
+		if len(args) == 3 {
+			minutes, err := strconv.Atoi(args[2])
+			if err != nil || minutes < 1 || minutes > MaxPINIdleMinutes {
+				send(usage)
+				return
+			}
+			idle = time.Duration(minutes) * time.Minute
+		}
+		raw, _ := json.Marshal(pinLock{Hash: pinHash(chatID, args[1]), Idle: idle})
+		if err := store.Set(pinLockKey(chatID), string(raw), 0); err != nil {
+			log.Printf("❌ Failed to save the PIN lock of ChatID %d: %v", chatID, err)
+			send("❌ Couldn't save the PIN, please try again later.")
+			return
+		}
+		updateSession(chatID, func(s *chatSession) { s.PINLastActive, s.PINFailures = time.Now(), 0 })
+		log.Printf("🔒 ChatID %d set a PIN lock after %s idle", chatID, idle)
+		response := fmt.Sprintf("🔒 PIN set - this chat locks after %d idle minutes. Your message with the PIN was deleted.", int(idle.Minutes()))
+		if !storeDurable() {
+			response += "\n\n⚠️ This bot keeps its state in memory, so the PIN is removed whenever it restarts. Check with /pin after updates."
+		}
+		send(response)
+
+	case "lock":
+		if !locked {
+			send("🔓 No PIN is set. " + usage)
+			return
+		}
+		updateSession(chatID, func(s *chatSession) { s.PINLastActive = time.Time{} })
+		log.Printf("🔒 ChatID %d locked", chatID)
+		send("🔒 Locked. Send your PIN to unlock.")
+
+	case "off":
+		if err := store.Delete(pinLockKey(chatID)); err != nil {
+			log.Printf("❌ Failed to remove the PIN lock of ChatID %d: %v", chatID, err)
+			send("❌ Couldn't remove the PIN, please try again later.")
+			return
+		}
+		updateSession(chatID, func(s *chatSession) { s.PINLastActive, s.PINFailures = time.Time{}, 0 })
+		log.Printf("🔓 ChatID %d removed its PIN lock", chatID)
+		send("🔓 PIN removed - the chat no longer locks.")
+
+	case "":
+		if !locked {
+			send("🔓 No PIN is set. " + usage)
+			return
+		}
+		send(fmt.Sprintf("🔒 This chat locks after %d idle minutes.\n\n%s", int(lock.Idle.Minutes()), usage))
+
+	default:
+		send(usage)
+	}
+}
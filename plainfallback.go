@@ -0,0 +1,87 @@
+package main
+
+import (
+	"html"
+	"log"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	markdownLink         = regexp.MustCompile(`\[([^\]]*)\]\(([^)]*)\)`)
+	markdownV2Escape     = regexp.MustCompile(`\\([_*\[\]()~` + "`" + `>#+\-=|{}.!\\])`)
+	htmlTag              = regexp.MustCompile(`<[^>]*>`)
+	markdownFormatting   = strings.NewReplacer("*", "", "_", "", "`", "")
+	markdownV2Formatting = strings.NewReplacer("*", "", "_", "", "`", "", "~", "", "||", "")
+)
+
+// isEntityParseError reports whether Telegram rejected a message's formatting,
+// e.g. "Bad Request: can't parse entities: Can't find end of the entity"
+func isEntityParseError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "can't parse entities")
+}
+
+// stripFormatting renders text written for parseMode as plain text: markup is
+// dropped, links keep their URL and escapes are undone
+func stripFormatting(text, parseMode string) string {
+	switch strings.ToLower(parseMode) {
+	case "html":
+		return html.UnescapeString(htmlTag.ReplaceAllString(text, ""))
+	case "markdownv2":
+		// Escaped characters are set aside so removing the markup leaves them alone
+		const placeholder = "\x00"
+		var escaped []string
+		text = markdownV2Escape.ReplaceAllStringFunc(text, func(m string) string {
+			escaped = append(escaped, m[1:])
+			return placeholder
+		})
+		text = markdownV2Formatting.Replace(markdownLink.ReplaceAllString(text, "$1 ($2)"))
+		for _, s := range escaped {
+			text = strings.Replace(text, placeholder, s, 1)
+		}
+		return text
+	case "markdown":
+		return markdownFormatting.Replace(markdownLink.ReplaceAllString(text, "$1 ($2)"))
+	}
+	return text
+}
+
+// withoutFormatting returns c as plain text, or false for messages that carry
+// no parse mode or can't be resent this way
+func withoutFormatting(c tgbotapi.Chattable) (tgbotapi.Chattable, string, bool) {
+	switch m := c.(type) {
+	case tgbotapi.MessageConfig:
+		if m.ParseMode == "" {
+			return nil, "", false
+		}
+		original := m.Text
+		m.Text, m.ParseMode = stripFormatting(m.Text, m.ParseMode), ""
+		return m, original, true
+	case tgbotapi.EditMessageTextConfig:
+		if m.ParseMode == "" {
+			return nil, "", false
+		}
+		original := m.Text
+		m.Text, m.ParseMode = stripFormatting(m.Text, m.ParseMode), ""
+		return m, original, true
+	}
+	return nil, "", false
+}
+
+// sendFormatted sends a message with a parse mode and, when Telegram can't
+// parse its formatting, resends it as plain text so the chat still gets it
+func (b *botInstance) sendFormatted(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	sent, err := b.api.Send(c)
+	if !isEntityParseError(err) {
+		return sent, err
+	}
+	plain, original, ok := withoutFormatting(c)
+	if !ok {
+		return sent, err
+	}
+	log.Printf("⚠️ Telegram couldn't parse the formatting, resending as plain text: %v - payload: %s", err, logText(original))
+	incCounter("spendwise_entity_parse_fallbacks_total")
+	return b.api.Send(plain)
+}
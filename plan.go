@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/parser"
+)
+
+const (
+	// PlanMaxCategories caps the categories /plan walks through, biggest first
+	PlanMaxCategories = 8
+	// PlanRoundTo rounds last month's actuals up when suggesting a budget
+	PlanRoundTo = 100
+	// PlanReminderInterval is how often the month-start /plan reminder is checked
+	PlanReminderInterval = time.Hour
+	// PlanStateTTL remembers that a month's /plan reminder was sent
+	PlanStateTTL = 40 * 24 * time.Hour
+)
+
+// PlanAlertPercents are the shares of a budget at which the backend sends budget alerts
+var PlanAlertPercents = []int{80, 100}
+
+// budgetPlan is the /plan conversation's progress, kept in the awaited-reply data
+type budgetPlan struct {
+	Month      string          `json:"month"`      // first day of the spending month planned
+	Categories []SummaryBucket `json:"categories"` // last month's actuals, in the order asked
+	Budgets    []SummaryBucket `json:"budgets"`    // budgets answered so far
+	Index      int             `json:"index"`      // category being asked about
+}
+
+// suggestedBudget pre-fills a budget from last month's spend, rounded up
+func suggestedBudget(actual float64) float64 {
+	return math.Ceil(actual/PlanRoundTo) * PlanRoundTo
+}
+
+// askPlanCategory asks for the budget of the plan's current category
+func (b *botInstance) askPlanCategory(chatID int64, plan budgetPlan) {
+	raw, _ := json.Marshal(plan)
+	setAwaiting(chatID, AwaitBudgetPlan, map[string]string{"plan": string(raw)})
+
+	formatter := formatterFor(chatID)
+	category := plan.Categories[plan.Index]
+	text := fmt.Sprintf("🗓️ Budget %d/%d · %s - last month %s.\n\nReply with an amount, ok for %s, skip, or cancel.",
+		plan.Index+1, len(plan.Categories), category.Label, formatter.Currency(category.Amount),
+		formatter.Currency(suggestedBudget(category.Amount)))
+	if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
+
+// handlePlanCommand starts the monthly budget planning conversation:
+// /plan walks through last month's categories, /plan off stops the month-start reminder
+func (b *botInstance) handlePlanCommand(msg *tgbotapi.Message) {
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+	if args := strings.Fields(msg.Text); len(args) == 2 && args[1] == "off" {
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.PlanReminders = false })
+		send("🔕 No more month-start planning reminders. /plan still works any time.")
+		return
+	}
+
+	b.showTyping(msg.Chat.ID)
+	from, _ := billingCycle(time.Now(), cycleStartDayFor(msg.Chat.ID))
+	lastFrom, lastTo := billingCycle(from.AddDate(0, 0, -1), cycleStartDayFor(msg.Chat.ID))
+	result, err := b.apiCallWithTiming("GET", "/api/summary/range"+b.summaryLocaleQuery(msg.Chat.ID)+
+		"&from="+lastFrom.Format("2006-01-02")+"&to="+lastTo.Format("2006-01-02"), nil)
+	var summary SummaryResponse
+	if err == nil {
+		err = json.Unmarshal(result.Data, &summary)
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load last month's categories for /plan for ChatID %d: %v", msg.Chat.ID, err)
+		send(userErrorText("loading last month's spending", err))
+		return
+	}
+
+	categories := append([]SummaryBucket(nil), summary.Categories...)
+	sort.SliceStable(categories, func(i, j int) bool { return categories[i].Amount > categories[j].Amount })
+	if len(categories) > PlanMaxCategories {
+		categories = categories[:PlanMaxCategories]
+	}
+	if len(categories) == 0 {
+		send("🗓️ There's no categorised spending from last month to plan from yet - try /plan again next month.")
+		return
+	}
+
+	log.Printf("🗓️ Starting budget plan for ChatID %d with %d categories", msg.Chat.ID, len(categories))
+	b.askPlanCategory(msg.Chat.ID, budgetPlan{Month: from.Format("2006-01-02"), Categories: categories})
+}
+
+// handleBudgetPlanReply takes one answer of the /plan conversation and asks
+// the next question, submitting the budgets after the last category
+func (b *botInstance) handleBudgetPlanReply(msg *tgbotapi.Message, data map[string]string) {
+	var plan budgetPlan
+	if err := json.Unmarshal([]byte(data["plan"]), &plan); err != nil || plan.Index >= len(plan.Categories) {
+		log.Printf("❌ Unreadable budget plan for ChatID %d: %v", msg.Chat.ID, err)
+		b.handleUnknownCommand(msg)
+		return
+	}
+
+	category := plan.Categories[plan.Index]
+	answer := strings.ToLower(strings.TrimSpace(msg.Text))
+	switch answer {
+	case "cancel", "stop":
+		log.Printf("🗓️ Budget plan cancelled by ChatID %d", msg.Chat.ID)
+		if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, "👌 Plan cancelled, nothing was saved.")); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+		return
+	case "skip":
+	case "ok", "okay", "yes", "keep":
+		plan.Budgets = append(plan.Budgets, SummaryBucket{Label: category.Label, Amount: suggestedBudget(category.Amount)})
+	default:
+		amount, ok := parser.ParseNumber(answer, formatterFor(msg.Chat.ID).DecimalComma())
+		if !ok || amount <= 0 {
+			if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, "❌ Reply with an amount like 4500, or ok, skip or cancel.")); err != nil {
+				log.Printf(ErrorSendMessage, err)
+			}
+			b.askPlanCategory(msg.Chat.ID, plan)
+			return
+		}
+		plan.Budgets = append(plan.Budgets, SummaryBucket{Label: category.Label, Amount: amount})
+	}
+
+	plan.Index++
+	if plan.Index < len(plan.Categories) {
+		b.askPlanCategory(msg.Chat.ID, plan)
+		return
+	}
+	b.submitBudgetPlan(msg, plan)
+}
+
+// submitBudgetPlan saves the planned budgets with the thresholds the backend
+// should alert at, and turns on the month-start reminder
+func (b *botInstance) submitBudgetPlan(msg *tgbotapi.Message, plan budgetPlan) {
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+	if len(plan.Budgets) == 0 {
+		send("👌 Every category was skipped, nothing to save.")
+		return
+	}
+
+	budgets := make([]map[string]interface{}, 0, len(plan.Budgets))
+	for _, budget := range plan.Budgets {
+		budgets = append(budgets, map[string]interface{}{
+			"category":       budget.Label,
+			"amount":         budget.Amount,
+			"alertAtPercent": PlanAlertPercents,
+		})
+	}
+	_, err := b.apiCallWithTiming("POST", "/api/budgets", map[string]interface{}{
+		"telegramChatId": strconv.FormatInt(msg.Chat.ID, 10),
+		"month":          plan.Month,
+		"budgets":        budgets,
+	})
+	if err != nil {
+		log.Printf("❌ Failed to save budget plan for ChatID %d: %v", msg.Chat.ID, err)
+		send(userErrorText("saving budgets", err))
+		return
+	}
+	updateSession(msg.Chat.ID, func(s *chatSession) { s.PlanReminders = true })
+
+	formatter := formatterFor(msg.Chat.ID)
+	lines := []string{"✅ Budgets saved:"}
+	var total float64
+	for _, budget := range plan.Budgets {
+		lines = append(lines, fmt.Sprintf("• %s - %s", budget.Label, formatter.Currency(budget.Amount)))
+		total += budget.Amount
+	}
+	percents := make([]string, len(PlanAlertPercents))
+	for i, percent := range PlanAlertPercents {
+		percents[i] = strconv.Itoa(percent) + "%"
+	}
+	lines = append(lines, "", "Total: "+formatter.Currency(total),
+		"I'll alert you when a category reaches "+strings.Join(percents, " and ")+" of its budget, and remind you to plan at the start of next month (/plan off to stop).")
+	log.Printf("🗓️ Saved %d budgets for ChatID %d", len(plan.Budgets), msg.Chat.ID)
+	send(strings.Join(lines, "\n"))
+}
+
+// runPlanReminders invites chats that planned before to plan again on the
+// first day of their spending month
+func (b *botInstance) runPlanReminders() {
+	now := time.Now()
+	if now.Hour() < NudgeSendFromHour || now.Hour() >= NudgeSendUntilHour {
+		log.Printf("🌙 Skipping plan reminders outside sending hours")
+		return
+	}
+
+	reminded := 0
+	for _, chatIDStr := range b.tenant.allowedChats() {
+		chatID, err := strconv.ParseInt(chatIDStr, 10, 64)
+		if err != nil || isLimited(chatID) || !viewSession(chatID).PlanReminders {
+			continue
+		}
+		from, _ := billingCycle(now, cycleStartDayFor(chatID))
+		if now.Day() != from.Day() {
+			continue
+		}
+		claimed, err := store.SetNX(fmt.Sprintf("plan-reminded:%d:%s", chatID, from.Format("2006-01-02")), instanceID, PlanStateTTL)
+		if err != nil || !claimed {
+			continue
+		}
+
+		reply := tgbotapi.NewMessage(chatID, "🗓️ A new month starts today - set this month's budgets with /plan, pre-filled from last month.\n\n/plan off to stop these")
+		if _, err := b.sendPaced(chatID, reply); err != nil {
+			log.Printf("❌ Failed to send plan reminder to ChatID %d: %v", chatID, err)
+			continue
+		}
+		reminded++
+	}
+	log.Printf("🗓️ Sent %d plan reminder(s)", reminded)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"log"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerPprof exposes the net/http/pprof handlers under /debug/pprof, behind the
+// internal IP allowlist and the shared API secret. They bypass requireReady so a
+// slow or stuck startup can be profiled too.
+func registerPprof(r *gin.Engine, allowlist gin.HandlerFunc) {
+	log.Println("🩺 Profiling endpoints enabled at /debug/pprof")
+	r.Group("/debug/pprof", allowlist, requireAPISecret).Any("/*profile", handlePprof)
+}
+
+func handlePprof(c *gin.Context) {
+	switch strings.TrimPrefix(c.Param("profile"), "/") {
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		// Index serves the listing as well as named profiles such as heap and goroutine
+		pprof.Index(c.Writer, c.Request)
+	}
+}
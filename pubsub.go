@@ -0,0 +1,164 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	PubSubTypeTelegramUpdate = "telegram_update"
+	PubSubTypeSendMessage    = "send_message"
+)
+
+// pubSubPush is the envelope Cloud Pub/Sub posts to push subscription endpoints
+type pubSubPush struct {
+	Message struct {
+		Data       string            `json:"data"`
+		Attributes map[string]string `json:"attributes"`
+		MessageID  string            `json:"messageId"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+// pubSubSendJob is a backend notification delivered through Pub/Sub
+type pubSubSendJob struct {
+	ChatID  int64  `json:"chatId"`
+	Message string `json:"message"`
+	BotID   string `json:"botId"`
+}
+
+// handlePubSubPush processes a Pub/Sub push delivery. A 2xx response acks the message;
+// any other status nacks it so Pub/Sub redelivers later, e.g. when handling an
+// update found the backend down. Malformed messages are acked and dropped because
+// retrying them can never succeed.
+func handlePubSubPush(c *gin.Context) {
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(config.PubSubToken)) != 1 {
+		log.Printf("❌ Unauthorized Pub/Sub push from IP: %s", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var push pubSubPush
+	if !bindJSON(c, &push, false) {
+		return // bindJSON already responded with 400
+	}
+
+	data, err := base64.StdEncoding.DecodeString(push.Message.Data)
+	if err != nil {
+		log.Printf("❌ Dropping Pub/Sub message %s with invalid data: %v", push.Message.MessageID, err)
+		c.JSON(http.StatusOK, gin.H{"status": "dropped"})
+		return
+	}
+
+	msgType := push.Message.Attributes["type"]
+	log.Printf("📨 Pub/Sub message %s of type %s from %s", push.Message.MessageID, msgType, push.Subscription)
+
+	b := defaultBot
+	if botID := push.Message.Attributes["botId"]; botID != "" {
+		var ok bool
+		if b, ok = bots[botID]; !ok {
+			log.Printf("❌ Dropping Pub/Sub message %s for unknown bot %q", push.Message.MessageID, botID)
+			c.JSON(http.StatusOK, gin.H{"status": "dropped"})
+			return
+		}
+	}
+
+	switch msgType {
+	case PubSubTypeTelegramUpdate:
+		// Updates share the interactive lane with /webhook; send jobs are background work
+		release, ok := acquireUpdateSlot(c)
+		if !ok {
+			return
+		}
+		defer release()
+		err = b.processPubSubUpdate(data)
+	case PubSubTypeSendMessage:
+		err = processPubSubSend(data)
+	default:
+		log.Printf("❌ Dropping Pub/Sub message %s with unknown type %q", push.Message.MessageID, msgType)
+		c.JSON(http.StatusOK, gin.H{"status": "dropped"})
+		return
+	}
+
+	if err != nil {
+		log.Printf("🔁 Nacking Pub/Sub message %s: %v", push.Message.MessageID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("✅ Acked Pub/Sub message %s", push.Message.MessageID)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// processPubSubUpdate handles a Telegram update forwarded through Pub/Sub. If handling
+// panics or finds the backend down, an error is returned and the dedupe claim is
+// released so the redelivery gets processed.
+func (b *botInstance) processPubSubUpdate(data []byte) (err error) {
+	var update tgbotapi.Update
+	if err := json.Unmarshal(data, &update); err != nil {
+		log.Printf("❌ Dropping Pub/Sub update with invalid JSON: %v", err)
+		return nil
+	}
+
+	if alreadyProcessed(b.ID, update.UpdateID) || !markUpdateSeen(b.ID, update.UpdateID) {
+		log.Printf("♻️ Skipping duplicate update %d for bot %s", update.UpdateID, b.ID)
+		return nil
+	}
+	startUpdate(b.ID, update.UpdateID)
+
+	dedupeKey := fmt.Sprintf("update:%s:%d", b.ID, update.UpdateID)
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			log.Printf("❌ Panic while handling Pub/Sub update %d for bot %s: %v\n%s", update.UpdateID, b.ID, r, stack)
+			reportError("pubsub update handler", r, stack)
+			store.Delete(dedupeKey)
+			err = fmt.Errorf("update %d panicked: %v", update.UpdateID, r)
+		}
+	}()
+
+	var outage atomic.Bool
+	scoped := *b
+	scoped.outage = &outage
+	scoped.dispatchUpdate(update)
+	if outage.Load() {
+		store.Delete(dedupeKey)
+		return fmt.Errorf("the backend was unavailable while handling update %d", update.UpdateID)
+	}
+	advanceWatermark(b.ID, update.UpdateID)
+	return nil
+}
+
+// processPubSubSend delivers a backend notification; send failures are returned so the
+// message is nacked and retried
+func processPubSubSend(data []byte) error {
+	var job pubSubSendJob
+	if err := json.Unmarshal(data, &job); err != nil || job.ChatID == 0 || job.Message == "" {
+		log.Printf("❌ Dropping invalid Pub/Sub send job: %v", err)
+		return nil
+	}
+
+	b := defaultBot
+	if job.BotID != "" {
+		var ok bool
+		if b, ok = bots[job.BotID]; !ok {
+			log.Printf("❌ Dropping Pub/Sub send job for unknown bot %q", job.BotID)
+			return nil
+		}
+	}
+
+	if _, err := b.sendPaced(job.ChatID, tgbotapi.NewMessage(job.ChatID, job.Message)); err != nil {
+		return fmt.Errorf("failed to send message to ChatID %d: %v", job.ChatID, err)
+	}
+	log.Printf("✅ Pub/Sub message delivered via bot %s to ChatID: %d", b.ID, job.ChatID)
+	return nil
+}
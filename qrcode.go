@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/skip2/go-qrcode"
+
+	"spendwise-telegram-go/parser"
+)
+
+const (
+	// QRCodeSize is the width and height of generated QR images in pixels
+	QRCodeSize = 512
+	// DefaultInvitePayload is the /start payload of onboarding QRs without a name
+	DefaultInvitePayload = "invite"
+)
+
+// startPayloadPattern is what Telegram accepts as a deep-link start parameter
+var startPayloadPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// qrPhoto renders content as a QR code photo
+func qrPhoto(chatID int64, content, caption string) (tgbotapi.PhotoConfig, error) {
+	png, err := qrcode.Encode(content, qrcode.Medium, QRCodeSize)
+	if err != nil {
+		return tgbotapi.PhotoConfig{}, fmt.Errorf("failed to generate QR code: %v", err)
+	}
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "qr.png", Bytes: png})
+	photo.Caption = caption
+	return photo, nil
+}
+
+// upiPaymentURI builds the upi://pay link UPI apps open when the QR is scanned
+func upiPaymentURI(payee, payeeName string, amount float64, note string) string {
+	params := url.Values{}
+	params.Set("pa", payee)
+	if payeeName != "" {
+		params.Set("pn", payeeName)
+	}
+	params.Set("am", strconv.FormatFloat(amount, 'f', 2, 64))
+	params.Set("cu", "INR")
+	if note != "" {
+		params.Set("tn", note)
+	}
+	// UPI apps expect %20 for spaces, not the form encoding's "+"
+	return "upi://pay?" + strings.ReplaceAll(params.Encode(), "+", "%20")
+}
+
+// handleUPICommand sends a UPI payment QR: /upi <amount> [note] [payee@bank]
+func (b *botInstance) handleUPICommand(msg *tgbotapi.Message) {
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	args := strings.Fields(msg.Text)[1:]
+	if len(args) == 0 {
+		send("Usage: /upi <amount> [note] [payee@bank]\n\nExample: /upi 500 electricity - sends a QR any UPI app can scan to pay it.")
+		return
+	}
+	amount, ok := parser.ParseNumber(args[0], formatterFor(msg.Chat.ID).DecimalComma())
+	if !ok || amount <= 0 {
+		send("❌ Give the amount first, e.g. /upi 500 electricity")
+		return
+	}
+
+	payee, payeeName := config.UPIID, config.UPIName
+	var note []string
+	for _, arg := range args[1:] {
+		if strings.Contains(arg, "@") {
+			payee, payeeName = arg, ""
+		} else {
+			note = append(note, arg)
+		}
+	}
+	if payee == "" {
+		send("❌ No UPI ID is configured - add the payee, e.g. /upi 500 electricity billdesk@hdfcbank")
+		return
+	}
+
+	caption := fmt.Sprintf("📷 Scan with any UPI app to pay %s to %s", formatterFor(msg.Chat.ID).Currency(amount), payee)
+	if len(note) > 0 {
+		caption += " for " + strings.Join(note, " ")
+	}
+	photo, err := qrPhoto(msg.Chat.ID, upiPaymentURI(payee, payeeName, amount, strings.Join(note, " ")), caption)
+	if err != nil {
+		log.Printf("❌ UPI QR for ChatID %d failed: %v", msg.Chat.ID, err)
+		send("❌ " + err.Error())
+		return
+	}
+	log.Printf("📷 Sending UPI QR for %.2f to %s to ChatID %d", amount, payee, msg.Chat.ID)
+	if _, err := b.api.Send(photo); err != nil {
+		log.Printf("❌ Failed to send UPI QR to ChatID %d: %v", msg.Chat.ID, err)
+	}
+}
+
+// handleInviteCommand sends an onboarding QR that opens the bot with a start
+// payload naming the invite: /invite [name]. Admin only.
+func (b *botInstance) handleInviteCommand(msg *tgbotapi.Message) {
+	if !isAdmin(msg.Chat.ID) {
+		log.Printf("❌ Non-admin ChatID %d tried to use /invite", msg.Chat.ID)
+		b.handleUnknownCommand(msg)
+		return
+	}
+
+	payload := DefaultInvitePayload
+	if args := strings.Fields(msg.Text); len(args) > 1 {
+		payload = args[1]
+	}
+	if !startPayloadPattern.MatchString(payload) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Invite names can only use letters, digits, _ and - (up to 64), e.g. /invite grandparents")
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+		return
+	}
+
+	link := "https://t.me/" + b.api.Self.UserName + "?start=" + payload
+	photo, err := qrPhoto(msg.Chat.ID, link, "📷 Scan to open the bot: "+link+
+		"\n\nNew chats get their chat ID to send you and admins are alerted with the invite name.")
+	if err == nil {
+		_, err = b.api.Send(photo)
+	}
+	if err != nil {
+		log.Printf("❌ Failed to send invite QR to ChatID %d: %v", msg.Chat.ID, err)
+		return
+	}
+	log.Printf("📷 Admin %d created invite QR %q", msg.Chat.ID, payload)
+}
+
+// startPayload returns the deep-link payload of a "/start <payload>" message
+func startPayload(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) != 2 || !strings.HasPrefix(fields[0], "/start") || !startPayloadPattern.MatchString(fields[1]) {
+		return ""
+	}
+	return fields[1]
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixQuickMenu = "quick_menu:"
+	// QuickMenuItems is how many routine expenses /quick offers
+	QuickMenuItems = 6
+	// QuickMenuLookback is the history /quick looks for routine expenses in
+	QuickMenuLookback = 8 * 7 * 24 * time.Hour
+	// QuickMenuMinUses is how often a description must appear to count as routine
+	QuickMenuMinUses = 2
+)
+
+// quickMenuItem is a routine expense offered by /quick with its typical amount
+type quickMenuItem struct {
+	Description string
+	Amount      float64
+}
+
+// routineExpenses picks the n most frequently logged descriptions, each with the
+// amount it is most often logged for. Ties go to the most recent.
+func routineExpenses(expenses []ExpenseRecord, n int) []quickMenuItem {
+	type usage struct {
+		description string
+		count       int
+		last        string             // date of the latest entry
+		amounts     map[float64]int    // amount -> times logged
+		amountLast  map[float64]string // amount -> date last logged
+	}
+	byKey := make(map[string]*usage)
+	for _, expense := range expenses {
+		description := strings.TrimSpace(expense.Description)
+		key := strings.ToLower(description)
+		if key == "" || expense.Amount <= 0 {
+			continue
+		}
+		u, ok := byKey[key]
+		if !ok {
+			u = &usage{amounts: make(map[float64]int), amountLast: make(map[float64]string)}
+			byKey[key] = u
+		}
+		u.count++
+		u.amounts[expense.Amount]++
+		if expense.Date >= u.amountLast[expense.Amount] {
+			u.amountLast[expense.Amount] = expense.Date
+		}
+		if expense.Date >= u.last {
+			u.last, u.description = expense.Date, description
+		}
+	}
+
+	var routine []*usage
+	for _, u := range byKey {
+		if u.count >= QuickMenuMinUses {
+			routine = append(routine, u)
+		}
+	}
+	sort.Slice(routine, func(i, j int) bool {
+		if routine[i].count != routine[j].count {
+			return routine[i].count > routine[j].count
+		}
+		return routine[i].last > routine[j].last
+	})
+
+	var items []quickMenuItem
+	for i := 0; i < len(routine) && i < n; i++ {
+		item := quickMenuItem{Description: routine[i].description}
+		for amount, count := range routine[i].amounts {
+			best := routine[i].amounts[item.Amount]
+			if count > best || (count == best && routine[i].amountLast[amount] > routine[i].amountLast[item.Amount]) {
+				item.Amount = amount
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// quickMenuLine is the expense line a menu item logs, with the amount written
+// the way the chat's parser reads it
+func quickMenuLine(item quickMenuItem, decimalComma bool) string {
+	amount := strconv.FormatFloat(item.Amount, 'f', -1, 64)
+	if decimalComma {
+		amount = strings.Replace(amount, ".", ",", 1)
+	}
+	return item.Description + " " + amount
+}
+
+// setQuickMenu remembers the items shown by the last /quick
+func setQuickMenu(chatID int64, items []quickMenuItem) {
+	updateSession(chatID, func(s *chatSession) {
+		s.QuickMenu = items
+	})
+}
+
+// handleQuickCommand offers the chat's routine expenses as one-tap buttons:
+// /quick for inline buttons, /quick keyboard to pin them below the input field
+// and /quick hide to remove that keyboard again
+func (b *botInstance) handleQuickCommand(msg *tgbotapi.Message) {
+	send := func(reply tgbotapi.MessageConfig) {
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	args := strings.Fields(msg.Text)[1:]
+	mode := ""
+	if len(args) > 0 {
+		mode = strings.ToLower(args[0])
+	}
+	switch mode {
+	case "", "keyboard":
+	case "hide":
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "👌 Quick keyboard hidden. Bring it back with /quick keyboard.")
+		reply.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
+		send(reply)
+		return
+	default:
+		send(tgbotapi.NewMessage(msg.Chat.ID, "Usage: /quick [keyboard|hide]\n\n/quick shows your routine expenses as buttons that log them in one tap. /quick keyboard keeps them below the input field, /quick hide removes them."))
+		return
+	}
+
+	now := time.Now()
+	params := url.Values{}
+	params.Set("from", now.Add(-QuickMenuLookback).Format("2006-01-02"))
+	params.Set("to", now.Format("2006-01-02"))
+	params.Set("telegramChatId", strconv.FormatInt(msg.Chat.ID, 10))
+	expenses, err := b.fetchExpenses(params)
+	if err != nil {
+		log.Printf("❌ Failed to fetch expenses for /quick for ChatID %d: %v", msg.Chat.ID, err)
+		send(tgbotapi.NewMessage(msg.Chat.ID, userErrorText("fetching your expenses", err)))
+		return
+	}
+	items := routineExpenses(expenses, QuickMenuItems)
+	if len(items) == 0 {
+		send(tgbotapi.NewMessage(msg.Chat.ID, "No routine expenses yet - anything you log at least twice in a few weeks shows up here."))
+		return
+	}
+
+	formatter := formatterFor(msg.Chat.ID)
+	log.Printf("⚡ Offering %d routine expenses to ChatID %d (%s)", len(items), msg.Chat.ID, mode)
+	if mode == "keyboard" {
+		// Keyboard buttons send their label as a message, which logs like a typed expense
+		var rows [][]tgbotapi.KeyboardButton
+		var row []tgbotapi.KeyboardButton
+		for _, item := range items {
+			row = append(row, tgbotapi.NewKeyboardButton(quickMenuLine(item, formatter.DecimalComma())))
+			if len(row) == QuickPickButtonsPerRow {
+				rows = append(rows, row)
+				row = nil
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+		keyboard := tgbotapi.NewReplyKeyboard(rows...)
+		keyboard.ResizeKeyboard = true
+		keyboard.InputFieldPlaceholder = "Tap to log, or type an expense"
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "⚡ Your routine expenses are below the input field - tap one to log it. /quick hide removes them.")
+		reply.ReplyMarkup = keyboard
+		send(reply)
+		return
+	}
+
+	setQuickMenu(msg.Chat.ID, items)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i, item := range items {
+		label := item.Description + " " + formatter.Currency(item.Amount)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("%s%d", CallbackPrefixQuickMenu, i)))
+		if len(row) == QuickPickButtonsPerRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "⚡ Tap to log a routine expense:")
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	send(reply)
+}
+
+// handleQuickMenuCallback logs the routine expense tapped on a /quick menu. The
+// menu stays so the same expense can be logged again.
+func (b *botInstance) handleQuickMenuCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	index, err := strconv.Atoi(strings.TrimPrefix(cb.Data, CallbackPrefixQuickMenu))
+	menu := viewSession(chatID).QuickMenu
+	if err != nil || index < 0 || index >= len(menu) {
+		log.Printf("❌ Stale quick menu callback for ChatID %d: %s", chatID, cb.Data)
+		b.answerCallback(cb, "This menu has expired, please send /quick again.")
+		return
+	}
+
+	item := menu[index]
+	b.answerCallback(cb, "Logging "+item.Description)
+	b.handleQuickExpense(&tgbotapi.Message{
+		MessageID: cb.Message.MessageID,
+		From:      cb.From,
+		Chat:      cb.Message.Chat,
+		Date:      cb.Message.Date,
+		Text:      quickMenuLine(item, formatterFor(chatID).DecimalComma()),
+	})
+}
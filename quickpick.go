@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/parser"
+)
+
+const (
+	CallbackPrefixQuickPick = "quick_pick:"
+	QuickPickButtons        = 6
+	QuickPickButtonsPerRow  = 2
+	// A message without a number is only taken for an expense description when it is this short
+	MaxDescriptionOnlyWords  = 4
+	MaxDescriptionOnlyLength = 40
+)
+
+// smallTalk lists messages that look like descriptions but aren't expenses
+var smallTalk = map[string]bool{
+	"hi": true, "hello": true, "hey": true, "ok": true, "okay": true, "yes": true, "no": true,
+	"thanks": true, "thank you": true, "bye": true, "good morning": true, "good night": true,
+}
+
+// parseBareAmount returns the amount if text is nothing but a positive number
+func parseBareAmount(text string, decimalComma bool) (float64, bool) {
+	amount, ok := parser.ParseNumber(strings.TrimSpace(text), decimalComma)
+	if !ok || amount <= 0 {
+		return 0, false
+	}
+	return amount, true
+}
+
+// handleAmountOnly asks what a bare amount was for, offering the chat's most used
+// recent descriptions as buttons; a typed reply works too
+func (b *botInstance) handleAmountOnly(msg *tgbotapi.Message, amount float64) {
+	amountStr := strconv.FormatFloat(amount, 'f', -1, 64)
+	setAwaiting(msg.Chat.ID, AwaitExpenseDescription, map[string]string{
+		"amount":    amountStr,
+		"messageId": strconv.Itoa(msg.MessageID),
+	})
+
+	question := fmt.Sprintf("What was %s for?", formatterFor(msg.Chat.ID).Currency(amount))
+	descriptions := topRecentDescriptions(msg.Chat.ID, QuickPickButtons)
+	if len(descriptions) == 0 {
+		log.Printf("📝 Asking ChatID %d what %s was for", msg.Chat.ID, amountStr)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, question+" Reply with a description, e.g. Lunch")
+		reply.ReplyToMessageID = msg.MessageID
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+		return
+	}
+
+	setOffered(msg.Chat.ID, descriptions)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i, description := range descriptions {
+		data := fmt.Sprintf("%s%s:%d", CallbackPrefixQuickPick, amountStr, i)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(description, data))
+		if len(row) == QuickPickButtonsPerRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	log.Printf("⚡ Offering %d quick descriptions for %s to ChatID: %d", len(descriptions), amountStr, msg.Chat.ID)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, question+" Tap one or type a description")
+	reply.ReplyToMessageID = msg.MessageID
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf("❌ Failed to send quick picks to ChatID %d: %v", msg.Chat.ID, err)
+	}
+}
+
+// handleQuickPickCallback logs the expense for a tapped quick-pick description
+func (b *botInstance) handleQuickPickCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	parts := strings.Split(strings.TrimPrefix(cb.Data, CallbackPrefixQuickPick), ":")
+	if len(parts) != 2 {
+		log.Printf("❌ Invalid quick pick callback: %s", cb.Data)
+		b.answerCallback(cb, "Invalid format.")
+		return
+	}
+
+	index, err := strconv.Atoi(parts[1])
+	description, ok := offeredDescription(chatID, index)
+	if err != nil || !ok {
+		log.Printf("❌ Stale quick pick callback for ChatID %d: %s", chatID, cb.Data)
+		b.answerCallback(cb, "These buttons have expired, please send the amount again.")
+		return
+	}
+
+	clearAwaiting(chatID)
+	b.answerCallback(cb, description)
+	edit := tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, "⚡ "+description+" "+parts[0])
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("⚠️ Failed to update quick pick message: %v", err)
+	}
+
+	// Log against the user's original amount message so the reaction lands there
+	messageID := cb.Message.MessageID
+	if cb.Message.ReplyToMessage != nil {
+		messageID = cb.Message.ReplyToMessage.MessageID
+	}
+	b.handleQuickExpense(&tgbotapi.Message{
+		MessageID: messageID,
+		From:      cb.From,
+		Chat:      cb.Message.Chat,
+		Date:      cb.Message.Date,
+		Text:      description + " " + parts[0],
+	})
+}
+
+// handleAmountDescription completes a bare amount with the description typed in
+// reply, logging the expense against the original amount message
+func (b *botInstance) handleAmountDescription(msg *tgbotapi.Message, data map[string]string) {
+	// Another bare amount starts over rather than becoming the description
+	opts := parseOptions(msg.Chat.ID)
+	if amount, ok := parseBareAmount(msg.Text, opts.DecimalComma); ok {
+		b.handleAmountOnly(msg, amount)
+		return
+	}
+
+	amount, _ := strconv.ParseFloat(data["amount"], 64)
+	entry, err := parser.ParseDescription(msg.Text, amount, opts)
+	expense := b.expenseFromEntry(entry, msg)
+	if err == nil {
+		err = validateExpenseInput(expense)
+	}
+	if err != nil {
+		log.Printf("❌ Invalid description for amount-only expense from ChatID %d: %v", msg.Chat.ID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ "+err.Error())
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
+			log.Printf(ErrorSendMessage, sendErr)
+		}
+		return
+	}
+
+	// React on the amount the user sent, like a one-line expense
+	original := *msg
+	if id, err := strconv.Atoi(data["messageId"]); err == nil && id > 0 {
+		original.MessageID = id
+	}
+	log.Printf("⚡ Completed amount-only expense for ChatID %d: %s", msg.Chat.ID, logText(expense.Description))
+	b.logExpenses(&original, []ExpenseInput{expense}, nil)
+}
+
+// plausibleDescription reports whether a message without a number looks like an
+// expense description, e.g. "auto rickshaw", rather than a question or small talk
+func plausibleDescription(text string) bool {
+	words := strings.Fields(text)
+	switch {
+	case len(words) == 0 || len(words) > MaxDescriptionOnlyWords:
+		return false
+	case len([]rune(text)) > MaxDescriptionOnlyLength || strings.ContainsAny(text, "?\n"):
+		return false
+	case strings.IndexFunc(text, unicode.IsLetter) < 0:
+		return false
+	}
+	return !smallTalk[strings.ToLower(strings.Join(words, " "))]
+}
+
+// handleDescriptionOnly asks how much a short description without a number cost,
+// falling back to the unknown-command reply for anything else
+func (b *botInstance) handleDescriptionOnly(msg *tgbotapi.Message) {
+	description := strings.Join(strings.Fields(msg.Text), " ")
+	if !msg.Chat.IsPrivate() || !plausibleDescription(description) {
+		log.Printf("❓ Unknown command received")
+		b.handleUnknownCommand(msg)
+		return
+	}
+
+	log.Printf("📝 Asking ChatID %d how much %s was", msg.Chat.ID, logText(description))
+	setAwaiting(msg.Chat.ID, AwaitExpenseAmount, map[string]string{
+		"description": description,
+		"messageId":   strconv.Itoa(msg.MessageID),
+	})
+	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("How much was %s? Reply with the amount, e.g. 40", description))
+	reply.ReplyToMessageID = msg.MessageID
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
+
+// handleDescriptionAmount completes a description-only message with the amount
+// sent in reply, logging the expense against the original message
+func (b *botInstance) handleDescriptionAmount(msg *tgbotapi.Message, data map[string]string) {
+	// Text without a number is a new message, not the answer
+	if !containsNumber(msg.Text, formatterFor(msg.Chat.ID).DecimalComma()) {
+		b.handleDescriptionOnly(msg)
+		return
+	}
+
+	original := *msg
+	if id, err := strconv.Atoi(data["messageId"]); err == nil && id > 0 {
+		original.MessageID = id
+	}
+	line := descriptionLine(data["description"], msg.Text)
+	log.Printf("⚡ Completing description-only expense for ChatID %d: %s", msg.Chat.ID, logText(line))
+	expenses, skipped, err := b.parseExpenses(line, &original)
+	if err != nil {
+		log.Printf("❌ Failed to parse description-only expense for ChatID %d: %v", msg.Chat.ID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ "+err.Error())
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
+			log.Printf(ErrorSendMessage, sendErr)
+		}
+		return
+	}
+	b.logExpenses(&original, expenses, skipped)
+}
+
+// descriptionLine joins a description with the reply giving its amount, moving
+// leading keywords of the reply ("yesterday 40") in front where the parser reads them
+func descriptionLine(description, reply string) string {
+	fields := strings.Fields(reply)
+	var lead []string
+	for len(fields) > 1 && parser.LeadingKeyword(fields[0]) {
+		lead, fields = append(lead, fields[0]), fields[1:]
+	}
+	return strings.Join(append(append(lead, description), fields...), " ")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	DefaultReactionEmoji = "👍"
+	// ProcessingReactionEmoji marks a batch that is still being saved. Telegram only
+	// accepts emoji from its fixed reaction set, which has no ⏳ or ✅.
+	ProcessingReactionEmoji = "👀"
+	// ProcessingReactionMinLines is the batch size that gets a processing reaction
+	ProcessingReactionMinLines = 2
+)
+
+// ReactionChoices are the success reactions a chat can pick with /reaction
+var ReactionChoices = []string{"👍", "👌", "🔥", "💯", "🎉", "❤"}
+
+// sendReaction sets the bot's reaction on a message, replacing any earlier one;
+// an empty emoji removes it
+func (b *botInstance) sendReaction(chatID int64, messageID int, emoji string) error {
+	startTime := time.Now()
+	defer func() {
+		debugf("⏱️ Reaction %q on message %d took %d ms", emoji, messageID, time.Since(startTime).Milliseconds())
+	}()
+
+	reaction := []map[string]string{}
+	if emoji != "" {
+		reaction = append(reaction, map[string]string{"type": "emoji", "emoji": emoji})
+	}
+	params := tgbotapi.Params{}
+	params.AddFirstValid("chat_id", chatID)
+	params.AddNonZero("message_id", messageID)
+	if err := params.AddInterface("reaction", reaction); err != nil {
+		return fmt.Errorf("failed to encode reaction: %v", err)
+	}
+
+	if _, err := b.api.MakeRequest("setMessageReaction", params); err != nil {
+		return fmt.Errorf("reaction request failed: %v", err)
+	}
+	log.Printf("Reaction sent successfully: %s to message %d", emoji, messageID)
+	return nil
+}
+
+// successReaction returns the emoji the chat wants on logged expenses
+func successReaction(chatID int64) string {
+	if emoji := viewSession(chatID).ReactionEmoji; emoji != "" {
+		return emoji
+	}
+	return DefaultReactionEmoji
+}
+
+// handleReactionCommand shows or changes the chat's success reaction: /reaction 🔥
+func (b *botInstance) handleReactionCommand(msg *tgbotapi.Message) {
+	choice := strings.Join(strings.Fields(msg.Text)[1:], " ")
+	choice = strings.TrimSuffix(choice, "\uFE0F") // keyboards send ❤ as ❤️ with a variation selector
+	choices := strings.Join(ReactionChoices, " ")
+	var response string
+
+	switch {
+	case choice == "":
+		response = fmt.Sprintf("Logged expenses get a %s reaction.\n\nPick another with /reaction <emoji>: %s", successReaction(msg.Chat.ID), choices)
+	case !slices.Contains(ReactionChoices, choice):
+		response = "❌ Telegram only allows certain reaction emoji. Choose one of: " + choices
+	default:
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.ReactionEmoji = choice })
+		response = fmt.Sprintf("%s Logged expenses will get this reaction from now on.", choice)
+		if err := b.sendReaction(msg.Chat.ID, msg.MessageID, choice); err != nil {
+			log.Printf("⚠️ Failed to preview reaction for ChatID %d: %v", msg.Chat.ID, err)
+		}
+	}
+
+	log.Printf("👍 Reaction settings command from ChatID %d: %s", msg.Chat.ID, choice)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
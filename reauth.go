@@ -0,0 +1,249 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const CallbackPrefixReauth = "reauth:"
+
+// sensitiveCommands delete data, reach every chat or manage users, so with
+// REAUTH_MINUTES set they need a recent confirmation from whoever holds the phone
+var sensitiveCommands = []string{"/delete", "/broadcast", "/block", "/unblock", "/cap"}
+
+// reauthHold is a sensitive command waiting for the tap that confirms it
+type reauthHold struct {
+	Command string
+	UserID  int64 // only the sender may confirm, e.g. in a group
+}
+
+func reauthHoldKey(chatID int64) string {
+	return "reauth-hold:" + strconv.FormatInt(chatID, 10)
+}
+
+// reauthKey marks a confirmation by one member of a chat, so in a group it
+// doesn't let the other members run sensitive commands
+func reauthKey(chatID, userID int64) string {
+	return fmt.Sprintf("reauth:%d:%d", chatID, userID)
+}
+
+// reauthPINKey holds the sensitive command a member was asked to send their
+// PIN for; per member, so in a group the others' messages aren't read as PINs
+func reauthPINKey(chatID, userID int64) string {
+	return fmt.Sprintf("reauth-pin:%d:%d", chatID, userID)
+}
+
+// reauthPINs reports whether the chat confirms sensitive commands with a PIN
+func reauthPINs(chatID int64) bool {
+	return config.ReauthWindow > 0 && config.ReauthPINs[strconv.FormatInt(chatID, 10)] != ""
+}
+
+// awaitingReauthPIN reports whether the member's next message is their PIN
+func awaitingReauthPIN(chatID, userID int64) bool {
+	if !reauthPINs(chatID) {
+		return false
+	}
+	_, ok, err := store.Get(reauthPINKey(chatID, userID))
+	return err == nil && ok
+}
+
+// takeReauthPIN returns and clears the command the member was asked to send
+// their PIN for
+func takeReauthPIN(chatID, userID int64) (string, bool) {
+	if !reauthPINs(chatID) {
+		return "", false
+	}
+	key := reauthPINKey(chatID, userID)
+	command, ok, err := store.Get(key)
+	if err != nil {
+		log.Printf("⚠️ Failed to read the held command of UserID %d in ChatID %d: %v", userID, chatID, err)
+		return "", false
+	}
+	if !ok {
+		return "", false
+	}
+	dropReauthPIN(chatID, userID)
+	return command, true
+}
+
+// dropReauthPIN stops waiting for the member's PIN, e.g. when they send a command
+func dropReauthPIN(chatID, userID int64) {
+	if !reauthPINs(chatID) {
+		return
+	}
+	if err := store.Delete(reauthPINKey(chatID, userID)); err != nil {
+		log.Printf("⚠️ Failed to drop the held command of UserID %d in ChatID %d: %v", userID, chatID, err)
+	}
+}
+
+// reauthFailuresKey counts a member's wrong PINs; it expires PINLockout after the last one
+func reauthFailuresKey(chatID, userID int64) string {
+	return fmt.Sprintf("reauth-failures:%d:%d", chatID, userID)
+}
+
+// reauthLockedOut reports whether the member sent MaxPINAttempts wrong PINs in
+// a row, within PINLockout of each other
+func reauthLockedOut(chatID, userID int64) bool {
+	raw, ok, err := store.Get(reauthFailuresKey(chatID, userID))
+	if err != nil {
+		log.Printf("⚠️ Failed to read PIN failures for ChatID %d, UserID %d: %v", chatID, userID, err)
+		return true
+	}
+	failures, _ := strconv.Atoi(raw)
+	return ok && failures >= MaxPINAttempts
+}
+
+func sensitiveCommand(text string) bool {
+	for _, command := range sensitiveCommands {
+		if strings.HasPrefix(text, command) {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmReauth unlocks sensitive commands for the member for REAUTH_MINUTES
+func confirmReauth(chatID, userID int64) {
+	if err := store.Set(reauthKey(chatID, userID), strconv.FormatInt(time.Now().Unix(), 10), config.ReauthWindow); err != nil {
+		log.Printf("⚠️ Failed to record the confirmation of UserID %d in ChatID %d: %v", userID, chatID, err)
+		return
+	}
+	log.Printf("🔐 UserID %d in ChatID %d confirmed sensitive commands for %s", userID, chatID, config.ReauthWindow)
+}
+
+// reauthorized lets a sensitive command through when its sender confirmed one
+// recently. Otherwise it asks for the chat's PIN, or a tap without one, and
+// reports false; the command runs once confirmed.
+func (b *botInstance) reauthorized(msg *tgbotapi.Message, text string) bool {
+	if config.ReauthWindow <= 0 || !sensitiveCommand(text) {
+		return true
+	}
+	chatID := msg.Chat.ID
+	if _, ok, err := store.Get(reauthKey(chatID, msg.From.ID)); err == nil && ok {
+		return true
+	}
+
+	command := strings.Fields(text)[0]
+	log.Printf("🔐 UserID %d in ChatID %d needs to confirm %s", msg.From.ID, chatID, command)
+	var reply tgbotapi.MessageConfig
+	if config.ReauthPINs[strconv.FormatInt(chatID, 10)] != "" && reauthLockedOut(chatID, msg.From.ID) {
+		reply = tgbotapi.NewMessage(chatID, fmt.Sprintf("⏳ Too many wrong PINs - try again in %d minutes.", int(PINLockout.Minutes())))
+	} else if config.ReauthPINs[strconv.FormatInt(chatID, 10)] != "" {
+		if err := store.Set(reauthPINKey(chatID, msg.From.ID), text, SessionAwaitTTL); err != nil {
+			log.Printf("❌ Failed to hold %s for a PIN for ChatID %d: %v", command, chatID, err)
+			reply = tgbotapi.NewMessage(chatID, "❌ Couldn't ask for a confirmation, please try again later.")
+		} else {
+			reply = tgbotapi.NewMessage(chatID, "🔐 "+command+" needs a recent confirmation. Send your PIN to continue.")
+		}
+	} else {
+		raw, _ := json.Marshal(reauthHold{Command: text, UserID: msg.From.ID})
+		if err := store.Set(reauthHoldKey(chatID), string(raw), SessionAwaitTTL); err != nil {
+			log.Printf("❌ Failed to hold %s for confirmation for ChatID %d: %v", command, chatID, err)
+			reply = tgbotapi.NewMessage(chatID, "❌ Couldn't ask for a confirmation, please try again later.")
+		} else {
+			reply = tgbotapi.NewMessage(chatID, "🔐 "+command+" needs a recent confirmation.")
+			reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ It's me - run "+command, CallbackPrefixReauth+"confirm"),
+				tgbotapi.NewInlineKeyboardButtonData("✖️ Cancel", CallbackPrefixReauth+"cancel"),
+			))
+		}
+	}
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+	return false
+}
+
+// handleReauthPIN checks the PIN a member sent for their held sensitive
+// command and runs the command when it matches. The PIN message is deleted
+// either way. MaxPINAttempts wrong PINs in a row stop PINs being checked for
+// PINLockout.
+func (b *botInstance) handleReauthPIN(msg *tgbotapi.Message, command string) {
+	chatID := msg.Chat.ID
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+	if _, err := b.api.Request(tgbotapi.NewDeleteMessage(chatID, msg.MessageID)); err != nil {
+		log.Printf("⚠️ Failed to delete PIN message: %v", err)
+	}
+	if reauthLockedOut(chatID, msg.From.ID) {
+		send(fmt.Sprintf("⏳ Too many wrong PINs - try again in %d minutes.", int(PINLockout.Minutes())))
+		return
+	}
+
+	pin := config.ReauthPINs[strconv.FormatInt(chatID, 10)]
+	if pin == "" || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(msg.Text)), []byte(pin)) != 1 {
+		key := reauthFailuresKey(chatID, msg.From.ID)
+		raw, _, _ := store.Get(key)
+		failures, _ := strconv.Atoi(raw)
+		failures++
+		if err := store.Set(key, strconv.Itoa(failures), PINLockout); err != nil {
+			log.Printf("⚠️ Failed to count a wrong PIN for ChatID %d, UserID %d: %v", chatID, msg.From.ID, err)
+		}
+		log.Printf("🔐 Wrong PIN from ChatID %d, UserID %d (%d in a row)", chatID, msg.From.ID, failures)
+		if failures >= MaxPINAttempts {
+			send(fmt.Sprintf("⏳ Too many wrong PINs - the command was not run. Try again in %d minutes.", int(PINLockout.Minutes())))
+			return
+		}
+		send("❌ Wrong PIN - the command was not run.")
+		return
+	}
+
+	if err := store.Delete(reauthFailuresKey(chatID, msg.From.ID)); err != nil {
+		log.Printf("⚠️ Failed to reset PIN failures for ChatID %d: %v", chatID, err)
+	}
+	confirmReauth(chatID, msg.From.ID)
+	msg.Text = command
+	b.handleMessage(msg)
+}
+
+// handleReauthCallback runs a held sensitive command once its sender taps
+// "It's me", or drops it
+func (b *botInstance) handleReauthCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	key := reauthHoldKey(chatID)
+	raw, ok, err := store.Get(key)
+	var hold reauthHold
+	if err == nil && ok {
+		err = json.Unmarshal([]byte(raw), &hold)
+	}
+	if err != nil || !ok {
+		log.Printf("❌ Stale confirmation callback for ChatID %d", chatID)
+		b.answerCallback(cb, "This confirmation has expired, please run the command again.")
+		return
+	}
+	if cb.From.ID != hold.UserID {
+		log.Printf("🔐 UserID %d tried to confirm a command of UserID %d in ChatID %d", cb.From.ID, hold.UserID, chatID)
+		b.alertCallback(cb, "🔐 Only the person who sent the command can confirm it.")
+		return
+	}
+	if err := store.Delete(key); err != nil {
+		log.Printf("⚠️ Failed to delete confirmation hold for ChatID %d: %v", chatID, err)
+	}
+	if _, err := b.api.Request(tgbotapi.NewDeleteMessage(chatID, cb.Message.MessageID)); err != nil {
+		log.Printf("⚠️ Failed to delete confirmation message: %v", err)
+	}
+
+	if strings.TrimPrefix(cb.Data, CallbackPrefixReauth) != "confirm" {
+		b.answerCallback(cb, "Cancelled")
+		return
+	}
+	b.answerCallback(cb, "Confirmed")
+	confirmReauth(chatID, cb.From.ID)
+	b.handleMessage(&tgbotapi.Message{
+		MessageID: cb.Message.MessageID,
+		From:      cb.From,
+		Chat:      cb.Message.Chat,
+		Date:      cb.Message.Date,
+		Text:      hold.Command,
+	})
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendwise-telegram-go/format"
+	"spendwise-telegram-go/parser"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	ReconcileDefaultAccount = "card"
+	ReconcileMaxCandidates  = 10
+)
+
+// reconcileCycle returns the statement cycle containing now for a cycle starting on startDay
+func reconcileCycle(now time.Time, startDay int) (time.Time, time.Time) {
+	from := time.Date(now.Year(), now.Month(), startDay, 0, 0, 0, 0, now.Location())
+	if now.Day() < startDay {
+		from = from.AddDate(0, -1, 0)
+	}
+	return from, now
+}
+
+func (b *botInstance) handleReconcileCommand(msg *tgbotapi.Message) {
+	args := strings.Fields(msg.Text)[1:]
+
+	account := ReconcileDefaultAccount
+	startDay := 1
+	for _, arg := range args {
+		if day, err := strconv.Atoi(arg); err == nil && day >= 1 && day <= 28 {
+			startDay = day
+		} else if known, ok := isKnownAccount(arg); ok {
+			account = known
+		} else {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Usage: /reconcile [account] [statement start day 1-28]\nExample: /reconcile card 15")
+			if _, err := b.api.Send(reply); err != nil {
+				log.Printf(ErrorSendMessage, err)
+			}
+			return
+		}
+	}
+
+	from, to := reconcileCycle(time.Now(), startDay)
+	log.Printf("🧮 Starting %s reconciliation for ChatID %d: %s to %s",
+		account, msg.Chat.ID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+
+	params := url.Values{}
+	params.Set("account", account)
+	params.Set("from", from.Format("2006-01-02"))
+	params.Set("to", to.Format("2006-01-02"))
+	params.Set("telegramChatId", strconv.FormatInt(msg.Chat.ID, 10))
+
+	expenses, err := b.fetchExpenses(params)
+	if err != nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, userErrorText("fetching expenses", err))
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
+			log.Printf(ErrorSendMessage, sendErr)
+		}
+		return
+	}
+
+	formatter := formatterFor(msg.Chat.ID)
+	response := fmt.Sprintf("🧮 %s cycle %s – %s\n\nLogged: %d expenses, %s\n\nReply with the statement total to compare.",
+		accountLabel(account), formatter.Date(from), formatter.Date(to), len(expenses), formatter.Currency(sumExpenses(expenses)))
+
+	setAwaiting(msg.Chat.ID, AwaitReconcileTotal, map[string]string{
+		"account": account,
+		"from":    from.Format("2006-01-02"),
+		"to":      to.Format("2006-01-02"),
+	})
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf("❌ Failed to send reconcile prompt to ChatID %d: %v", msg.Chat.ID, err)
+	}
+}
+
+// handleReconcileTotal compares a pasted statement total against the logged
+// expenses. A message that isn't just an amount drops the question and is
+// handled as usual, reported by returning false.
+func (b *botInstance) handleReconcileTotal(msg *tgbotapi.Message, data map[string]string) bool {
+	statementTotal, err := parseStatementAmount(msg.Text, formatterFor(msg.Chat.ID))
+	if err != nil {
+		log.Printf("💬 Reply from ChatID %d isn't a statement total, handling it as a message: %v", msg.Chat.ID, err)
+		return false
+	}
+
+	account := data["account"]
+	params := url.Values{}
+	params.Set("from", data["from"])
+	params.Set("to", data["to"])
+	params.Set("telegramChatId", strconv.FormatInt(msg.Chat.ID, 10))
+
+	all, err := b.fetchExpenses(params)
+	if err != nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, userErrorText("fetching expenses", err))
+		if _, sendErr := b.api.Send(reply); sendErr != nil {
+			log.Printf(ErrorSendMessage, sendErr)
+		}
+		return true
+	}
+
+	var tagged, others []ExpenseRecord
+	for _, expense := range all {
+		if strings.EqualFold(expense.Account, account) {
+			tagged = append(tagged, expense)
+		} else {
+			others = append(others, expense)
+		}
+	}
+
+	formatter := formatterFor(msg.Chat.ID)
+	logged := sumExpenses(tagged)
+	diff := statementTotal - logged
+	log.Printf("🧮 Reconciliation for ChatID %d: statement=%.2f logged=%.2f diff=%.2f",
+		msg.Chat.ID, statementTotal, logged, diff)
+
+	response := fmt.Sprintf("🧮 %s Reconciliation\n\nStatement: %s\nLogged: %s\nDifference: %s\n",
+		accountLabel(account), formatter.Currency(statementTotal), formatter.Currency(logged), formatter.Currency(diff))
+
+	switch {
+	case math.Abs(diff) < 0.01:
+		response += "\n✅ Everything matches!"
+	case diff > 0:
+		response += "\nThe statement is higher — these expenses weren't tagged " + account + " and could explain it:\n"
+		response += renderReconcileCandidates(reconcileCandidates(others, diff), diff, formatter.Currency)
+		response += "\nAnything still missing was probably never logged."
+	default:
+		response += "\nMore was logged than billed — check these " + account + " expenses for duplicates or wrong tags:\n"
+		response += renderReconcileCandidates(reconcileCandidates(tagged, -diff), -diff, formatter.Currency)
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf("❌ Failed to send reconciliation to ChatID %d: %v", msg.Chat.ID, err)
+	} else {
+		log.Printf("✅ Reconciliation sent to ChatID: %d", msg.Chat.ID)
+	}
+	return true
+}
+
+// reconcileCandidates returns expenses that fit within the gap, closest amounts first
+func reconcileCandidates(expenses []ExpenseRecord, gap float64) []ExpenseRecord {
+	var candidates []ExpenseRecord
+	for _, expense := range expenses {
+		if expense.Amount <= gap+0.01 {
+			candidates = append(candidates, expense)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return math.Abs(candidates[i].Amount-gap) < math.Abs(candidates[j].Amount-gap)
+	})
+	if len(candidates) > ReconcileMaxCandidates {
+		candidates = candidates[:ReconcileMaxCandidates]
+	}
+	return candidates
+}
+
+func renderReconcileCandidates(candidates []ExpenseRecord, gap float64, currency func(float64) string) string {
+	if len(candidates) == 0 {
+		return "  (no candidates found)\n"
+	}
+	var b strings.Builder
+	for _, expense := range candidates {
+		marker := ""
+		if math.Abs(expense.Amount-gap) < 0.01 {
+			marker = " ← exact match"
+		}
+		fmt.Fprintf(&b, "  • %s %s - %s%s\n", expense.Date, expense.Description, currency(expense.Amount), marker)
+	}
+	return b.String()
+}
+
+// statementCurrencyMarks may lead an amount pasted from a statement
+var statementCurrencyMarks = []string{"₹", "rs.", "rs", "inr", "$", "€", "£"}
+
+// parseStatementAmount reads a single amount pasted from a statement, such as
+// "₹12,345.50", in the number grammar of the parser package. Anything beside
+// the number and a leading currency mark is rejected rather than guessed at.
+func parseStatementAmount(text string, formatter *format.Formatter) (float64, error) {
+	cleaned := strings.TrimSpace(text)
+	marks := append([]string{strings.ToLower(formatter.Symbol())}, statementCurrencyMarks...)
+	for _, mark := range marks {
+		if mark != "" && strings.HasPrefix(strings.ToLower(cleaned), mark) {
+			cleaned = strings.TrimSpace(cleaned[len(mark):])
+			break
+		}
+	}
+	if cleaned == "" {
+		return 0, fmt.Errorf("no amount found")
+	}
+	amount, ok := parser.ParseNumber(cleaned, formatter.DecimalComma())
+	if !ok || amount <= 0 || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("invalid amount %q", text)
+	}
+	return amount, nil
+}
+
+func sumExpenses(expenses []ExpenseRecord) float64 {
+	var total float64
+	for _, expense := range expenses {
+		total += expense.Amount
+	}
+	return total
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixRecurring = "recurring:"
+	// RecurringMinOccurrences is how many roughly monthly entries trigger the suggestion
+	RecurringMinOccurrences = 3
+	// RecurringLookback covers RecurringMinOccurrences monthly cycles with some slack
+	RecurringLookback = 100 * 24 * time.Hour
+	// RecurringMinGapDays and RecurringMaxGapDays bound what counts as "monthly"
+	RecurringMinGapDays = 25
+	RecurringMaxGapDays = 35
+	// RecurringOfferTTL stops the same description from being suggested again
+	RecurringOfferTTL = 90 * 24 * time.Hour
+)
+
+// recurringSuggestion is the expense a "Make this recurring?" prompt refers to
+type recurringSuggestion struct {
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	Day         int     `json:"day"`
+}
+
+// recurringOfferKey identifies a description in the store and in callback data,
+// hashed so long descriptions fit Telegram's 64-byte callback limit
+func recurringOfferKey(chatID int64, description string) (string, string) {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(description))))
+	hash := hex.EncodeToString(sum[:6])
+	return fmt.Sprintf("recurring-offered:%d:%s", chatID, hash), hash
+}
+
+// isMonthlyPattern reports whether the most recent dates are spaced roughly a month apart
+func isMonthlyPattern(dates []time.Time) bool {
+	if len(dates) < RecurringMinOccurrences {
+		return false
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	recent := dates[len(dates)-RecurringMinOccurrences:]
+	for i := 1; i < len(recent); i++ {
+		gap := int(recent[i].Sub(recent[i-1]).Hours() / 24)
+		if gap < RecurringMinGapDays || gap > RecurringMaxGapDays {
+			return false
+		}
+	}
+	return true
+}
+
+// maybeSuggestRecurring offers to turn a just-logged expense into a recurring
+// reminder when it has been logged roughly monthly. Local history gates the
+// backend query so most expenses cost nothing extra.
+func (b *botInstance) maybeSuggestRecurring(msg *tgbotapi.Message, expense ExpenseInput) {
+	key := strings.ToLower(strings.TrimSpace(expense.Description))
+	entry, ok := viewSession(msg.Chat.ID).Recent[key]
+	if !ok || entry.Count < RecurringMinOccurrences {
+		return
+	}
+
+	offeredKey, hash := recurringOfferKey(msg.Chat.ID, key)
+	if _, offered, _ := store.Get(offeredKey); offered {
+		return
+	}
+
+	now := time.Now()
+	params := url.Values{}
+	params.Set("from", now.Add(-RecurringLookback).Format("2006-01-02"))
+	params.Set("to", now.Format("2006-01-02"))
+	params.Set("telegramChatId", strconv.FormatInt(msg.Chat.ID, 10))
+	expenses, err := b.fetchExpenses(params)
+	if err != nil {
+		log.Printf("⚠️ Skipping recurring check for ChatID %d: %v", msg.Chat.ID, err)
+		return
+	}
+
+	var dates []time.Time
+	for _, record := range expenses {
+		if !strings.EqualFold(strings.TrimSpace(record.Description), key) {
+			continue
+		}
+		if date, err := time.Parse("2006-01-02", record.Date); err == nil {
+			dates = append(dates, date)
+		}
+	}
+	if !isMonthlyPattern(dates) {
+		return
+	}
+
+	suggestion := recurringSuggestion{Description: expense.Description, Amount: expense.Amount, Day: now.Day()}
+	data, _ := json.Marshal(suggestion)
+	if err := store.Set(offeredKey, string(data), RecurringOfferTTL); err != nil {
+		log.Printf("⚠️ Failed to store recurring suggestion for ChatID %d: %v", msg.Chat.ID, err)
+		return
+	}
+
+	log.Printf("🔁 Suggesting recurring reminder for %s to ChatID: %d", logText(expense.Description), msg.Chat.ID)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("🔁 You log %s about every month. Make it a recurring reminder on day %d?",
+		expense.Description, suggestion.Day))
+	reply.ReplyToMessageID = msg.MessageID
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔁 Make recurring", CallbackPrefixRecurring+"yes:"+hash),
+		tgbotapi.NewInlineKeyboardButtonData("No thanks", CallbackPrefixRecurring+"no:"+hash),
+	))
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
+
+// handleRecurringCallback creates the reminder when the user accepts the suggestion
+func (b *botInstance) handleRecurringCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	answer, hash, _ := strings.Cut(strings.TrimPrefix(cb.Data, CallbackPrefixRecurring), ":")
+
+	if answer != "yes" {
+		b.answerCallback(cb, "OK, I won't ask again")
+		b.api.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, "👌 Not making it recurring."))
+		return
+	}
+
+	key := fmt.Sprintf("recurring-offered:%d:%s", chatID, hash)
+	raw, ok, err := store.Get(key)
+	var suggestion recurringSuggestion
+	if err == nil && ok {
+		err = json.Unmarshal([]byte(raw), &suggestion)
+	}
+	if err != nil || !ok {
+		log.Printf("❌ Stale recurring callback for ChatID %d: %s", chatID, cb.Data)
+		b.answerCallback(cb, "This suggestion has expired.")
+		return
+	}
+	// Consume the offer before creating the reminder so a double tap can't create two
+	if err := store.Delete(key); err != nil {
+		log.Printf("⚠️ Failed to delete recurring suggestion for ChatID %d: %v", chatID, err)
+	}
+
+	if err := b.createMonthlyReminder(chatID, suggestion); err != nil {
+		log.Printf("❌ Failed to create recurring reminder for ChatID %d: %v", chatID, err)
+		if err := store.Set(key, raw, RecurringOfferTTL); err != nil {
+			log.Printf("⚠️ Failed to restore recurring suggestion for ChatID %d: %v", chatID, err)
+		}
+		b.alertCallback(cb, "❌ Couldn't create the reminder")
+		return
+	}
+	// Keep a marker so the same description isn't suggested again
+	if err := store.Set(key, "created", RecurringOfferTTL); err != nil {
+		log.Printf("⚠️ Failed to store recurring suggestion for ChatID %d: %v", chatID, err)
+	}
+
+	b.answerCallback(cb, "Reminder created")
+	text := fmt.Sprintf("✅ %s (%s) will be reminded on day %d every month. See /reminders.",
+		suggestion.Description, formatterFor(chatID).Currency(suggestion.Amount), suggestion.Day)
+	if _, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, text)); err != nil {
+		log.Printf("⚠️ Failed to update recurring suggestion message: %v", err)
+	}
+}
+
+// createMonthlyReminder creates a recurring bill reminder through the backend
+func (b *botInstance) createMonthlyReminder(chatID int64, suggestion recurringSuggestion) error {
+	_, err := b.apiCallWithTiming("POST", "/api/reminders/create", map[string]interface{}{
+		"description":     suggestion.Description,
+		"amount":          suggestion.Amount,
+		"mainType":        "bill",
+		"dayOfMonthStart": suggestion.Day,
+		"dayOfMonthEnd":   suggestion.Day,
+		"telegramChatId":  strconv.FormatInt(chatID, 10),
+	})
+	return err
+}
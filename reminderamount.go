@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixReminderAmount = "reminder_amount:"
+
+	AwaitReminderAmount = "reminder_amount"
+)
+
+func reminderAmountData(reminderID, reminderType string) string {
+	return CallbackPrefixReminderAmount + reminderID + ":" + reminderType
+}
+
+// reminderQuestion is the context kept while the chat answers a question about
+// a reminder from a notification: the reminder and the notification's buttons
+// once the reminder's own are gone
+func reminderQuestion(cb *tgbotapi.CallbackQuery, reminderID, reminderType string) map[string]string {
+	data := map[string]string{
+		"reminderId":   reminderID,
+		"reminderType": reminderType,
+		"messageId":    strconv.Itoa(cb.Message.MessageID),
+	}
+	if remaining := withoutReminderButtons(cb.Message.ReplyMarkup, reminderID, reminderType); remaining != nil {
+		raw, _ := json.Marshal(remaining)
+		data["keyboard"] = string(raw)
+	}
+	return data
+}
+
+// removeReminderButtons takes a reminder that is done off its notification,
+// keeping the other bills' buttons
+func (b *botInstance) removeReminderButtons(chatID int64, data map[string]string) {
+	messageID, err := strconv.Atoi(data["messageId"])
+	if err != nil || messageID <= 0 {
+		return
+	}
+	keyboard := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if raw := data["keyboard"]; raw != "" {
+		json.Unmarshal([]byte(raw), &keyboard)
+	}
+	if _, err := b.api.Send(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, keyboard)); err != nil {
+		log.Printf("⚠️ Failed to remove reminder buttons: %v", err)
+	}
+}
+
+// handleReminderAmountCallback asks for the amount a bill actually came to;
+// the reply updates the reminder and marks it done
+func (b *botInstance) handleReminderAmountCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	reminderID, reminderType, ok := strings.Cut(strings.TrimPrefix(cb.Data, CallbackPrefixReminderAmount), ":")
+	if !ok || reminderID == "" {
+		log.Printf("❌ Invalid reminder amount callback: %s", cb.Data)
+		b.answerCallback(cb, "Invalid format.")
+		return
+	}
+	if !b.supports(CapReminderUpdate) {
+		b.alertCallback(cb, userErrorText("updating the reminder", errUnsupported{capability: CapReminderUpdate}))
+		return
+	}
+
+	setAwaiting(chatID, AwaitReminderAmount, reminderQuestion(cb, reminderID, reminderType))
+	b.answerCallback(cb, "Send the amount")
+
+	reply := tgbotapi.NewMessage(chatID, "✏️ What did the bill come to? Send the amount, e.g. 1240 - it's saved on the reminder and the bill is marked as done.")
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
+
+// handleReminderAmountReply saves the billed amount on the reminder through
+// the backend, then marks the reminder done. Unless MARK_DONE_EXPENSE is off,
+// the amount is also recorded as paid and logged as a Bills expense. A message
+// that isn't just an amount drops the question and is handled as usual,
+// reported by returning false.
+func (b *botInstance) handleReminderAmountReply(msg *tgbotapi.Message, data map[string]string) bool {
+	chatID := msg.Chat.ID
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	amount, err := parseStatementAmount(msg.Text, formatterFor(chatID))
+	if err != nil {
+		log.Printf("💬 Reply from ChatID %d isn't a reminder amount, handling it as a message: %v", chatID, err)
+		return false
+	}
+
+	reminderID, reminderType := data["reminderId"], data["reminderType"]
+	if _, err := b.apiCallWithTiming("PATCH", "/api/reminders/update", map[string]interface{}{
+		"reminderId":   reminderID,
+		"reminderType": reminderType,
+		"amount":       amount,
+		"userId":       strconv.FormatInt(chatID, 10),
+	}); err != nil {
+		log.Printf("❌ Failed to update amount of reminder %s: %v", reminderID, err)
+		send(userErrorText("updating the reminder", err))
+		return true
+	}
+	formatted := formatterFor(chatID).Currency(amount)
+	log.Printf("✏️ Reminder %s amount updated to %.2f by ChatID %d", reminderID, amount, chatID)
+
+	if markDoneExpenseMode(chatID) != MarkDoneExpenseOff {
+		// The reply already says what was paid, so log it rather than asking again
+		if reminder, ok := b.findReminder(chatID, reminderID); ok {
+			data["description"] = reminder.Description
+		}
+		send("✏️ Amount updated to " + formatted + ".")
+		b.completePaidReminder(msg, data, amount)
+		return true
+	}
+
+	if _, err := b.markReminderDone(chatID, reminderID, reminderType, 0); err != nil {
+		log.Printf("❌ Failed to mark reminder as done - ID: %s, Error: %v", reminderID, err)
+		send("✏️ Amount updated to " + formatted + ", but " + strings.TrimPrefix(userErrorText("marking it as done", err), "❌ "))
+		return true
+	}
+	clearEscalation(reminderID)
+	b.removeReminderButtons(chatID, data)
+	send("✅ Amount updated to " + formatted + " and marked as done.")
+	return true
+}
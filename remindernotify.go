@@ -0,0 +1,192 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// MaxReminderButtons caps the mark-done buttons under one reminder message
+	MaxReminderButtons = 10
+	// ReminderButtonLabelLength keeps button labels readable on small screens
+	ReminderButtonLabelLength = 30
+)
+
+// dueReminders returns the reminders still to be paid this period, in payload order
+func dueReminders(reminders []Reminder, now time.Time) []Reminder {
+	var due []Reminder
+	for _, reminder := range reminders {
+		if activeThisPeriod(reminder, now) && !paidForCurrentPeriod(reminder, now) {
+			due = append(due, reminder)
+		}
+	}
+	return due
+}
+
+// markDoneKeyboard returns a row per due reminder: mark it done, or update
+// its amount first when the bill came out different
+func markDoneKeyboard(due []Reminder) *tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, reminder := range due {
+		if len(rows) == MaxReminderButtons {
+			break
+		}
+		data := CallbackPrefixMarkDone + reminder.ID + ":" + reminder.Type
+		if len(data) > MaxCallbackDataLen {
+			log.Printf("⚠️ Reminder %s is too long for a mark-done button", reminder.ID)
+			continue
+		}
+		label := reminder.Description
+		if runes := []rune(label); len(runes) > ReminderButtonLabelLength {
+			label = string(runes[:ReminderButtonLabelLength-1]) + "…"
+		}
+		row := tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("✅ "+label, data))
+		if amountData := reminderAmountData(reminder.ID, reminder.Type); len(amountData) <= MaxCallbackDataLen {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData("✏️ Amount", amountData))
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &markup
+}
+
+// reminderRecipients de-duplicates the payload's Telegram user IDs, keeping their order
+func reminderRecipients(ids []string) []string {
+	seen := make(map[string]bool)
+	var recipients []string
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			recipients = append(recipients, id)
+		}
+	}
+	return recipients
+}
+
+// handleNotifyReminders renders the daily reminder message for every Telegram
+// user in a NotificationPayload, limited to the reminders that user should see
+// and in their own currency format with mark-done buttons, and delivers them
+// through the rate-limited send queue. Users with nothing due are skipped.
+func handleNotifyReminders(c *gin.Context) {
+	var req struct {
+		NotificationPayload
+		BotID string `json:"botId"` // optional, defaults to the main bot
+	}
+	if !bindJSON(c, &req, true) {
+		return
+	}
+
+	recipients := reminderRecipients(req.TelegramUserIds)
+	if len(recipients) == 0 || len(recipients) > MaxBulkRecipients {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "telegramUserIds must contain 1-" + strconv.Itoa(MaxBulkRecipients) + " chat IDs"})
+		return
+	}
+	b, ok := internalBot(req.BotID)
+	if !ok {
+		log.Printf("❌ Internal notify-reminders for unknown bot %q", req.BotID)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown bot"})
+		return
+	}
+
+	now := time.Now()
+	log.Printf("🔔 Notifying %d users about %d reminders via bot %s", len(recipients), len(req.Reminders), b.ID)
+	results := make([]bulkResult, len(recipients))
+	workers := make(chan struct{}, BulkSendWorkers)
+	var wg sync.WaitGroup
+	for i, id := range recipients {
+		chatID, err := strconv.ParseInt(id, 10, 64)
+		results[i] = bulkResult{ChatID: chatID}
+		if err != nil {
+			results[i].Error = "invalid chat ID " + id
+			continue
+		}
+		t, allowed := tenantForChat(chatID)
+		if !allowed || !t.servedBy(b) {
+			log.Printf("⚠️ Skipping reminder notification to ChatID %d, not a chat of bot %s", chatID, b.ID)
+			results[i].Error = "chat is not allowed for this bot"
+			continue
+		}
+		reminders := t.remindersFor(chatID, req.Reminders)
+		due := dueReminders(reminders, now)
+		if len(due) == 0 {
+			debugf("🔔 Nothing due for ChatID %d, skipping", chatID)
+			results[i].Skipped = true
+			continue
+		}
+
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, chatID int64) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			msg := renderRemindersList(reminders, formatterFor(chatID), now, false).message(chatID)
+			if markup := markDoneKeyboard(due); markup != nil {
+				msg.ReplyMarkup = *markup
+			}
+			sent, err := b.sendPaced(chatID, msg)
+			if err != nil {
+				log.Printf("❌ Reminder notification to ChatID %d failed: %v", chatID, err)
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Success = true
+			results[i].MessageID = sent.MessageID
+		}(i, chatID)
+	}
+	wg.Wait()
+
+	delivered, skipped := 0, 0
+	for _, r := range results {
+		if r.Success {
+			delivered++
+		} else if r.Skipped {
+			skipped++
+		}
+	}
+	log.Printf("✅ Reminder notifications finished via bot %s - %d delivered, %d with nothing due, %d failed",
+		b.ID, delivered, skipped, len(results)-delivered-skipped)
+	c.JSON(http.StatusOK, gin.H{"success": delivered+skipped == len(results), "delivered": delivered, "results": results})
+}
+
+// withoutReminderButtons drops a reminder's mark-done and amount buttons
+func withoutReminderButtons(markup *tgbotapi.InlineKeyboardMarkup, reminderID, reminderType string) *tgbotapi.InlineKeyboardMarkup {
+	markup = withoutButton(markup, CallbackPrefixMarkDone+reminderID+":"+reminderType)
+	return withoutButton(markup, reminderAmountData(reminderID, reminderType))
+}
+
+// withoutButton returns the message's keyboard minus the button carrying data,
+// or nil when no other button is left
+func withoutButton(markup *tgbotapi.InlineKeyboardMarkup, data string) *tgbotapi.InlineKeyboardMarkup {
+	if markup == nil {
+		return nil
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, row := range markup.InlineKeyboard {
+		var kept []tgbotapi.InlineKeyboardButton
+		for _, button := range row {
+			if button.CallbackData == nil || *button.CallbackData != data {
+				kept = append(kept, button)
+			}
+		}
+		if len(kept) > 0 {
+			rows = append(rows, kept)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	remaining := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &remaining
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixPaid = "paid:"
+
+	AwaitPaidAmount = "paid_amount"
+
+	// ReminderExpenseCategory is the category of expenses logged for paid reminders
+	ReminderExpenseCategory = "Bills"
+	// MarkDoneClaimTTL drops repeat taps on a reminder's button while it is marked done
+	MarkDoneClaimTTL = 10 * time.Minute
+)
+
+// MARK_DONE_EXPENSE modes
+const (
+	MarkDoneExpenseOff  = "off"
+	MarkDoneExpenseAsk  = "ask"
+	MarkDoneExpenseAuto = "auto"
+)
+
+// markDoneExpenseModes are the values MARK_DONE_EXPENSE accepts
+var markDoneExpenseModes = []string{MarkDoneExpenseOff, MarkDoneExpenseAsk, MarkDoneExpenseAuto}
+
+// markDoneExpenseMode returns whether marking the chat's reminders done logs an expense
+func markDoneExpenseMode(chatID int64) string {
+	mode := config.MarkDoneExpense
+	if override := userSettings(chatID).MarkDoneExpense; override != "" {
+		mode = override
+	}
+	if mode == "" {
+		return MarkDoneExpenseOff
+	}
+	return strings.ToLower(mode)
+}
+
+// findReminder looks a reminder of the chat up in the current payload
+func (b *botInstance) findReminder(chatID int64, reminderID string) (Reminder, bool) {
+	payload, err := b.fetchReminderPayload()
+	if err != nil {
+		log.Printf("⚠️ Failed to load reminder %s for ChatID %d: %v", reminderID, chatID, err)
+		return Reminder{}, false
+	}
+	for _, reminder := range b.tenant.remindersFor(chatID, payload.Reminders) {
+		if reminder.ID == reminderID {
+			return reminder, true
+		}
+	}
+	return Reminder{}, false
+}
+
+// askPaidAmount holds a mark-done back until the chat says how much was paid,
+// offering the reminder's amount as the default
+func (b *botInstance) askPaidAmount(cb *tgbotapi.CallbackQuery, reminderID, reminderType string) {
+	chatID := cb.Message.Chat.ID
+	data := reminderQuestion(cb, reminderID, reminderType)
+	question := "💸 How much did you pay?"
+	var buttons []tgbotapi.InlineKeyboardButton
+	if reminder, ok := b.findReminder(chatID, reminderID); ok {
+		data["description"] = reminder.Description
+		question = "💸 How much did you pay for " + reminder.Description + "?"
+		if reminder.Amount > 0 {
+			amount := formatterFor(chatID).Currency(reminder.Amount)
+			data["amount"] = strconv.FormatFloat(reminder.Amount, 'f', -1, 64)
+			question += " (default " + amount + ")"
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData("✅ Paid "+amount, CallbackPrefixPaid+"default"))
+		}
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData("⏭️ Just mark done", CallbackPrefixPaid+"skip"))
+
+	setAwaiting(chatID, AwaitPaidAmount, data)
+	b.answerCallback(cb, "How much did you pay?")
+	log.Printf("💸 Asking ChatID %d what was paid for reminder %s", chatID, reminderID)
+	reply := tgbotapi.NewMessage(chatID, question+"\n\nSend the amount to log it as an expense and mark the bill done.")
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(buttons...))
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
+
+// markPaidAutomatically marks the reminder done with its own amount paid and
+// logs the expense without asking. It reports false when the reminder has no
+// amount, leaving a plain mark-done to the caller.
+func (b *botInstance) markPaidAutomatically(cb *tgbotapi.CallbackQuery, reminderID, reminderType string) bool {
+	chatID := cb.Message.Chat.ID
+	reminder, ok := b.findReminder(chatID, reminderID)
+	if !ok || reminder.Amount <= 0 {
+		return false
+	}
+	// Claim the tap so a double tap can't log the payment twice
+	claim := fmt.Sprintf("mark-done:%d:%d:%s", chatID, cb.Message.MessageID, reminderID)
+	claimed, err := store.SetNX(claim, instanceID, MarkDoneClaimTTL)
+	if err != nil || !claimed {
+		log.Printf("🔁 Ignoring repeat mark-done of reminder %s from ChatID %d", reminderID, chatID)
+		b.answerCallback(cb, "Already marked as done")
+		return true
+	}
+
+	data := reminderQuestion(cb, reminderID, reminderType)
+	data["description"] = reminder.Description
+	b.answerCallback(cb, "✅ Marked as done")
+	done := b.completePaidReminder(&tgbotapi.Message{
+		MessageID: cb.Message.MessageID,
+		From:      cb.From,
+		Chat:      cb.Message.Chat,
+		Date:      cb.Message.Date,
+	}, data, reminder.Amount)
+	if !done {
+		if err := store.Delete(claim); err != nil {
+			log.Printf("⚠️ Failed to release mark-done claim for reminder %s: %v", reminderID, err)
+		}
+	}
+	return true
+}
+
+// handlePaidAmountReply completes a held mark-done with the amount typed. A
+// message that isn't just an amount drops the question and is handled as
+// usual, reported by returning false.
+func (b *botInstance) handlePaidAmountReply(msg *tgbotapi.Message, data map[string]string) bool {
+	amount, err := parseStatementAmount(msg.Text, formatterFor(msg.Chat.ID))
+	if err != nil {
+		log.Printf("💬 Reply from ChatID %d isn't a paid amount, handling it as a message: %v", msg.Chat.ID, err)
+		return false
+	}
+	b.completePaidReminder(msg, data, amount)
+	return true
+}
+
+// handlePaidCallback completes a held mark-done with the default amount, or
+// without logging an expense
+func (b *botInstance) handlePaidCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	if viewSession(chatID).Awaiting != AwaitPaidAmount {
+		b.answerCallback(cb, "This question has expired, tap Mark as done again.")
+		return
+	}
+	kind, data, ok := takeAwaiting(chatID)
+	if !ok || kind != AwaitPaidAmount {
+		b.answerCallback(cb, "This question has expired, tap Mark as done again.")
+		return
+	}
+	if _, err := b.api.Send(tgbotapi.NewEditMessageReplyMarkup(chatID, cb.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})); err != nil {
+		log.Printf("⚠️ Failed to remove paid amount buttons: %v", err)
+	}
+
+	amount := 0.0
+	if strings.TrimPrefix(cb.Data, CallbackPrefixPaid) == "default" {
+		amount, _ = strconv.ParseFloat(data["amount"], 64)
+	}
+	b.answerCallback(cb, "Marking as done")
+	b.completePaidReminder(&tgbotapi.Message{
+		MessageID: cb.Message.MessageID,
+		From:      cb.From,
+		Chat:      cb.Message.Chat,
+		Date:      cb.Message.Date,
+	}, data, amount)
+}
+
+// completePaidReminder marks the reminder done with the amount paid in its
+// payment history, then logs the payment as a Bills expense. Zero marks it
+// done without an expense. It reports whether the reminder was marked done.
+func (b *botInstance) completePaidReminder(msg *tgbotapi.Message, data map[string]string, amount float64) bool {
+	chatID := msg.Chat.ID
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+
+	reminderID := data["reminderId"]
+	if _, err := b.markReminderDone(chatID, reminderID, data["reminderType"], amount); err != nil {
+		log.Printf("❌ Failed to mark reminder as done - ID: %s, Error: %v", reminderID, err)
+		send(userErrorText("marking it as done", err))
+		return false
+	}
+	clearEscalation(reminderID)
+	b.removeReminderButtons(chatID, data)
+	log.Printf("✅ Reminder %s marked as done by ChatID %d, paid %.2f", reminderID, chatID, amount)
+	if amount <= 0 {
+		send("✅ Marked as done.")
+		return true
+	}
+
+	send("✅ Marked as done with " + formatterFor(chatID).Currency(amount) + " paid.")
+	description := data["description"]
+	if description == "" {
+		description = "Bill"
+	}
+	b.logExpenses(msg, []ExpenseInput{{
+		Description:    description,
+		Amount:         amount,
+		Date:           time.Now().Format("2006-01-02"),
+		Source:         "bot",
+		UserName:       b.getUserName(msg),
+		TelegramChatID: strconv.FormatInt(chatID, 10),
+		Account:        defaultAccountFor(chatID),
+		Category:       ReminderExpenseCategory,
+	}}, nil)
+	return true
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendwise-telegram-go/format"
+)
+
+// monthListContains reports whether a month list (as stored on reminders) includes
+// the month of t. Entries may be "2006-01", a month number, or an English month name.
+func monthListContains(months []string, t time.Time) bool {
+	for _, month := range months {
+		if monthMatches(month, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func monthMatches(entry string, t time.Time) bool {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return false
+	}
+
+	if parsed, err := time.Parse("2006-01", entry); err == nil {
+		return parsed.Year() == t.Year() && parsed.Month() == t.Month()
+	}
+
+	if n, err := strconv.Atoi(entry); err == nil {
+		return time.Month(n) == t.Month()
+	}
+
+	name := strings.ToLower(entry)
+	full := strings.ToLower(t.Month().String())
+	return name == full || (len(name) >= 3 && strings.HasPrefix(full, name))
+}
+
+// isReminderPaid reports whether the reminder has been paid for the month of t
+func isReminderPaid(reminder Reminder, t time.Time) bool {
+	return monthListContains(reminder.PaidMonths, t)
+}
+
+// reminderDueDate parses the explicit due date of a reminder, if it has one
+func reminderDueDate(reminder Reminder, loc *time.Location) (time.Time, bool) {
+	if reminder.DueDate == "" {
+		return time.Time{}, false
+	}
+	due, err := time.ParseInLocation("2006-01-02", reminder.DueDate, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return due, true
+}
+
+// daysOverdue returns how many days have passed since the reminder's due window
+// closed, along with the month the payment belongs to. Zero means not overdue.
+// Day-of-month bills are judged by the latest active month whose window has
+// closed, looking back up to OverdueLookbackMonths so a bill left unpaid last
+// month keeps escalating after the 1st.
+func daysOverdue(reminder Reminder, now time.Time) (int, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if due, ok := reminderDueDate(reminder, now.Location()); ok {
+		days := int(today.Sub(due).Hours() / 24)
+		if days < 0 {
+			days = 0
+		}
+		return days, due
+	}
+
+	if reminder.DayOfMonthEnd <= 0 {
+		return 0, today
+	}
+	created := time.Unix(reminder.CreatedAt.Seconds, 0).In(now.Location())
+	for i := 0; i <= OverdueLookbackMonths; i++ {
+		month := time.Date(today.Year(), today.Month()-time.Month(i), 1, 0, 0, 0, 0, now.Location())
+		end := dayInMonth(month, reminder.DayOfMonthEnd)
+		switch {
+		case !today.After(end):
+			continue
+		case reminder.CreatedAt.Seconds > 0 && end.Before(created):
+			return 0, today
+		case !isReminderActive(reminder, month):
+			continue
+		case isReminderPaid(reminder, month):
+			return 0, today
+		}
+		return int(math.Round(today.Sub(end).Hours() / 24)), month
+	}
+	return 0, today
+}
+
+// dayInMonth returns midnight on the given day of t's month, clamped to the
+// month's last day so a day-31 bill falls on 30 April or 28 February
+func dayInMonth(t time.Time, day int) time.Time {
+	last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	return time.Date(t.Year(), t.Month(), min(day, last), 0, 0, 0, 0, t.Location())
+}
+
+// dueWindowStarted reports whether the reminder's payment window has opened by now.
+// Explicit due dates count from the start of their month.
+func dueWindowStarted(reminder Reminder, now time.Time) bool {
+	if due, ok := reminderDueDate(reminder, now.Location()); ok {
+		monthStart := time.Date(due.Year(), due.Month(), 1, 0, 0, 0, 0, now.Location())
+		return !now.Before(monthStart)
+	}
+	return reminder.DayOfMonthStart > 0 && !now.Before(dayInMonth(now, reminder.DayOfMonthStart))
+}
+
+// isReminderActive reports whether the reminder applies to the month of t;
+// an empty ActiveMonths list means every month
+func isReminderActive(reminder Reminder, t time.Time) bool {
+	return len(reminder.ActiveMonths) == 0 || monthListContains(reminder.ActiveMonths, t)
+}
+
+// pendingReminders returns active reminders whose window has started but which
+// have not been paid for the relevant month
+func pendingReminders(reminders []Reminder, now time.Time) []Reminder {
+	var pending []Reminder
+	for _, reminder := range reminders {
+		paidMonth := now
+		if due, ok := reminderDueDate(reminder, now.Location()); ok {
+			paidMonth = due
+		}
+		if !isReminderActive(reminder, paidMonth) || !dueWindowStarted(reminder, now) || isReminderPaid(reminder, paidMonth) {
+			continue
+		}
+		pending = append(pending, reminder)
+	}
+	return pending
+}
+
+// formatExplicitDueDate phrases a one-off due date relative to today:
+// "Due on 15 Jul (in 3 days)", "Due Today" or "Overdue since 1 Jul (14 days)"
+func formatExplicitDueDate(due, now time.Time) string {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	days := int(math.Round(due.Sub(today).Hours() / 24)) // rounded, days around DST are 23 or 25 hours
+	label := due.Format("2 Jan")
+	if due.Year() != today.Year() {
+		label = due.Format("2 Jan 2006")
+	}
+
+	switch {
+	case days == 0:
+		return "Due Today"
+	case days == 1:
+		return "Due on " + label + " (tomorrow)"
+	case days > 1:
+		return fmt.Sprintf("Due on %s (in %d days)", label, days)
+	case days == -1:
+		return "Overdue since " + label + " (1 day)"
+	}
+	return fmt.Sprintf("Overdue since %s (%d days)", label, -days)
+}
+
+// paidForCurrentPeriod reports whether a reminder is paid for the month it is
+// due in: its explicit due date's month, otherwise the month of now
+func paidForCurrentPeriod(reminder Reminder, now time.Time) bool {
+	paidMonth := now
+	if due, ok := reminderDueDate(reminder, now.Location()); ok {
+		paidMonth = due
+	}
+	return isReminderPaid(reminder, paidMonth)
+}
+
+// reminderUrgency groups unpaid reminders in the /reminders list
+type reminderUrgency int
+
+const (
+	UrgencyOverdue reminderUrgency = iota
+	UrgencyDueToday
+	UrgencyUpcoming
+)
+
+var urgencyHeadings = map[reminderUrgency]string{
+	UrgencyOverdue:  "🚨 Overdue",
+	UrgencyDueToday: "📅 Due today",
+	UrgencyUpcoming: "🗓️ Upcoming",
+}
+
+// reminderWindow returns the first and last day a reminder is due in the month
+// of now; ok is false when it has neither a due date nor a day-of-month window
+func reminderWindow(reminder Reminder, now time.Time) (start, end time.Time, ok bool) {
+	if due, ok := reminderDueDate(reminder, now.Location()); ok {
+		return due, due, true
+	}
+	if reminder.DayOfMonthStart <= 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	endDay := reminder.DayOfMonthEnd
+	if endDay < reminder.DayOfMonthStart {
+		endDay = reminder.DayOfMonthStart
+	}
+	start = dayInMonth(now, reminder.DayOfMonthStart)
+	end = dayInMonth(now, endDay)
+	return start, end, true
+}
+
+// classifyReminder places a reminder relative to today; reminders without any
+// due information count as upcoming and sort last
+func classifyReminder(reminder Reminder, now time.Time) (reminderUrgency, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start, end, ok := reminderWindow(reminder, now)
+	switch {
+	case !ok:
+		return UrgencyUpcoming, time.Time{}
+	case end.Before(today):
+		return UrgencyOverdue, start
+	case !start.After(today):
+		return UrgencyDueToday, start
+	}
+	return UrgencyUpcoming, start
+}
+
+// formatMonthList renders a reminder's month list compactly, e.g. "Mar, Sep 2026"
+// entries become "Mar" and "Sep 2026"; unrecognised entries are kept as-is
+func formatMonthList(months []string) string {
+	labels := make([]string, 0, len(months))
+	for _, entry := range months {
+		entry = strings.TrimSpace(entry)
+		if parsed, err := time.Parse("2006-01", entry); err == nil {
+			labels = append(labels, parsed.Format("Jan 2006"))
+			continue
+		}
+		if n, err := strconv.Atoi(entry); err == nil && n >= 1 && n <= 12 {
+			labels = append(labels, time.Month(n).String()[:3])
+			continue
+		}
+		label := entry
+		for m := time.January; m <= time.December; m++ {
+			if monthMatches(entry, time.Date(2000, m, 1, 0, 0, 0, 0, time.UTC)) {
+				label = m.String()[:3]
+				break
+			}
+		}
+		labels = append(labels, label)
+	}
+	return strings.Join(labels, ", ")
+}
+
+// activeThisPeriod reports whether a reminder applies to the month it is due in
+func activeThisPeriod(reminder Reminder, now time.Time) bool {
+	month := now
+	if due, ok := reminderDueDate(reminder, now.Location()); ok {
+		month = due
+	}
+	return isReminderActive(reminder, month)
+}
+
+// renderRemindersList renders the /reminders reply. Unpaid bills are grouped into
+// overdue, due today and upcoming sections sorted by due date, each with a count
+// and subtotal; bills already paid this period are struck through and tucked into
+// a collapsed section at the end. Reminders whose ActiveMonths exclude this month
+// are only counted, unless showAll lists them with the months they apply to.
+func renderRemindersList(reminders []Reminder, formatter *format.Formatter, now time.Time, showAll bool) *richText {
+	type entry struct {
+		reminder Reminder
+		urgency  reminderUrgency
+		due      time.Time
+	}
+	var due []entry
+	var paid, inactive []Reminder
+	for _, reminder := range reminders {
+		if !activeThisPeriod(reminder, now) {
+			inactive = append(inactive, reminder)
+			continue
+		}
+		if paidForCurrentPeriod(reminder, now) {
+			paid = append(paid, reminder)
+			continue
+		}
+		urgency, date := classifyReminder(reminder, now)
+		due = append(due, entry{reminder: reminder, urgency: urgency, due: date})
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		a, b := due[i], due[j]
+		switch {
+		case a.urgency != b.urgency:
+			return a.urgency < b.urgency
+		case a.due.IsZero() != b.due.IsZero():
+			return b.due.IsZero()
+		case !a.due.Equal(b.due):
+			return a.due.Before(b.due)
+		}
+		return strings.ToLower(a.reminder.Description) < strings.ToLower(b.reminder.Description)
+	})
+
+	out := &richText{}
+	out.write("🔔 Daily Reminders\n")
+	if len(due) == 0 {
+		out.write("\nAll bills are paid for this month 🎉\n")
+	}
+	for i := 0; i < len(due); {
+		group := due[i:]
+		for j := range group {
+			if group[j].urgency != due[i].urgency {
+				group = group[:j]
+				break
+			}
+		}
+		subtotal := 0.0
+		for _, e := range group {
+			subtotal += e.reminder.Amount
+		}
+
+		out.write("\n")
+		out.styled("bold", fmt.Sprintf("%s (%d) - %s", urgencyHeadings[due[i].urgency], len(group), formatter.Currency(subtotal)))
+		out.write("\n")
+		for _, e := range group {
+			dueText := formatDueDate(e.reminder)
+			if e.urgency == UrgencyOverdue && e.reminder.DueDate == "" {
+				if days, _ := daysOverdue(e.reminder, now); days > 0 {
+					dueText += fmt.Sprintf(", %d day(s) overdue", days)
+				}
+			}
+			line := fmt.Sprintf("%s - %s (%s)", e.reminder.Description, formatter.Currency(e.reminder.Amount), dueText)
+			out.write("  • " + line + "\n")
+			log.Printf("📌 Reminder %d: %s", i+1, logText(line))
+			i++
+		}
+	}
+	if len(due) > 0 {
+		out.write("\nPlease check the app to take action.")
+	}
+
+	if len(paid) > 0 {
+		out.write("\n\n")
+		out.styled("bold", fmt.Sprintf("✅ Already paid (%d)", len(paid)))
+		out.write("\n")
+		start := out.length
+		for i, reminder := range paid {
+			if i > 0 {
+				out.write("\n")
+			}
+			out.write("✅ ")
+			out.styled("strikethrough", fmt.Sprintf("%s - %s", reminder.Description, formatter.Currency(reminder.Amount)))
+		}
+		out.span("expandable_blockquote", start)
+	}
+
+	switch {
+	case len(inactive) > 0 && showAll:
+		out.write("\n\n")
+		out.styled("bold", fmt.Sprintf("💤 Other months (%d)", len(inactive)))
+		for _, reminder := range inactive {
+			out.write(fmt.Sprintf("\n  • %s - %s (%s)", reminder.Description, formatter.Currency(reminder.Amount), formatMonthList(reminder.ActiveMonths)))
+		}
+	case len(inactive) > 0:
+		out.write(fmt.Sprintf("\n\n💤 %d reminder(s) not active this month - /reminders all to see them", len(inactive)))
+	}
+	return out
+}
+
+// remindersFor returns the reminders a chat should see. Chats mapped in
+// ReminderOwners see their own reminders plus shared ones: reminders without an
+// owner or whose owner isn't mapped to any chat. Unmapped chats see everything
+// and limited chats see none. A nil tenant uses the chat's own household.
+func (t *tenant) remindersFor(chatID int64, reminders []Reminder) []Reminder {
+	if isLimited(chatID) {
+		return nil
+	}
+	if t == nil {
+		if t, _ = tenantForChat(chatID); t == nil {
+			return reminders
+		}
+	}
+	owner, ok := t.ReminderOwners[strconv.FormatInt(chatID, 10)]
+	if !ok {
+		return reminders
+	}
+
+	mapped := make(map[string]bool, len(t.ReminderOwners))
+	for _, userID := range t.ReminderOwners {
+		mapped[userID] = true
+	}
+	var own []Reminder
+	for _, reminder := range reminders {
+		if reminder.UserID == owner || reminder.UserID == "" || !mapped[reminder.UserID] {
+			own = append(own, reminder)
+		}
+	}
+	return own
+}
+
+// reminderChats returns the chats to notify about a reminder: the chats mapped
+// to its owner, or fallback (the payload's telegramUserIds) for shared reminders
+func (t *tenant) reminderChats(reminder Reminder, fallback []string) []string {
+	var chats []string
+	if t != nil && reminder.UserID != "" {
+		for chatID, userID := range t.ReminderOwners {
+			if userID == reminder.UserID && !config.LimitedIDs[chatID] {
+				chats = append(chats, chatID)
+			}
+		}
+	}
+	if len(chats) == 0 {
+		chats = fallback
+	} else {
+		sort.Strings(chats)
+	}
+	var notify []string
+	for _, chatID := range chats {
+		if !config.LimitedIDs[chatID] {
+			notify = append(notify, chatID)
+		}
+	}
+	return notify
+}
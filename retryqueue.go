@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// RetryQueueInterval is how often queued batches are retried
+	RetryQueueInterval = time.Minute
+	// RetryQueueTTL keeps a chat's queue around while the backend stays down
+	RetryQueueTTL = 14 * 24 * time.Hour
+	// MaxQueuedBatches caps the batches waiting per chat; beyond it saves fail as before
+	MaxQueuedBatches = 50
+)
+
+// errBackendUnavailable marks failures where the SpendWise server could not be
+// reached, timed out or answered 5xx, as opposed to rejecting the request
+type errBackendUnavailable struct {
+	cause    error
+	timedOut bool // the request was sent, so the backend may have acted on it
+}
+
+func (e errBackendUnavailable) Error() string {
+	return e.cause.Error()
+}
+
+// isBackendUnavailable reports whether err means the request is worth retrying later
+func isBackendUnavailable(err error) bool {
+	var unavailable errBackendUnavailable
+	return errors.As(err, &unavailable)
+}
+
+// backendTimedOut reports whether err is a backend request that timed out
+func backendTimedOut(err error) bool {
+	var unavailable errBackendUnavailable
+	return errors.As(err, &unavailable) && unavailable.timedOut
+}
+
+// isTimeout reports whether a transport error is a timeout rather than a
+// failure to connect
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// queuedBatch is a parsed message whose save failed because the backend was down
+type queuedBatch struct {
+	Expenses  []ExpenseInput
+	MessageID int // the user's message, replied to once synced
+	QueuedAt  time.Time
+	Attempts  int
+}
+
+func retryQueueKey(botID string, chatID int64) string {
+	return fmt.Sprintf("retry-queue:%s:%d", botID, chatID)
+}
+
+// queueForRetry appends a batch the backend couldn't take to the chat's queue,
+// a list in the store so instances don't overwrite each other's batches. It
+// reports false when the queue is full or the store is unavailable.
+func (b *botInstance) queueForRetry(msg *tgbotapi.Message, expenses []ExpenseInput) bool {
+	key := retryQueueKey(b.ID, msg.Chat.ID)
+	if queued, err := store.ListLen(key); err != nil || queued >= MaxQueuedBatches {
+		log.Printf("⚠️ Retry queue for ChatID %d is full or unavailable (%d batches): %v", msg.Chat.ID, queued, err)
+		return false
+	}
+	raw, err := json.Marshal(queuedBatch{Expenses: expenses, MessageID: msg.MessageID, QueuedAt: time.Now()})
+	if err != nil {
+		return false
+	}
+	queued, err := store.PushBack(key, string(raw), RetryQueueTTL)
+	if err != nil {
+		log.Printf("❌ Failed to queue expenses for ChatID %d: %v", msg.Chat.ID, err)
+		return false
+	}
+	log.Printf("💾 Queued %d expenses for ChatID %d until the backend is back (%d batches waiting)",
+		len(expenses), msg.Chat.ID, queued)
+	incCounter("spendwise_retry_queued_total", "bot", b.ID)
+	return true
+}
+
+// runRetryQueue resends queued batches for the tenant's chats, oldest first,
+// and confirms each one in the chat once it is saved. Each batch is popped
+// before it is sent, so two instances never send the same one. The run stops
+// at the first batch the backend still can't take, which goes back to the
+// front of its queue for next time.
+func (b *botInstance) runRetryQueue() {
+	synced := 0
+chats:
+	for _, chatIDStr := range b.tenant.allowedChats() {
+		chatID, err := strconv.ParseInt(chatIDStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		key := retryQueueKey(b.ID, chatID)
+
+		for {
+			raw, ok, err := store.PopFront(key)
+			if err != nil {
+				log.Printf("⚠️ Failed to read retry queue %s: %v", key, err)
+				break chats
+			}
+			if !ok {
+				break
+			}
+			var batch queuedBatch
+			if err := json.Unmarshal([]byte(raw), &batch); err != nil {
+				log.Printf("⚠️ Discarding unreadable batch from retry queue %s: %v", key, err)
+				continue
+			}
+
+			err = b.resendBatch(chatID, batch)
+			if err != nil && isBackendUnavailable(err) && !backendTimedOut(err) {
+				log.Printf("💾 Backend still unavailable, keeping queued batches for ChatID %d: %v", chatID, err)
+				batch.Attempts++
+				raw, _ := json.Marshal(batch)
+				if err := store.PushFront(key, string(raw), RetryQueueTTL); err != nil {
+					log.Printf("❌ Failed to requeue batch for ChatID %d: %v", chatID, err)
+				}
+				break chats
+			}
+			if err == nil {
+				synced++
+			}
+		}
+	}
+
+	if synced > 0 {
+		log.Printf("💾 Retry queue run finished - %d batches synced", synced)
+	}
+}
+
+// resendBatch saves one queued batch and tells the chat how it went. It returns
+// the error only so the caller can tell an unreachable backend apart; rejected
+// batches are reported to the chat and dropped. So is a resend that timed out,
+// since the backend may have saved it and sending it again could duplicate it.
+func (b *botInstance) resendBatch(chatID int64, batch queuedBatch) error {
+	result, err := b.apiCallWithTiming("POST", "/api/expenses/create-batch-from-bot", batch.Expenses)
+	if err != nil && isBackendUnavailable(err) && !backendTimedOut(err) {
+		return err
+	}
+
+	var apiResp struct {
+		Success bool     `json:"success"`
+		Error   string   `json:"error"`
+		Details string   `json:"details"`
+		IDs     []string `json:"ids"`
+	}
+	var text string
+	switch {
+	case backendTimedOut(err):
+		text = "⚠️ The SpendWise server didn't confirm the expenses saved while it was down. Check /summary before logging them again."
+	case err != nil:
+		text = "❌ Couldn't sync expenses saved while the server was down: " + err.Error()
+	case json.Unmarshal(result.Data, &apiResp) != nil:
+		err = fmt.Errorf("unreadable API response")
+		text = "❌ Couldn't sync expenses saved while the server was down: error parsing API response"
+	case !apiResp.Success:
+		err = fmt.Errorf("%s", apiResp.Error)
+		text = "❌ Couldn't sync expenses saved while the server was down: " + apiResp.Error
+		if apiResp.Details != "" {
+			text += "\nDetails: " + apiResp.Details
+		}
+	default:
+		for _, expense := range batch.Expenses {
+			recordRecentDescription(chatID, expense.Description)
+		}
+		if len(batch.Expenses) == 1 && len(apiResp.IDs) == 1 {
+			linkMessage(chatID, batch.MessageID, EntityExpense, apiResp.IDs[0])
+		}
+		text = fmt.Sprintf("✅ Synced %d expense(s) (total %s) now that the server is back",
+			len(batch.Expenses), formatterFor(chatID).Currency(batchTotal(batch.Expenses)))
+	}
+
+	if err != nil {
+		log.Printf("❌ Dropping queued batch for ChatID %d after %d attempts: %v", chatID, batch.Attempts+1, err)
+	} else {
+		log.Printf("💾 Synced queued batch of %d expenses for ChatID %d, queued %s ago",
+			len(batch.Expenses), chatID, time.Since(batch.QueuedAt).Round(time.Second))
+	}
+
+	reply := tgbotapi.NewMessage(chatID, text)
+	reply.ReplyToMessageID = batch.MessageID
+	reply.AllowSendingWithoutReply = true
+	if _, sendErr := b.sendPaced(chatID, reply); sendErr != nil {
+		log.Printf(ErrorSendMessage, sendErr)
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// richText builds a plain-text message with formatting entities attached, which
+// avoids escaping user-provided text for Markdown or HTML parse modes.
+// Telegram measures entity offsets in UTF-16 code units.
+type richText struct {
+	text     strings.Builder
+	length   int
+	entities []tgbotapi.MessageEntity
+}
+
+func (t *richText) write(s string) {
+	t.text.WriteString(s)
+	t.length += len(utf16.Encode([]rune(s)))
+}
+
+// styled writes s formatted as kind, e.g. "strikethrough" or "bold"
+func (t *richText) styled(kind, s string) {
+	start := t.length
+	t.write(s)
+	t.span(kind, start)
+}
+
+// span formats everything written since start as kind
+func (t *richText) span(kind string, start int) {
+	if t.length > start {
+		t.entities = append(t.entities, tgbotapi.MessageEntity{Type: kind, Offset: start, Length: t.length - start})
+	}
+}
+
+// message returns a ready-to-send message for chatID, with entities ordered by offset
+func (t *richText) message(chatID int64) tgbotapi.MessageConfig {
+	sort.SliceStable(t.entities, func(i, j int) bool { return t.entities[i].Offset < t.entities[j].Offset })
+	msg := tgbotapi.NewMessage(chatID, t.text.String())
+	msg.Entities = t.entities
+	return msg
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// scheduledJob is a background task run on a fixed interval
+type scheduledJob struct {
+	Name     string
+	Interval time.Duration
+	Run      func()
+}
+
+var scheduledJobs []scheduledJob
+
+// registerJob adds a job to be started by startScheduler
+func registerJob(name string, interval time.Duration, run func()) {
+	scheduledJobs = append(scheduledJobs, scheduledJob{Name: name, Interval: interval, Run: run})
+}
+
+// startScheduler runs every registered job on its own ticker. Jobs only execute
+// on the instance currently holding the scheduler lease.
+func startScheduler() {
+	if len(scheduledJobs) == 0 {
+		log.Println("⏰ No scheduled jobs registered")
+		return
+	}
+
+	go runLeaderElection()
+
+	for _, job := range scheduledJobs {
+		log.Printf("⏰ Scheduling job %s every %s", job.Name, job.Interval)
+		go runJobLoop(job)
+	}
+}
+
+func runJobLoop(job scheduledJob) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		runJob(job)
+	}
+}
+
+// runJob executes a single job run, keeping a panic from killing the scheduler
+func runJob(job scheduledJob) {
+	if !schedulerLeader() {
+		log.Printf("⏰ Skipping job %s, another instance is the scheduler leader", job.Name)
+		return
+	}
+
+	startTime := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			log.Printf("❌ Scheduled job %s panicked: %v\n%s", job.Name, r, stack)
+			reportError("job "+job.Name, r, stack)
+		}
+		duration := time.Since(startTime)
+		log.Printf("⏱️ Scheduled job %s completed in %d ms", job.Name, duration.Milliseconds())
+	}()
+
+	log.Printf("⏰ Running scheduled job %s", job.Name)
+	job.Run()
+}
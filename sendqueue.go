@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// SendGlobalInterval paces a bot to Telegram's ~30 messages per second
+	SendGlobalInterval = time.Second / 30
+	// SendChatInterval is Telegram's 1 message per second limit for private chats
+	SendChatInterval = time.Second
+	// SendGroupInterval keeps groups under 20 messages per minute
+	SendGroupInterval = 3 * time.Second
+	// SendMaxAttempts bounds retries after 429 Too Many Requests
+	SendMaxAttempts = 4
+	// SendBackgroundInterval paces paced (background) sends below SendGlobalInterval,
+	// leaving a fifth of Telegram's budget for direct replies to users
+	SendBackgroundInterval = SendGlobalInterval * 5 / 4
+	// BackgroundYieldMax bounds how long one background send waits for interactive
+	// updates to drain, so steady traffic can't starve digests and broadcasts
+	BackgroundYieldMax  = 5 * time.Second
+	BackgroundYieldPoll = 50 * time.Millisecond
+)
+
+// sendLimiter hands out send slots per bot. Each caller reserves the next free
+// slot for its chat and the bot as a whole, so concurrent bulk sends queue up in
+// reservation order instead of tripping Telegram's rate limits.
+type sendLimiter struct {
+	mu          sync.Mutex
+	nextGlobal  time.Time
+	nextChat    map[int64]time.Time
+	pausedUntil time.Time
+	waiting     atomic.Int64
+}
+
+func newSendLimiter() *sendLimiter {
+	return &sendLimiter{nextChat: make(map[int64]time.Time)}
+}
+
+// reserve books the next slot for the chat and returns how long to wait for it
+func (l *sendLimiter) reserve(chatID int64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	slot := now
+	for _, t := range []time.Time{l.nextGlobal, l.nextChat[chatID], l.pausedUntil} {
+		if t.After(slot) {
+			slot = t
+		}
+	}
+
+	interval := SendChatInterval
+	if chatID < 0 {
+		interval = SendGroupInterval
+	}
+	l.nextGlobal = slot.Add(SendBackgroundInterval)
+	l.nextChat[chatID] = slot.Add(interval)
+
+	// Forget chats whose slots are in the past so the map stays small
+	if len(l.nextChat) > 1000 {
+		for id, next := range l.nextChat {
+			if next.Before(now) {
+				delete(l.nextChat, id)
+			}
+		}
+	}
+	return slot.Sub(now)
+}
+
+// pause holds every send of the bot until Telegram's retry_after has passed
+func (l *sendLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// yieldToInteractive holds background work while at least half the update
+// slots are busy handling user messages and callbacks, for up to BackgroundYieldMax
+func yieldToInteractive() {
+	busy := max(maxConcurrentUpdates()/2, 1)
+	if len(updateSlots()) < busy {
+		return
+	}
+
+	startTime := time.Now()
+	for len(updateSlots()) >= busy && time.Since(startTime) < BackgroundYieldMax {
+		time.Sleep(BackgroundYieldPoll)
+	}
+	incCounter("spendwise_background_yields_total")
+	debugf("🚦 Background send yielded %d ms to interactive updates", time.Since(startTime).Milliseconds())
+}
+
+// queueDepth is the number of sends currently waiting for a slot
+func (l *sendLimiter) queueDepth() int64 {
+	return l.waiting.Load()
+}
+
+// sendPaced sends through the bot's rate limiter, waiting for a free slot and
+// retrying after 429 responses. Use it for bulk and scheduled sends; replies to
+// a user's own message go straight through b.api.Send. Paced sends are the
+// background lane: they yield to interactive updates and leave headroom in
+// Telegram's rate limit for replies.
+func (b *botInstance) sendPaced(chatID int64, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	yieldToInteractive()
+
+	depth := b.limiter.waiting.Add(1)
+	setGauge("spendwise_send_queue_depth", float64(depth), "bot", b.ID)
+	defer func() {
+		setGauge("spendwise_send_queue_depth", float64(b.limiter.waiting.Add(-1)), "bot", b.ID)
+	}()
+
+	var lastErr error
+	for attempt := 1; attempt <= SendMaxAttempts; attempt++ {
+		if wait := b.limiter.reserve(chatID); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		msg, err := b.sendFormatted(c)
+		var tgErr *tgbotapi.Error
+		if err == nil || !errors.As(err, &tgErr) || tgErr.RetryAfter <= 0 {
+			return msg, err
+		}
+
+		retryAfter := time.Duration(tgErr.RetryAfter) * time.Second
+		log.Printf("⚠️ Telegram rate limit for bot %s sending to ChatID %d, retrying in %s (attempt %d/%d)",
+			b.ID, chatID, retryAfter, attempt, SendMaxAttempts)
+		incCounter("spendwise_send_rate_limited_total", "bot", b.ID)
+		b.limiter.pause(retryAfter)
+		lastErr = err
+	}
+	return tgbotapi.Message{}, fmt.Errorf("rate limited after %d attempts: %v", SendMaxAttempts, lastErr)
+}
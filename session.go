@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// SessionAwaitTTL is how long the bot waits for the answer to a question
+	SessionAwaitTTL = 10 * time.Minute
+
+	AwaitReconcileTotal     = "reconcile_total"
+	AwaitExpenseDescription = "expense_description"
+	AwaitExpenseAmount      = "expense_amount"
+	AwaitExpenseCategory    = "expense_category"
+	AwaitBudgetPlan         = "budget_plan"
+
+	// MaxTrackedDescriptions caps the recent descriptions remembered per chat
+	MaxTrackedDescriptions = 50
+	// SessionTTL is how long an idle chat's session is kept in the store
+	SessionTTL = 30 * 24 * time.Hour
+	// SessionLockTTL bounds how long a crashed instance can hold a chat's session
+	// lock; SessionLockWait is how long an update waits for it
+	SessionLockTTL  = 5 * time.Second
+	SessionLockWait = 2 * SessionLockTTL
+)
+
+// chatSession holds conversational state for a chat. It is stored as JSON in the
+// coordination store so any instance can continue a conversation.
+type chatSession struct {
+	Awaiting   string            // what the next plain message from the chat answers
+	Data       map[string]string // context for the awaited answer
+	AwaitSince time.Time
+
+	Recent  map[string]*recentDescription // lower-cased description -> usage
+	Offered []string                      // descriptions shown on the last quick-pick keyboard
+
+	DefaultAccount string // payment account picked via /accounts
+
+	NudgesEnabled    bool      // opted in to missed-day nudges via /nudges on
+	NudgesMutedUntil time.Time // nudges paused via /nudges mute
+
+	ReactionEmoji string // success reaction picked via /reaction; empty means the default
+
+	DigestEnabled bool // opted in to the Sunday weekly digest via /digest on
+
+	StreaksEnabled bool    // streak celebrations turned on via /streaks on
+	DailyBudget    float64 // daily budget for under-budget streaks; 0 means none
+
+	MonthlyCap float64 // spending cap set by an admin via /cap; 0 means none
+
+	QuickMenu []quickMenuItem // routine expenses shown by the last /quick
+
+	DashboardMessageID int // pinned group dashboard from /dashboard on; 0 means none
+
+	PlanReminders bool // reminded to /plan at month start, turned on by the first saved plan
+
+	Trip     *tripRecord // running /trip that new expenses are tagged with
+	LastTrip *tripRecord // most recently ended trip, reported by /trip
+
+	// The /pin lock itself is kept outside the session, see pinLock
+	PINLastActive     time.Time // last unlocked activity; zero locks the chat
+	PINFailures       int       // wrong PINs in a row
+	PINLockedOutUntil time.Time // no PINs are checked until then after MaxPINAttempts failures
+}
+
+// recentDescription tracks how often and how recently a description was logged
+type recentDescription struct {
+	Text     string
+	Count    int
+	LastUsed time.Time
+}
+
+func sessionKey(chatID int64) string {
+	return "session:" + strconv.FormatInt(chatID, 10)
+}
+
+// sessionLockKey serializes read-modify-write cycles on a chat's session
+// across every instance sharing the store
+func sessionLockKey(chatID int64) string {
+	return "session-lock:" + strconv.FormatInt(chatID, 10)
+}
+
+// loadSession reads a chat's session from the coordination store
+func loadSession(chatID int64) *chatSession {
+	s := &chatSession{}
+	raw, ok, err := store.Get(sessionKey(chatID))
+	if err != nil {
+		log.Printf("⚠️ Failed to load session for ChatID %d: %v", chatID, err)
+		return s
+	}
+	if ok {
+		if err := json.Unmarshal([]byte(raw), s); err != nil {
+			log.Printf("⚠️ Discarding unreadable session for ChatID %d: %v", chatID, err)
+			return &chatSession{}
+		}
+	}
+	return s
+}
+
+// saveSession writes a chat's session back to the coordination store; callers
+// must hold the chat's session lock
+func saveSession(chatID int64, s *chatSession) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		log.Printf("❌ Failed to encode session for ChatID %d: %v", chatID, err)
+		return
+	}
+	if err := store.Set(sessionKey(chatID), string(raw), SessionTTL); err != nil {
+		log.Printf("⚠️ Failed to save session for ChatID %d: %v", chatID, err)
+	}
+}
+
+// updateSession applies fn to the chat's session and persists the result while
+// holding the chat's session lock, so concurrent updates on any instance don't
+// overwrite each other. If the lock can't be had, the update still goes ahead.
+func updateSession(chatID int64, fn func(s *chatSession)) {
+	release, err := acquireLock(sessionLockKey(chatID), SessionLockTTL, SessionLockWait)
+	if err != nil {
+		log.Printf("⚠️ Updating the session of ChatID %d without its lock: %v", chatID, err)
+	} else {
+		defer release()
+	}
+
+	s := loadSession(chatID)
+	fn(s)
+	saveSession(chatID, s)
+}
+
+// viewSession returns a snapshot of the chat's session
+func viewSession(chatID int64) *chatSession {
+	return loadSession(chatID)
+}
+
+// setAwaiting records that the next plain message from the chat answers a question
+func setAwaiting(chatID int64, kind string, data map[string]string) {
+	updateSession(chatID, func(s *chatSession) {
+		s.Awaiting = kind
+		s.Data = data
+		s.AwaitSince = time.Now()
+	})
+	log.Printf("💬 ChatID %d now awaiting %s", chatID, kind)
+}
+
+// takeAwaiting returns and clears the pending question for a chat, ignoring expired ones
+func takeAwaiting(chatID int64) (string, map[string]string, bool) {
+	var kind string
+	var data map[string]string
+	var expired bool
+
+	updateSession(chatID, func(s *chatSession) {
+		kind, data = s.Awaiting, s.Data
+		expired = time.Since(s.AwaitSince) > SessionAwaitTTL
+		s.Awaiting, s.Data = "", nil
+	})
+
+	if kind == "" {
+		return "", nil, false
+	}
+	if expired {
+		log.Printf("⌛ Awaited %s for ChatID %d expired", kind, chatID)
+		return "", nil, false
+	}
+	return kind, data, true
+}
+
+// clearAwaiting drops any pending question for a chat
+func clearAwaiting(chatID int64) {
+	if viewSession(chatID).Awaiting == "" {
+		return
+	}
+	updateSession(chatID, func(s *chatSession) {
+		s.Awaiting, s.Data = "", nil
+	})
+}
+
+// recordRecentDescription remembers a successfully logged description for quick picks
+func recordRecentDescription(chatID int64, description string) {
+	key := strings.ToLower(strings.TrimSpace(description))
+	if key == "" {
+		return
+	}
+
+	updateSession(chatID, func(s *chatSession) {
+		if s.Recent == nil {
+			s.Recent = make(map[string]*recentDescription)
+		}
+
+		entry, ok := s.Recent[key]
+		if !ok {
+			if len(s.Recent) >= MaxTrackedDescriptions {
+				evictOldestDescription(s)
+			}
+			entry = &recentDescription{Text: description}
+			s.Recent[key] = entry
+		}
+		entry.Count++
+		entry.LastUsed = time.Now()
+	})
+}
+
+func evictOldestDescription(s *chatSession) {
+	oldestKey := ""
+	var oldest time.Time
+	for key, entry := range s.Recent {
+		if oldestKey == "" || entry.LastUsed.Before(oldest) {
+			oldestKey, oldest = key, entry.LastUsed
+		}
+	}
+	delete(s.Recent, oldestKey)
+}
+
+// topRecentDescriptions returns up to n descriptions, most used first and then most recent
+func topRecentDescriptions(chatID int64, n int) []string {
+	s := viewSession(chatID)
+	if len(s.Recent) == 0 {
+		return nil
+	}
+
+	entries := make([]*recentDescription, 0, len(s.Recent))
+	for _, entry := range s.Recent {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+
+	var top []string
+	for i := 0; i < len(entries) && i < n; i++ {
+		top = append(top, entries[i].Text)
+	}
+	return top
+}
+
+// setOffered remembers the descriptions shown on a quick-pick keyboard
+func setOffered(chatID int64, descriptions []string) {
+	updateSession(chatID, func(s *chatSession) {
+		s.Offered = descriptions
+	})
+}
+
+// offeredDescription returns the description at index on the last quick-pick keyboard
+func offeredDescription(chatID int64, index int) (string, bool) {
+	s := viewSession(chatID)
+	if index < 0 || index >= len(s.Offered) {
+		return "", false
+	}
+	return s.Offered[index], true
+}
+
+// handleAwaitedReply routes a plain message that answers an earlier bot question.
+// It reports false when an amount question got something else, which is then
+// handled like any other message.
+func (b *botInstance) handleAwaitedReply(msg *tgbotapi.Message, kind string, data map[string]string) bool {
+	log.Printf("💬 Handling awaited %s reply from ChatID: %d", kind, msg.Chat.ID)
+	switch kind {
+	case AwaitReconcileTotal:
+		return b.handleReconcileTotal(msg, data)
+	case AwaitExpenseDescription:
+		b.handleAmountDescription(msg, data)
+	case AwaitExpenseAmount:
+		b.handleDescriptionAmount(msg, data)
+	case AwaitExpenseCategory:
+		b.handleExpenseCategory(msg, data)
+	case AwaitBudgetPlan:
+		b.handleBudgetPlanReply(msg, data)
+	case AwaitReminderAmount:
+		return b.handleReminderAmountReply(msg, data)
+	case AwaitPaidAmount:
+		return b.handlePaidAmountReply(msg, data)
+	default:
+		log.Printf("⚠️ Unknown awaited reply kind: %s", kind)
+		b.handleUnknownCommand(msg)
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"spendwise-telegram-go/format"
+	"spendwise-telegram-go/parser"
+)
+
+// MaxCycleStartDay keeps the billing cycle start on a day every month has
+const MaxCycleStartDay = 28
+
+// UserSettings holds per-chat preferences; empty fields inherit the deployment defaults
+type UserSettings struct {
+	format.Settings
+	CycleStartDay int `json:"cycleStartDay,omitempty"` // overrides CYCLE_START_DAY for this chat
+	// MarkDoneExpense overrides MARK_DONE_EXPENSE for this chat
+	MarkDoneExpense string `json:"markDoneExpense,omitempty"`
+}
+
+// userSettings returns the configured settings for a chat within its household, if any
+func userSettings(chatID int64) UserSettings {
+	t, ok := tenantForChat(chatID)
+	if !ok {
+		return UserSettings{}
+	}
+	return t.UserSettings[strconv.FormatInt(chatID, 10)]
+}
+
+// defaultFormatSettings returns the deployment-wide formatting defaults
+func defaultFormatSettings() format.Settings {
+	locale := config.Locale
+	if locale == "" {
+		locale = format.DefaultLocale
+		if config.DigitGrouping == GroupingWestern {
+			locale = "en-US"
+		}
+	}
+	return format.Settings{
+		Locale:         locale,
+		CurrencySymbol: config.CurrencySymbol,
+		DateFormat:     config.DateFormat,
+	}
+}
+
+// formatterFor returns a formatter using the chat's settings over the defaults
+func formatterFor(chatID int64) *format.Formatter {
+	settings := defaultFormatSettings()
+	if t, ok := tenantForChat(chatID); ok {
+		settings = overlayFormatSettings(settings, t.Settings)
+	}
+	return format.New(overlayFormatSettings(settings, userSettings(chatID).Settings))
+}
+
+// parseOptions returns the expense parser options for a chat, reading amounts
+// with the decimal separator of the chat's locale
+func parseOptions(chatID int64) parser.Options {
+	return parser.Options{Now: time.Now(), Accounts: knownAccounts(), DecimalComma: formatterFor(chatID).DecimalComma()}
+}
+
+// cycleStartDayFor returns the day of month the chat's spending month starts on
+func cycleStartDayFor(chatID int64) int {
+	day := config.CycleStartDay
+	if override := userSettings(chatID).CycleStartDay; override != 0 {
+		day = override
+	}
+	if day < 1 || day > MaxCycleStartDay {
+		return 1
+	}
+	return day
+}
+
+// billingCycle returns the first and last day of the spending month containing
+// now for a month starting on startDay; startDay 1 gives the calendar month
+func billingCycle(now time.Time, startDay int) (time.Time, time.Time) {
+	from := time.Date(now.Year(), now.Month(), startDay, 0, 0, 0, 0, now.Location())
+	if now.Day() < startDay {
+		from = from.AddDate(0, -1, 0)
+	}
+	return from, from.AddDate(0, 1, -1)
+}
+
+// overlayFormatSettings returns base with every non-empty field of override applied
+func overlayFormatSettings(base, override format.Settings) format.Settings {
+	if override.Locale != "" {
+		base.Locale = override.Locale
+	}
+	if override.CurrencySymbol != "" {
+		base.CurrencySymbol = override.CurrencySymbol
+	}
+	if override.DateFormat != "" {
+		base.DateFormat = override.DateFormat
+	}
+	return base
+}
+
+// summaryLocaleQuery returns query parameters asking the backend to render summaries
+// with the chat's locale and currency symbol, plus the breakdowns used for charts.
+// Backends that support it answer with a structured payload the bot renders itself.
+func (b *botInstance) summaryLocaleQuery(chatID int64) string {
+	formatter := formatterFor(chatID)
+	params := url.Values{}
+	params.Set("locale", formatter.Locale())
+	params.Set("currency", formatter.Symbol())
+	if b.supports(CapStructuredSummaries) {
+		params.Set("include", "breakdown")
+		params.Set("format", "structured")
+	}
+	return "?" + params.Encode()
+}
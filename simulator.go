@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SimulatedUpdateIDBase keeps synthetic update IDs clear of real Telegram ones
+const SimulatedUpdateIDBase = 1 << 30
+
+// simulatedCall is one Bot API request the bot made while handling a simulated update
+type simulatedCall struct {
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// telegramSimulator stands in for the Bot API when DEBUG_SIMULATOR is set. Every
+// request is answered locally with a plausible result and recorded, so updates
+// can be driven end to end without a Telegram account or network access.
+type telegramSimulator struct {
+	run       sync.Mutex // one simulation at a time, so recorded calls belong to its update
+	mu        sync.Mutex
+	calls     []simulatedCall
+	recording bool
+	nextID    atomic.Int64
+}
+
+var simulator = &telegramSimulator{}
+
+// Do implements tgbotapi.HTTPClient
+func (s *telegramSimulator) Do(req *http.Request) (*http.Response, error) {
+	method := path.Base(req.URL.Path)
+	params := simulatedParams(req)
+
+	s.record(simulatedCall{Method: method, Params: params})
+	debugf("🧪 Simulated Bot API call %s", method)
+
+	var result interface{} = true
+	switch {
+	case method == "getMe":
+		result = tgbotapi.User{ID: 1, IsBot: true, FirstName: "SpendWise", UserName: "spendwise_simulator_bot"}
+	case method == "getWebhookInfo":
+		result = tgbotapi.WebhookInfo{}
+	case strings.HasPrefix(method, "send"), strings.HasPrefix(method, "edit"):
+		chatID, _ := strconv.ParseInt(paramString(params["chat_id"]), 10, 64)
+		messageID := int(s.nextID.Add(1))
+		if id, err := strconv.Atoi(paramString(params["message_id"])); err == nil {
+			messageID = id
+		}
+		result = tgbotapi.Message{
+			MessageID: messageID,
+			Date:      int(time.Now().Unix()),
+			Chat:      &tgbotapi.Chat{ID: chatID},
+			Text:      paramString(params["text"]),
+		}
+	}
+
+	raw, _ := json.Marshal(result)
+	body, _ := json.Marshal(tgbotapi.APIResponse{Ok: true, Result: raw})
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(string(body))),
+		Request:    req,
+	}, nil
+}
+
+func (s *telegramSimulator) record(call simulatedCall) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.recording {
+		s.calls = append(s.calls, call)
+	}
+}
+
+// simulatedParams decodes a Bot API request body, whichever encoding it uses
+func simulatedParams(req *http.Request) map[string]interface{} {
+	params := make(map[string]interface{})
+	if req.Body == nil {
+		return params
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		json.NewDecoder(req.Body).Decode(&params)
+	case "multipart/form-data":
+		if err := req.ParseMultipartForm(1 << 20); err == nil {
+			for key, values := range req.MultipartForm.Value {
+				params[key] = values[0]
+			}
+			for key, files := range req.MultipartForm.File {
+				params[key] = "file:" + files[0].Filename
+			}
+		}
+	default:
+		if err := req.ParseForm(); err == nil {
+			for key := range req.PostForm {
+				params[key] = req.PostForm.Get(key)
+			}
+		}
+	}
+	return params
+}
+
+func paramString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return ""
+}
+
+// handleSimulateUpdate synthesizes a Telegram update from {chatId, text} (or
+// {chatId, callbackData}), runs it through handleUpdate and returns the Bot API
+// calls the bot made in response.
+func handleSimulateUpdate(c *gin.Context) {
+	var req struct {
+		ChatID       int64  `json:"chatId"`
+		Text         string `json:"text"`
+		CallbackData string `json:"callbackData"` // simulates pressing an inline button
+		MessageID    int    `json:"messageId"`    // message the button belongs to
+		FirstName    string `json:"firstName"`
+		ForwardFrom  string `json:"forwardFrom"` // simulates a message forwarded from this sender
+		BotID        string `json:"botId"`
+	}
+	if !bindJSON(c, &req, true) {
+		return
+	}
+	if req.ChatID == 0 || (req.Text == "") == (req.CallbackData == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "chatId and exactly one of text or callbackData are required"})
+		return
+	}
+	b, ok := internalBot(req.BotID)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown bot"})
+		return
+	}
+	if req.FirstName == "" {
+		req.FirstName = "Simulator"
+	}
+
+	simulator.run.Lock()
+	defer simulator.run.Unlock()
+	simulator.mu.Lock()
+	simulator.recording, simulator.calls = true, nil
+	simulator.mu.Unlock()
+
+	updateID := SimulatedUpdateIDBase + int(simulator.nextID.Add(1))
+	update := simulatedUpdate(updateID, req.ChatID, req.FirstName, req.Text, req.CallbackData, req.MessageID)
+	if req.ForwardFrom != "" && update.Message != nil {
+		update.Message.ForwardSenderName = req.ForwardFrom
+		update.Message.ForwardDate = update.Message.Date
+	}
+	log.Printf("🧪 Simulating update %d for ChatID %d via bot %s", updateID, req.ChatID, b.ID)
+	b.handleUpdate(update)
+
+	simulator.mu.Lock()
+	calls := simulator.calls
+	simulator.recording, simulator.calls = false, nil
+	simulator.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"updateId": updateID, "calls": calls})
+}
+
+// simulatedUpdate builds the update Telegram would send for a private chat
+func simulatedUpdate(updateID int, chatID int64, firstName, text, callbackData string, messageID int) tgbotapi.Update {
+	from := &tgbotapi.User{ID: chatID, FirstName: firstName}
+	chat := &tgbotapi.Chat{ID: chatID, Type: "private", FirstName: firstName}
+	update := tgbotapi.Update{UpdateID: updateID}
+
+	if callbackData != "" {
+		update.CallbackQuery = &tgbotapi.CallbackQuery{
+			ID:      strconv.Itoa(updateID),
+			From:    from,
+			Message: &tgbotapi.Message{MessageID: messageID, From: from, Chat: chat, Date: int(time.Now().Unix())},
+			Data:    callbackData,
+		}
+		return update
+	}
+
+	msg := &tgbotapi.Message{
+		MessageID: int(simulator.nextID.Add(1)),
+		From:      from,
+		Chat:      chat,
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len([]rune(command))}}
+	}
+	update.Message = msg
+	return update
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	StartupMaxAttempts    = 8
+	StartupInitialBackoff = time.Second
+	StartupMaxBackoff     = 30 * time.Second
+)
+
+// ready flips once every startup dependency is connected; until then only
+// /health and /metrics are served and other routes answer 503.
+var ready atomic.Bool
+
+// permanentError marks a startup failure that retrying cannot fix, such as a
+// malformed Redis URL or a bot token Telegram rejects.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// retryStartup runs fn with exponential backoff until it succeeds, fails
+// permanently or StartupMaxAttempts is reached.
+func retryStartup(name string, fn func() error) error {
+	backoff := StartupInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		var perm permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if attempt == StartupMaxAttempts {
+			return fmt.Errorf("%s failed after %d attempts: %v", name, attempt, err)
+		}
+
+		log.Printf("⚠️ %s failed (attempt %d/%d), retrying in %s: %v", name, attempt, StartupMaxAttempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > StartupMaxBackoff {
+			backoff = StartupMaxBackoff
+		}
+	}
+}
+
+// telegramPermanent wraps Telegram errors that mean the token itself is bad
+func telegramPermanent(err error) error {
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) && (tgErr.Code == http.StatusUnauthorized || tgErr.Code == http.StatusNotFound) {
+		return permanent(err)
+	}
+	return err
+}
+
+// startup connects every external dependency with retries, then starts the
+// background jobs and marks the instance ready. Only unrecoverable failures
+// terminate the process.
+func startup(forceWebhook bool) {
+	if err := retryStartup("coordination store", initCoordination); err != nil {
+		log.Fatalf("❌ Failed to connect coordination store: %v", err)
+	}
+
+	if err := initBots(); err != nil {
+		log.Fatalf("❌ Failed to start bot: %v", err)
+	}
+	log.Printf("✅ %d bot(s) initialized successfully", len(bots))
+	loadWatermarks()
+	negotiateBackends()
+	startBotUsersRefresh(config.BotUsersRefresh)
+
+	// Setup webhook (only calls setWebhook when the registration changed)
+	for _, b := range bots {
+		if err := retryStartup("webhook setup for bot "+b.ID, func() error { return b.setupWebhook(forceWebhook) }); err != nil {
+			log.Fatalf("❌ Failed to set webhook: %v", err)
+		}
+	}
+
+	// Background jobs
+	registerJob("reminder-escalation", EscalationInterval, func() {
+		for _, b := range bots {
+			for _, t := range tenants {
+				if t.escalationBot(b) {
+					b.forTenant(t).runReminderEscalation()
+				}
+			}
+		}
+	})
+	registerJob("missed-day-nudges", NudgeInterval, func() {
+		for _, b := range bots {
+			for _, t := range tenants {
+				if t.escalationBot(b) {
+					b.forTenant(t).runMissedDayNudges()
+				}
+			}
+		}
+	})
+	registerJob("weekly-digest", DigestInterval, func() {
+		for _, b := range bots {
+			for _, t := range tenants {
+				if t.escalationBot(b) {
+					b.forTenant(t).runWeeklyDigests()
+				}
+			}
+		}
+	})
+	registerJob("budget-plan-reminders", PlanReminderInterval, func() {
+		for _, b := range bots {
+			for _, t := range tenants {
+				if t.escalationBot(b) {
+					b.forTenant(t).runPlanReminders()
+				}
+			}
+		}
+	})
+	registerJob("group-dashboards", DashboardInterval, func() {
+		for _, b := range bots {
+			for _, t := range tenants {
+				if t.escalationBot(b) {
+					b.forTenant(t).runDashboardRefresh()
+				}
+			}
+		}
+	})
+	registerJob("retry-queue", RetryQueueInterval, func() {
+		for _, b := range bots {
+			for _, t := range tenants {
+				b.forTenant(t).runRetryQueue()
+			}
+		}
+	})
+	registerJob("webhook-check", WebhookCheckInterval, checkWebhooks)
+	startScheduler()
+
+	warmUp()
+	ready.Store(true)
+	log.Println("🔗 Server ready to accept requests")
+	startKeepAlive(config.KeepAliveInterval)
+}
+
+// requireReady rejects requests until startup has finished; Telegram and
+// Pub/Sub retry 503 responses, so no update is lost while connecting.
+func requireReady(c *gin.Context) {
+	if !ready.Load() {
+		log.Printf("⏳ Rejecting %s %s while starting up", c.Request.Method, c.Request.URL.Path)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "starting"})
+		return
+	}
+	c.Next()
+}
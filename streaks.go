@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/format"
+)
+
+const (
+	// StreakLookbackDays bounds how far back streaks are counted
+	StreakLookbackDays = 120
+	// StreakCelebrationTTL keeps a milestone from being celebrated twice
+	StreakCelebrationTTL = 48 * time.Hour
+)
+
+// streakMilestones are the streak lengths worth a message after logging
+var streakMilestones = []int{3, 7, 14, 21, 30, 50, 75, 100}
+
+// spendingStreaks are the chat's current streaks, counted in days
+type spendingStreaks struct {
+	Logging      int // consecutive days with at least one expense, up to today
+	UnderBudget  int // consecutive days at or under the daily budget, up to today
+	DailyBudget  float64
+	loggedToday  bool
+	underToday   bool
+	budgetActive bool
+}
+
+// computeStreaks counts streaks from daily totals keyed by YYYY-MM-DD. A logging
+// streak still counts while today has nothing logged yet; budget streaks start at
+// the first logged day so an empty history doesn't count as saving.
+func computeStreaks(dailyTotals map[string]float64, logged map[string]bool, budget float64, now time.Time) spendingStreaks {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	streaks := spendingStreaks{DailyBudget: budget, budgetActive: budget > 0}
+
+	day := today
+	if !logged[day.Format("2006-01-02")] {
+		day = day.AddDate(0, 0, -1)
+	} else {
+		streaks.loggedToday = true
+	}
+	for i := 0; i < StreakLookbackDays && logged[day.Format("2006-01-02")]; i++ {
+		streaks.Logging++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	if !streaks.budgetActive {
+		return streaks
+	}
+	first := today
+	for key := range logged {
+		if d, err := time.ParseInLocation("2006-01-02", key, now.Location()); err == nil && d.Before(first) {
+			first = d
+		}
+	}
+	streaks.underToday = dailyTotals[today.Format("2006-01-02")] <= budget
+	for day := today; !day.Before(first) && dailyTotals[day.Format("2006-01-02")] <= budget; day = day.AddDate(0, 0, -1) {
+		streaks.UnderBudget++
+	}
+	return streaks
+}
+
+// fetchStreaks loads the chat's recent expenses and computes its streaks
+func (b *botInstance) fetchStreaks(chatID int64, now time.Time) (spendingStreaks, error) {
+	params := url.Values{}
+	params.Set("from", now.AddDate(0, 0, -StreakLookbackDays).Format("2006-01-02"))
+	params.Set("to", now.Format("2006-01-02"))
+	params.Set("telegramChatId", strconv.FormatInt(chatID, 10))
+	expenses, err := b.fetchExpenses(params)
+	if err != nil {
+		return spendingStreaks{}, err
+	}
+
+	totals := make(map[string]float64)
+	logged := make(map[string]bool)
+	for _, expense := range expenses {
+		totals[expense.Date] += expense.Amount
+		logged[expense.Date] = true
+	}
+	return computeStreaks(totals, logged, viewSession(chatID).DailyBudget, now), nil
+}
+
+// lines describes the streaks worth mentioning, e.g. for the weekly digest
+func (s spendingStreaks) lines(formatter *format.Formatter) []string {
+	var lines []string
+	if s.Logging >= 2 {
+		lines = append(lines, fmt.Sprintf("📝 %d-day logging streak", s.Logging))
+	}
+	if s.budgetActive && s.UnderBudget >= 2 {
+		lines = append(lines, fmt.Sprintf("💚 %d days under your %s daily budget", s.UnderBudget, formatter.Currency(s.DailyBudget)))
+	}
+	return lines
+}
+
+// isStreakMilestone reports whether a streak length deserves a celebration
+func isStreakMilestone(days int) bool {
+	for _, milestone := range streakMilestones {
+		if days == milestone {
+			return true
+		}
+	}
+	return days > 100 && days%50 == 0
+}
+
+// maybeCelebrateStreak sends a short message when logging just reached a
+// streak milestone. Only chats that turned streaks on are checked.
+func (b *botInstance) maybeCelebrateStreak(chatID int64) {
+	if !viewSession(chatID).StreaksEnabled {
+		return
+	}
+	streaks, err := b.fetchStreaks(chatID, time.Now())
+	if err != nil {
+		log.Printf("⚠️ Skipping streak check for ChatID %d: %v", chatID, err)
+		return
+	}
+
+	celebrations := map[string]string{}
+	if streaks.loggedToday && isStreakMilestone(streaks.Logging) {
+		celebrations[fmt.Sprintf("logging:%d", streaks.Logging)] = fmt.Sprintf("🔥 %d-day logging streak! Keep it going.", streaks.Logging)
+	}
+	if streaks.budgetActive && streaks.underToday && isStreakMilestone(streaks.UnderBudget) {
+		celebrations[fmt.Sprintf("budget:%d", streaks.UnderBudget)] = fmt.Sprintf("🎉 %d days under your daily budget!", streaks.UnderBudget)
+	}
+
+	for _, kind := range mapKeys(celebrations) {
+		message := celebrations[kind]
+		key := fmt.Sprintf("streak-celebrated:%d:%s", chatID, kind)
+		if claimed, err := store.SetNX(key, instanceID, StreakCelebrationTTL); err != nil || !claimed {
+			continue
+		}
+		log.Printf("🔥 Celebrating streak for ChatID %d: %s", chatID, message)
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, message)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+}
+
+// handleStreaksCommand manages streak tracking: /streaks on|off|budget <amount>
+func (b *botInstance) handleStreaksCommand(msg *tgbotapi.Message) {
+	args := strings.Fields(msg.Text)
+	log.Printf("🔥 Streaks command from ChatID %d: %s", msg.Chat.ID, strings.Join(args[1:], " "))
+	formatter := formatterFor(msg.Chat.ID)
+
+	var response string
+	switch {
+	case len(args) == 2 && args[1] == "on":
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.StreaksEnabled = true })
+		response = "🔥 Streaks on - I'll cheer when you hit a logging streak. Set a daily budget with /streaks budget 500 to track days under it too."
+	case len(args) == 2 && args[1] == "off":
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.StreaksEnabled = false })
+		response = "🔕 Streaks turned off."
+	case len(args) == 3 && args[1] == "budget":
+		budget, err := parseStatementAmount(args[2], formatterFor(msg.Chat.ID))
+		if args[2] == "0" {
+			budget, err = 0, nil
+		}
+		if err != nil {
+			response = "❌ Give the daily budget as an amount, e.g. /streaks budget 500 (0 to clear)"
+			break
+		}
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.DailyBudget = budget })
+		if budget == 0 {
+			response = "💚 Daily budget cleared."
+		} else {
+			response = "💚 Daily budget set to " + formatter.Currency(budget) + "."
+		}
+	default:
+		session := viewSession(msg.Chat.ID)
+		status := "off"
+		if session.StreaksEnabled {
+			status = "on"
+		}
+		response = "Streaks: " + status
+		if session.DailyBudget > 0 {
+			response += ", daily budget " + formatter.Currency(session.DailyBudget)
+		}
+		if session.StreaksEnabled {
+			if streaks, err := b.fetchStreaks(msg.Chat.ID, time.Now()); err != nil {
+				log.Printf("⚠️ Failed to load streaks for ChatID %d: %v", msg.Chat.ID, err)
+			} else if lines := streaks.lines(formatter); len(lines) > 0 {
+				response += "\n\n" + strings.Join(lines, "\n")
+			}
+		}
+		response += "\n\n" +
+			"• /streaks on - Celebrate logging streaks\n" +
+			"• /streaks off - Stop streak messages\n" +
+			"• /streaks budget 500 - Also track days under a daily budget"
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
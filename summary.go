@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CallbackPrefixSummaryPage = "summary_page:"
+	// SummaryPagesTTL is how long ◀️ ▶️ navigation keeps working on a summary message
+	SummaryPagesTTL = 24 * time.Hour
+	// SummaryPageMaxChars keeps a page comfortably readable on a phone screen
+	SummaryPageMaxChars = 1500
+	// summaryPageIndicator is the data of the n/total button, which does nothing
+	summaryPageIndicator = "-"
+)
+
+// splitSummaryPages splits a Markdown summary into pages, starting a new page at
+// every section heading (a line that is entirely bold, optionally after an emoji)
+// and breaking sections that are still too long on line boundaries.
+func splitSummaryPages(markdown string) []string {
+	var sections []string
+	var current []string
+	for _, line := range strings.Split(strings.TrimSpace(markdown), "\n") {
+		if isSummaryHeading(line) && len(strings.TrimSpace(strings.Join(current, "\n"))) > 0 {
+			sections = append(sections, strings.Join(current, "\n"))
+			current = nil
+		}
+		current = append(current, line)
+	}
+	sections = append(sections, strings.Join(current, "\n"))
+
+	var pages []string
+	for _, section := range sections {
+		pages = append(pages, chunkLines(strings.TrimSpace(section), SummaryPageMaxChars)...)
+	}
+	return pages
+}
+
+// isSummaryHeading matches "*Title*", "**Title**" and "🏆 **Top Categories:**",
+// but not "💰 **Total Spent:** ₹45,230.00"
+func isSummaryHeading(line string) bool {
+	line = strings.TrimSpace(line)
+	if start := strings.Index(line, "*"); start > 0 && !strings.ContainsAny(line[:start], "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") {
+		line = line[start:]
+	}
+	for _, marker := range []string{"**", "*"} {
+		inner := strings.TrimSuffix(strings.TrimPrefix(line, marker), marker)
+		if len(line) >= 2*len(marker)+1 && strings.HasPrefix(line, marker) && strings.HasSuffix(line, marker) && !strings.Contains(inner, "*") {
+			return true
+		}
+	}
+	return false
+}
+
+// chunkLines splits text into chunks of at most max bytes without breaking lines
+func chunkLines(text string, max int) []string {
+	var chunks []string
+	var current strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if current.Len() > 0 && current.Len()+len(line)+1 > max {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n")
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// sendMarkdown sends Markdown text, resending it as plain text if Telegram rejects
+// the markup (e.g. an unbalanced "*" in backend-rendered summaries)
+func (b *botInstance) sendMarkdown(chatID int64, text string) (tgbotapi.Message, error) {
+	reply := tgbotapi.NewMessage(chatID, text)
+	reply.ParseMode = "Markdown"
+	return b.sendFormatted(reply)
+}
+
+// summaryPageKeyboard renders ◀️ n/total ▶️ navigation for a paged summary
+func summaryPageKeyboard(index, total int) tgbotapi.InlineKeyboardMarkup {
+	prev := index - 1
+	if prev < 0 {
+		prev = total - 1
+	}
+	next := (index + 1) % total
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("◀️", CallbackPrefixSummaryPage+strconv.Itoa(prev)),
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d/%d", index+1, total), CallbackPrefixSummaryPage+summaryPageIndicator),
+		tgbotapi.NewInlineKeyboardButtonData("▶️", CallbackPrefixSummaryPage+strconv.Itoa(next)),
+	))
+}
+
+func summaryPagesKey(chatID int64, messageID int) string {
+	return fmt.Sprintf("summary:%d:%d", chatID, messageID)
+}
+
+// sendSummaryPages sends a Markdown summary, paginated with navigation buttons
+// when it has more than one section. Pages are kept in the shared store so any
+// instance can serve the button taps.
+func (b *botInstance) sendSummaryPages(chatID int64, markdown string) (tgbotapi.Message, error) {
+	pages := splitSummaryPages(markdown)
+	if len(pages) == 1 {
+		return b.sendMarkdown(chatID, pages[0])
+	}
+
+	reply := tgbotapi.NewMessage(chatID, pages[0])
+	reply.ParseMode = "Markdown"
+	reply.ReplyMarkup = summaryPageKeyboard(0, len(pages))
+	sent, err := b.sendFormatted(reply)
+	if err != nil {
+		return sent, err
+	}
+
+	data, _ := json.Marshal(pages)
+	if err := store.Set(summaryPagesKey(chatID, sent.MessageID), string(data), SummaryPagesTTL); err != nil {
+		log.Printf("⚠️ Failed to store summary pages for ChatID %d: %v", chatID, err)
+	}
+	log.Printf("📄 Sent summary page 1/%d to ChatID: %d", len(pages), chatID)
+	return sent, nil
+}
+
+// handleSummaryPageCallback edits a paged summary message in place to show another page
+func (b *botInstance) handleSummaryPageCallback(cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	arg := strings.TrimPrefix(cb.Data, CallbackPrefixSummaryPage)
+	if arg == summaryPageIndicator {
+		b.answerCallback(cb, "")
+		return
+	}
+
+	index, err := strconv.Atoi(arg)
+	if err != nil {
+		log.Printf("❌ Invalid summary page callback: %s", cb.Data)
+		b.answerCallback(cb, "Invalid format.")
+		return
+	}
+
+	raw, ok, err := store.Get(summaryPagesKey(chatID, cb.Message.MessageID))
+	var pages []string
+	if err == nil && ok {
+		err = json.Unmarshal([]byte(raw), &pages)
+	}
+	if err != nil || !ok || index < 0 || index >= len(pages) {
+		log.Printf("❌ Stale summary page callback for ChatID %d: %s (err: %v)", chatID, cb.Data, err)
+		b.answerCallback(cb, "This summary has expired, please run the command again.")
+		return
+	}
+
+	b.answerCallback(cb, "")
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, cb.Message.MessageID, pages[index], summaryPageKeyboard(index, len(pages)))
+	edit.ParseMode = "Markdown"
+	if _, err := b.sendFormatted(edit); err != nil {
+		log.Printf("⚠️ Failed to show summary page %d for ChatID %d: %v", index+1, chatID, err)
+	}
+}
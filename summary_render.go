@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"text/template"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/format"
+)
+
+// SummaryTopItems is how many of the largest expenses a summary lists
+const SummaryTopItems = 10
+
+// SummaryItem is one expense in a structured summary
+type SummaryItem struct {
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	Category    string  `json:"category,omitempty"`
+	Date        string  `json:"date,omitempty"`
+	Note        string  `json:"note,omitempty"`
+}
+
+// summaryTemplate renders a structured summary. Every section starts with a bold
+// heading so /month pagination shows one section per page.
+var summaryTemplate = template.Must(template.New("summary").Funcs(template.FuncMap{
+	"md": func(text string) string { return tgbotapi.EscapeText(tgbotapi.ModeMarkdown, text) },
+}).Parse(`📊 *{{md .Title}}*
+
+💰 Total spent: {{.Currency .Total}}
+🧾 Expenses: {{.Count}}
+{{- if .Average}}
+📈 Daily average: {{.Currency .Average}}{{end}}
+{{- if .Categories}}
+
+🏆 *Categories*
+` + "```" + `
+{{.Bars}}
+` + "```" + `{{end}}
+{{- if .TopItems}}
+
+💸 *Top expenses*
+{{- range .TopItems}}
+• {{md .Description}} - {{$.Currency .Amount}}{{if .Category}} ({{md .Category}}){{end}}{{if .Note}} - _{{md .Note}}_{{end}}{{end}}{{end}}
+{{- if .Daily}}
+
+📅 *Daily breakdown*
+` + "`{{.Spark}}`" + `
+Peak: {{md .Peak.Label}} ({{.Currency .Peak.Amount}}){{end}}`))
+
+// summaryView is the data passed to summaryTemplate
+type summaryView struct {
+	Title      string
+	Total      float64
+	Count      int
+	Average    float64
+	Categories []SummaryBucket
+	TopItems   []SummaryItem
+	Daily      []SummaryBucket
+	Peak       SummaryBucket
+	formatter  *format.Formatter
+}
+
+func (v summaryView) Currency(amount float64) string {
+	return v.formatter.Currency(amount)
+}
+
+func (v summaryView) Bars() string {
+	return categoryBars(v.Categories, v.formatter)
+}
+
+func (v summaryView) Spark() string {
+	values := make([]float64, len(v.Daily))
+	for i, day := range v.Daily {
+		values[i] = day.Amount
+	}
+	return sparkline(values)
+}
+
+// isStructured reports whether the backend sent a structured payload rather than
+// only pre-rendered Markdown
+func (s SummaryResponse) isStructured() bool {
+	return s.Total != nil
+}
+
+// render returns the Markdown to send for a summary: the bot's own rendering of a
+// structured payload, or the backend Markdown (plus charts) for older backends.
+func (s SummaryResponse) render(formatter *format.Formatter) string {
+	if !s.isStructured() {
+		return s.withCharts(formatter)
+	}
+
+	view := summaryView{
+		Title:      s.Title,
+		Total:      *s.Total,
+		Count:      s.Count,
+		Average:    s.DailyAverage,
+		Categories: s.Categories,
+		formatter:  formatter,
+	}
+	if view.Title == "" {
+		view.Title = "Summary"
+	}
+	if view.Count == 0 {
+		view.Count = len(s.Items)
+	}
+
+	view.TopItems = append([]SummaryItem(nil), s.Items...)
+	sort.SliceStable(view.TopItems, func(i, j int) bool { return view.TopItems[i].Amount > view.TopItems[j].Amount })
+	if len(view.TopItems) > SummaryTopItems {
+		view.TopItems = view.TopItems[:SummaryTopItems]
+	}
+
+	if len(s.Daily) > 1 {
+		view.Daily = s.Daily
+		view.Peak = s.Daily[0]
+		for _, day := range s.Daily {
+			if day.Amount > view.Peak.Amount {
+				view.Peak = day
+			}
+		}
+	}
+
+	var b strings.Builder
+	if err := summaryTemplate.Execute(&b, view); err != nil {
+		log.Printf("❌ Failed to render structured summary, falling back to Markdown: %v", err)
+		if s.Markdown != "" {
+			return s.withCharts(formatter)
+		}
+		return fmt.Sprintf("📊 %s\n\n💰 Total spent: %s", s.Title, formatter.Currency(*s.Total))
+	}
+	return b.String()
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	"spendwise-telegram-go/format"
+)
+
+// HeaderTenantID tells a shared backend which household a request belongs to
+const HeaderTenantID = "x-spendwise-tenant"
+
+// TenantConfig describes an independent household sharing this deployment.
+// Empty apiUrl/apiSecret fall back to the bot's backend; the id is sent to the
+// backend in the x-spendwise-tenant header.
+type TenantConfig struct {
+	ID             string                  `json:"id"`
+	BotID          string                  `json:"botId"` // optional: only this bot serves the tenant
+	AllowedIDs     []string                `json:"allowedIds"`
+	UserNames      map[string]string       `json:"userNames"`
+	ReminderOwners map[string]string       `json:"reminderOwners"` // chat ID -> SpendWise userId of their reminders
+	APIUrl         string                  `json:"apiUrl"`
+	APISecret      string                  `json:"apiSecret"`
+	Settings       format.Settings         `json:"settings"`
+	UserSettings   map[string]UserSettings `json:"userSettings"`
+}
+
+// tenant is a resolved household; the default tenant is built from the top-level config.
+// AllowedIDs and UserNames are replaced by the /api/bot-users refresh, so they
+// are read through allowedChats and userName. A nil tenant, as on a bot that
+// hasn't resolved an update to a household, means no tenant restriction.
+type tenant struct {
+	ID              string
+	BotID           string
+	AllowedIDs      map[string]bool
+	UserNames       map[string]string
+	configuredIDs   map[string]bool   // from the config, kept when the backend drops a chat
+	configuredNames map[string]string // used for chats the backend sends no name for
+	ReminderOwners  map[string]string
+	APIUrl          string
+	APISecret       string
+	Settings        format.Settings
+	UserSettings    map[string]UserSettings
+}
+
+var (
+	tenants      []*tenant
+	tenantByChat = make(map[string]*tenant)
+	// tenantsMu guards tenantByChat and every tenant's AllowedIDs and UserNames
+	tenantsMu sync.RWMutex
+)
+
+// buildTenants resolves the default household and any configured tenants, making
+// sure every chat ID belongs to exactly one of them
+func buildTenants() error {
+	all := []*tenant{{
+		AllowedIDs:     config.AllowedIDs,
+		UserNames:      config.UserNames,
+		ReminderOwners: config.ReminderOwners,
+		UserSettings:   config.UserSettings,
+	}}
+
+	seen := map[string]bool{"": true}
+	for _, tc := range config.Tenants {
+		if tc.ID == "" || seen[tc.ID] {
+			return fmt.Errorf("tenant ids must be unique and non-empty (got %q)", tc.ID)
+		}
+		seen[tc.ID] = true
+		all = append(all, &tenant{
+			ID:             tc.ID,
+			BotID:          tc.BotID,
+			AllowedIDs:     idSet(tc.AllowedIDs),
+			UserNames:      tc.UserNames,
+			ReminderOwners: tc.ReminderOwners,
+			APIUrl:         tc.APIUrl,
+			APISecret:      tc.APISecret,
+			Settings:       tc.Settings,
+			UserSettings:   tc.UserSettings,
+		})
+	}
+
+	for _, t := range all {
+		for chatID := range t.AllowedIDs {
+			if other, exists := tenantByChat[chatID]; exists {
+				return fmt.Errorf("chat %s is allowed in both tenant %q and %q", chatID, other.ID, t.ID)
+			}
+			tenantByChat[chatID] = t
+		}
+	}
+	for _, t := range all {
+		t.configuredIDs, t.configuredNames = t.AllowedIDs, t.UserNames
+	}
+	tenants = all
+
+	log.Printf("🏠 %d tenant(s) configured", len(tenants))
+	return nil
+}
+
+// tenantForChat returns the household a chat belongs to, if it is allowed at all
+func tenantForChat(chatID int64) (*tenant, bool) {
+	return tenantForChatID(strconv.FormatInt(chatID, 10))
+}
+
+// tenantForChatID is tenantForChat for a chat ID as written in the config
+func tenantForChatID(chatID string) (*tenant, bool) {
+	tenantsMu.RLock()
+	defer tenantsMu.RUnlock()
+	t, ok := tenantByChat[chatID]
+	return t, ok
+}
+
+// tenantWithID returns the household with the given ID; "" is the default one
+func tenantWithID(id string) (*tenant, bool) {
+	for _, t := range tenants {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// allowedChatCount returns the number of allowed chats across all tenants
+func allowedChatCount() int {
+	tenantsMu.RLock()
+	defer tenantsMu.RUnlock()
+	return len(tenantByChat)
+}
+
+// allowedChatIDs returns every allowed chat ID across all tenants, sorted
+func allowedChatIDs() []string {
+	tenantsMu.RLock()
+	defer tenantsMu.RUnlock()
+	return mapKeys(tenantByChat)
+}
+
+// allowedChats returns the tenant's allowed chat IDs, sorted
+func (t *tenant) allowedChats() []string {
+	if t == nil {
+		return allowedChatIDs()
+	}
+	tenantsMu.RLock()
+	defer tenantsMu.RUnlock()
+	return mapKeys(t.AllowedIDs)
+}
+
+// userName returns the configured or backend-provided name of a chat, or ""
+func (t *tenant) userName(chatID string) string {
+	if t == nil {
+		owner, ok := tenantForChatID(chatID)
+		if !ok {
+			return ""
+		}
+		t = owner
+	}
+	tenantsMu.RLock()
+	defer tenantsMu.RUnlock()
+	return t.UserNames[chatID]
+}
+
+// servedBy reports whether the bot may handle chats of this tenant
+func (t *tenant) servedBy(b *botInstance) bool {
+	return t == nil || t.BotID == "" || t.BotID == b.ID
+}
+
+// forTenant returns a copy of the bot whose backend calls are scoped to the tenant
+func (b *botInstance) forTenant(t *tenant) *botInstance {
+	scoped := *b
+	scoped.tenant = t
+	if t.APIUrl != "" {
+		scoped.apiURL = t.APIUrl
+	}
+	if t.APISecret != "" {
+		scoped.apiSecret = t.APISecret
+	}
+	return &scoped
+}
+
+// resolveTenant scopes the bot to the chat's household, reporting false for
+// chats that are not allowed on this bot
+func (b *botInstance) resolveTenant(chatID int64) (*botInstance, bool) {
+	t, ok := tenantForChat(chatID)
+	if !ok || !t.servedBy(b) {
+		return b, false
+	}
+	return b.forTenant(t), true
+}
+
+// escalationBot reports whether this bot runs scheduled jobs for the tenant;
+// tenants not bound to a bot are handled by the default bot
+func (t *tenant) escalationBot(b *botInstance) bool {
+	if t == nil || t.BotID == "" {
+		return b.ID == DefaultBotID
+	}
+	return t.BotID == b.ID
+}
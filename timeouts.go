@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// DefaultCommandTimeout bounds the backend calls made while handling one update
+	DefaultCommandTimeout = 20 * time.Second
+	// SlowCommandAfter is how long a command may run before the chat sees "typing..."
+	SlowCommandAfter = 2 * time.Second
+	// TypingRefreshInterval renews the typing action, which Telegram shows for ~5 seconds
+	TypingRefreshInterval = 4 * time.Second
+)
+
+// commandTimeout returns the configured per-update deadline
+func commandTimeout() time.Duration {
+	if config.CommandTimeout > 0 {
+		return config.CommandTimeout
+	}
+	return DefaultCommandTimeout
+}
+
+// context returns the deadline of the update being handled; background jobs
+// run without one
+func (b *botInstance) context() context.Context {
+	if b.ctx != nil {
+		return b.ctx
+	}
+	return context.Background()
+}
+
+// withCommandDeadline scopes the bot to one update: backend calls share a
+// deadline of commandTimeout, and once the command has taken SlowCommandAfter
+// the chat is shown a typing action until it finishes. Call the returned
+// function when the update is done.
+func (b *botInstance) withCommandDeadline(chatID int64) (*botInstance, func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout())
+	scoped := *b
+	scoped.ctx = ctx
+
+	started := time.Now()
+	finished := make(chan struct{})
+	go func() {
+		timer := time.NewTimer(SlowCommandAfter)
+		defer timer.Stop()
+		for {
+			select {
+			case <-finished:
+				return
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				debugf("⌛ Command for ChatID %d still running after %d ms, sending typing action", chatID, time.Since(started).Milliseconds())
+				b.showTyping(chatID)
+				timer.Reset(TypingRefreshInterval)
+			}
+		}
+	}()
+
+	return &scoped, func() {
+		close(finished)
+		if ctx.Err() == context.DeadlineExceeded {
+			log.Printf("⌛ Command for ChatID %d hit the %s timeout", chatID, commandTimeout())
+		}
+		cancel()
+	}
+}
+
+// showTyping shows "typing..." in the chat before a handler calls the backend,
+// so a cold start doesn't look like the bot ignored the message
+func (b *botInstance) showTyping(chatID int64) {
+	if _, err := b.api.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping)); err != nil {
+		log.Printf("⚠️ Failed to send typing action to ChatID %d: %v", chatID, err)
+	}
+}
+
+// backendError explains a failed backend request, naming the command timeout
+// when that is what ended it
+func (b *botInstance) backendError(err error) error {
+	if b.context().Err() == context.DeadlineExceeded {
+		return fmt.Errorf("the SpendWise server didn't answer within %d seconds, please try again in a moment", int(commandTimeout().Seconds()))
+	}
+	return fmt.Errorf("request failed: %v", err)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/parser"
+)
+
+// quickExpenseBlocked reports whether text matches one of the configured
+// QuickExpenseBlocklist patterns, e.g. OTP messages or addresses
+func quickExpenseBlocked(text string) bool {
+	for _, pattern := range config.QuickExpenseBlocklist {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue // reported by validate-config
+		}
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+func quickExpenseAmountInRange(amount float64) bool {
+	amount = math.Abs(amount)
+	if config.QuickExpenseMinAmount > 0 && amount < config.QuickExpenseMinAmount {
+		return false
+	}
+	return config.QuickExpenseMaxAmount <= 0 || amount <= config.QuickExpenseMaxAmount
+}
+
+// quickExpenseRejection returns why a message containing a number still isn't
+// taken as an expense under the configured heuristics, or "" when it is. A
+// message qualifies when any of its lines has an amount in range and enough
+// description words; a line that is just an amount needs no words, since the
+// bot asks what it was for.
+func quickExpenseRejection(text string, decimalComma bool) string {
+	if quickExpenseBlocked(text) {
+		return "it matches a blocked pattern"
+	}
+	if config.QuickExpenseMinAmount <= 0 && config.QuickExpenseMaxAmount <= 0 && config.QuickExpenseMinWords <= 0 {
+		return ""
+	}
+
+	reason := ""
+	for _, line := range strings.Split(text, "\n") {
+		line, _ = parser.SplitNote(line)
+		fields := strings.Fields(line)
+		words, amounts, inRange := 0, 0, 0
+		for _, part := range fields {
+			if amount, ok := parser.ParseNumber(part, decimalComma); ok {
+				amounts++
+				if quickExpenseAmountInRange(amount) {
+					inRange++
+				}
+			} else if strings.IndexFunc(part, unicode.IsLetter) >= 0 {
+				words++
+			}
+		}
+		switch {
+		case amounts == 0:
+			continue
+		case inRange == 0:
+			reason = "its amount is outside the range expenses are expected in"
+		case words < config.QuickExpenseMinWords && len(fields) > 1:
+			reason = fmt.Sprintf("it has fewer than %d description words", config.QuickExpenseMinWords)
+		default:
+			return ""
+		}
+	}
+	return reason
+}
+
+// handleNotAnExpense explains in private chats why a message with a number
+// wasn't logged; groups stay quiet so pasted codes and addresses aren't answered
+func (b *botInstance) handleNotAnExpense(msg *tgbotapi.Message, reason string) {
+	log.Printf("🙈 Not treating message from ChatID %d as an expense: %s", msg.Chat.ID, reason)
+	if !msg.Chat.IsPrivate() {
+		return
+	}
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "🙈 Not logged - this doesn't look like an expense ("+reason+"). Send /e <text> to log it anyway, e.g. /e Rent 25000")
+	reply.ReplyToMessageID = msg.MessageID
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
+
+// escapeCommandText returns the text of an "/e <text>" message; /e must be the
+// whole first word so /expense and /edit aren't mistaken for it
+func escapeCommandText(text string) (string, bool) {
+	i := strings.IndexFunc(text, unicode.IsSpace)
+	if i < 0 {
+		i = len(text)
+	}
+	command := text[:i]
+	if at := strings.Index(command, "@"); at > 0 {
+		command = command[:at] // strip /cmd@BotName
+	}
+	if !strings.EqualFold(command, "/e") {
+		return "", false
+	}
+	return strings.TrimSpace(text[i:]), true
+}
+
+// handleEscapeCommand logs /e <text> as an expense, skipping the quick-expense
+// heuristics that decide whether a plain message is one
+func (b *botInstance) handleEscapeCommand(msg *tgbotapi.Message, text string) {
+	if text == "" {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Usage: /e <text>\n\nLogs the text as an expense even when it doesn't look like one, e.g. /e Rent 25000")
+		if _, err := b.api.Send(reply); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+		return
+	}
+
+	expenseMsg := *msg
+	expenseMsg.Text = text
+	if amount, ok := parseBareAmount(text, formatterFor(msg.Chat.ID).DecimalComma()); ok {
+		b.handleAmountOnly(&expenseMsg, amount)
+		return
+	}
+	b.handleQuickExpense(&expenseMsg)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"spendwise-telegram-go/format"
+)
+
+// TripMaxNameLen caps trip names, which are stored on every expense of the trip
+const TripMaxNameLen = 40
+
+// tripRecord is a trip started with /trip start; To is empty while it runs
+type tripRecord struct {
+	Name string
+	From string // first day, 2006-01-02
+	To   string // last day once ended
+}
+
+// tagTrip stamps expenses with the chat's running trip, so nobody has to
+// remember to mark each line
+func tagTrip(chatID int64, expenses []ExpenseInput) {
+	trip := viewSession(chatID).Trip
+	if trip == nil {
+		return
+	}
+	for i := range expenses {
+		if expenses[i].Trip == "" {
+			expenses[i].Trip = trip.Name
+		}
+	}
+}
+
+// tripExpenses lists the expenses logged for a trip. The backend is asked to
+// filter by trip; older backends ignore that, so the list is filtered again here.
+func (b *botInstance) tripExpenses(chatID int64, trip tripRecord, now time.Time) ([]ExpenseRecord, error) {
+	to := trip.To
+	if to == "" {
+		to = now.Format("2006-01-02")
+	}
+	params := url.Values{}
+	params.Set("from", trip.From)
+	params.Set("to", to)
+	params.Set("telegramChatId", strconv.FormatInt(chatID, 10))
+	params.Set("trip", trip.Name)
+	expenses, err := b.fetchExpenses(params)
+	if err != nil {
+		return nil, err
+	}
+	var onTrip []ExpenseRecord
+	for _, expense := range expenses {
+		if strings.EqualFold(expense.Trip, trip.Name) {
+			onTrip = append(onTrip, expense)
+		}
+	}
+	return onTrip, nil
+}
+
+// renderTripReport shows a trip's total, spend per day and per person; with
+// more than one person each is compared with an even share of the total
+func renderTripReport(trip tripRecord, expenses []ExpenseRecord, formatter *format.Formatter) string {
+	status := "so far"
+	if trip.To != "" {
+		status = "ended"
+	}
+	total := sumExpenses(expenses)
+	lines := []string{
+		fmt.Sprintf("🧳 Trip %s (%s)", trip.Name, status),
+		fmt.Sprintf("Total: %s (%d expenses)", formatter.Currency(total), len(expenses)),
+	}
+	if len(expenses) == 0 {
+		return strings.Join(lines, "\n")
+	}
+
+	byDay := make(map[string]float64)
+	byPerson := make(map[string]float64)
+	for _, expense := range expenses {
+		byDay[expense.Date] += expense.Amount
+		name := expense.UserName
+		if name == "" {
+			name = "Unknown"
+		}
+		byPerson[name] += expense.Amount
+	}
+
+	lines = append(lines, "", "📅 Per day")
+	for _, day := range mapKeys(byDay) {
+		label := day
+		if date, err := time.Parse("2006-01-02", day); err == nil {
+			label = formatter.Date(date)
+		}
+		lines = append(lines, fmt.Sprintf("• %s - %s", label, formatter.Currency(byDay[day])))
+	}
+
+	people := mapKeys(byPerson)
+	sort.SliceStable(people, func(i, j int) bool { return byPerson[people[i]] > byPerson[people[j]] })
+	lines = append(lines, "", "👥 Per person")
+	share := total / float64(len(people))
+	for _, person := range people {
+		line := fmt.Sprintf("• %s - %s", person, formatter.Currency(byPerson[person]))
+		if len(people) > 1 {
+			switch diff := byPerson[person] - share; {
+			case math.Abs(diff) < 0.005:
+				line += " · even"
+			case diff > 0:
+				line += " · gets back " + formatter.Currency(diff)
+			default:
+				line += " · owes " + formatter.Currency(-diff)
+			}
+		}
+		lines = append(lines, line)
+	}
+	if len(people) > 1 {
+		lines = append(lines, "", "Even share: "+formatter.Currency(share)+" each")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleTripCommand groups expenses by trip: /trip start <name> tags every
+// expense logged until /trip end, which sends the trip report; /trip shows the
+// running or last trip's report
+func (b *botInstance) handleTripCommand(msg *tgbotapi.Message) {
+	send := func(text string) {
+		if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text)); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+	}
+	args := strings.Fields(msg.Text)[1:]
+	action := ""
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+	}
+	now := time.Now()
+	session := viewSession(msg.Chat.ID)
+
+	switch action {
+	case "start":
+		name := strings.TrimSpace(strings.Join(args[1:], " "))
+		if name == "" || len([]rune(name)) > TripMaxNameLen {
+			send(fmt.Sprintf("Usage: /trip start <name>, e.g. /trip start Goa (up to %d characters)", TripMaxNameLen))
+			return
+		}
+		if session.Trip != nil {
+			send("🧳 Trip " + session.Trip.Name + " is still running - send /trip end first.")
+			return
+		}
+		trip := &tripRecord{Name: name, From: now.Format("2006-01-02")}
+		updateSession(msg.Chat.ID, func(s *chatSession) { s.Trip = trip })
+		log.Printf("🧳 Trip %s started for ChatID %d", logText(name), msg.Chat.ID)
+		send("🧳 Trip " + name + " started - everything logged here is tagged with it until /trip end.")
+
+	case "end":
+		if session.Trip == nil {
+			send("🧳 No trip is running. Start one with /trip start <name>.")
+			return
+		}
+		trip := *session.Trip
+		trip.To = now.Format("2006-01-02")
+		updateSession(msg.Chat.ID, func(s *chatSession) {
+			s.Trip = nil
+			s.LastTrip = &trip
+		})
+		log.Printf("🧳 Trip %s ended for ChatID %d", logText(trip.Name), msg.Chat.ID)
+		b.sendTripReport(msg.Chat.ID, trip, now)
+
+	case "", "report":
+		trip := session.Trip
+		if trip == nil {
+			trip = session.LastTrip
+		}
+		if trip == nil {
+			send("🧳 No trips yet. /trip start Goa tags everything you log until /trip end, then sends a report with the total, spend per day and per person.")
+			return
+		}
+		b.sendTripReport(msg.Chat.ID, *trip, now)
+
+	default:
+		send("Usage: /trip start <name> | /trip end | /trip\n\n" +
+			"Expenses logged between start and end are tagged with the trip; /trip end and /trip show the total, spend per day and per person.")
+	}
+}
+
+// sendTripReport loads a trip's expenses and sends its report
+func (b *botInstance) sendTripReport(chatID int64, trip tripRecord, now time.Time) {
+	b.showTyping(chatID)
+	expenses, err := b.tripExpenses(chatID, trip, now)
+	if err != nil {
+		log.Printf("❌ Failed to fetch expenses of trip %s for ChatID %d: %v", logText(trip.Name), chatID, err)
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, userErrorText("loading the trip's expenses", err))); err != nil {
+			log.Printf(ErrorSendMessage, err)
+		}
+		return
+	}
+	if _, err := b.api.Send(tgbotapi.NewMessage(chatID, renderTripReport(trip, expenses, formatterFor(chatID)))); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/joho/godotenv"
+	"golang.org/x/text/language"
+)
+
+// ValidateTimeout bounds each reachability check of validate-config
+const ValidateTimeout = 10 * time.Second
+
+// configReport collects validate-config findings in the order they were found
+type configReport struct {
+	lines    []string
+	errors   int
+	warnings int
+}
+
+func (r *configReport) ok(format string, args ...interface{}) {
+	r.lines = append(r.lines, "✅ "+fmt.Sprintf(format, args...))
+}
+
+func (r *configReport) warnf(format string, args ...interface{}) {
+	r.warnings++
+	r.lines = append(r.lines, "⚠️ "+fmt.Sprintf(format, args...))
+}
+
+func (r *configReport) errorf(format string, args ...interface{}) {
+	r.errors++
+	r.lines = append(r.lines, "❌ "+fmt.Sprintf(format, args...))
+}
+
+// validateConfigCommand loads the configuration the way serve would, checks it
+// for mistakes and prints a report. It exits 1 when any check fails so CI/CD can
+// stop a rollout; warnings alone exit 0.
+func validateConfigCommand(args []string) {
+	flags := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	offline := flags.Bool("offline", false, "skip reachability checks against Telegram and the backend")
+	flags.Parse(args)
+
+	// Loader chatter goes to stderr; the report is the only stdout output
+	log.SetOutput(os.Stderr)
+
+	report := &configReport{}
+	if checkRequiredConfig(report) {
+		config = loadConfig()
+		checkConfigValues(report)
+		if !*offline {
+			checkReachability(report)
+		}
+	}
+
+	fmt.Println("SpendWise configuration report")
+	fmt.Println()
+	for _, line := range report.lines {
+		fmt.Println(line)
+	}
+	fmt.Printf("\n%d error(s), %d warning(s)\n", report.errors, report.warnings)
+	if report.errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkRequiredConfig inspects the raw configuration source for required fields,
+// since loadConfig stops the process on the first missing one
+func checkRequiredConfig(r *configReport) bool {
+	missing := func(name, value string) bool {
+		if value == "" {
+			r.errorf("%s is required", name)
+			return true
+		}
+		return false
+	}
+
+	if raw := os.Getenv("CONFIG_JSON"); raw != "" {
+		var sc SecretConfig
+		if err := json.Unmarshal([]byte(raw), &sc); err != nil {
+			r.errorf("CONFIG_JSON is not valid: %v (serve would fall back to individual environment variables)", err)
+			return false
+		}
+		r.ok("CONFIG_JSON parsed")
+
+		strict := json.NewDecoder(bytes.NewReader([]byte(raw)))
+		strict.DisallowUnknownFields()
+		if err := strict.Decode(&SecretConfig{}); err != nil {
+			r.warnf("CONFIG_JSON: %v (typo? the value is ignored)", err)
+		}
+
+		bad := missing("botToken", sc.BotToken)
+		bad = missing("botUrl", sc.BotUrl) || bad
+		bad = missing("apiSecret", sc.APISecret) || bad
+		return !bad
+	}
+
+	godotenv.Load()
+	r.ok("Using individual environment variables")
+	bad := missing("BOT_TOKEN", os.Getenv("BOT_TOKEN"))
+	bad = missing("BOT_URL", os.Getenv("BOT_URL")) || bad
+	bad = missing("API_SECRET", os.Getenv("API_SECRET")) || bad
+	return !bad
+}
+
+// checkConfigValues runs the same validation serve does at startup, plus
+// consistency checks between the ID lists
+func checkConfigValues(r *configReport) {
+	if u, err := url.Parse(config.BotUrl); err != nil || u.Scheme != "https" || u.Host == "" {
+		r.errorf("BOT_URL %q must be an https URL (Telegram only delivers webhooks over HTTPS)", config.BotUrl)
+	} else {
+		r.ok("BOT_URL %s", config.BotUrl)
+	}
+	if u, err := url.Parse(config.APIUrl); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		r.errorf("API_URL %q is not an http(s) URL", config.APIUrl)
+	}
+
+	if err := buildTenants(); err != nil {
+		r.errorf("Tenants: %v", err)
+	} else {
+		r.ok("%d tenant(s), %d allowed chat(s)", len(tenants), allowedChatCount())
+	}
+	switch {
+	case config.BotUsersRefresh < 0:
+		r.errorf("BOT_USERS_REFRESH_MINUTES can't be negative")
+	case config.BotUsersRefresh > 0:
+		r.ok("Allowed chats and names are also loaded from /api/bot-users every %s", config.BotUsersRefresh)
+	case allowedChatCount() == 0:
+		r.warnf("No allowed chat IDs - every message will be rejected")
+	}
+	for _, chatID := range mapKeys(tenantByChat) {
+		if _, err := strconv.ParseInt(chatID, 10, 64); err != nil {
+			r.errorf("Allowed chat ID %q is not a number", chatID)
+		}
+	}
+
+	if config.CycleStartDay < 0 || config.CycleStartDay > MaxCycleStartDay {
+		r.errorf("CYCLE_START_DAY %d must be between 1 and %d", config.CycleStartDay, MaxCycleStartDay)
+	}
+	for _, id := range mapKeys(config.UserSettings) {
+		if day := config.UserSettings[id].CycleStartDay; day < 0 || day > MaxCycleStartDay {
+			r.errorf("USER_SETTINGS cycleStartDay %d for %s must be between 1 and %d", day, id, MaxCycleStartDay)
+		}
+	}
+
+	notAllowed := func(list string, ids []string) {
+		for _, id := range ids {
+			if _, ok := tenantByChat[id]; !ok {
+				r.warnf("%s entry %s is not an allowed chat ID", list, id)
+			}
+		}
+	}
+	for _, alias := range mapKeys(config.CommandAliases) {
+		command := config.CommandAliases[alias]
+		name, _, _ := strings.Cut(command, " ")
+		slashAlias := strings.HasPrefix(alias, "/")
+		switch {
+		case strings.TrimSpace(alias) == "":
+			r.errorf("COMMAND_ALIASES has an empty alias for %q", command)
+		case slashAlias && strings.ContainsAny(alias, " \t"):
+			r.errorf("COMMAND_ALIASES alias %q must be a single word", alias)
+		case !strings.HasPrefix(command, "/"):
+			r.errorf("COMMAND_ALIASES %q must map to a command starting with /, got %q", alias, command)
+		case !slices.Contains(knownCommands, name):
+			r.warnf("COMMAND_ALIASES %q maps to %s, which is not a known command", alias, name)
+		case slices.Contains(knownCommands, strings.ToLower(alias)):
+			r.warnf("COMMAND_ALIASES %q shadows the built-in command", alias)
+		}
+	}
+
+	for _, code := range mapKeys(config.ErrorMessages) {
+		if _, ok := DefaultErrorMessages[code]; !ok {
+			r.warnf("ERROR_MESSAGES has a message for %q, which is not a known error code", code)
+		}
+	}
+
+	notAllowed("USER_NAMES", mapKeys(config.UserNames))
+	notAllowed("REMINDER_OWNERS", mapKeys(config.ReminderOwners))
+	notAllowed("USER_SETTINGS", mapKeys(config.UserSettings))
+	notAllowed("ADMIN_IDS", mapKeys(config.AdminIDs))
+	notAllowed("LIMITED_IDS", mapKeys(config.LimitedIDs))
+	if config.MarkDoneExpense != "" && !slices.Contains(markDoneExpenseModes, strings.ToLower(config.MarkDoneExpense)) {
+		r.errorf("MARK_DONE_EXPENSE %q must be one of %s", config.MarkDoneExpense, strings.Join(markDoneExpenseModes, ", "))
+	}
+	for _, id := range mapKeys(config.UserSettings) {
+		if mode := config.UserSettings[id].MarkDoneExpense; mode != "" && !slices.Contains(markDoneExpenseModes, strings.ToLower(mode)) {
+			r.errorf("USER_SETTINGS markDoneExpense %q for %s must be one of %s", mode, id, strings.Join(markDoneExpenseModes, ", "))
+		}
+	}
+	if config.UPIID != "" && !strings.Contains(config.UPIID, "@") {
+		r.errorf("UPI_ID %q is not a UPI ID like name@bank", config.UPIID)
+	}
+	if config.QuickExpenseMinAmount < 0 || config.QuickExpenseMaxAmount < 0 || config.QuickExpenseMinWords < 0 {
+		r.errorf("QUICK_EXPENSE_MIN_AMOUNT, QUICK_EXPENSE_MAX_AMOUNT and QUICK_EXPENSE_MIN_WORDS can't be negative")
+	}
+	if config.QuickExpenseMaxAmount > 0 && config.QuickExpenseMinAmount > config.QuickExpenseMaxAmount {
+		r.errorf("QUICK_EXPENSE_MIN_AMOUNT %g is above QUICK_EXPENSE_MAX_AMOUNT %g", config.QuickExpenseMinAmount, config.QuickExpenseMaxAmount)
+	}
+	if config.ApprovalThreshold < 0 {
+		r.errorf("APPROVAL_THRESHOLD can't be negative")
+	}
+	if config.ApprovalThreshold > 0 && len(config.ApproverIDs) == 0 {
+		r.warnf("APPROVAL_THRESHOLD is set but APPROVER_IDS is empty, so nothing will need approval")
+	}
+	if config.MaxPlausibleAmount < 0 {
+		r.errorf("MAX_PLAUSIBLE_AMOUNT can't be negative")
+	}
+	for _, pattern := range config.QuickExpenseBlocklist {
+		if _, err := regexp.Compile(pattern); err != nil {
+			r.errorf("QUICK_EXPENSE_BLOCKLIST pattern %q is invalid: %v", pattern, err)
+		}
+	}
+	for id := range config.LimitedIDs {
+		if config.AdminIDs[id] {
+			r.errorf("Chat ID %s is in both ADMIN_IDS and LIMITED_IDS", id)
+		}
+	}
+	notAllowed("ESCALATION_CC_IDS", config.EscalationCCIDs)
+	notAllowed("APPROVER_IDS", config.ApproverIDs)
+	notAllowed("REAUTH_PINS", mapKeys(config.ReauthPINs))
+	if config.ReauthWindow < 0 {
+		r.errorf("REAUTH_MINUTES can't be negative")
+	}
+	if len(config.ReauthPINs) > 0 && config.ReauthWindow == 0 {
+		r.warnf("REAUTH_PINS is set but REAUTH_MINUTES is not, so no PIN will be asked for")
+	}
+	for _, id := range mapKeys(config.ReauthPINs) {
+		if pin := config.ReauthPINs[id]; len(pin) < 4 || strings.HasPrefix(pin, "/") {
+			r.errorf("REAUTH_PINS entry for %s must be at least 4 characters and not start with /", id)
+		}
+	}
+	for _, id := range mapKeys(config.AllowedIDs) {
+		if _, named := config.UserNames[id]; !named && config.BotUsersRefresh == 0 {
+			r.warnf("Allowed chat ID %s has no USER_NAMES entry", id)
+		}
+	}
+
+	if config.Locale != "" {
+		if _, err := language.Parse(config.Locale); err != nil {
+			r.errorf("LOCALE %q is not a valid BCP 47 tag: %v", config.Locale, err)
+		}
+	}
+
+	if _, err := parseCIDRs(config.WebhookAllowedCIDRs); err != nil {
+		r.errorf("WEBHOOK_ALLOWED_CIDRS: %v", err)
+	}
+	if _, err := parseCIDRs(config.InternalAllowedCIDRs); err != nil {
+		r.errorf("INTERNAL_ALLOWED_CIDRS: %v", err)
+	}
+	if _, err := parseCIDRs(config.TrustedProxies); err != nil {
+		r.errorf("TRUSTED_PROXIES: %v", err)
+	}
+	if err := initBackendAuth(); err != nil {
+		r.errorf("Backend auth: %v", err)
+	} else {
+		r.ok("Backend auth mode %s", config.BackendAuth)
+	}
+
+	if config.WebhookSecret == "" {
+		r.warnf("WEBHOOK_SECRET is not set - anyone who finds the webhook URL can post updates")
+	}
+}
+
+// checkReachability verifies every bot token with getMe and that the backends answer
+func checkReachability(r *configReport) {
+	for _, bc := range botConfigs() {
+		api, err := tgbotapi.NewBotAPI(bc.BotToken)
+		if err != nil {
+			r.errorf("Bot %s: Telegram rejected the token or is unreachable: %v", bc.ID, err)
+			continue
+		}
+		r.ok("Bot %s authenticated as @%s", bc.ID, api.Self.UserName)
+	}
+
+	backends := map[string]bool{config.APIUrl: true}
+	for _, tc := range config.Tenants {
+		if tc.APIUrl != "" {
+			backends[tc.APIUrl] = true
+		}
+	}
+	client := &http.Client{Timeout: ValidateTimeout, Transport: backendTransport}
+	for apiURL := range backends {
+		resp, err := client.Get(apiURL)
+		if err != nil {
+			r.errorf("Backend %s is unreachable: %v", apiURL, err)
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			r.warnf("Backend %s answered HTTP %d", apiURL, resp.StatusCode)
+		} else {
+			r.ok("Backend %s reachable (HTTP %d)", apiURL, resp.StatusCode)
+		}
+	}
+}
+
+// mapKeys returns the keys of m in sorted order, so reports are stable
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Build information, injected at build time:
+//
+//	go build -ldflags "-X main.GitCommit=$(git rev-parse --short HEAD) -X main.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	GitCommit = "unknown"
+	BuildTime = "unknown"
+)
+
+// BuildInfo describes which revision this instance is running
+type BuildInfo struct {
+	GitCommit string `json:"gitCommit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// buildInfo returns the injected build information, falling back to the VCS
+// stamp Go embeds when the binary was built without ldflags.
+func buildInfo() BuildInfo {
+	info := BuildInfo{GitCommit: GitCommit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.GitCommit == "unknown":
+				info.GitCommit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildTime == "unknown":
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+func (b *botInstance) handleVersionCommand(msg *tgbotapi.Message) {
+	info := buildInfo()
+	log.Printf("🏷️ Sending version %s to ChatID: %d", info.GitCommit, msg.Chat.ID)
+
+	response := fmt.Sprintf("🏷️ SpendWise Bot\n\nCommit: %s\nBuilt: %s\nGo: %s\nInstance: %s\n%s",
+		info.GitCommit, info.BuildTime, info.GoVersion, instanceID, b.backendVersionLine())
+	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
+	if _, err := b.api.Send(reply); err != nil {
+		log.Printf(ErrorSendMessage, err)
+	}
+}
+
+func handleVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, buildInfo())
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// KeepAliveTimeout bounds each self-ping
+	KeepAliveTimeout = 10 * time.Second
+	// WarmupBackendTimeout bounds the backend calls made while warming up
+	WarmupBackendTimeout = 15 * time.Second
+)
+
+// warmUp runs once before the instance reports ready so the first real update
+// doesn't pay for cold connections: it opens a connection (and, with OIDC, fetches
+// an identity token) to every backend and builds each allowed chat's formatter.
+// Failures are logged only; the first real request simply pays the cost instead.
+func warmUp() {
+	startTime := time.Now()
+
+	seen := make(map[string]bool)
+	for _, b := range bots {
+		for _, t := range tenants {
+			scoped := b.forTenant(t)
+			if seen[scoped.apiURL] {
+				continue
+			}
+			seen[scoped.apiURL] = true
+
+			scoped, done := scoped.withWarmupDeadline()
+			result, err := scoped.apiCallWithTiming("GET", "/health", nil)
+			done()
+			if err != nil {
+				log.Printf("⚠️ Warm-up call to backend %s failed: %v", scoped.apiURL, err)
+				continue
+			}
+			log.Printf("🔥 Backend %s warmed up in %d ms", scoped.apiURL, result.APITime.Milliseconds())
+		}
+	}
+
+	for _, chatIDStr := range allowedChatIDs() {
+		if chatID, err := strconv.ParseInt(chatIDStr, 10, 64); err == nil {
+			formatterFor(chatID)
+		}
+	}
+
+	log.Printf("🔥 Warm-up finished in %d ms", time.Since(startTime).Milliseconds())
+}
+
+// withWarmupDeadline bounds a warm-up call so a sleeping backend cannot hold
+// readiness back for the full HTTP client timeout
+func (b *botInstance) withWarmupDeadline() (*botInstance, func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), WarmupBackendTimeout)
+	scoped := *b
+	scoped.ctx = ctx
+	return &scoped, cancel
+}
+
+// handleWarmup answers 200 once startup and warm-up are complete and 503 before,
+// for use as a Cloud Run startup probe or by a scheduler pinging the service
+func handleWarmup(c *gin.Context) {
+	if !ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "starting"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "warm", "uptimeSeconds": int(time.Since(processStart).Seconds())})
+}
+
+// startKeepAlive pings the instance's own public /health every interval so the
+// platform keeps it (and its connections) warm between messages. On Cloud Run
+// this only helps with CPU always allocated or min instances, since throttled
+// instances don't run timers while idle.
+func startKeepAlive(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	target := config.BotUrl + "/health"
+	log.Printf("💓 Keep-alive pinging %s every %s", target, interval)
+
+	client := &http.Client{Timeout: KeepAliveTimeout}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			startTime := time.Now()
+			resp, err := client.Get(target)
+			if err != nil {
+				log.Printf("⚠️ Keep-alive ping failed: %v", err)
+				continue
+			}
+			resp.Body.Close()
+			debugf("💓 Keep-alive ping answered %d in %d ms", resp.StatusCode, time.Since(startTime).Milliseconds())
+		}
+	}()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// UpdateWatermarkTTL matches Telegram's own window: after a week without updates
+// the next update_id is chosen at random, so an older watermark is meaningless.
+const UpdateWatermarkTTL = 7 * 24 * time.Hour
+
+const (
+	// WatermarkGapTimeout is how long a missing update_id may hold the watermark
+	// back. Gaps are updates handled by another instance, ids Telegram skipped or
+	// failed updates not redelivered here; once the per-update dedupe has
+	// forgotten them, holding the watermark back protects nothing.
+	WatermarkGapTimeout = UpdateDedupeTTL
+	// MaxWatermarkPending bounds the completed update_ids kept above the
+	// watermark; beyond it the oldest gaps are given up early
+	MaxWatermarkPending = 10000
+	// WatermarkGapCheckInterval limits how often the completed ids are scanned for stale gaps
+	WatermarkGapCheckInterval = time.Minute
+)
+
+// watermarks tracks the processed update_ids per bot. Only the value loaded at
+// startup is used for skipping; updates racing each other while running may
+// legitimately arrive out of order and are left to the per-update dedupe.
+// Updates are handled concurrently, so the watermark only advances over
+// consecutive completed update_ids (Telegram numbers them sequentially): after
+// a crash, an update that never finished is above it and gets processed when
+// it is redelivered. Gaps this instance never fills are skipped after
+// WatermarkGapTimeout.
+var watermarks = struct {
+	sync.Mutex
+	startup   map[string]int
+	highest   map[string]int               // consecutive update_ids up to here are done
+	done      map[string]map[int]time.Time // completed update_ids above highest, and when
+	lastCheck map[string]time.Time         // last scan for stale gaps
+}{
+	startup:   make(map[string]int),
+	highest:   make(map[string]int),
+	done:      make(map[string]map[int]time.Time),
+	lastCheck: make(map[string]time.Time),
+}
+
+func watermarkKey(botID string) string {
+	return fmt.Sprintf("update-watermark:%s", botID)
+}
+
+// loadWatermarks reads each bot's persisted watermark from the shared store
+func loadWatermarks() {
+	watermarks.Lock()
+	defer watermarks.Unlock()
+	for id := range bots {
+		value, ok, err := store.Get(watermarkKey(id))
+		if err != nil {
+			log.Printf("⚠️ Failed to load update watermark for bot %s: %v", id, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		updateID, err := strconv.Atoi(value)
+		if err != nil {
+			log.Printf("⚠️ Ignoring invalid update watermark %q for bot %s", value, id)
+			continue
+		}
+		watermarks.startup[id] = updateID
+		watermarks.highest[id] = updateID
+		log.Printf("🔖 Bot %s resuming after update_id %d", id, updateID)
+	}
+}
+
+// alreadyProcessed reports whether an update was handled before the last restart
+func alreadyProcessed(botID string, updateID int) bool {
+	watermarks.Lock()
+	defer watermarks.Unlock()
+	mark, ok := watermarks.startup[botID]
+	return ok && updateID <= mark
+}
+
+// startUpdate notes an update that is about to be handled. Without a persisted
+// watermark, the first one seen is where the watermark starts counting.
+func startUpdate(botID string, updateID int) {
+	watermarks.Lock()
+	defer watermarks.Unlock()
+	if _, ok := watermarks.highest[botID]; !ok {
+		watermarks.highest[botID] = updateID - 1
+	}
+}
+
+// advanceWatermark marks updateID handled and persists the watermark if that
+// moved it. An update that fails, e.g. a nacked Pub/Sub delivery, holds the
+// watermark back until its redelivery completes or WatermarkGapTimeout passes.
+// Instances share the persisted watermark, which only ever moves up.
+func advanceWatermark(botID string, updateID int) {
+	now := time.Now()
+	watermarks.Lock()
+	mark, ok := watermarks.highest[botID]
+	if !ok {
+		mark = updateID - 1
+	}
+	if updateID <= mark {
+		watermarks.Unlock()
+		return
+	}
+	done := watermarks.done[botID]
+	if done == nil {
+		done = make(map[int]time.Time)
+		watermarks.done[botID] = done
+	}
+	done[updateID] = now
+	mark = skipStaleGaps(botID, mark, now)
+	for _, next := done[mark+1]; next; _, next = done[mark+1] {
+		delete(done, mark+1)
+		mark++
+	}
+	previous := watermarks.highest[botID]
+	watermarks.highest[botID] = mark
+	watermarks.Unlock()
+	if ok && mark == previous {
+		return
+	}
+
+	if err := store.SetMax(watermarkKey(botID), int64(mark), UpdateWatermarkTTL); err != nil {
+		log.Printf("⚠️ Failed to persist update watermark for bot %s: %v", botID, err)
+	}
+}
+
+// skipStaleGaps moves the watermark up to just below the lowest completed
+// update_id when that one has waited longer than WatermarkGapTimeout for the
+// ids before it, or when too many completed ids are kept; callers must hold
+// watermarks
+func skipStaleGaps(botID string, mark int, now time.Time) int {
+	done := watermarks.done[botID]
+	if len(done) <= MaxWatermarkPending && now.Sub(watermarks.lastCheck[botID]) < WatermarkGapCheckInterval {
+		return mark
+	}
+	watermarks.lastCheck[botID] = now
+	for len(done) > 0 {
+		lowest := -1
+		for id := range done {
+			if lowest < 0 || id < lowest {
+				lowest = id
+			}
+		}
+		if len(done) <= MaxWatermarkPending && now.Sub(done[lowest]) < WatermarkGapTimeout {
+			break
+		}
+		log.Printf("🔖 Bot %s gave up waiting for update_ids %d-%d", botID, mark+1, lowest-1)
+		delete(done, lowest)
+		mark = lowest
+		for _, next := done[mark+1]; next; _, next = done[mark+1] {
+			delete(done, mark+1)
+			mark++
+		}
+	}
+	return mark
+}
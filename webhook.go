@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// WebhookCheckInterval is how often the registered webhook is verified against Telegram
+const WebhookCheckInterval = 10 * time.Minute
+
+// checkWebhooks inspects every bot's webhook registration, exports its health as
+// metrics and re-registers any webhook that was dropped or points at a stale URL.
+func checkWebhooks() {
+	for _, b := range bots {
+		b.checkWebhook()
+	}
+}
+
+func (b *botInstance) checkWebhook() {
+	info, err := b.api.GetWebhookInfo()
+	if err != nil {
+		log.Printf("❌ Webhook check failed for bot %s: %v", b.ID, err)
+		incCounter("spendwise_webhook_check_errors_total", "bot", b.ID)
+		return
+	}
+
+	setGauge("spendwise_webhook_pending_updates", float64(info.PendingUpdateCount), "bot", b.ID)
+	setGauge("spendwise_webhook_last_error_timestamp_seconds", float64(info.LastErrorDate), "bot", b.ID)
+	if info.LastErrorMessage != "" {
+		log.Printf("⚠️ Webhook for bot %s reports last error at %s: %s (pending updates: %d)",
+			b.ID, time.Unix(int64(info.LastErrorDate), 0).Format(time.RFC3339), info.LastErrorMessage, info.PendingUpdateCount)
+	}
+
+	expected := b.webhookURL()
+	if info.URL == expected {
+		return
+	}
+
+	if info.URL == "" {
+		log.Printf("⚠️ Webhook for bot %s was dropped, re-registering", b.ID)
+	} else {
+		log.Printf("⚠️ Webhook for bot %s points at stale URL %s, re-registering", b.ID, info.URL)
+	}
+	if err := b.registerWebhook(false); err != nil {
+		log.Printf("❌ Failed to re-register webhook for bot %s: %v", b.ID, err)
+		return
+	}
+	incCounter("spendwise_webhook_reregistrations_total", "bot", b.ID)
+}